@@ -0,0 +1,484 @@
+package empaths
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ErrAccessDenied is returned (wrapped) by Resolver.ResolveErr when a path accesses
+// data outside of the prefixes allowed with WithAllowedPrefixes, a name denied
+// with WithDeniedFields, or a method not allowed with WithAllowedMethods.
+var ErrAccessDenied = errors.New("access denied")
+
+// WithAllowedPrefixes restricts the model paths a Resolver evaluates to the given
+// prefixes, such as ".User.Name" or ".Orders". A path is allowed if it starts with
+// one of the prefixes, so ".Orders" allows ".Orders[0].Total" but neither ".User"
+// nor the root "." itself. Indices and wildcards are not part of the comparison:
+// ".Users.Name" (or ".Users[*].Name") allows ".Users[0].Name" and
+// ".Users[?.Age > 30].Name".
+//
+// Every model path of an expression is checked, including those in filters, which
+// are relative to the filtered collection: ".Users[?.Active==true].Name" requires
+// both ".Users.Active" and ".Users.Name" to be allowed. The key paths of sort and
+// groupby are relative to their collection in the same way, and keys that are not string
+// literals are rejected. Paths after variables are not restricted, since variables
+// are supplied by the application.
+//
+// Paths are checked before they are evaluated, and malformed paths (see Tokens) are
+// rejected. Malformed prefixes allow nothing. The targets of aliases (see
+// PathAliaser) are only known when they are resolved and are checked then, so
+// ".User.addr.City", with addr an alias of ".Address", requires both
+// ".User.addr.City" and ".User.Address.City" to be allowed.
+func WithAllowedPrefixes(prefixes []string) Option {
+	return func(r *Resolver) {
+		r.allowed = make([][]string, 0, len(prefixes))
+		for _, prefix := range prefixes {
+			if names, ok := pathNames(prefix); ok {
+				r.allowed = append(r.allowed, names)
+			}
+		}
+	}
+}
+
+// WithDeniedFields rejects paths that access any of the given field, method, or map
+// key names anywhere in the data, such as "PasswordHash" in ".User.PasswordHash",
+// ".Users[?.PasswordHash=='x']", or "groupby(.Users, '.PasswordHash')". Result
+// selectors are not part of the name, so "Lookup" also denies ".Lookup#1". Unlike
+// allowed prefixes, denied names also apply to paths after variables.
+//
+// Paths are checked before they are evaluated, and malformed paths (see Tokens) are
+// rejected. The targets of aliases (see PathAliaser) are checked when they are
+// resolved.
+func WithDeniedFields(names []string) Option {
+	return func(r *Resolver) {
+		r.denied = make(map[string]bool, len(names))
+		for _, name := range names {
+			r.denied[name] = true
+		}
+	}
+}
+
+// WithAllowedMethods restricts the methods that paths may call to the given methods
+// of the given types, so that only methods known to be free of side effects are
+// invoked. Calling any other method, as in ".User.Delete", aborts the evaluation
+// with an error wrapping ErrAccessDenied. A type allows its methods to be called on
+// both values and pointers of the type:
+//
+//	resolver := empaths.NewResolver(empaths.WithAllowedMethods(map[reflect.Type][]string{
+//		reflect.TypeOf(User{}): {"FullName", "IsAdmin"},
+//	}))
+//
+// Since methods are looked up at run time, they are checked when they are called
+// rather than before the path is evaluated, which includes the methods called by
+// filters, each, and the key paths of sort and groupby. An empty map allows no
+// methods at all. Fields, map keys, and function values are not affected.
+func WithAllowedMethods(methods map[reflect.Type][]string) Option {
+	return func(r *Resolver) {
+		r.allowedMethods = make(map[reflect.Type]map[string]bool, len(methods))
+		for typ, names := range methods {
+			if typ == nil {
+				continue
+			}
+			typ = indirectType(typ)
+			if r.allowedMethods[typ] == nil {
+				r.allowedMethods[typ] = make(map[string]bool, len(names))
+			}
+			for _, name := range names {
+				r.allowedMethods[typ][name] = true
+			}
+		}
+	}
+}
+
+// allowsMethod reports whether resolving the segment name against value may call a
+// method. If it may not, the evaluation is aborted with an error wrapping
+// ErrAccessDenied.
+func (s *evalState) allowsMethod(name string, value reflect.Value) bool {
+	if s.resolver == nil || s.resolver.allowedMethods == nil || !value.IsValid() {
+		return true
+	}
+	if _, ok := asPathResolvable(value); ok {
+		return true
+	}
+	name, _, _ = splitResultSelector(name)
+	if _, ok := value.Type().MethodByName(name); !ok {
+		return true
+	}
+	if s.resolver.allowedMethods[indirectType(value.Type())][name] {
+		return true
+	}
+	s.err = fmt.Errorf("%w: method %s of %s is not allowed", ErrAccessDenied, name, value.Type())
+	return false
+}
+
+// allowsAlias reports whether the access rules of the Resolver allow the model path
+// that path, a suffix of the model path being resolved, is expanded to by an alias
+// (see PathAliaser). Paths are checked before they are evaluated, when only the name
+// of the alias is known, so its target is checked when it is resolved. If it is not
+// allowed, the evaluation is aborted with an error wrapping ErrAccessDenied.
+func (s *evalState) allowsAlias(path string, expanded string) bool {
+	r := s.resolver
+	if r == nil || !r.hasAccessRules() {
+		return true
+	}
+	end := strings.IndexAny(path, ".[")
+	if end == -1 {
+		end = len(path)
+	}
+	names, _ := pathNames("." + expanded[:len(expanded)-len(path)+end])
+	for _, name := range names {
+		if r.denied[name] {
+			s.err = fmt.Errorf("%w: %q is denied", ErrAccessDenied, name)
+			return false
+		}
+	}
+	if r.allowed == nil {
+		return true
+	}
+	base := ""
+	if s.audit != nil {
+		base = s.audit.model
+	}
+	if strings.HasPrefix(base, "$") {
+		// Paths after variables are not restricted.
+		return true
+	}
+	if !strings.HasSuffix(s.modelPath, path) {
+		// The path was itself expanded by an alias, so what precedes it is unknown.
+		s.err = fmt.Errorf("%w: %q is not within an allowed prefix", ErrAccessDenied, expanded)
+		return false
+	}
+	modelPath := s.modelPath[:len(s.modelPath)-len(path)] + expanded
+	names, ok := pathNames(joinAuditPath(base, modelPath))
+	if !ok {
+		// Paths after function calls are checked like paths of the data.
+		names, _ = pathNames("." + modelPath)
+	}
+	if err := r.checkAllowed(names, "."+modelPath); err != nil {
+		s.err = err
+		return false
+	}
+	return true
+}
+
+// hasAccessRules reports whether the Resolver restricts the paths it evaluates.
+func (r *Resolver) hasAccessRules() bool {
+	return r.allowed != nil || r.denied != nil
+}
+
+// accessScope holds the names of the values that model paths in an expression are
+// resolved against.
+type accessScope struct {
+	// data holds the names of the value of the root path '.'.
+	data []string
+	// owner holds the names of the value of '^'.
+	owner []string
+	// inFilter is true inside filters, where '^' refers to owner.
+	inFilter bool
+	// unrestricted is true if the allowed prefixes do not apply.
+	unrestricted bool
+}
+
+// checkAccess reports an error wrapping ErrAccessDenied if path accesses data that
+// the access rules of the Resolver do not allow.
+func (r *Resolver) checkAccess(path string) error {
+	tokens, err := r.tokens(path)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrAccessDenied, err)
+	}
+	if !hasOperand(tokens) {
+		// An expression without operands evaluates to the data itself.
+		return r.checkAllowed(nil, path)
+	}
+	return r.checkAccessTokens(path, tokens, accessScope{})
+}
+
+// checkAccessTokens checks every model path of the expression given as tokens.
+func (r *Resolver) checkAccessTokens(path string, tokens []Token, scope accessScope) error {
+	for i := 0; i < len(tokens); {
+		names := scope.data
+		unrestricted := scope.unrestricted
+		start := i
+		switch tokens[i].Kind {
+		case TokenField:
+		case TokenRoot:
+			names = nil
+			start++
+		case TokenParent:
+			// Outside of filters '^' is nil and accesses nothing.
+			names = scope.owner
+			unrestricted = unrestricted || !scope.inFilter
+			start++
+		case TokenVariable:
+			unrestricted = true
+			start++
+		case TokenFunction:
+			if isEachCall(tokens, i) {
+				end, err := r.checkEachAccess(path, tokens, i, scope)
+				if err != nil {
+					return err
+				}
+				i = end
+				continue
+			}
+			if err := r.checkKeyAccess(tokens, i, scope); err != nil {
+				return err
+			}
+			i++
+			continue
+		default:
+			i++
+			continue
+		}
+
+		end := start
+		for end < len(tokens) && isSegmentToken(tokens[end]) && (end == i || isAdjacent(tokens[end-1], tokens[end])) {
+			if tokens[end].Kind == TokenFilterStart {
+				end = filterEnd(tokens, end)
+			}
+			end++
+		}
+
+		names = append([]string(nil), names...)
+		for j := start; j < end; j++ {
+			switch tokens[j].Kind {
+			case TokenField, TokenIndex:
+				if name, ok := segmentName(tokens[j]); ok {
+					if r.denied[name] {
+						return fmt.Errorf("%w: %q is denied", ErrAccessDenied, name)
+					}
+					names = append(names, name)
+				}
+			case TokenFilterStart:
+				closeIndex := filterEnd(tokens, j)
+				filterScope := accessScope{data: names, inFilter: true, unrestricted: unrestricted}
+				if len(names) > 0 {
+					filterScope.owner = names[:len(names)-1]
+				}
+				if err := r.checkAccessTokens(path, tokens[j+1:closeIndex], filterScope); err != nil {
+					return err
+				}
+				j = closeIndex
+			}
+		}
+		if !unrestricted {
+			if err := r.checkAllowed(names, path[tokens[i].Pos:tokens[end-1].Pos+len(tokens[end-1].Text)]); err != nil {
+				return err
+			}
+		}
+		i = end
+	}
+	return nil
+}
+
+// checkEachAccess checks the each call whose name is tokens[i] and returns the index
+// of its closing parenthesis. If the collection is a single model path, the
+// expression evaluated for every element is checked against its elements, like a
+// filter; otherwise it is checked against the data, like a path after a function
+// call.
+func (r *Resolver) checkEachAccess(path string, tokens []Token, i int, scope accessScope) (int, error) {
+	args, end := callArguments(tokens, i+1)
+	elemScope := scope
+	if i == 0 || tokens[i-1].Kind != TokenPipe {
+		if len(args) == 0 {
+			return end, nil
+		}
+		collection := args[0]
+		args = args[1:]
+		if err := r.checkAccessTokens(path, collection, scope); err != nil {
+			return 0, err
+		}
+		elemScope = elementScope(collection, scope)
+	}
+	for j, arg := range args {
+		argScope := scope
+		if j == 0 {
+			argScope = elemScope
+		}
+		if err := r.checkAccessTokens(path, arg, argScope); err != nil {
+			return 0, err
+		}
+	}
+	return end, nil
+}
+
+// keyedFuncs holds the functions that resolve key paths, given as arguments after
+// the collection, against each element of the collection.
+var keyedFuncs = map[string]bool{"sort": true, "groupby": true}
+
+// checkKeyAccess checks the key paths of the call of a function in keyedFuncs whose
+// name is tokens[i] against the elements of the collection, like the expression of
+// each. The arguments of the call are checked like those of any other function. A
+// key that is not a string literal is only known when the path is evaluated, so it
+// is denied.
+func (r *Resolver) checkKeyAccess(tokens []Token, i int, scope accessScope) error {
+	name := tokens[i].Text
+	if !keyedFuncs[name] || i+1 == len(tokens) || tokens[i+1].Kind != TokenLeftParen {
+		return nil
+	}
+	args, _ := callArguments(tokens, i+1)
+	elemScope := scope
+	if i == 0 || tokens[i-1].Kind != TokenPipe {
+		if len(args) == 0 {
+			return nil
+		}
+		elemScope = elementScope(args[0], scope)
+		args = args[1:]
+	}
+	if name == "groupby" && len(args) > 1 {
+		// Further arguments are ignored.
+		args = args[:1]
+	}
+	for _, arg := range args {
+		if len(arg) != 1 || arg[0].Kind != TokenString {
+			return fmt.Errorf("%w: the key of %s must be a string literal", ErrAccessDenied, name)
+		}
+		key := arg[0].Value.(string)
+		if name == "sort" {
+			if key == "asc" || key == "desc" {
+				continue
+			}
+			key = strings.TrimPrefix(key, "-")
+		}
+		keyTokens, err := r.tokens(key)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrAccessDenied, err)
+		}
+		if !hasOperand(keyTokens) {
+			// The elements themselves are compared.
+			continue
+		}
+		if err := r.checkAccessTokens(key, keyTokens, elemScope); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// elementScope returns the scope of an expression resolved against the elements of
+// a collection given as tokens: the elements of a single model path, with '^'
+// referring to the data, or the data itself for any other collection.
+func elementScope(collection []Token, scope accessScope) accessScope {
+	if !isSingleModelPath(collection) {
+		return scope
+	}
+	names := append([]string(nil), scope.data...)
+	for j := 0; j < len(collection); j++ {
+		switch collection[j].Kind {
+		case TokenField, TokenIndex:
+			if name, ok := segmentName(collection[j]); ok {
+				names = append(names, name)
+			}
+		case TokenFilterStart:
+			j = filterEnd(collection, j)
+		}
+	}
+	return accessScope{data: names, owner: scope.data, inFilter: true, unrestricted: scope.unrestricted}
+}
+
+// checkAllowed reports an error if the value with the given names is not within
+// one of the allowed prefixes. expr is the expression referring to it.
+func (r *Resolver) checkAllowed(names []string, expr string) error {
+	if r.allowed == nil {
+		return nil
+	}
+	for _, prefix := range r.allowed {
+		if hasNamePrefix(names, prefix) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %q is not within an allowed prefix", ErrAccessDenied, expr)
+}
+
+// hasOperand reports whether an expression has an operand. Expressions consisting
+// only of bare words (or nothing) evaluate to their data.
+func hasOperand(tokens []Token) bool {
+	for _, token := range tokens {
+		if token.Kind != TokenWord {
+			return true
+		}
+	}
+	return false
+}
+
+// hasNamePrefix reports whether names starts with prefix.
+func hasNamePrefix(names []string, prefix []string) bool {
+	if len(names) < len(prefix) {
+		return false
+	}
+	for i, name := range prefix {
+		if names[i] != name {
+			return false
+		}
+	}
+	return true
+}
+
+// isSegmentToken reports whether a token is a segment of a model path.
+func isSegmentToken(token Token) bool {
+	switch token.Kind {
+	case TokenField, TokenIndex, TokenWildcard, TokenFilterStart:
+		return true
+	default:
+		return false
+	}
+}
+
+// isAdjacent reports whether next directly follows prev in the path.
+func isAdjacent(prev Token, next Token) bool {
+	return next.Pos == prev.Pos+len(prev.Text)
+}
+
+// filterEnd returns the index of the TokenFilterEnd matching the TokenFilterStart
+// at start.
+func filterEnd(tokens []Token, start int) int {
+	depth := 0
+	for i := start; i < len(tokens); i++ {
+		switch tokens[i].Kind {
+		case TokenFilterStart:
+			depth++
+		case TokenFilterEnd:
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return len(tokens) - 1
+}
+
+// segmentName returns the field, method, or map key name of a field or index
+// segment, without the result selector of a field ("Lookup" for ".Lookup#1"). The
+// root path "." and integer indices have no name.
+func segmentName(token Token) (string, bool) {
+	if token.Kind == TokenField {
+		name, _, _ := splitResultSelector(token.Text[1:])
+		return name, name != ""
+	}
+	raw := token.Text[1 : len(token.Text)-1]
+	if _, err := strconv.Atoi(raw); err == nil {
+		return "", false
+	}
+	return unquoteKey(raw), true
+}
+
+// pathNames returns the names of a single model path, without indices and
+// wildcards.
+func pathNames(path string) ([]string, bool) {
+	tokens, err := Tokens(path)
+	if err != nil || !isSingleModelPath(tokens) {
+		return nil, false
+	}
+	names := []string{}
+	for _, token := range tokens {
+		if token.Kind == TokenField || token.Kind == TokenIndex {
+			if name, ok := segmentName(token); ok {
+				names = append(names, name)
+			}
+		}
+	}
+	return names, true
+}
@@ -0,0 +1,206 @@
+package empaths
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// AccessUser has a field that must not be exposed
+type AccessUser struct {
+	Name         string
+	Email        string
+	PasswordHash string
+	Friends      []AccessUser
+}
+
+// Secret is a method that must not be called
+func (u AccessUser) Secret() string {
+	return "secret of " + u.Name
+}
+
+func createAccessData() map[string]any {
+	return map[string]any{
+		"User": AccessUser{Name: "Alice", PasswordHash: "x1", Friends: []AccessUser{{Name: "Bob", PasswordHash: "x2"}}},
+		"Settings": map[string]any{
+			"theme":  "dark",
+			"db.url": "postgres://",
+		},
+		"Limit": 2,
+	}
+}
+
+func TestResolver_AllowedPrefixes(t *testing.T) {
+	data := createAccessData()
+	resolver := NewResolver(WithAllowedPrefixes([]string{".User.Name", ".User.Initials", ".User.Friends[*].Name", ".Settings.theme", ".Limit", "invalid["}))
+
+	tests := []struct {
+		name     string
+		path     string
+		expected any
+		denied   bool
+	}{
+		{"allowed field", ".User.Name", "Alice", false},
+		{"allowed key", ".Settings.theme", "dark", false},
+		{"allowed key in brackets", ".Settings['theme']", "dark", false},
+		{"allowed in list", ".User.Friends[0].Name", "Bob", false},
+		{"allowed projection", "join(.User.Friends[*].Name, ',')", "Bob", false},
+		{"allowed root prefix", ".Limit", 2, false},
+		{"literals", "'a' 1 true", "a1true", false},
+		{"denied field", ".User.Email", nil, true},
+		{"denied parent", ".User", nil, true},
+		{"denied method", ".User.Secret", nil, true},
+		{"allowed result selector", ".User.Initials#0", "A", false},
+		{"denied result selector", ".User.Secret#0", nil, true},
+		{"denied key", ".Settings['db.url']", nil, true},
+		{"denied root", ".", nil, true},
+		{"denied empty path", "", nil, true},
+		{"denied bare words", "  anything ", nil, true},
+		{"denied bare root reference", "$", nil, true},
+		{"denied in concatenation", ".User.Name ' ' .User.Email", nil, true},
+		{"denied in comparison", "?.User.PasswordHash == 'x1'", nil, true},
+		{"denied in function", "count(.User.Friends)", nil, true},
+		{"denied in filter", ".User.Friends[?.PasswordHash == 'x2'].Name", nil, true},
+		{"allowed filter", ".User.Friends[?.Name == 'Bob'].Name", []string{"Bob"}, false},
+		{"root reference in filter", ".User.Friends[?.Name != $.User.Email].Name", nil, true},
+		{"parent reference in filter", ".User.Friends[?.Name == ^.Name].Name", []any{}, false},
+		{"parent reference denied", ".User.Friends[?.Name == ^.Email].Name", nil, true},
+		{"parent outside filter", "^.Anything", nil, false},
+		{"allowed each", "each(.User.Friends[*].Name, .)", "Bob", false},
+		{"denied in each", "each(.User.Friends[*].Name, ^.Email)", nil, true},
+		{"denied each collection", "each(.User.Friends, .Name)", nil, true},
+		{"allowed sort", "sort(.User.Friends[*].Name, 'desc')", []string{"Bob"}, false},
+		{"allowed groupby", "count(groupby(.User.Friends[*].Name, '.'))", 1, false},
+		{"malformed", ".User.Name[", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := resolver.ResolveErr(tt.path, data, nil)
+			if tt.denied != errors.Is(err, ErrAccessDenied) {
+				t.Fatalf("ResolveErr(%q) error = %v, want access denied: %v", tt.path, err, tt.denied)
+			}
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("ResolveErr(%q) = %v, want %v", tt.path, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestResolver_DeniedFields(t *testing.T) {
+	data := createAccessData()
+	resolver := NewResolver(WithDeniedFields([]string{"PasswordHash", "Secret", "db.url"}))
+
+	tests := []struct {
+		name     string
+		path     string
+		expected any
+		denied   bool
+	}{
+		{"other field", ".User.Name", "Alice", false},
+		{"whole value", ".User.Friends[0].Name", "Bob", false},
+		{"denied field", ".User.PasswordHash", nil, true},
+		{"denied at any depth", ".User.Friends[0].PasswordHash", nil, true},
+		{"denied method", "'x' .User.Secret", nil, true},
+		{"denied result selector", ".User.Secret#0", nil, true},
+		{"denied result selector in filter", "count(.User.Friends[?.Secret#0 == ''])", nil, true},
+		{"denied key", ".Settings['db.url']", nil, true},
+		{"denied in filter", "count(.User.Friends[?.PasswordHash == 'x2'])", nil, true},
+		{"denied after root", "$.User.PasswordHash", nil, true},
+		{"denied after variable", "$user.PasswordHash", nil, true},
+		{"denied in filter after variable", "count($user.Friends[?.PasswordHash == 'x2'])", nil, true},
+		{"denied in each", "each(.User.Friends, .PasswordHash)", nil, true},
+		{"sort key", "sort(.User.Friends, '.Name')[0].Name", "Bob", false},
+		{"denied sort key", "sort(.User.Friends, '.PasswordHash')", nil, true},
+		{"denied descending sort key", "sort(.User.Friends, 'asc', '-.PasswordHash')", nil, true},
+		{"denied piped sort key", ".User.Friends | sort('.PasswordHash')", nil, true},
+		{"denied sort key path", "sort(.User.Friends, '.Friends[0].PasswordHash')", nil, true},
+		{"dynamic sort key", "sort(.User.Friends, .Settings.theme)", nil, true},
+		{"groupby key", "count(groupby(.User.Friends, '.Name'))", 1, false},
+		{"denied groupby key", "groupby(.User.Friends, '.PasswordHash')", nil, true},
+		{"denied piped groupby key", ".User.Friends | groupby('.Friends[0].PasswordHash')", nil, true},
+		{"dynamic groupby key", "groupby(.User.Friends, $key)", nil, true},
+		{"string literal", "'PasswordHash'", "PasswordHash", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := resolver.ResolveErr(tt.path, data, nil)
+			if tt.denied != errors.Is(err, ErrAccessDenied) {
+				t.Fatalf("ResolveErr(%q) error = %v, want access denied: %v", tt.path, err, tt.denied)
+			}
+			if result != tt.expected && !tt.denied {
+				t.Errorf("ResolveErr(%q) = %v, want %v", tt.path, result, tt.expected)
+			}
+		})
+	}
+}
+
+// Initials is a method without side effects
+func (u AccessUser) Initials() string {
+	return u.Name[:1]
+}
+
+func TestResolver_AllowedMethods(t *testing.T) {
+	data := createAccessData()
+	data["Person"] = createTestPerson()
+	data["Pointer"] = &AccessUser{Name: "Carol"}
+	resolver := NewResolver(WithAllowedMethods(map[reflect.Type][]string{
+		reflect.TypeOf(&AccessUser{}): {"Initials"},
+		reflect.TypeOf(Person{}):      {"IsAdult"},
+	}))
+
+	tests := []struct {
+		name     string
+		path     string
+		expected any
+		denied   bool
+	}{
+		{"field", ".User.Name", "Alice", false},
+		{"allowed method", ".User.Initials", "A", false},
+		{"allowed method in filter", "count(.User.Friends[?.Initials == 'B'])", 1, false},
+		{"allowed method through pointer", ".Pointer.Initials", "C", false},
+		{"allowed method of other type", ".Person.IsAdult", true, false},
+		{"method of other type", ".Person.GetFullName", nil, true},
+		{"method not allowed", "'x' .User.Secret", nil, true},
+		{"method not allowed in filter", "count(.User.Friends[?.Secret == ''])", nil, true},
+		{"result selector", ".User.Secret#0", nil, true},
+		{"allowed method in sort key", "sort(.User.Friends, '.Initials')[0].Name", "Bob", false},
+		{"method not allowed in sort key", "sort(.User.Friends, '.Secret')", nil, true},
+		{"method not allowed in groupby key", "groupby(.User.Friends, '.Secret')", nil, true},
+		{"method not allowed in piped groupby key", ".User.Friends | groupby('.Secret')", nil, true},
+		{"map key", ".Settings.theme", "dark", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := resolver.ResolveErr(tt.path, data, nil)
+			if tt.denied != errors.Is(err, ErrAccessDenied) {
+				t.Fatalf("ResolveErr(%q) error = %v, want access denied: %v", tt.path, err, tt.denied)
+			}
+			if result != tt.expected {
+				t.Errorf("ResolveErr(%q) = %v, want %v", tt.path, result, tt.expected)
+			}
+		})
+	}
+
+	t.Run("no methods", func(t *testing.T) {
+		_, err := NewResolver(WithAllowedMethods(nil)).ResolveErr(".User.Initials", data, nil)
+		if !errors.Is(err, ErrAccessDenied) {
+			t.Errorf("ResolveErr() error = %v, want access denied", err)
+		}
+	})
+}
+
+func TestResolver_AccessVariables(t *testing.T) {
+	resolver := NewResolver(WithAllowedPrefixes([]string{".Limit"}))
+	vars := map[string]any{"user": createAccessData()["User"]}
+
+	path := "join($user.Friends[?.Email == ''].Name, ',')"
+	if err := resolver.checkAccess(path); err != nil {
+		t.Errorf("checkAccess(%q) = %v, paths after variables should not be restricted", path, err)
+	}
+	if result := ResolveWithVars(path, nil, vars, nil); result != "Bob" {
+		t.Errorf("ResolveWithVars(%q) = %v, want Bob", path, result)
+	}
+}
@@ -0,0 +1,77 @@
+package empaths
+
+import (
+	"reflect"
+	"strings"
+)
+
+// pathAliaserType is the type of the PathAliaser interface.
+var pathAliaserType = reflect.TypeOf((*PathAliaser)(nil)).Elem()
+
+// pathAliases returns the aliases that value declares (see PathAliaser), or nil.
+func pathAliases(value reflect.Value) map[string]string {
+	if !value.IsValid() || (value.Kind() == reflect.Ptr && value.IsNil()) {
+		return nil
+	}
+	aliaser, ok := implementation(value, pathAliaserType)
+	if !ok {
+		return nil
+	}
+	return aliaser.(PathAliaser).PathAliases()
+}
+
+// expandPathAlias replaces the name at the start of a model path (without its
+// leading '.') with the target of the alias value declares for it, so "addr.City"
+// becomes "Address.City" if value aliases "addr" to ".Address".
+func expandPathAlias(path string, value reflect.Value) string {
+	if path == "" || path[0] == '[' {
+		return path
+	}
+	aliases := pathAliases(value)
+	if len(aliases) == 0 {
+		return path
+	}
+	end := strings.IndexAny(path, ".[")
+	if end == -1 {
+		end = len(path)
+	}
+	target := strings.TrimPrefix(aliases[path[:end]], ".")
+	if target == "" {
+		return path
+	}
+	return target + path[end:]
+}
+
+// expandSegmentAlias replaces the first of segments with the segments of the target
+// of the alias value declares for it, like expandPathAlias.
+func expandSegmentAlias(value reflect.Value, segments []pathSegment) []pathSegment {
+	if segments[0].bracket {
+		return segments
+	}
+	aliases := pathAliases(value)
+	if len(aliases) == 0 {
+		return segments
+	}
+	target, ok := aliases[segments[0].name]
+	if !ok || strings.TrimPrefix(target, ".") == "" {
+		return segments
+	}
+	if !strings.HasPrefix(target, ".") {
+		target = "." + target
+	}
+	expanded, err := parseSegments(target)
+	if err != nil || len(expanded) == 0 {
+		return segments
+	}
+	return append(expanded, segments[1:]...)
+}
+
+// aliasNames returns the names of the aliases that value declares.
+func aliasNames(value reflect.Value) []string {
+	aliases := pathAliases(value)
+	names := make([]string, 0, len(aliases))
+	for name := range aliases {
+		names = append(names, name)
+	}
+	return names
+}
@@ -0,0 +1,181 @@
+package empaths
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// AliasedAddress is the address of an AliasedUser.
+type AliasedAddress struct {
+	City string
+}
+
+// AliasedUser declares stable path names for its fields.
+type AliasedUser struct {
+	DisplayName string
+	Address     *AliasedAddress
+	Tags        []string
+	Nick        string
+}
+
+var aliasedUserAliases = map[string]string{
+	"display_name": "DisplayName",
+	"addr":         ".Address",
+	"city":         ".Address.City",
+	"first_tag":    "Tags[0]",
+	"Nick":         "DisplayName",
+	"nickname":     "Nick",
+}
+
+func (AliasedUser) PathAliases() map[string]string { return aliasedUserAliases }
+
+func TestResolve_PathAliases(t *testing.T) {
+	user := &AliasedUser{
+		DisplayName: "Alice",
+		Address:     &AliasedAddress{City: "Berlin"},
+		Tags:        []string{"admin", "ops"},
+		Nick:        "al",
+	}
+	data := map[string]any{"User": user, "Users": []*AliasedUser{user, {DisplayName: "Bob"}}}
+
+	tests := []struct {
+		name     string
+		path     string
+		expected any
+	}{
+		{"field name", ".User.display_name", "Alice"},
+		{"field path", ".User.addr.City", "Berlin"},
+		{"nested path", ".User.city", "Berlin"},
+		{"path with index", ".User.first_tag", "admin"},
+		{"alias shadows field", ".User.Nick", "Alice"},
+		{"target is not aliased again", ".User.nickname", "al"},
+		{"go name still works", ".User.DisplayName", "Alice"},
+		{"missing name", ".User.unknown", nil},
+		{"in filter", ".Users[?.display_name == 'Bob'].display_name", []string{"Bob"}},
+		{"in concatenation", ".User.display_name ' from ' .User.city", "Alice from Berlin"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Resolve(tt.path, data, nil)
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("Resolve(%q) = %#v, want %#v", tt.path, result, tt.expected)
+			}
+		})
+	}
+
+	t.Run("completion", func(t *testing.T) {
+		completions := NewExplorer(data, nil).Complete(".User.disp")
+		if !reflect.DeepEqual(completions, []string{".User.display_name"}) {
+			t.Errorf("Complete() = %v, want [.User.display_name]", completions)
+		}
+	})
+
+	t.Run("suggestions", func(t *testing.T) {
+		_, err := NewResolver(WithStrict()).ResolveErr(".User.display_nam", data, nil)
+		var notFound *FieldNotFoundError
+		if !errors.As(err, &notFound) || len(notFound.Suggestions) == 0 || notFound.Suggestions[0] != "display_name" {
+			t.Errorf("ResolveErr() error = %v, want a suggestion of display_name", err)
+		}
+	})
+}
+
+// AliasedAccount aliases a field that access rules may deny.
+type AliasedAccount struct {
+	DisplayName  string
+	PasswordHash string
+}
+
+func (AliasedAccount) PathAliases() map[string]string {
+	return map[string]string{"name": "DisplayName", "pw": "PasswordHash"}
+}
+
+func TestResolve_PathAliasAccess(t *testing.T) {
+	account := AliasedAccount{DisplayName: "Alice", PasswordHash: "secret"}
+	data := map[string]any{"User": account, "Users": []AliasedAccount{account}}
+
+	tests := []struct {
+		name     string
+		opts     []Option
+		path     string
+		expected any
+		denied   bool
+	}{
+		{"denied target", []Option{WithDeniedFields([]string{"PasswordHash"})}, ".User.pw", nil, true},
+		{"denied target in filter", []Option{WithDeniedFields([]string{"PasswordHash"})}, ".Users[?.pw == 'secret'].name", nil, true},
+		{"other target", []Option{WithDeniedFields([]string{"PasswordHash"})}, ".User.name", "Alice", false},
+		{"target within prefix", []Option{WithAllowedPrefixes([]string{".User"})}, ".User.pw", "secret", false},
+		{"target outside prefix", []Option{WithAllowedPrefixes([]string{".User.pw"})}, ".User.pw", nil, true},
+		{"name and target allowed", []Option{WithAllowedPrefixes([]string{".User.name", ".User.DisplayName"})}, ".User.name", "Alice", false},
+		{"target in filter within prefix", []Option{WithAllowedPrefixes([]string{".Users"})}, ".Users[?.pw == 'secret'].name", []string{"Alice"}, false},
+		{"target in filter outside prefix", []Option{WithAllowedPrefixes([]string{".Users.name", ".Users.pw", ".Users.DisplayName"})}, ".Users[?.pw == 'secret'].name", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := NewResolver(tt.opts...).ResolveErr(tt.path, data, nil)
+			if errors.Is(err, ErrAccessDenied) != tt.denied {
+				t.Fatalf("ResolveErr(%q) error = %v, want denied %v", tt.path, err, tt.denied)
+			}
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("ResolveErr(%q) = %#v, want %#v", tt.path, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSet_PathAliases(t *testing.T) {
+	tests := []struct {
+		name   string
+		path   string
+		value  any
+		create bool
+		check  func(u *AliasedUser) any
+		want   any
+	}{
+		{"field", ".display_name", "Bob", false, func(u *AliasedUser) any { return u.DisplayName }, "Bob"},
+		{"nested path", ".city", "Paris", false, func(u *AliasedUser) any { return u.Address.City }, "Paris"},
+		{"index", ".first_tag", "root", false, func(u *AliasedUser) any { return u.Tags[0] }, "root"},
+		{"path continues", ".addr.City", "Oslo", false, func(u *AliasedUser) any { return u.Address.City }, "Oslo"},
+		{"create", ".city", "Rome", true, func(u *AliasedUser) any { return u.Address.City }, "Rome"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := &AliasedUser{Address: &AliasedAddress{}, Tags: []string{"a"}}
+			if tt.create {
+				user = &AliasedUser{}
+			}
+			var err error
+			if tt.create {
+				err = SetCreate(tt.path, user, tt.value)
+			} else {
+				err = Set(tt.path, user, tt.value)
+			}
+			if err != nil {
+				t.Fatalf("Set(%q) error = %v", tt.path, err)
+			}
+			if got := tt.check(user); got != tt.want {
+				t.Errorf("after Set(%q) got %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+
+	t.Run("delete", func(t *testing.T) {
+		user := &AliasedUser{DisplayName: "Alice"}
+		if err := Delete(".display_name", user); err != nil || user.DisplayName != "" {
+			t.Errorf("Delete() error = %v, DisplayName = %q", err, user.DisplayName)
+		}
+	})
+}
+
+func TestAnalyze_PathAliases(t *testing.T) {
+	accesses, err := Analyze(".display_name", reflect.TypeOf(AliasedUser{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(accesses) != 1 || accesses[0].Kind != AccessDynamic {
+		t.Errorf("Analyze() = %v, want one dynamic access", accesses)
+	}
+}
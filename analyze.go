@@ -0,0 +1,433 @@
+package empaths
+
+import (
+	"reflect"
+	"strconv"
+)
+
+// AccessKind identifies how an Access reaches into the data.
+type AccessKind int
+
+const (
+	// AccessField reads a struct field.
+	AccessField AccessKind = iota
+	// AccessMethod calls a method.
+	AccessMethod
+	// AccessKey reads the value of a map key (including sync.Maps and iter.Seq2
+	// iterators).
+	AccessKey
+	// AccessIndex reads an element of a slice, array, or iter.Seq iterator.
+	AccessIndex
+	// AccessElements reads all elements of a collection, for a wildcard or filter.
+	AccessElements
+	// AccessDynamic is a segment whose meaning depends on the data: it is applied to
+	// an interface, a value with custom resolution (such as a PathResolvable), a
+	// variable, or the result of a function, so it may read a field or key or call a
+	// method.
+	AccessDynamic
+)
+
+// accessKindNames holds the names returned by AccessKind.String.
+var accessKindNames = [...]string{
+	AccessField:    "field",
+	AccessMethod:   "method",
+	AccessKey:      "key",
+	AccessIndex:    "index",
+	AccessElements: "elements",
+	AccessDynamic:  "dynamic",
+}
+
+// String returns the name of the access kind.
+func (k AccessKind) String() string {
+	if k >= 0 && int(k) < len(accessKindNames) {
+		return accessKindNames[k]
+	}
+	return "AccessKind(" + strconv.Itoa(int(k)) + ")"
+}
+
+// Access describes a segment of a model path that an expression would resolve,
+// as reported by Analyze.
+type Access struct {
+	// Path is the model path up to and including the segment, e.g. ".User.Name" or
+	// ".Users[*].Address". Paths inside filters include the filtered collection
+	// (".Users[*].Active" for ".Users[?.Active]"), as do paths in the expression of
+	// each (".Users[*].Name" for "each(.Users, .Name)"). Paths after variables and
+	// function results start with the variable ("$user.Name") or the call
+	// ("first(.Users).Name").
+	Path string
+	// Kind is how the segment reaches into the data.
+	Kind AccessKind
+	// Type is the type the segment is applied to, such as the struct holding a field
+	// or the receiver of a method; it is nil if the type is not known.
+	Type reflect.Type
+	// Name is the field, method, or map key name, or the index; it is "*" for
+	// AccessElements.
+	Name string
+}
+
+// Analyze reports the fields, map keys, elements, and methods that path would
+// access when resolved against data of type sampleType, without evaluating it
+// and without calling any methods. This allows expressions written by users to be
+// checked before they are evaluated, e.g. against a list of allowed methods:
+//
+//	accesses, err := empaths.Analyze("count(.Orders[?.Total > 100]) .Customer.Name", reflect.TypeOf(Account{}))
+//	for _, access := range accesses {
+//		if access.Kind == empaths.AccessMethod || access.Kind == empaths.AccessDynamic {
+//			// review access.Type and access.Name
+//		}
+//	}
+//
+// Each access is reported once, in the order in which it appears in path. Segments
+// whose type cannot be known without the data are reported as AccessDynamic, as
+// are all segments that follow them. Fields and methods are looked up the way
+// Resolve does, so function values in fields and map values are followed to their
+// first result as they would be called.
+//
+// Parameters:
+//   - path: The path expression
+//   - sampleType: The type of the data the path would be resolved against
+//
+// Returns:
+//   - The accesses of the path, or nil if there are none
+//   - A *SyntaxError if the path is malformed, or a *FieldNotFoundError if a name
+//     or index cannot exist on its type
+func Analyze(path string, sampleType reflect.Type) ([]Access, error) {
+	tokens, err := Tokens(path)
+	if err != nil {
+		return nil, err
+	}
+	a := &analyzer{path: path, root: sampleType, seen: make(map[Access]bool)}
+	if err := a.expression(tokens, analyzerScope{data: sampleType}); err != nil {
+		return nil, err
+	}
+	return a.accesses, nil
+}
+
+// analyzer holds the state of Analyze.
+type analyzer struct {
+	// path is the analyzed path expression.
+	path string
+	// root is the type of the data, referred to by '$'.
+	root reflect.Type
+	// accesses holds the accesses found so far.
+	accesses []Access
+	// seen holds the accesses found so far, to report each of them once.
+	seen map[Access]bool
+}
+
+// analyzerScope holds the types and paths of the values that model paths in an
+// expression are resolved against.
+type analyzerScope struct {
+	// data and dataPath are the type and path of the value of '.'.
+	data     reflect.Type
+	dataPath string
+	// owner and ownerPath are the type and path of the value of '^'.
+	owner     reflect.Type
+	ownerPath string
+	// inFilter is true inside filters, where '^' refers to owner.
+	inFilter bool
+}
+
+// expression analyzes every model path of the expression given as tokens.
+func (a *analyzer) expression(tokens []Token, scope analyzerScope) error {
+	for i := 0; i < len(tokens); {
+		typ, path := scope.data, scope.dataPath
+		start := i
+		switch tokens[i].Kind {
+		case TokenField:
+			if i > 0 && isAdjacent(tokens[i-1], tokens[i]) && (tokens[i-1].Kind == TokenRightParen || tokens[i-1].Kind == TokenFunction) {
+				// A path resolved against the result of a function.
+				typ, path = nil, a.callText(tokens, i-1)
+			}
+		case TokenRoot:
+			typ, path = a.root, ""
+			start++
+		case TokenParent:
+			if !scope.inFilter {
+				// Outside of filters '^' is nil and accesses nothing.
+				i++
+				continue
+			}
+			typ, path = scope.owner, scope.ownerPath
+			start++
+		case TokenVariable:
+			typ, path = nil, tokens[i].Text
+			start++
+		case TokenFunction:
+			if isEachCall(tokens, i) {
+				end, err := a.each(tokens, i, scope)
+				if err != nil {
+					return err
+				}
+				i = end
+				continue
+			}
+			i++
+			continue
+		default:
+			i++
+			continue
+		}
+
+		end := start
+		for end < len(tokens) && isSegmentToken(tokens[end]) && (end == i || isAdjacent(tokens[end-1], tokens[end])) {
+			if tokens[end].Kind == TokenFilterStart {
+				end = filterEnd(tokens, end)
+			}
+			end++
+		}
+		if _, _, err := a.segments(tokens[start:end], typ, path); err != nil {
+			return err
+		}
+		i = end
+	}
+	return nil
+}
+
+// each analyzes the each call whose name is tokens[i] and returns the index of its
+// closing parenthesis. The expression it evaluates for every element is analyzed
+// against the elements of the collection, like a filter; their type is only known
+// if the collection is a single model path.
+func (a *analyzer) each(tokens []Token, i int, scope analyzerScope) (int, error) {
+	args, end := callArguments(tokens, i+1)
+	elemScope := analyzerScope{dataPath: "each()[*]", owner: scope.data, ownerPath: scope.dataPath, inFilter: true}
+	if i == 0 || tokens[i-1].Kind != TokenPipe {
+		if len(args) == 0 {
+			return end, nil
+		}
+		collection := args[0]
+		args = args[1:]
+		if isSingleModelPath(collection) {
+			typ, path, err := a.segments(collection, scope.data, scope.dataPath)
+			if err != nil {
+				return 0, err
+			}
+			if last := collection[len(collection)-1].Kind; last == TokenWildcard || last == TokenFilterEnd {
+				elemScope.data, elemScope.dataPath = typ, path
+			} else {
+				elemScope.dataPath = path + "[*]"
+				elemScope.data = a.elements(typ, elemScope.dataPath)
+			}
+		} else if err := a.expression(collection, scope); err != nil {
+			return 0, err
+		}
+	}
+	for j, arg := range args {
+		argScope := scope
+		if j == 0 {
+			argScope = elemScope
+		}
+		if err := a.expression(arg, argScope); err != nil {
+			return 0, err
+		}
+	}
+	return end, nil
+}
+
+// segments analyzes the segments of a model path, resolved against a value of type
+// typ at path; typ is nil if it is not known. It returns the type and path of the
+// value the model path refers to.
+func (a *analyzer) segments(tokens []Token, typ reflect.Type, path string) (reflect.Type, string, error) {
+	// owner is the value holding the collection of the current bracket segments,
+	// which '^' refers to inside a filter.
+	owner, ownerPath := typ, path
+	for j := 0; j < len(tokens); j++ {
+		token := tokens[j]
+		var err error
+		switch token.Kind {
+		case TokenField:
+			if token.Text == "." {
+				continue
+			}
+			owner, ownerPath = typ, path
+			path += token.Text
+			typ, err = a.member(token.Text[1:], typ, path)
+		case TokenIndex:
+			path += token.Text
+			typ, err = a.element(unquoteKey(token.Text[1:len(token.Text)-1]), typ, path)
+		case TokenWildcard, TokenFilterStart:
+			path += "[*]"
+			typ = a.elements(typ, path)
+			if token.Kind == TokenFilterStart {
+				closeIndex := filterEnd(tokens, j)
+				filterScope := analyzerScope{data: typ, dataPath: path, owner: owner, ownerPath: ownerPath, inFilter: true}
+				if err := a.expression(tokens[j+1:closeIndex], filterScope); err != nil {
+					return nil, "", err
+				}
+				j = closeIndex
+			}
+		}
+		if err != nil {
+			return nil, "", err
+		}
+	}
+	return typ, path, nil
+}
+
+// member records the access of a field, method, or map key name on a value of type
+// typ and returns the type of the result.
+func (a *analyzer) member(name string, typ reflect.Type, path string) (reflect.Type, error) {
+	typ, dynamic := analyzedType(typ)
+	if dynamic {
+		a.add(Access{Path: path, Kind: AccessDynamic, Type: typ, Name: name})
+		return nil, nil
+	}
+
+	base, result, selected := splitResultSelector(name)
+	if method, ok := typ.MethodByName(base); ok && method.Type.NumIn() == 1 && method.Type.NumOut() > result {
+		a.add(Access{Path: path, Kind: AccessMethod, Type: typ, Name: base})
+		if !selected {
+			result = 0
+		}
+		return calledType(method.Type.Out(result)), nil
+	}
+
+	switch typ.Kind() {
+	case reflect.Struct:
+		if typ == syncMapType {
+			a.add(Access{Path: path, Kind: AccessKey, Type: typ, Name: name})
+			return nil, nil
+		}
+		if field, ok := typ.FieldByName(name); ok && field.IsExported() {
+			a.add(Access{Path: path, Kind: AccessField, Type: typ, Name: name})
+			return calledType(field.Type), nil
+		}
+	case reflect.Map:
+		if parseMapKey(name, typ.Key()).IsValid() {
+			a.add(Access{Path: path, Kind: AccessKey, Type: typ, Name: name})
+			return calledType(typ.Elem()), nil
+		}
+	case reflect.Func:
+		if iteratorArity(typ) == 2 {
+			a.add(Access{Path: path, Kind: AccessKey, Type: typ, Name: name})
+			return calledType(typ.In(0).In(1)), nil
+		}
+	}
+	return nil, &FieldNotFoundError{Field: name, Type: typ, Suggestions: suggestNames(name, memberNames(reflect.New(typ).Elem()))}
+}
+
+// element records the access of an index or key in brackets on a value of type typ
+// and returns the type of the result.
+func (a *analyzer) element(key string, typ reflect.Type, path string) (reflect.Type, error) {
+	typ, dynamic := analyzedType(typ)
+	if dynamic || implementsAny(typ, indexResolvableType, keyResolvableType) {
+		a.add(Access{Path: path, Kind: AccessDynamic, Type: typ, Name: key})
+		return nil, nil
+	}
+
+	_, indexErr := strconv.Atoi(key)
+	switch typ.Kind() {
+	case reflect.Array, reflect.Slice:
+		if indexErr == nil {
+			a.add(Access{Path: path, Kind: AccessIndex, Type: typ, Name: key})
+			return calledType(typ.Elem()), nil
+		}
+	case reflect.Map:
+		if parseMapKey(key, typ.Key()).IsValid() {
+			a.add(Access{Path: path, Kind: AccessKey, Type: typ, Name: key})
+			return calledType(typ.Elem()), nil
+		}
+	case reflect.Struct:
+		if typ == syncMapType {
+			a.add(Access{Path: path, Kind: AccessKey, Type: typ, Name: key})
+			return nil, nil
+		}
+	case reflect.Func:
+		switch iteratorArity(typ) {
+		case 1:
+			if indexErr == nil {
+				a.add(Access{Path: path, Kind: AccessIndex, Type: typ, Name: key})
+				return calledType(typ.In(0).In(0)), nil
+			}
+		case 2:
+			a.add(Access{Path: path, Kind: AccessKey, Type: typ, Name: key})
+			return calledType(typ.In(0).In(1)), nil
+		}
+	}
+	return nil, &FieldNotFoundError{Field: key, Type: typ}
+}
+
+// elements records the access of all elements of a value of type typ and returns
+// the type of the elements, or nil if it is not known.
+func (a *analyzer) elements(typ reflect.Type, path string) reflect.Type {
+	typ, dynamic := analyzedType(typ)
+	if dynamic {
+		a.add(Access{Path: path, Kind: AccessDynamic, Type: typ, Name: "*"})
+		return nil
+	}
+	a.add(Access{Path: path, Kind: AccessElements, Type: typ, Name: "*"})
+	switch typ.Kind() {
+	case reflect.Array, reflect.Slice, reflect.Map:
+		return calledType(typ.Elem())
+	case reflect.Func:
+		if arity := iteratorArity(typ); arity > 0 {
+			return calledType(typ.In(0).In(arity - 1))
+		}
+	}
+	return nil
+}
+
+// add records an access unless it has been recorded before.
+func (a *analyzer) add(access Access) {
+	if a.seen[access] {
+		return
+	}
+	a.seen[access] = true
+	a.accesses = append(a.accesses, access)
+}
+
+// callText returns the source text of the function call or piped function that
+// ends with tokens[end], such as "first(.Users)".
+func (a *analyzer) callText(tokens []Token, end int) string {
+	start := end
+	if tokens[end].Kind == TokenRightParen {
+		depth := 0
+		for start = end; start > 0; start-- {
+			if tokens[start].Kind == TokenRightParen {
+				depth++
+			} else if tokens[start].Kind == TokenLeftParen {
+				depth--
+				if depth == 0 {
+					break
+				}
+			}
+		}
+		if start > 0 && (tokens[start-1].Kind == TokenFunction || tokens[start-1].Kind == TokenReference) {
+			start--
+		}
+	}
+	return a.path[tokens[start].Pos : tokens[end].Pos+len(tokens[end].Text)]
+}
+
+// analyzedType removes pointers from typ and reports whether what a segment
+// resolves against a value of the type depends on the value: for unknown types,
+// interfaces, and types with custom resolution.
+func analyzedType(typ reflect.Type) (reflect.Type, bool) {
+	if typ == nil {
+		return nil, true
+	}
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	return typ, typ.Kind() == reflect.Interface || implementsAny(typ, pathResolvableType, pathAliaserType)
+}
+
+// implementsAny reports whether typ or a pointer to it implements one of ifaces.
+func implementsAny(typ reflect.Type, ifaces ...reflect.Type) bool {
+	for _, iface := range ifaces {
+		if typ.Implements(iface) || reflect.PointerTo(typ).Implements(iface) {
+			return true
+		}
+	}
+	return false
+}
+
+// calledType returns the type of the first result of a function without arguments
+// that is not an iterator, which is called when it is resolved, and any other type
+// as is.
+func calledType(typ reflect.Type) reflect.Type {
+	if typ.Kind() == reflect.Func && typ.NumIn() == 0 && typ.NumOut() > 0 {
+		return typ.Out(0)
+	}
+	return typ
+}
@@ -0,0 +1,143 @@
+package empaths
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Directory is the data model of the Analyze tests.
+type Directory struct {
+	Registry Registry
+	People   []Person
+	ByName   map[string]*Person
+	Head     func() Person
+	Extra    any
+	Ring     Ring
+}
+
+func TestAnalyze(t *testing.T) {
+	directory := reflect.TypeOf(Directory{})
+	person := reflect.TypeOf(Person{})
+	people := reflect.TypeOf([]Person{})
+
+	tests := []struct {
+		name     string
+		path     string
+		expected []Access
+	}{
+		{"field", ".People", []Access{
+			{".People", AccessField, directory, "People"},
+		}},
+		{"index and method", ".People[0].GetFullName", []Access{
+			{".People", AccessField, directory, "People"},
+			{".People[0]", AccessIndex, people, "0"},
+			{".People[0].GetFullName", AccessMethod, person, "GetFullName"},
+		}},
+		{"result selector", ".Registry.FindAdmin#1", []Access{
+			{".Registry", AccessField, directory, "Registry"},
+			{".Registry.FindAdmin#1", AccessMethod, reflect.TypeOf(Registry{}), "FindAdmin"},
+		}},
+		{"map key through pointer", ".ByName.alice.Address.City", []Access{
+			{".ByName", AccessField, directory, "ByName"},
+			{".ByName.alice", AccessKey, reflect.TypeOf(map[string]*Person{}), "alice"},
+			{".ByName.alice.Address", AccessField, person, "Address"},
+			{".ByName.alice.Address.City", AccessField, reflect.TypeOf(Address{}), "City"},
+		}},
+		{"function value", ".Head.IsAdult", []Access{
+			{".Head", AccessField, directory, "Head"},
+			{".Head.IsAdult", AccessMethod, person, "IsAdult"},
+		}},
+		{"filter", "count(.People[?.IsAdult && ^.Extra == 1])", []Access{
+			{".People", AccessField, directory, "People"},
+			{".People[*]", AccessElements, people, "*"},
+			{".People[*].IsAdult", AccessMethod, person, "IsAdult"},
+			{".Extra", AccessField, directory, "Extra"},
+		}},
+		{"reported once", ".People[0].Name .People[0].Name", []Access{
+			{".People", AccessField, directory, "People"},
+			{".People[0]", AccessIndex, people, "0"},
+			{".People[0].Name", AccessField, person, "Name"},
+		}},
+		{"interface", ".Extra.Delete", []Access{
+			{".Extra", AccessField, directory, "Extra"},
+			{".Extra.Delete", AccessDynamic, reflect.TypeOf((*any)(nil)).Elem(), "Delete"},
+		}},
+		{"custom resolution", ".Ring[3]", []Access{
+			{".Ring", AccessField, directory, "Ring"},
+			{".Ring[3]", AccessDynamic, reflect.TypeOf(Ring{}), "3"},
+		}},
+		{"function result", "first(.People).Name", []Access{
+			{".People", AccessField, directory, "People"},
+			{"first(.People).Name", AccessDynamic, nil, "Name"},
+		}},
+		{"variable", "$user.Name", []Access{
+			{"$user.Name", AccessDynamic, nil, "Name"},
+		}},
+		{"root in filter", "count(.People[?.Age > $.Registry.Size])", []Access{
+			{".People", AccessField, directory, "People"},
+			{".People[*]", AccessElements, people, "*"},
+			{".People[*].Age", AccessField, person, "Age"},
+			{".Registry", AccessField, directory, "Registry"},
+			{".Registry.Size", AccessMethod, reflect.TypeOf(Registry{}), "Size"},
+		}},
+		{"each", "each(.People, .Name ^.Extra)", []Access{
+			{".People", AccessField, directory, "People"},
+			{".People[*]", AccessElements, people, "*"},
+			{".People[*].Name", AccessField, person, "Name"},
+			{".Extra", AccessField, directory, "Extra"},
+		}},
+		{"each over filter", "each(.People[?.IsAdult], .Name)", []Access{
+			{".People", AccessField, directory, "People"},
+			{".People[*]", AccessElements, people, "*"},
+			{".People[*].IsAdult", AccessMethod, person, "IsAdult"},
+			{".People[*].Name", AccessField, person, "Name"},
+		}},
+		{"piped each", ".People | each(.Name)", []Access{
+			{".People", AccessField, directory, "People"},
+			{"each()[*].Name", AccessDynamic, nil, "Name"},
+		}},
+		{"no model paths", "'hello' len('x')", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			accesses, err := Analyze(tt.path, directory)
+			if err != nil {
+				t.Fatalf("Analyze(%q) error = %v", tt.path, err)
+			}
+			if !reflect.DeepEqual(accesses, tt.expected) {
+				t.Errorf("Analyze(%q) = %v, want %v", tt.path, accesses, tt.expected)
+			}
+		})
+	}
+}
+
+func TestAnalyze_Errors(t *testing.T) {
+	directory := reflect.TypeOf(Directory{})
+
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{"unknown field", ".People[0].Nmae", `field "Nmae" not found in empaths.Person; did you mean "Name"?`},
+		{"invalid index", ".People[x]", `field "x" not found in []empaths.Person`},
+		{"field of a number", ".Registry.Admin.Scores[x].y", `field "y" not found in int`},
+		{"syntax error", ".People[0", "syntax error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Analyze(tt.path, directory)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("Analyze(%q) error = %v, want %q", tt.path, err, tt.want)
+			}
+			var notFound *FieldNotFoundError
+			if !errors.As(err, &notFound) && !errors.Is(err, ErrSyntax) {
+				t.Errorf("Analyze(%q) error = %T, want *FieldNotFoundError or *SyntaxError", tt.path, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package empaths
+
+import "strings"
+
+// AuditRecord lists what a single evaluation of a path expression read.
+type AuditRecord struct {
+	// Path is the evaluated path expression.
+	Path string
+	// References are the names of the external references (":name") that were
+	// resolved, in the order of their first use.
+	References []string
+	// Fields are the model paths that were resolved, in the order of their first
+	// use. They are written relative to the data passed to Resolve (".User.Email"),
+	// with wildcards and filters written as "[*]", so the fields read inside the
+	// filter of ".Users[?.Active].Name" are recorded as ".Users[*].Active" and
+	// ".Users[*].Name". Paths resolved against the result of a function call start
+	// with the function ("first().Email").
+	Fields []string
+	// Secret reports whether the evaluation read a secret value (see WithSecrets).
+	Secret bool
+}
+
+// Auditor receives the AuditRecord of evaluations. It is called synchronously after
+// each evaluation and must be safe for concurrent use if the Resolver is used
+// concurrently.
+type Auditor func(record AuditRecord)
+
+// WithAudit makes a Resolver report which external references and model fields
+// every evaluation through Resolve, ResolveErr, ResolveCtx, and the typed variants
+// such as ResolveString read, batched into one AuditRecord per call, so it can be
+// recorded which templates read which attributes:
+//
+//	resolver := empaths.NewResolver(empaths.WithAudit(func(record empaths.AuditRecord) {
+//		auditLog.Info("template evaluated", "path", record.Path, "fields", record.Fields)
+//	}))
+//
+// Fields are recorded when they are accessed, whether or not they exist, and also
+// if the evaluation fails later. Paths that are rejected before evaluation, e.g. by
+// the access rules, are reported without references and fields.
+func WithAudit(auditor Auditor) Option {
+	return func(r *Resolver) {
+		r.auditor = auditor
+	}
+}
+
+// tracksFields reports whether evaluations record the fields they read in an
+// auditLog: for WithAudit, to match secret paths, to name the segments passed to the
+// Authorizer, and to check the targets of aliases against the allowed prefixes.
+func (r *Resolver) tracksFields() bool {
+	return r.auditor != nil || r.secrets != nil || r.authorizer != nil || r.allowed != nil
+}
+
+// audit reports the references and fields read by an evaluation. state is nil if
+// the path was not evaluated.
+func (r *Resolver) audit(path string, state *evalState) {
+	record := AuditRecord{Path: path}
+	if state != nil && state.audit != nil {
+		record.References = state.audit.references
+		record.Fields = state.audit.fields
+	}
+	record.Secret = state.readSecret()
+	r.auditor(record)
+}
+
+// auditLog collects the references and fields read by an evaluation (see
+// WithAudit). Besides the recorded names, it tracks the paths of the values that
+// model paths are currently resolved against.
+type auditLog struct {
+	references []string
+	fields     []string
+	seen       map[string]bool
+	// data is the path of the value that model paths starting with '.' are resolved
+	// against: empty for the top-level data, ".Users[*]" inside the filter of
+	// ".Users[?...]".
+	data string
+	// parent is the path of the value referred to by '^'.
+	parent string
+	// model is the path of the value the model path being resolved (the modelPath of
+	// the evalState) starts at.
+	model string
+}
+
+// newAuditLog returns an empty auditLog.
+func newAuditLog() *auditLog {
+	return &auditLog{seen: make(map[string]bool)}
+}
+
+// reference records the name of an external reference.
+func (a *auditLog) reference(name string) {
+	if key := ":" + name; !a.seen[key] {
+		a.seen[key] = true
+		a.references = append(a.references, name)
+	}
+}
+
+// visit records modelPath, resolved against the value at base, and makes base the
+// start of the model path being resolved. It returns a function that restores the
+// previous start.
+func (a *auditLog) visit(base string, modelPath string) func() {
+	if modelPath != "" {
+		if field := joinAuditPath(base, modelPath); !a.seen[field] {
+			a.seen[field] = true
+			a.fields = append(a.fields, field)
+		}
+	}
+	outer := a.model
+	a.model = base
+	return func() { a.model = outer }
+}
+
+// rebase makes base the path that model paths starting with '.' are resolved
+// against, as for "$.Budget" or "^.Limit". It returns a function that restores the
+// previous path.
+func (a *auditLog) rebase(base string) func() {
+	outer := a.data
+	a.data = base
+	return func() { a.data = outer }
+}
+
+// project enters the elements selected by a wildcard or filter. consumed is the
+// part of the model path being resolved that precedes the selector, e.g. "Users"
+// for "Users[?.Active].Name". It returns a function that restores the previous
+// paths.
+func (a *auditLog) project(consumed string) func() {
+	outerData, outerParent := a.data, a.parent
+	a.data = joinAuditPath(a.model, consumed+"[*]")
+	a.parent = joinAuditPath(a.model, auditOwner(normalizeAuditPath(consumed)))
+	return func() { a.data, a.parent = outerData, outerParent }
+}
+
+// joinAuditPath appends the model path modelPath (without its leading '.') to the
+// path of the value it is resolved against, writing filters as "[*]".
+func joinAuditPath(base string, modelPath string) string {
+	modelPath = normalizeAuditPath(modelPath)
+	if modelPath == "" {
+		return base
+	}
+	if base != "" && modelPath[0] == '[' {
+		return base + modelPath
+	}
+	return base + "." + modelPath
+}
+
+// normalizeAuditPath replaces the filters in a model path with "[*]".
+func normalizeAuditPath(modelPath string) string {
+	if !strings.Contains(modelPath, "[?") {
+		return modelPath
+	}
+	var sb strings.Builder
+	for index := 0; index < len(modelPath); {
+		if modelPath[index] != '[' {
+			sb.WriteByte(modelPath[index])
+			index++
+			continue
+		}
+		end := findClosingASCII(modelPath, index)
+		if end == -1 {
+			end = len(modelPath) - 1
+		}
+		if index+1 < len(modelPath) && modelPath[index+1] == '?' {
+			sb.WriteString("[*]")
+		} else {
+			sb.WriteString(modelPath[index : end+1])
+		}
+		index = end + 1
+	}
+	return sb.String()
+}
+
+// auditOwner returns the part of a normalized model path that refers to the value
+// holding the collection at its end, the counterpart of the owner of the evalState:
+// "A" for "A.Users[0]", and "" if it is the value the model path starts at.
+func auditOwner(modelPath string) string {
+	for strings.HasSuffix(modelPath, "]") {
+		open := strings.LastIndexByte(modelPath, '[')
+		if open == -1 {
+			return ""
+		}
+		modelPath = modelPath[:open]
+	}
+	if dot := strings.LastIndexByte(modelPath, '.'); dot != -1 {
+		return modelPath[:dot]
+	}
+	return ""
+}
@@ -0,0 +1,49 @@
+package empaths
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestResolver_WithAudit(t *testing.T) {
+	team := createTestTeam()
+	team["Limit"] = 28
+	team["Lead"] = map[string]any{"Reports": team["Users"]}
+	var records []AuditRecord
+	resolver := NewResolver(WithAudit(func(record AuditRecord) {
+		records = append(records, record)
+	}))
+	refs := func(name string, data any) any { return name }
+
+	tests := []struct {
+		name       string
+		path       string
+		references []string
+		fields     []string
+	}{
+		{"single field", ".Users[0].Name", nil, []string{".Users[0].Name"}},
+		{"recorded once", ".Users[0].Name ' ' .Scores.math ' ' .Users[0].Name", nil, []string{".Users[0].Name", ".Scores.math"}},
+		{"references", ":greeting ' ' .Users[1].Name ' ' :greeting ' ' :sign", []string{"greeting", "sign"}, []string{".Users[1].Name"}},
+		{"wildcard", "join(.Users[*].Name, ',')", nil, []string{".Users[*].Name"}},
+		{"filter", "join(.Users[?.Active && .Age > $.Limit].Name, ',')", nil, []string{".Users[*].Name", ".Users[*].Active", ".Users[*].Age", ".Limit"}},
+		{"parent", "count(.Lead.Reports[?.Age > ^.Limit])", nil, []string{".Lead.Reports[*]", ".Lead.Reports[*].Age", ".Lead.Limit"}},
+		{"function result", "first(.Users).Name", nil, []string{".Users", "first().Name"}},
+		{"missing field", ".Missing.Name", nil, []string{".Missing.Name"}},
+		{"no model paths", "'hello'", nil, nil},
+		{"empty path", "", nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records = nil
+			resolver.Resolve(tt.path, team, refs)
+			if len(records) != 1 {
+				t.Fatalf("Resolve(%q) audited %d evaluations, want 1", tt.path, len(records))
+			}
+			record := records[0]
+			if record.Path != tt.path || !reflect.DeepEqual(record.References, tt.references) || !reflect.DeepEqual(record.Fields, tt.fields) {
+				t.Errorf("Resolve(%q) audited %+v, want references %v and fields %v", tt.path, record, tt.references, tt.fields)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package empaths
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Authorizer decides whether a subject, such as the user or tenant a template is
+// rendered for, may read the value at segmentPath. It must be safe for concurrent
+// use if the Resolver is used concurrently.
+type Authorizer func(subject any, segmentPath string) bool
+
+// WithAuthorizer makes a Resolver consult authorizer before every field, method,
+// index, and key access, for row- and field-level access control inside an
+// evaluation:
+//
+//	resolver := empaths.NewResolver(empaths.WithAuthorizer(func(subject any, segmentPath string) bool {
+//		return !strings.HasSuffix(segmentPath, ".Salary") || subject.(*User).IsAdmin
+//	}))
+//	resolver.ResolveCtx(empaths.WithSubject(ctx, user), ".Employees[0].Salary", data, nil)
+//
+// The subject is the value passed to WithSubject for the context of ResolveCtx, or
+// nil for evaluations without it. segmentPath is the model path up to and including
+// the accessed segment, written like the Fields of AuditRecord: relative to the data
+// passed to Resolve (".Employees[0].Salary"), with the elements of wildcards and
+// filters written as "[*]" (".Employees[*].Salary"), and starting with the variable
+// or function for paths resolved against those ("$user.Name", "first().Name"). Keys
+// are written in dot notation if they are names and quoted in brackets otherwise,
+// so ".Limits['cpu']" is checked as ".Limits.cpu" and ".Labels[app name]" as
+// ".Labels['app name']".
+//
+// A segment that is not authorized resolves to nil, like a missing field. In strict
+// mode (see WithStrict), the evaluation is aborted with an error wrapping
+// ErrAccessDenied instead.
+func WithAuthorizer(authorizer Authorizer) Option {
+	return func(r *Resolver) {
+		r.authorizer = authorizer
+	}
+}
+
+// subjectKey is the context key of the subject set with WithSubject.
+type subjectKey struct{}
+
+// WithSubject returns a copy of ctx that carries subject, which is passed to the
+// Authorizer of a Resolver (see WithAuthorizer) when ctx is passed to ResolveCtx.
+func WithSubject(ctx context.Context, subject any) context.Context {
+	return context.WithValue(ctx, subjectKey{}, subject)
+}
+
+// authorize reports whether the segment at the start of path, which is a suffix of
+// the model path being resolved, may be read. segment is the segment as written in
+// the path, e.g. "Name" or "[0]".
+func (s *evalState) authorize(segment string, path string) bool {
+	if s.resolver == nil || s.resolver.authorizer == nil {
+		return true
+	}
+	consumed := segment
+	if strings.HasSuffix(s.modelPath, path) {
+		consumed = s.modelPath[:len(s.modelPath)-len(path)] + segment
+	}
+	base := ""
+	if s.audit != nil {
+		base = s.audit.model
+	}
+	segmentPath := joinAuditPath(base, strings.TrimPrefix(canonicalSegments(consumed), "."))
+	if s.resolver.authorizer(s.subject, segmentPath) {
+		return true
+	}
+	if s.diagnose() {
+		s.fail(fmt.Errorf("%w: %s is not authorized", ErrAccessDenied, segmentPath))
+	}
+	return false
+}
+
+// canonicalSegments writes a model path (without its leading '.') in the notation
+// passed to the Authorizer: keys that are names in dot notation, other keys quoted
+// (see keySegment), and wildcards and filters as "[*]".
+func canonicalSegments(modelPath string) string {
+	var sb strings.Builder
+	for index := 0; index < len(modelPath); {
+		switch modelPath[index] {
+		case '.':
+			index++
+		case '[':
+			end := findClosingASCII(modelPath, index)
+			if end == -1 {
+				end = len(modelPath) - 1
+			}
+			raw := modelPath[index+1 : end]
+			if raw == "*" || (len(raw) > 0 && raw[0] == '?') {
+				sb.WriteString("[*]")
+			} else {
+				sb.WriteString(keySegment(unquoteKey(raw)))
+			}
+			index = end + 1
+		default:
+			start := index
+			for index < len(modelPath) && modelPath[index] != '.' && modelPath[index] != '[' {
+				index++
+			}
+			sb.WriteString("." + modelPath[start:index])
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,80 @@
+package empaths
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestResolver_WithAuthorizer(t *testing.T) {
+	team := createTestTeam()
+	var checked []string
+	authorizer := func(subject any, segmentPath string) bool {
+		checked = append(checked, segmentPath)
+		if subject == "admin" {
+			return true
+		}
+		return !strings.HasSuffix(segmentPath, ".Age") && segmentPath != ".Scores.science"
+	}
+	resolver := NewResolver(WithAuthorizer(authorizer))
+
+	tests := []struct {
+		name     string
+		path     string
+		subject  any
+		expected any
+		checked  []string
+	}{
+		{"authorized", ".Users[0].Name", nil, "Alice", []string{".Users", ".Users[0]", ".Users[0].Name"}},
+		{"unauthorized field", ".Users[1].Age", nil, nil, []string{".Users", ".Users[1]", ".Users[1].Age"}},
+		{"unauthorized key", ".Scores['science']", nil, nil, []string{".Scores", ".Scores.science"}},
+		{"subject", ".Users[1].Age", "admin", 25, nil},
+		{"filter", "count(.Users[?.Age > 26])", nil, 0, nil},
+		{"filter for subject", "count(.Users[?.Age > 26])", "admin", 2, nil},
+		{"memoized", ".Scores.math ' ' .Scores.science", nil, "95 ", []string{".Scores", ".Scores.math", ".Scores.science"}},
+		{"root", "$.Users[2].Name", nil, "Carol", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checked = nil
+			ctx := context.Background()
+			if tt.subject != nil {
+				ctx = WithSubject(ctx, tt.subject)
+			}
+			result, err := resolver.ResolveCtx(ctx, tt.path, team, nil)
+			if err != nil {
+				t.Fatalf("ResolveCtx(%q) error = %v", tt.path, err)
+			}
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("ResolveCtx(%q) = %v, want %v", tt.path, result, tt.expected)
+			}
+			if tt.checked != nil && !reflect.DeepEqual(checked, tt.checked) {
+				t.Errorf("ResolveCtx(%q) checked %q, want %q", tt.path, checked, tt.checked)
+			}
+		})
+	}
+
+	t.Run("filter segments", func(t *testing.T) {
+		checked = nil
+		resolver.Resolve("count(.Users[?.Active])", team, nil)
+		want := []string{".Users", ".Users[*].Active"}
+		if !reflect.DeepEqual(checked[:2], want) {
+			t.Errorf("Resolve() checked %q, want %q first", checked, want)
+		}
+	})
+
+	t.Run("strict", func(t *testing.T) {
+		strict := NewResolver(WithStrict(), WithAuthorizer(authorizer))
+		_, err := strict.ResolveErr(".Users[0].Name ' ' .Users[0].Age", team, nil)
+		if !errors.Is(err, ErrAccessDenied) || !strings.Contains(err.Error(), ".Users[0].Age") {
+			t.Errorf("ResolveErr() error = %v, want ErrAccessDenied for .Users[0].Age", err)
+		}
+		_, trace := strict.ResolveTrace(".Users[0].Age", team, nil)
+		if len(trace) != 1 || !errors.Is(trace[0], ErrAccessDenied) {
+			t.Errorf("ResolveTrace() = %v, want ErrAccessDenied", trace)
+		}
+	})
+}
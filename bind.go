@@ -0,0 +1,212 @@
+package empaths
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Bind sets the values of a submitted form or query string on target, keyed by model
+// paths without their leading dot, so forms can be decoded with the same paths that
+// templates use to render them:
+//
+//	// user.name=Alice&user.address.city=Berlin&items[0].qty=2&tags=a&tags=b
+//	var order Order
+//	err := empaths.Bind(r.Form, &order)
+//
+// Every key is set like SetCreate sets it, so missing intermediate values are
+// allocated and slices grow to the addressed index. Field names are matched
+// exactly or, failing that, case-insensitively, so "user.address.city" sets
+// User.Address.City. The strings are converted to the type of their target: to
+// strings, booleans, and numbers (rejecting values that overflow the type), to
+// types implementing encoding.TextUnmarshaler (such as time.Time), to structs and
+// arrays written as JSON, and to pointers to these types. All values of a key fill a
+// slice; for other types the first value is used. An empty string sets the zero
+// value of types other than strings, and values set on interfaces are strings.
+//
+// Keys are bound in sorted order. A key that cannot be bound does not stop the
+// others, so target may be partially modified if Bind returns an error.
+//
+// Parameters:
+//   - values: The form or query values, e.g. http.Request.Form
+//   - target: A non-nil pointer to the data to fill
+//
+// Returns:
+//   - Error naming every key that could not be bound, joined with errors.Join
+func Bind(values url.Values, target any) error {
+	root := reflect.ValueOf(target)
+	if root.Kind() != reflect.Ptr || root.IsNil() {
+		return errors.New("binding requires a non-nil pointer")
+	}
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var errs []error
+	for _, key := range keys {
+		if err := bindKey(key, values[key], target, root.Type().Elem()); err != nil {
+			errs = append(errs, fmt.Errorf("bind %q: %w", key, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// bindKey sets the values of a form key on target, whose element type is typ.
+func bindKey(key string, raw []string, target any, typ reflect.Type) error {
+	path, leaf, err := bindPath(key, typ)
+	if err != nil {
+		return err
+	}
+	value, err := formValue(raw, leaf)
+	if err != nil {
+		return err
+	}
+	return SetCreate(path, target, value)
+}
+
+// bindPath returns the model path that a form key addresses in a value of type typ,
+// with the names of struct fields matched case-insensitively, and the type of the
+// value at that path. The type is nil if it depends on the data, behind an
+// interface.
+func bindPath(key string, typ reflect.Type) (string, reflect.Type, error) {
+	if !strings.HasPrefix(key, ".") {
+		key = "." + key
+	}
+	segments, err := parseSegments(key)
+	if err != nil {
+		return "", nil, err
+	}
+	var sb strings.Builder
+	for _, segment := range segments {
+		for typ != nil && typ.Kind() == reflect.Ptr {
+			typ = typ.Elem()
+		}
+		if typ != nil {
+			switch typ.Kind() {
+			case reflect.Struct:
+				field, ok := bindField(typ, segment.name)
+				if !ok {
+					return "", nil, fmt.Errorf("field %s not found in %s", segment, typ)
+				}
+				segment.name, typ = field.Name, field.Type
+			case reflect.Map, reflect.Slice, reflect.Array:
+				typ = typ.Elem()
+			case reflect.Interface:
+				typ = nil
+			default:
+				return "", nil, fmt.Errorf("cannot resolve %s on %s", segment, typ)
+			}
+		}
+		if segment.bracket {
+			sb.WriteString(formatKey(segment.name))
+		} else {
+			sb.WriteString("." + segment.name)
+		}
+	}
+	if sb.Len() == 0 {
+		return ".", typ, nil
+	}
+	return sb.String(), typ, nil
+}
+
+// bindField returns the exported field of a struct type named name, or failing
+// that, the first one whose name matches it case-insensitively.
+func bindField(typ reflect.Type, name string) (reflect.StructField, bool) {
+	if field, ok := typ.FieldByName(name); ok && field.IsExported() {
+		return field, true
+	}
+	for _, field := range reflect.VisibleFields(typ) {
+		if field.IsExported() && !field.Anonymous && strings.EqualFold(field.Name, name) {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// formValue converts the values of a form key to typ (see Bind); typ is nil for
+// values set on interfaces.
+func formValue(raw []string, typ reflect.Type) (any, error) {
+	if len(raw) == 0 {
+		return nil, errors.New("no value")
+	}
+	if typ == nil || typ.Kind() == reflect.Interface {
+		return raw[0], nil
+	}
+	if typ.Kind() == reflect.Slice && typ.Elem().Kind() != reflect.Uint8 && !reflect.PointerTo(typ).Implements(textUnmarshalerType) {
+		slice := reflect.MakeSlice(typ, len(raw), len(raw))
+		for i, s := range raw {
+			elem, err := formScalar(s, typ.Elem())
+			if err != nil {
+				return nil, err
+			}
+			slice.Index(i).Set(elem)
+		}
+		return slice.Interface(), nil
+	}
+	value, err := formScalar(raw[0], typ)
+	if err != nil {
+		return nil, err
+	}
+	return value.Interface(), nil
+}
+
+// formScalar converts a single form value to typ.
+func formScalar(s string, typ reflect.Type) (reflect.Value, error) {
+	if typ.Kind() == reflect.Ptr {
+		elem, err := formScalar(s, typ.Elem())
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		ptr := reflect.New(typ.Elem())
+		ptr.Elem().Set(elem)
+		return ptr, nil
+	}
+	if typ.Kind() == reflect.Interface {
+		return reflect.ValueOf(s).Convert(typ), nil
+	}
+	if typ.Kind() == reflect.Slice && typ.Elem().Kind() == reflect.Uint8 {
+		return reflect.ValueOf([]byte(s)).Convert(typ), nil
+	}
+	if s == "" && typ.Kind() != reflect.String {
+		return reflect.Zero(typ), nil
+	}
+
+	if !reflect.PointerTo(typ).Implements(textUnmarshalerType) {
+		value := reflect.New(typ).Elem()
+		var err error
+		switch typ.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			var n int64
+			if n, err = strconv.ParseInt(s, 10, typ.Bits()); err == nil {
+				value.SetInt(n)
+				return value, nil
+			}
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			var n uint64
+			if n, err = strconv.ParseUint(s, 10, typ.Bits()); err == nil {
+				value.SetUint(n)
+				return value, nil
+			}
+		case reflect.Float32, reflect.Float64:
+			var f float64
+			if f, err = strconv.ParseFloat(s, typ.Bits()); err == nil {
+				value.SetFloat(f)
+				return value, nil
+			}
+		}
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("cannot convert %q to %s", s, typ)
+		}
+	}
+	value := parseMapKey(s, typ)
+	if !value.IsValid() {
+		return reflect.Value{}, fmt.Errorf("cannot convert %q to %s", s, typ)
+	}
+	return value, nil
+}
@@ -0,0 +1,137 @@
+package empaths
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// BindAddress is the address of a BindForm.
+type BindAddress struct {
+	City string
+	Zip  int
+}
+
+// BindItem is a line of a BindForm.
+type BindItem struct {
+	SKU string
+	Qty uint8
+}
+
+// BindForm is the data model of the Bind tests.
+type BindForm struct {
+	Name     string
+	Age      int
+	Active   bool
+	Score    float64
+	Address  *BindAddress
+	Items    []BindItem
+	Tags     []string
+	Ports    []int
+	Due      time.Time
+	Nickname *string
+	Attrs    map[string]int
+	Extra    any
+	Raw      []byte
+}
+
+func TestBind(t *testing.T) {
+	nickname := "Al"
+	tests := []struct {
+		name     string
+		query    string
+		expected BindForm
+	}{
+		{"string", "Name=Alice", BindForm{Name: "Alice"}},
+		{"case-insensitive names", "name=Alice&address.city=Berlin", BindForm{Name: "Alice", Address: &BindAddress{City: "Berlin"}}},
+		{"numbers and booleans", "age=30&active=true&score=1.5&address.zip=10115", BindForm{Age: 30, Active: true, Score: 1.5, Address: &BindAddress{Zip: 10115}}},
+		{"slice elements", "items[1].qty=2&items[0].sku=A1", BindForm{Items: []BindItem{{SKU: "A1"}, {Qty: 2}}}},
+		{"repeated values", "tags=a&tags=b&ports=80&ports=443", BindForm{Tags: []string{"a", "b"}, Ports: []int{80, 443}}},
+		{"indexed values", "tags[1]=b&tags[0]=a", BindForm{Tags: []string{"a", "b"}}},
+		{"text unmarshaler", "due=2024-06-01T12:00:00Z", BindForm{Due: time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)}},
+		{"pointer", "nickname=Al", BindForm{Nickname: &nickname}},
+		{"map", "attrs.height=180&attrs[with.dot]=1", BindForm{Attrs: map[string]int{"height": 180, "with.dot": 1}}},
+		{"interface", "extra.color=red", BindForm{Extra: map[string]any{"color": "red"}}},
+		{"bytes", "raw=abc", BindForm{Raw: []byte("abc")}},
+		{"empty number", "age=", BindForm{}},
+		{"first value", "name=Alice&name=Bob", BindForm{Name: "Alice"}},
+		{"leading dot", ".Name=Alice", BindForm{Name: "Alice"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatalf("ParseQuery(%q) error = %v", tt.query, err)
+			}
+			var form BindForm
+			if err := Bind(values, &form); err != nil {
+				t.Fatalf("Bind(%q) error = %v", tt.query, err)
+			}
+			if !reflect.DeepEqual(form, tt.expected) {
+				t.Errorf("Bind(%q) = %+v, want %+v", tt.query, form, tt.expected)
+			}
+		})
+	}
+}
+
+func TestBind_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		keys  []string
+	}{
+		{"unknown field", "nmae=Alice", []string{`"nmae"`}},
+		{"not a number", "age=thirty", []string{`"age"`}},
+		{"overflow", "items[0].qty=300", []string{`"items[0].qty"`}},
+		{"not a time", "due=tomorrow", []string{`"due"`}},
+		{"below a leaf", "name.first=Alice", []string{`"name.first"`}},
+		{"malformed", "items[0=1", []string{`"items[0"`}},
+		{"every failing key", "age=x&name=Alice&score=y", []string{`"age"`, `"score"`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, _ := url.ParseQuery(tt.query)
+			var form BindForm
+			err := Bind(values, &form)
+			if err == nil {
+				t.Fatalf("Bind(%q) error = nil, want an error", tt.query)
+			}
+			for _, key := range tt.keys {
+				if !strings.Contains(err.Error(), key) {
+					t.Errorf("Bind(%q) error = %v, want it to name %s", tt.query, err, key)
+				}
+			}
+		})
+	}
+
+	t.Run("other keys are bound", func(t *testing.T) {
+		var form BindForm
+		_ = Bind(url.Values{"age": {"x"}, "name": {"Alice"}}, &form)
+		if form.Name != "Alice" {
+			t.Errorf("Name = %q, want %q", form.Name, "Alice")
+		}
+	})
+
+	t.Run("not a pointer", func(t *testing.T) {
+		if err := Bind(url.Values{"name": {"Alice"}}, BindForm{}); err == nil {
+			t.Errorf("Bind() error = nil, want an error")
+		}
+		var nilForm *BindForm
+		if err := Bind(url.Values{"name": {"Alice"}}, nilForm); err == nil {
+			t.Errorf("Bind() error = nil, want an error")
+		}
+	})
+
+	t.Run("joined errors", func(t *testing.T) {
+		err := Bind(url.Values{"age": {"x"}, "score": {"y"}}, &BindForm{})
+		var joined interface{ Unwrap() []error }
+		if !errors.As(err, &joined) || len(joined.Unwrap()) != 2 {
+			t.Errorf("Bind() error = %v, want two joined errors", err)
+		}
+	})
+}
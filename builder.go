@@ -0,0 +1,241 @@
+package empaths
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// PathBuilder builds path expressions from parts, taking care of quoting and
+// escaping. Building paths this way is safe for user-supplied keys and values,
+// which could otherwise inject path syntax when concatenated into a path string.
+//
+// A PathBuilder is an immutable value: every method returns a new builder, so a
+// common prefix can be reused for several paths.
+//
+//	path := empaths.Path().Field("Users").Index(0).Key(userKey).String()
+//	rule := empaths.Path().Field("Status").Compare("==", wanted).String()
+type PathBuilder struct {
+	path string
+	err  error
+}
+
+// Path starts a new model path. Without further segments it addresses the data itself.
+func Path() PathBuilder {
+	return PathBuilder{}
+}
+
+// Field appends a struct field, method, or string map key in dot notation. Names
+// that are not plain identifiers are appended in quoted bracket notation instead,
+// so they can never be misread as path syntax.
+func (b PathBuilder) Field(name string) PathBuilder {
+	if !isPlainName(name) {
+		return b.Key(name)
+	}
+	b.path += "." + name
+	return b
+}
+
+// Index appends an array or slice index.
+func (b PathBuilder) Index(index int) PathBuilder {
+	if index < 0 && b.err == nil {
+		b.err = fmt.Errorf("negative index %d", index)
+	}
+	b.path = b.modelPath() + "[" + strconv.Itoa(index) + "]"
+	return b
+}
+
+// Key appends a map key in quoted bracket notation. The key is escaped, so it may
+// contain any characters.
+func (b PathBuilder) Key(key string) PathBuilder {
+	b.path = b.modelPath() + "[" + QuoteLiteral(key) + "]"
+	return b
+}
+
+// All appends a wildcard ("[*]") that selects every element of a collection.
+func (b PathBuilder) All() PathBuilder {
+	b.path = b.modelPath() + "[*]"
+	return b
+}
+
+// Filter appends a filter ("[?...]") that selects the elements of a collection for
+// which condition is true. condition is evaluated against each element and is
+// usually built with Compare, e.g. Path().Field("Active").Compare("==", true).
+func (b PathBuilder) Filter(condition PathBuilder) PathBuilder {
+	if condition.err != nil && b.err == nil {
+		b.err = condition.err
+	}
+	filter := condition.String()
+	if !strings.HasPrefix(filter, "?") {
+		filter = "?" + filter + "==true"
+	}
+	b.path = b.modelPath() + "[" + filter + "]"
+	return b
+}
+
+// Compare turns the path into a comparison of the addressed value with a literal.
+// The operator must be one of the comparison operators supported by Resolve (for
+// example "==", "!=", "contains", or "in"). value is written as a literal: strings
+// are quoted and escaped, numbers, booleans, and nil are written bare, and slices
+// of those become list literals.
+func (b PathBuilder) Compare(operator string, value any) PathBuilder {
+	if _, end, err := parseOperator(operator, 0); (err != nil || end != len(operator)) && b.err == nil {
+		b.err = fmt.Errorf("invalid comparison operator %q", operator)
+	}
+	literal, err := formatLiteral(value)
+	if err != nil && b.err == nil {
+		b.err = err
+	}
+	b.path = "?" + b.modelPath() + " " + operator + " " + literal
+	return b
+}
+
+// Compile returns the built path expression as a CompiledPath, or the first error
+// encountered while building or compiling it.
+func (b PathBuilder) Compile() (*CompiledPath, error) {
+	path, err := b.Build()
+	if err != nil {
+		return nil, err
+	}
+	return Compile(path)
+}
+
+// String returns the built path expression.
+func (b PathBuilder) String() string {
+	if b.path == "" {
+		return "."
+	}
+	return b.path
+}
+
+// Build returns the built path expression, or the first error encountered while
+// building it (such as a negative index or an unsupported literal type).
+func (b PathBuilder) Build() (string, error) {
+	if b.err != nil {
+		return "", b.err
+	}
+	return b.String(), nil
+}
+
+// modelPath returns the path built so far with a leading '.', as required before a
+// bracket segment.
+func (b PathBuilder) modelPath() string {
+	return modelPathOf(b.path)
+}
+
+// JoinPath appends the model path sub to the model path base, taking care of the
+// dots between them:
+//
+//	JoinPath(".User", "Name")         // ".User.Name"
+//	JoinPath(".User.", ".Name")       // ".User.Name"
+//	JoinPath(".Users", "[0].Name")    // ".Users[0].Name"
+//	JoinPath(".", ".Name")            // ".Name"
+//	JoinPath(".Labels", "'app.kubernetes.io/name'") // ".Labels['app.kubernetes.io/name']"
+//
+// sub may start with or without a dot, also before a bracket segment. A sub that is a quoted string literal is a
+// single map key and is appended in bracket notation, so keys that contain dots or
+// brackets stay one segment. An empty sub (or ".") returns base; an empty base is
+// the root path.
+func JoinPath(base string, sub string) string {
+	base = strings.TrimSuffix(base, ".")
+	if strings.HasPrefix(sub, ".[") {
+		sub = sub[1:]
+	}
+	switch {
+	case sub == "" || sub == ".":
+		if base == "" {
+			return "."
+		}
+		return base
+	case sub[0] == '\'' || sub[0] == '"':
+		return modelPathOf(base) + "[" + sub + "]"
+	case sub[0] == '[':
+		return modelPathOf(base) + sub
+	case sub[0] == '.':
+		return base + sub
+	default:
+		return base + "." + sub
+	}
+}
+
+// modelPathOf returns path, or the root path "." if path is empty.
+func modelPathOf(path string) string {
+	if path == "" {
+		return "."
+	}
+	return path
+}
+
+// isPlainName reports whether name can be written in dot notation: it must be an
+// identifier (letters, digits, and underscores, not starting with a digit).
+func isPlainName(name string) bool {
+	if name == "" || !isIdentStart(name, 0) {
+		return false
+	}
+	for index := 0; index < len(name); {
+		width := identCharWidth(name, index)
+		if width == 0 {
+			return false
+		}
+		index += width
+	}
+	return true
+}
+
+// QuoteLiteral returns s as a single-quoted string literal that Resolve reads back
+// as exactly s. Backslashes, quotes, newlines, tabs, and carriage returns are escaped.
+func QuoteLiteral(s string) string {
+	var sb strings.Builder
+	sb.Grow(len(s) + 2)
+	sb.WriteByte('\'')
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\\', '\'':
+			sb.WriteByte('\\')
+			sb.WriteByte(c)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\t':
+			sb.WriteString(`\t`)
+		case '\r':
+			sb.WriteString(`\r`)
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	sb.WriteByte('\'')
+	return sb.String()
+}
+
+// formatLiteral writes value as a path literal.
+func formatLiteral(value any) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "nil", nil
+	case string:
+		return QuoteLiteral(v), nil
+	case bool:
+		return toString(v), nil
+	case []string:
+		items := make([]any, len(v))
+		for i, item := range v {
+			items[i] = item
+		}
+		return formatLiteral(items)
+	case []any:
+		parts := make([]string, len(v))
+		for i, item := range v {
+			part, err := formatLiteral(item)
+			if err != nil {
+				return "", err
+			}
+			parts[i] = part
+		}
+		return "[" + strings.Join(parts, ", ") + "]", nil
+	default:
+		if _, ok := toFloat64(value); ok {
+			return toString(value), nil
+		}
+		return QuoteLiteral(toString(value)), fmt.Errorf("unsupported literal type %T", value)
+	}
+}
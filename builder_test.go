@@ -0,0 +1,148 @@
+package empaths
+
+import (
+	"testing"
+)
+
+func TestPathBuilder(t *testing.T) {
+	tests := []struct {
+		name     string
+		builder  PathBuilder
+		expected string
+	}{
+		{"empty", Path(), "."},
+		{"field", Path().Field("User").Field("Name"), ".User.Name"},
+		{"index", Path().Field("Users").Index(0).Field("Name"), ".Users[0].Name"},
+		{"root index", Path().Index(2), ".[2]"},
+		{"key", Path().Field("Data").Key("my key"), ".Data['my key']"},
+		{"key with quote", Path().Key("it's"), `.['it\'s']`},
+		{"field that is not a name", Path().Field("a.b"), ".['a.b']"},
+		{"field starting with a digit", Path().Field("Data").Field("0"), ".Data['0']"},
+		{"unicode field", Path().Field("Straße"), ".Straße"},
+		{"wildcard", Path().Field("Users").All().Field("Name"), ".Users[*].Name"},
+		{"compare string", Path().Field("Status").Compare("==", "active"), "?.Status == 'active'"},
+		{"compare number", Path().Field("Age").Compare("!=", 30), "?.Age != 30"},
+		{"compare list", Path().Field("Status").Compare("in", []string{"a", "b"}), "?.Status in ['a', 'b']"},
+		{"filter", Path().Field("Users").Filter(Path().Field("Active").Compare("==", true)).Field("Name"), ".Users[?.Active == true].Name"},
+		{"filter without comparison", Path().Field("Users").Filter(Path().Field("Active")), ".Users[?.Active==true]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := tt.builder.Build()
+			if err != nil {
+				t.Fatalf("Build() returned error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("Build() = %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestPathBuilder_Resolves(t *testing.T) {
+	hostile := `x' .Secret '`
+	data := map[string]any{
+		"Data":   map[string]any{hostile: "value", "a.b[0]": "dotted", "line\nbreak": "escaped"},
+		"Secret": "leaked",
+		"Status": hostile,
+		"Users":  []Member{{Name: "Alice", Active: true}, {Name: "Bob"}},
+	}
+
+	tests := []struct {
+		name     string
+		builder  PathBuilder
+		expected any
+	}{
+		{"hostile key", Path().Field("Data").Key(hostile), "value"},
+		{"key with dots and brackets", Path().Field("Data").Field("a.b[0]"), "dotted"},
+		{"key with newline", Path().Field("Data").Key("line\nbreak"), "escaped"},
+		{"hostile comparison value", Path().Field("Status").Compare("==", hostile), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := tt.builder.String()
+			result := Resolve(path, data, nil)
+			if result != tt.expected {
+				t.Errorf("Resolve(%q) = %v, want %v", path, result, tt.expected)
+			}
+		})
+	}
+
+	t.Run("filter projection", func(t *testing.T) {
+		path := Path().Field("Users").Filter(Path().Field("Active").Compare("==", true)).Field("Name").String()
+		result, ok := Resolve(path, data, nil).([]string)
+		if !ok || len(result) != 1 || result[0] != "Alice" {
+			t.Errorf("Resolve(%q) = %v, want [Alice]", path, result)
+		}
+	})
+}
+
+func TestPathBuilder_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		builder PathBuilder
+	}{
+		{"negative index", Path().Index(-1)},
+		{"invalid operator", Path().Field("A").Compare("=", 1)},
+		{"operator with trailing garbage", Path().Field("A").Compare("==x", 1)},
+		{"unsupported literal", Path().Field("A").Compare("==", struct{}{})},
+		{"error in filter", Path().Field("A").Filter(Path().Index(-1))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.builder.Build(); err == nil {
+				t.Errorf("Build() should return an error")
+			}
+		})
+	}
+}
+
+func TestQuoteLiteral(t *testing.T) {
+	inputs := []string{"", "plain", "it's", `back\slash`, "new\nline", "tab\t", "日本語", `'\'`}
+	for _, input := range inputs {
+		quoted := QuoteLiteral(input)
+		if result := Resolve(quoted, nil, nil); result != input {
+			t.Errorf("Resolve(QuoteLiteral(%q)) = %q, want %q", input, result, input)
+		}
+	}
+}
+
+func TestJoinPath(t *testing.T) {
+	tests := []struct {
+		base     string
+		sub      string
+		expected string
+	}{
+		{".User", "Name", ".User.Name"},
+		{".User", ".Name", ".User.Name"},
+		{".User.", ".Name", ".User.Name"},
+		{".User", "Address.City", ".User.Address.City"},
+		{".Users", "[0].Name", ".Users[0].Name"},
+		{".Users[0]", "Name", ".Users[0].Name"},
+		{".Users", ".[0]", ".Users[0]"},
+		{".", ".[0]", ".[0]"},
+		{".", ".Name", ".Name"},
+		{".", "Name", ".Name"},
+		{"", "Name", ".Name"},
+		{".", "[0]", ".[0]"},
+		{"", "[0]", ".[0]"},
+		{".User", "", ".User"},
+		{".User", ".", ".User"},
+		{".", ".", "."},
+		{"", "", "."},
+		{".Labels", "'app.kubernetes.io/name'", ".Labels['app.kubernetes.io/name']"},
+		{".Labels", QuoteLiteral("it's"), `.Labels['it\'s']`},
+		{"", "'key'", ".['key']"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.base+"+"+tt.sub, func(t *testing.T) {
+			if result := JoinPath(tt.base, tt.sub); result != tt.expected {
+				t.Errorf("JoinPath(%q, %q) = %q, want %q", tt.base, tt.sub, result, tt.expected)
+			}
+		})
+	}
+}
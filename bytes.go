@@ -0,0 +1,56 @@
+package empaths
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"reflect"
+)
+
+// BytesEncoding determines how a Resolver converts byte slices to strings (see
+// WithBytesEncoding).
+type BytesEncoding int
+
+const (
+	// BytesAsString converts a byte slice to the string it holds. This is the default.
+	BytesAsString BytesEncoding = iota
+	// BytesAsHex encodes a byte slice as lowercase hexadecimal.
+	BytesAsHex
+	// BytesAsBase64 encodes a byte slice with standard base64 encoding.
+	BytesAsBase64
+)
+
+// WithBytesEncoding makes a Resolver encode byte slices (including named types such
+// as json.RawMessage) when it converts them to strings: the operands of a
+// concatenation and the results of the fmt function. By default byte slices are
+// converted to the string they hold, which suits textual payloads; binary data such
+// as digests or keys is better rendered as hex or base64:
+//
+//	resolver := empaths.NewResolver(empaths.WithBytesEncoding(empaths.BytesAsHex))
+//	resolver.Resolve("'checksum: ' .Digest", file, nil) // "checksum: 9f86d081..."
+//
+// A Formatter (see WithFormatter) takes precedence over the encoding.
+func WithBytesEncoding(encoding BytesEncoding) Option {
+	return func(r *Resolver) {
+		r.bytesEncoding = encoding
+	}
+}
+
+// encodeBytes converts v to a string with the given encoding if it is a byte slice.
+// It returns false for any other value and for BytesAsString.
+func encodeBytes(v any, encoding BytesEncoding) (string, bool) {
+	if encoding == BytesAsString || v == nil {
+		return "", false
+	}
+	value := reflect.ValueOf(v)
+	if value.Kind() != reflect.Slice || value.Type().Elem().Kind() != reflect.Uint8 {
+		return "", false
+	}
+	switch encoding {
+	case BytesAsHex:
+		return hex.EncodeToString(value.Bytes()), true
+	case BytesAsBase64:
+		return base64.StdEncoding.EncodeToString(value.Bytes()), true
+	default:
+		return "", false
+	}
+}
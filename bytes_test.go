@@ -0,0 +1,51 @@
+package empaths
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestResolver_WithBytesEncoding(t *testing.T) {
+	data := map[string]any{
+		"Payload": []byte("hi"),
+		"Raw":     json.RawMessage(`{"a":1}`),
+		"Name":    "Alice",
+	}
+
+	tests := []struct {
+		name     string
+		encoding BytesEncoding
+		path     string
+		expected any
+	}{
+		{"string", BytesAsString, "'payload: ' .Payload", "payload: hi"},
+		{"raw message as string", BytesAsString, "'raw: ' .Raw", `raw: {"a":1}`},
+		{"hex", BytesAsHex, "'payload: ' .Payload", "payload: 6869"},
+		{"base64", BytesAsBase64, "'payload: ' .Payload", "payload: aGk="},
+		{"raw message as base64", BytesAsBase64, "'' .Raw", "eyJhIjoxfQ=="},
+		{"fmt", BytesAsHex, ".Payload | fmt('')", "6869"},
+		{"strings are not encoded", BytesAsHex, "'name: ' .Name", "name: Alice"},
+		{"single operand is not converted", BytesAsHex, "len(.Payload)", 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolver := NewResolver(WithBytesEncoding(tt.encoding))
+			if result := resolver.Resolve(tt.path, data, nil); result != tt.expected {
+				t.Errorf("Resolve(%q) = %v, want %v", tt.path, result, tt.expected)
+			}
+		})
+	}
+
+	t.Run("formatter takes precedence", func(t *testing.T) {
+		resolver := NewResolver(WithBytesEncoding(BytesAsHex), WithFormatter(func(v any, hint string) (string, bool) {
+			if b, ok := v.([]byte); ok {
+				return "<" + string(b) + ">", true
+			}
+			return "", false
+		}))
+		if result := resolver.ResolveString(".Payload", data, nil); result != "<hi>" {
+			t.Errorf("ResolveString() = %q, want %q", result, "<hi>")
+		}
+	})
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// formatFromName derives the input format from a file name.
+func formatFromName(name string) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".yaml", ".yml":
+		return "yaml"
+	case ".toml":
+		return "toml"
+	default:
+		return "json"
+	}
+}
+
+// decodeDocuments decodes all documents in r. JSON and YAML input may contain
+// several documents; TOML input is always a single document.
+func decodeDocuments(r io.Reader, format string) ([]any, error) {
+	switch format {
+	case "json":
+		decoder := json.NewDecoder(r)
+		var documents []any
+		for {
+			var document any
+			if err := decoder.Decode(&document); errors.Is(err, io.EOF) {
+				return documents, nil
+			} else if err != nil {
+				return nil, fmt.Errorf("invalid JSON: %w", err)
+			}
+			documents = append(documents, document)
+		}
+	case "yaml":
+		decoder := yaml.NewDecoder(r)
+		var documents []any
+		for {
+			var document any
+			if err := decoder.Decode(&document); errors.Is(err, io.EOF) {
+				return documents, nil
+			} else if err != nil {
+				return nil, fmt.Errorf("invalid YAML: %w", err)
+			}
+			documents = append(documents, document)
+		}
+	case "toml":
+		var document map[string]any
+		if _, err := toml.NewDecoder(r).Decode(&document); err != nil {
+			return nil, fmt.Errorf("invalid TOML: %w", err)
+		}
+		return []any{document}, nil
+	default:
+		return nil, fmt.Errorf("unknown input format %q", format)
+	}
+}
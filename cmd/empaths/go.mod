@@ -0,0 +1,14 @@
+module github.com/authentic-devel/empaths/cmd/empaths
+
+go 1.21.0
+
+require (
+	github.com/BurntSushi/toml v1.4.0
+	github.com/authentic-devel/empaths v0.0.0
+	golang.org/x/term v0.20.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require golang.org/x/sys v0.20.0 // indirect
+
+replace github.com/authentic-devel/empaths => ../..
@@ -0,0 +1,135 @@
+// The empaths command resolves a path expression against JSON, YAML, or TOML
+// documents and prints the result.
+//
+// Usage:
+//
+//	empaths [-f json|yaml|toml] [-o text|json] PATH [FILE...]
+//	empaths [-f json|yaml|toml] repl FILE
+//
+// Without FILE arguments the document is read from standard input. The input format
+// is derived from the file extension unless -f is given, and defaults to JSON. A
+// file may contain several documents (a JSON stream or "---" separated YAML); the
+// path is resolved against each of them.
+//
+// The repl command loads a document and evaluates the expressions typed at the
+// prompt. On a terminal, Tab completes field, method, and map key names.
+//
+// Examples:
+//
+//	empaths '.items[0].metadata.name' deployment.yaml
+//	empaths -o json '.spec.containers[*].image' pod.json
+//	curl -s https://api.example.com/users | empaths "count(.[?.active==true])"
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+
+	"github.com/authentic-devel/empaths"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run executes the command and returns its exit code.
+func run(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("empaths", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	inputFormat := flags.String("f", "", "input format: json, yaml, or toml (default: from file extension, else json)")
+	outputFormat := flags.String("o", "text", "output format: text or json")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: empaths [-f json|yaml|toml] [-o text|json] PATH [FILE...]")
+		fmt.Fprintln(stderr, "       empaths [-f json|yaml|toml] repl FILE")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() < 1 || (*outputFormat != "text" && *outputFormat != "json") {
+		flags.Usage()
+		return 2
+	}
+
+	if flags.Arg(0) == "repl" {
+		return runREPL(flags.Args()[1:], *inputFormat, stdin, stdout, stderr)
+	}
+
+	path := flags.Arg(0)
+	files := flags.Args()[1:]
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+
+	for _, name := range files {
+		documents, err := readDocuments(name, *inputFormat, stdin)
+		if err != nil {
+			fmt.Fprintf(stderr, "empaths: %v\n", err)
+			return 1
+		}
+		for _, document := range documents {
+			result := empaths.Resolve(path, document, nil)
+			if err := writeResult(stdout, result, *outputFormat); err != nil {
+				fmt.Fprintf(stderr, "empaths: %v\n", err)
+				return 1
+			}
+		}
+	}
+	return 0
+}
+
+// readDocuments reads and decodes all documents of a file ("-" for standard input).
+func readDocuments(name string, format string, stdin io.Reader) ([]any, error) {
+	if format == "" {
+		format = formatFromName(name)
+	}
+
+	var r io.Reader = stdin
+	if name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	documents, err := decodeDocuments(r, format)
+	if err != nil && name != "-" {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return documents, err
+}
+
+// writeResult prints a resolved value. In text mode strings and scalars are printed
+// as they are and collections as indented JSON; in JSON mode everything is JSON.
+func writeResult(w io.Writer, result any, format string) error {
+	if format == "text" && !isCollection(result) {
+		if result == nil {
+			result = ""
+		}
+		_, err := fmt.Fprintln(w, result)
+		return err
+	}
+
+	encoded, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(encoded))
+	return err
+}
+
+// isCollection reports whether v is printed as JSON in text mode.
+func isCollection(v any) bool {
+	switch reflect.ValueOf(v).Kind() {
+	case reflect.Map, reflect.Slice, reflect.Array, reflect.Struct:
+		return true
+	default:
+		return false
+	}
+}
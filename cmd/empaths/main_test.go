@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/authentic-devel/empaths"
+	"golang.org/x/term"
+)
+
+const deploymentYAML = `items:
+  - metadata:
+      name: web
+    spec:
+      replicas: 3
+      ports: [80, 443]
+  - metadata:
+      name: worker
+    spec:
+      replicas: 1
+`
+
+const usersJSON = `[
+  {"name": "alice", "active": true},
+  {"name": "bob", "active": false},
+  {"name": "carol", "active": true}
+]`
+
+const configTOML = `title = "demo"
+
+[server]
+host = "localhost"
+port = 8080
+`
+
+func writeFile(t *testing.T, name string, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRun(t *testing.T) {
+	yamlFile := writeFile(t, "deployment.yaml", deploymentYAML)
+	jsonFile := writeFile(t, "users.json", usersJSON)
+	tomlFile := writeFile(t, "config.toml", configTOML)
+
+	tests := []struct {
+		name     string
+		args     []string
+		stdin    string
+		expected string
+	}{
+		{"yaml field", []string{".items[0].metadata.name", yamlFile}, "", "web\n"},
+		{"yaml number", []string{".items[1].spec.replicas", yamlFile}, "", "1\n"},
+		{"yaml wildcard", []string{"-o", "json", ".items[*].metadata.name", yamlFile}, "", "[\n  \"web\",\n  \"worker\"\n]\n"},
+		{"yaml collection as text", []string{".items[0].spec.ports", yamlFile}, "", "[\n  80,\n  443\n]\n"},
+		{"json filter", []string{"count(.[?.active==true])", jsonFile}, "", "2\n"},
+		{"json string as json", []string{"-o", "json", ".[0].name", jsonFile}, "", "\"alice\"\n"},
+		{"toml", []string{".server.host ':' .server.port", tomlFile}, "", "localhost:8080\n"},
+		{"stdin json", []string{".a.b"}, `{"a": {"b": "c"}}`, "c\n"},
+		{"stdin json stream", []string{".id"}, `{"id": 1} {"id": 2}`, "1\n2\n"},
+		{"stdin yaml", []string{"-f", "yaml", ".a"}, "a: 1\n---\na: 2\n", "1\n2\n"},
+		{"missing value", []string{".nothing"}, `{}`, "\n"},
+		{"missing value as json", []string{"-o", "json", ".nothing"}, `{}`, "null\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := run(tt.args, strings.NewReader(tt.stdin), &stdout, &stderr)
+			if code != 0 {
+				t.Fatalf("run(%q) exited with %d: %s", tt.args, code, stderr.String())
+			}
+			if stdout.String() != tt.expected {
+				t.Errorf("run(%q) printed %q, want %q", tt.args, stdout.String(), tt.expected)
+			}
+		})
+	}
+}
+
+func TestRun_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		args  []string
+		stdin string
+		code  int
+	}{
+		{"no path", []string{}, "", 2},
+		{"bad output format", []string{"-o", "xml", ".a"}, "{}", 2},
+		{"bad input format", []string{"-f", "xml", ".a"}, "{}", 1},
+		{"invalid json", []string{".a"}, "{", 1},
+		{"missing file", []string{".a", filepath.Join(t.TempDir(), "missing.json")}, "", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := run(tt.args, strings.NewReader(tt.stdin), &stdout, &stderr); code != tt.code {
+				t.Errorf("run(%q) exited with %d, want %d", tt.args, code, tt.code)
+			}
+			if stderr.Len() == 0 {
+				t.Errorf("run(%q) should print an error", tt.args)
+			}
+		})
+	}
+}
+
+func TestRun_REPL(t *testing.T) {
+	yamlFile := writeFile(t, "deployment.yaml", deploymentYAML)
+
+	var stdout, stderr bytes.Buffer
+	input := ".items[0].metadata.name\n\ncount(.items)\nexit\n.items[1].metadata.name\n"
+	code := run([]string{"repl", yamlFile}, strings.NewReader(input), &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("repl exited with %d: %s", code, stderr.String())
+	}
+	if expected := "web\n2\n"; stdout.String() != expected {
+		t.Errorf("repl printed %q, want %q", stdout.String(), expected)
+	}
+
+	if code := run([]string{"repl"}, strings.NewReader(""), &stdout, &stderr); code != 2 {
+		t.Errorf("repl without file exited with %d, want 2", code)
+	}
+}
+
+func TestCompleteLine(t *testing.T) {
+	explorer := empaths.NewExplorer(map[string]any{"items": 1, "info": 2, "title": 3}, nil)
+
+	tests := []struct {
+		name     string
+		line     string
+		pos      int
+		wantLine string
+		wantOk   bool
+		printed  string
+	}{
+		{name: "unique completion", line: ".ti", pos: 3, wantLine: ".title", wantOk: true},
+		{name: "completion before the cursor", line: ".t .items", pos: 2, wantLine: ".title .items", wantOk: true},
+		{name: "ambiguous completion lists candidates", line: ".i", pos: 2, printed: "info  items"},
+		{name: "no completion", line: ".x", pos: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			terminal := term.NewTerminal(struct {
+				io.Reader
+				io.Writer
+			}{strings.NewReader(""), &out}, "> ")
+			line, _, ok := completeLine(explorer, terminal, tt.line, tt.pos)
+			if ok != tt.wantOk || line != tt.wantLine {
+				t.Errorf("completeLine(%q) = %q, %v, want %q, %v", tt.line, line, ok, tt.wantLine, tt.wantOk)
+			}
+			// The candidates are written before completeLine returns.
+			if !strings.Contains(out.String(), tt.printed) || tt.printed == "" && out.Len() > 0 {
+				t.Errorf("completeLine(%q) printed %q, want %q", tt.line, out.String(), tt.printed)
+			}
+		})
+	}
+}
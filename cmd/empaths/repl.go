@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/authentic-devel/empaths"
+	"golang.org/x/term"
+)
+
+// runREPL loads a document and reads path expressions line by line, printing the
+// result of each. On a terminal, the Tab key completes field and key names.
+func runREPL(files []string, format string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	if len(files) != 1 || files[0] == "-" {
+		fmt.Fprintln(stderr, "usage: empaths [-f json|yaml|toml] repl FILE")
+		return 2
+	}
+	documents, err := readDocuments(files[0], format, nil)
+	if err != nil {
+		fmt.Fprintf(stderr, "empaths: %v\n", err)
+		return 1
+	}
+	var data any = documents
+	if len(documents) == 1 {
+		data = documents[0]
+	}
+	explorer := empaths.NewExplorer(data, nil)
+
+	if f, ok := stdin.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		return runTerminal(explorer, f, stdout, stderr)
+	}
+
+	scanner := bufio.NewScanner(stdin)
+	for scanner.Scan() {
+		if !evaluateLine(explorer, scanner.Text(), stdout, stderr) {
+			break
+		}
+	}
+	return 0
+}
+
+// runTerminal runs the REPL on a terminal in raw mode, with line editing, history,
+// and tab completion.
+func runTerminal(explorer *empaths.Explorer, f *os.File, stdout io.Writer, stderr io.Writer) int {
+	state, err := term.MakeRaw(int(f.Fd()))
+	if err != nil {
+		fmt.Fprintf(stderr, "empaths: %v\n", err)
+		return 1
+	}
+	defer term.Restore(int(f.Fd()), state)
+
+	terminal := term.NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{f, stdout}, "empaths> ")
+	terminal.AutoCompleteCallback = func(line string, pos int, key rune) (string, int, bool) {
+		if key != '\t' {
+			return "", 0, false
+		}
+		return completeLine(explorer, terminal, line, pos)
+	}
+
+	fmt.Fprintln(terminal, "Type a path expression, Tab to complete, Ctrl-D to exit.")
+	for {
+		line, err := terminal.ReadLine()
+		if err != nil {
+			return 0
+		}
+		if !evaluateLine(explorer, line, terminal, terminal) {
+			return 0
+		}
+	}
+}
+
+// completeLine handles the Tab key: the text before the cursor is extended to the
+// longest common prefix of all completions, and if that makes no progress the
+// candidates are listed.
+func completeLine(explorer *empaths.Explorer, terminal *term.Terminal, line string, pos int) (string, int, bool) {
+	input := line[:pos]
+	completions := explorer.Complete(input)
+	if len(completions) == 0 {
+		return "", 0, false
+	}
+
+	prefix := completions[0]
+	for _, completion := range completions[1:] {
+		for !strings.HasPrefix(completion, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	if len(prefix) > len(input) {
+		return prefix + line[pos:], len(prefix), true
+	}
+
+	head := input[:strings.LastIndexAny(input, ".")+1]
+	names := make([]string, len(completions))
+	for i, completion := range completions {
+		names[i] = strings.TrimPrefix(completion, head)
+	}
+	// The terminal is unlocked while the callback runs, so the list can be written
+	// to it; it redraws the prompt and the line below the list.
+	fmt.Fprintln(terminal, strings.Join(names, "  "))
+	return "", 0, false
+}
+
+// evaluateLine resolves one line of input and prints the result. It returns false
+// if the REPL should exit.
+func evaluateLine(explorer *empaths.Explorer, line string, stdout io.Writer, stderr io.Writer) bool {
+	line = strings.TrimSpace(line)
+	switch line {
+	case "":
+		return true
+	case "exit", "quit":
+		return false
+	}
+	if err := writeResult(stdout, explorer.Resolve(line), "text"); err != nil {
+		fmt.Fprintf(stderr, "empaths: %v\n", err)
+	}
+	return true
+}
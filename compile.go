@@ -0,0 +1,274 @@
+package empaths
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CompiledPath is a path expression that has been checked for syntax errors once,
+// so it can be stored and evaluated many times. Applications that evaluate the same
+// path repeatedly (or load paths from configuration) should compile them at startup
+// to fail fast on malformed paths.
+//
+// A CompiledPath is immutable and safe for concurrent use.
+type CompiledPath struct {
+	path string
+	// modelPath is true if the path consists of a single model path (such as
+	// ".Users[0].Name"), which can be extended with Append.
+	modelPath bool
+	// parts holds the operands of a concatenation with its constant operands folded
+	// (see foldConstants); it is nil if the path is evaluated as a whole.
+	parts []compiledPart
+	// constant is true if the path consists of constant operands only; value then
+	// holds its result.
+	constant bool
+	value    any
+	// references holds the names of the external references in the path.
+	references []string
+}
+
+// compiledPart is a part of a folded concatenation: either constant text or an
+// expression that is evaluated when the path is resolved.
+type compiledPart struct {
+	// text is the folded text of consecutive constant operands.
+	text string
+	// expr is the source of consecutive operands that depend on the data; it is
+	// empty for constant text.
+	expr string
+}
+
+// CompileOption configures the compilation of a path.
+type CompileOption func(*compileConfig)
+
+// compileConfig holds the options of Compile.
+type compileConfig struct {
+	// pureRefs holds the values of the references that are folded.
+	pureRefs map[string]any
+}
+
+// WithPureReferences marks external references as pure: their values depend neither
+// on the data nor on the ReferenceResolver passed to Resolve, such as translated
+// labels or configuration. They are resolved once with refResolver when the path
+// is compiled (with nil data) and folded like literals.
+//
+// Parameters:
+//   - refResolver: Resolves the pure references at compile time
+//   - names: The names of the pure references, without their colon
+func WithPureReferences(refResolver ReferenceResolver, names ...string) CompileOption {
+	return func(c *compileConfig) {
+		if c.pureRefs == nil {
+			c.pureRefs = make(map[string]any, len(names))
+		}
+		for _, name := range names {
+			c.pureRefs[name] = refResolver(name, nil)
+		}
+	}
+}
+
+// Compile checks a path expression for syntax errors and returns it as a CompiledPath.
+//
+// Operands of a concatenation that do not depend on the data - string, number, and
+// keyword literals and references marked with WithPureReferences - are folded when
+// the path is compiled, so a template such as "'Dear ' .Name ', thank you for your
+// order.'" only evaluates ".Name" when it is resolved.
+//
+// Parameters:
+//   - path: The path expression
+//   - opts: Options of the compilation
+//
+// Returns:
+//   - The compiled path
+//   - A *SyntaxError if the path is malformed (see Tokens)
+func Compile(path string, opts ...CompileOption) (*CompiledPath, error) {
+	tokens, err := Tokens(path)
+	if err != nil {
+		return nil, err
+	}
+	var config compileConfig
+	for _, opt := range opts {
+		opt(&config)
+	}
+	compiled := &CompiledPath{path: path, modelPath: isSingleModelPath(tokens), references: referenceNames(tokens)}
+	compiled.foldConstants(tokens, &config)
+	return compiled, nil
+}
+
+// MustCompile is like Compile but panics if the path is malformed. It simplifies the
+// initialization of global variables holding compiled paths.
+func MustCompile(path string, opts ...CompileOption) *CompiledPath {
+	compiled, err := Compile(path, opts...)
+	if err != nil {
+		panic(fmt.Sprintf("empaths: Compile(%q): %v", path, err))
+	}
+	return compiled
+}
+
+// String returns the source of the compiled path.
+func (p *CompiledPath) String() string {
+	return p.path
+}
+
+// References returns the names of the external references in the compiled path,
+// like the package-level References. The names of references folded with
+// WithPureReferences are included.
+func (p *CompiledPath) References() []string {
+	return p.references
+}
+
+// Resolve evaluates the compiled path against data, like the package-level Resolve.
+func (p *CompiledPath) Resolve(data any, refResolver ReferenceResolver) any {
+	if p.constant {
+		return p.value
+	}
+	if p.parts == nil {
+		return Resolve(p.path, data, refResolver)
+	}
+	var sb strings.Builder
+	for _, part := range p.parts {
+		if part.expr == "" {
+			sb.WriteString(part.text)
+		} else {
+			sb.WriteString(concatenated(Resolve(part.expr, data, refResolver)))
+		}
+	}
+	return sb.String()
+}
+
+// foldConstants splits a concatenation into constant text and the expressions
+// between it. Paths that are not a plain concatenation, such as comparisons, and
+// paths without constant operands are left to be evaluated as a whole.
+func (p *CompiledPath) foldConstants(tokens []Token, config *compileConfig) {
+	var parts []compiledPart
+	var constants []any
+	operands := 0
+	exprStart, exprEnd := -1, -1
+	depth := 0
+	for i, token := range tokens {
+		if depth == 0 {
+			switch token.Kind {
+			case TokenComparison, TokenLogical, TokenOperator, TokenWord, TokenLet:
+				return
+			}
+		}
+		if value, ok := constantValue(tokens, i, depth, config); ok {
+			if exprStart != -1 {
+				parts = append(parts, compiledPart{expr: p.path[exprStart:exprEnd]})
+				exprStart = -1
+			}
+			if n := len(parts); n > 0 && parts[n-1].expr == "" {
+				parts[n-1].text += concatenated(value)
+			} else {
+				parts = append(parts, compiledPart{text: concatenated(value)})
+			}
+			constants = append(constants, value)
+			operands++
+			continue
+		}
+		switch token.Kind {
+		case TokenLeftParen, TokenListStart, TokenFilterStart:
+			depth++
+		case TokenRightParen, TokenListEnd, TokenFilterEnd:
+			depth--
+		}
+		if exprStart == -1 {
+			exprStart = token.Pos
+			operands++
+		}
+		exprEnd = token.Pos + len(token.Text)
+	}
+	if len(constants) == 0 {
+		return
+	}
+	if exprStart != -1 {
+		parts = append(parts, compiledPart{expr: p.path[exprStart:exprEnd]})
+	}
+	switch {
+	case len(constants) == 1 && operands == 1:
+		p.constant, p.value = true, constants[0]
+	case len(parts) == 1:
+		p.constant, p.value = true, parts[0].text
+	default:
+		p.parts = parts
+	}
+}
+
+// constantValue returns the value of the token at index i if it is a constant
+// operand of a concatenation: a literal or pure reference at the top level that is
+// neither negated nor passed to a pipe, and a pure reference that is given no
+// operand.
+func constantValue(tokens []Token, i int, depth int, config *compileConfig) (any, bool) {
+	if depth > 0 || i > 0 && tokens[i-1].Kind == TokenNegation || i+1 < len(tokens) && tokens[i+1].Kind == TokenPipe {
+		return nil, false
+	}
+	token := tokens[i]
+	switch token.Kind {
+	case TokenString, TokenNumber, TokenKeyword:
+		return token.Value, true
+	case TokenReference:
+		if i > 0 && tokens[i-1].Kind == TokenPipe || i+1 < len(tokens) && tokens[i+1].Kind == TokenLeftParen {
+			// The value depends on the piped operand or the argument.
+			return nil, false
+		}
+		value, ok := config.pureRefs[token.Text[1:]]
+		return value, ok
+	default:
+		return nil, false
+	}
+}
+
+// Append returns a new CompiledPath with the model path sub appended (see JoinPath).
+// The receiver must be a single model path such as ".Users[0]", not an expression
+// such as "'Hello ' .Name".
+//
+// Parameters:
+//   - sub: The model path to append (e.g. "Name", ".Address.City", or "[0]")
+//
+// Returns:
+//   - The extended path
+//   - Error if the receiver is not a single model path or the result is malformed
+func (p *CompiledPath) Append(sub string) (*CompiledPath, error) {
+	if !p.modelPath {
+		return nil, fmt.Errorf("cannot append to %q: not a model path", p.path)
+	}
+	joined, err := Compile(JoinPath(p.path, sub))
+	if err != nil {
+		return nil, err
+	}
+	if !joined.modelPath {
+		return nil, fmt.Errorf("cannot append %q to %q: not a model path", sub, p.path)
+	}
+	return joined, nil
+}
+
+// isSingleModelPath reports whether tokens form exactly one model path.
+func isSingleModelPath(tokens []Token) bool {
+	if len(tokens) == 0 || tokens[0].Kind != TokenField {
+		return false
+	}
+	depth := 0
+	for i, token := range tokens {
+		switch token.Kind {
+		case TokenFilterStart:
+			depth++
+			continue
+		case TokenFilterEnd:
+			depth--
+		}
+		if depth > 0 {
+			continue
+		}
+		if !isPathToken(token) {
+			return false
+		}
+		if i > 0 && token.Pos != tokens[i-1].Pos+len(tokens[i-1].Text) {
+			return false
+		}
+	}
+	return true
+}
+
+// concatenated returns v converted to a string the way an operand of a concatenation
+// is converted by the package-level Resolve.
+func concatenated(v any) string {
+	return (&evalState{}).format(v, "")
+}
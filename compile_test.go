@@ -0,0 +1,158 @@
+package empaths
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCompile(t *testing.T) {
+	team := createTestTeam()
+
+	compiled, err := Compile("count(.Users[?.Active==true])")
+	if err != nil {
+		t.Fatalf("Compile error = %v", err)
+	}
+	if compiled.String() != "count(.Users[?.Active==true])" {
+		t.Errorf("String() = %q", compiled.String())
+	}
+	if result := compiled.Resolve(team, nil); result != 2 {
+		t.Errorf("Resolve = %v, want 2", result)
+	}
+
+	var syntaxErr *SyntaxError
+	if _, err := Compile(".Users[0"); !errors.As(err, &syntaxErr) {
+		t.Errorf("Compile(malformed) error = %v, want *SyntaxError", err)
+	}
+}
+
+func TestMustCompile(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("MustCompile(malformed) should panic")
+		}
+	}()
+	MustCompile("'unterminated")
+}
+
+func TestCompiledPath_Append(t *testing.T) {
+	team := createTestTeam()
+
+	tests := []struct {
+		name     string
+		base     string
+		sub      string
+		expected string
+		value    any
+		wantErr  bool
+	}{
+		{"field", ".Users[0]", "Name", ".Users[0].Name", "Alice", false},
+		{"index", ".Users", "[2].Age", ".Users[2].Age", 35, false},
+		{"key", ".Scores", "'math'", ".Scores['math']", 95, false},
+		{"root", ".", "Scores.science", ".Scores.science", 88, false},
+		{"filter", ".Users[?.Active==true]", "Name", ".Users[?.Active==true].Name", nil, false},
+		{"expression base", "'Hi ' .Users[0].Name", "Age", "", nil, true},
+		{"comparison base", "?.Users[0].Active", "Name", "", nil, true},
+		{"expression sub", ".Users[0]", "Name ' '", "", nil, true},
+		{"malformed sub", ".Users", "[0", "", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			appended, err := MustCompile(tt.base).Append(tt.sub)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Append(%q) to %q should return an error, got %q", tt.sub, tt.base, appended)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Append(%q) to %q error = %v", tt.sub, tt.base, err)
+			}
+			if appended.String() != tt.expected {
+				t.Errorf("Append(%q) to %q = %q, want %q", tt.sub, tt.base, appended, tt.expected)
+			}
+			if tt.value != nil {
+				if result := appended.Resolve(team, nil); result != tt.value {
+					t.Errorf("Resolve(%q) = %v, want %v", appended, result, tt.value)
+				}
+			}
+		})
+	}
+}
+
+func TestPathBuilder_Compile(t *testing.T) {
+	compiled, err := Path().Field("Users").Index(1).Field("Name").Compile()
+	if err != nil || compiled.Resolve(createTestTeam(), nil) != "Bob" {
+		t.Errorf("Compile() = %v, %v, want path resolving to Bob", compiled, err)
+	}
+	if _, err := Path().Index(-1).Compile(); err == nil {
+		t.Errorf("Compile() should return the build error")
+	}
+}
+
+func TestCompile_ConstantFolding(t *testing.T) {
+	team := createTestTeam()
+	refResolver := func(name string, data any) any {
+		switch name {
+		case "greeting":
+			return "Hello"
+		case "user":
+			return data
+		}
+		return nil
+	}
+	pureCalls := 0
+	pure := WithPureReferences(func(name string, data any) any {
+		pureCalls++
+		return refResolver(name, data)
+	}, "greeting")
+
+	tests := []struct {
+		name     string
+		path     string
+		parts    int
+		constant bool
+	}{
+		{"static text around a path", "'Dear ' .Users[0].Name ', welcome!'", 3, false},
+		{"adjacent literals", "'a' 'b' 1.5 true .Users[1].Name 'c'", 3, false},
+		{"pure reference", ":greeting ' ' .Users[0].Name", 2, false},
+		{"impure reference", ":user.Scores.math ' points'", 2, false},
+		{"consecutive paths", "'x' .Users[0].Name .Users[1].Name", 2, false},
+		{"constants only", "'a' 1 :greeting", 0, true},
+		{"single literal", "42", 0, true},
+		{"single nil", "nil", 0, true},
+		{"literal in function", "'n=' count(.Users) ' ' len('abc')", 4, false},
+		{"literal in filter", "'active: ' count(.Users[?.Name=='Bob'])", 2, false},
+		{"negated literal", "'x' !'true'", 2, false},
+		{"piped literal", "'abc' | len ' chars'", 2, false},
+		{"pure reference with argument", "'x' :greeting(.Users[0].Name)", 2, false},
+		{"piped into pure reference", "'x' .Users[0].Name | :greeting", 2, false},
+		{"list literal", "'x' ['a', 'b'] | join('-')", 2, false},
+		{"composite value", "'scores: ' .Scores", 2, false},
+		{"comparison", "?.Users[0].Name == 'Alice'", 0, false},
+		{"no constants", ".Users[0].Name .Users[1].Name", 0, false},
+		{"bare word", "'a' word .Users[0].Name", 0, false},
+		{"binding", "let $u = .Users[0]; $u.Name ' ' $u.Age", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compiled, err := Compile(tt.path, pure)
+			if err != nil {
+				t.Fatalf("Compile(%q) error = %v", tt.path, err)
+			}
+			if len(compiled.parts) != tt.parts || compiled.constant != tt.constant {
+				t.Errorf("Compile(%q) folded into %d parts (constant: %v), want %d (constant: %v)",
+					tt.path, len(compiled.parts), compiled.constant, tt.parts, tt.constant)
+			}
+			expected := Resolve(tt.path, team, refResolver)
+			if result := compiled.Resolve(team, refResolver); result != expected {
+				t.Errorf("Resolve(%q) = %v, want %v", tt.path, result, expected)
+			}
+		})
+	}
+
+	if pureCalls != len(tests) {
+		t.Errorf("pure reference resolved %d times, want once per compilation", pureCalls)
+	}
+}
@@ -0,0 +1,64 @@
+package empaths
+
+import "fmt"
+
+// Warning describes a suspicious part of a path expression that Resolve tolerates,
+// such as an unterminated string literal, which is closed at the end of the path,
+// or an unexpected character, which is skipped.
+type Warning struct {
+	// Pos is the byte offset in the path at which the problem was detected.
+	Pos int
+	// Msg describes the problem.
+	Msg string
+}
+
+// String returns the warning with its position.
+func (w Warning) String() string {
+	return fmt.Sprintf("offset %d: %s", w.Pos, w.Msg)
+}
+
+// ResolveDiag evaluates a path expression like Resolve and additionally reports the
+// parts of it that look malformed. See Resolver.ResolveDiag.
+//
+// Parameters:
+//   - path: The path expression to evaluate
+//   - data: The data model to evaluate the path against
+//   - refResolver: Optional function to resolve external references (prefixed with ':')
+//
+// Returns:
+//   - The resolved value, the same as Resolve returns
+//   - The warnings about the path, in the order of their position
+func ResolveDiag(path string, data any, refResolver ReferenceResolver) (any, []Warning) {
+	return defaultResolver.ResolveDiag(path, data, refResolver)
+}
+
+// ResolveDiag evaluates a path expression like Resolve and additionally reports the
+// parts of it that look malformed, so that a user interface can point out a
+// suspicious path without rejecting it:
+//
+//	result, warnings := resolver.ResolveDiag(".User..Name 'Hello", data, nil)
+//	// warnings == [offset 5: empty path segment, offset 12: unterminated string literal]
+//
+// The warnings are the syntax errors that Tokens and strict mode (see WithStrict)
+// report, but where those stop at the first error, ResolveDiag skips each malformed
+// part the way Resolve does and reports all of them: unterminated string literals
+// and missing closing parentheses are closed at the end of the path, unexpected
+// characters are skipped, and empty path segments are ignored. In addition, bare
+// words, which do not contribute to the result, are reported.
+//
+// The result is the same as Resolve returns; for a Resolver created with WithStrict
+// it is nil if there are warnings other than ignored words.
+//
+// Parameters:
+//   - path: The path expression to evaluate
+//   - data: The data model to evaluate the path against
+//   - refResolver: Optional function to resolve external references (prefixed with ':')
+//
+// Returns:
+//   - The resolved value
+//   - The warnings about the path, in the order of their position
+func (r *Resolver) ResolveDiag(path string, data any, refResolver ReferenceResolver) (any, []Warning) {
+	t := &tokenizer{path: path, prefixes: r.prefixes, lenient: true}
+	_ = t.tokenize(0, len(path))
+	return r.Resolve(path, data, refResolver), t.warnings
+}
@@ -0,0 +1,62 @@
+package empaths
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestResolveDiag(t *testing.T) {
+	person := createTestPerson()
+
+	tests := []struct {
+		name     string
+		path     string
+		expected any
+		warnings []Warning
+	}{
+		{"well-formed", ".Name ' ' .Address.City", "Alice NYC", nil},
+		{"unterminated string", ".Name ' is here", "Alice is here", []Warning{{6, "unterminated string literal"}}},
+		{"empty segment", ".Address..City", "NYC", []Warning{{8, "empty path segment"}}},
+		{"unknown character", ".Name # .Age", "Alice30", []Warning{{6, "unexpected '#'"}}},
+		{"several", ".Name, .Age)", "Alice30", []Warning{{5, "unexpected ','"}, {11, "unexpected ')'"}}},
+		{"missing parenthesis", "len(.Name", nil, []Warning{{9, "missing ')'"}}},
+		{"missing bracket", ".Tags[0", nil, []Warning{{5, "missing ']'"}}},
+		{"empty brackets", ".Tags[].x", nil, []Warning{{5, "empty brackets"}}},
+		{"quote in filter", "count(.Tags[?. == 'go])", nil, []Warning{{11, "missing ']'"}, {23, "missing ')'"}}},
+		{"ignored word", ".Name and .Age", "Alice30", []Warning{{6, `word "and" is ignored`}}},
+		{"missing reference name", ": .Name", "Alice", []Warning{{0, "missing reference name"}}},
+		{"missing function after pipe", ".Tags | 'x'", `["developer","gopher","tester"]x`, []Warning{{8, "missing function name after '|'"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, warnings := ResolveDiag(tt.path, person, nil)
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("ResolveDiag(%q) = %v, want %v", tt.path, result, tt.expected)
+			}
+			if !reflect.DeepEqual(warnings, tt.warnings) {
+				t.Errorf("ResolveDiag(%q) warnings = %v, want %v", tt.path, warnings, tt.warnings)
+			}
+		})
+	}
+
+	t.Run("same result as Resolve", func(t *testing.T) {
+		for _, tt := range tests {
+			result, _ := ResolveDiag(tt.path, person, nil)
+			if want := Resolve(tt.path, person, nil); !reflect.DeepEqual(result, want) {
+				t.Errorf("ResolveDiag(%q) = %v, Resolve() = %v", tt.path, result, want)
+			}
+		}
+	})
+
+	t.Run("custom prefix", func(t *testing.T) {
+		resolver := NewResolver(WithPrefix('@', func(path string, data any, index int, _ ReferenceResolver) (any, int) {
+			name, end := ReadPrefixName(path, index)
+			return name, end
+		}))
+		result, warnings := resolver.ResolveDiag("@x ~", person, nil)
+		if result != "x" || !reflect.DeepEqual(warnings, []Warning{{3, "unexpected '~'"}}) {
+			t.Errorf("ResolveDiag() = %v, %v", result, warnings)
+		}
+	})
+}
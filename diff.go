@@ -0,0 +1,113 @@
+package empaths
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ChangeKind identifies the kind of a change found by Diff.
+type ChangeKind int
+
+const (
+	// ChangeModified is a value that differs between both sides.
+	ChangeModified ChangeKind = iota
+	// ChangeAdded is a value that exists only in the new data.
+	ChangeAdded
+	// ChangeRemoved is a value that exists only in the old data.
+	ChangeRemoved
+)
+
+// String returns the name of the change kind.
+func (k ChangeKind) String() string {
+	switch k {
+	case ChangeModified:
+		return "modified"
+	case ChangeAdded:
+		return "added"
+	case ChangeRemoved:
+		return "removed"
+	default:
+		return fmt.Sprintf("ChangeKind(%d)", int(k))
+	}
+}
+
+// Change is a difference between two values found by Diff.
+type Change struct {
+	// Kind is the kind of change.
+	Kind ChangeKind
+	// Path is the model path of the value that changed, e.g. ".Users[0].Name".
+	Path string
+	// Old is the value in the old data, or nil for ChangeAdded.
+	Old any
+	// New is the value in the new data, or nil for ChangeRemoved.
+	New any
+}
+
+// Diff compares two values and returns the leaf values that differ, with paths in
+// the syntax Resolve accepts:
+//
+//	empaths.Diff(before, after)
+//	// [{modified .Users[0].Name Alice Alicia} {added .Tags[2] <nil> new}]
+//
+// The values are compared leaf by leaf as returned by Flatten, so the two values may
+// have different types, e.g. a struct and a map[string]any decoded from JSON. Leaves
+// are equal if reflect.DeepEqual reports them as equal. Changes are ordered by path,
+// with indices compared numerically.
+//
+// Parameters:
+//   - a: The old data
+//   - b: The new data
+//
+// Returns:
+//   - The changes from a to b, or nil if there are none
+func Diff(a, b any) []Change {
+	oldFlat, newFlat := Flatten(a), Flatten(b)
+
+	var changes []Change
+	for path, oldValue := range oldFlat {
+		newValue, ok := newFlat[path]
+		switch {
+		case !ok:
+			changes = append(changes, Change{Kind: ChangeRemoved, Path: path, Old: oldValue})
+		case !reflect.DeepEqual(oldValue, newValue):
+			changes = append(changes, Change{Kind: ChangeModified, Path: path, Old: oldValue, New: newValue})
+		}
+	}
+	for path, newValue := range newFlat {
+		if _, ok := oldFlat[path]; !ok {
+			changes = append(changes, Change{Kind: ChangeAdded, Path: path, New: newValue})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return comparePaths(changes[i].Path, changes[j].Path) < 0
+	})
+	return changes
+}
+
+// comparePaths orders two plain model paths segment by segment, comparing indices
+// numerically, so ".Tags[2]" comes before ".Tags[10]". Paths that are not plain
+// model paths are compared as strings.
+func comparePaths(a, b string) int {
+	segmentsA, errA := parseSegments(a)
+	segmentsB, errB := parseSegments(b)
+	if errA != nil || errB != nil {
+		return strings.Compare(a, b)
+	}
+	for i := 0; i < len(segmentsA) && i < len(segmentsB); i++ {
+		nameA, nameB := segmentsA[i].name, segmentsB[i].name
+		if nameA == nameB {
+			continue
+		}
+		indexA, errA := strconv.Atoi(nameA)
+		indexB, errB := strconv.Atoi(nameB)
+		if errA == nil && errB == nil {
+			return indexA - indexB
+		}
+		return strings.Compare(nameA, nameB)
+	}
+	return len(segmentsA) - len(segmentsB)
+}
@@ -0,0 +1,81 @@
+package empaths
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	before := createTestPerson()
+	after := createTestPerson()
+	after.Name = "Alicia"
+	after.Address.City = "Boston"
+	after.Tags = append([]string{}, "developer", "gopher", "tester", "a", "b", "c", "d", "e", "f", "g", "h")
+	after.Scores = map[string]int{"math": 95, "art": 70}
+
+	changes := Diff(before, after)
+	expected := []Change{
+		{ChangeModified, ".Address.City", "NYC", "Boston"},
+		{ChangeModified, ".Name", "Alice", "Alicia"},
+		{ChangeAdded, ".Scores.art", nil, 70},
+		{ChangeRemoved, ".Scores.science", 88, nil},
+		{ChangeAdded, ".Tags[3]", nil, "a"},
+		{ChangeAdded, ".Tags[4]", nil, "b"},
+		{ChangeAdded, ".Tags[5]", nil, "c"},
+		{ChangeAdded, ".Tags[6]", nil, "d"},
+		{ChangeAdded, ".Tags[7]", nil, "e"},
+		{ChangeAdded, ".Tags[8]", nil, "f"},
+		{ChangeAdded, ".Tags[9]", nil, "g"},
+		{ChangeAdded, ".Tags[10]", nil, "h"},
+	}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("Diff =\n%v\nwant\n%v", changes, expected)
+	}
+}
+
+func TestDiff_Values(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     any
+		expected []Change
+	}{
+		{"equal", createTestPerson(), createTestPerson(), nil},
+		{"struct and map", Address{City: "NYC", Zip: 1}, map[string]any{"City": "NYC", "Street": "", "Zip": 2}, []Change{
+			{ChangeModified, ".Zip", 1, 2},
+		}},
+		{"nil and value", map[string]any{"A": nil}, map[string]any{"A": 1}, []Change{
+			{ChangeModified, ".A", nil, 1},
+		}},
+		{"absent and nil", map[string]any{}, map[string]any{"A": nil}, []Change{
+			{ChangeRemoved, ".", map[string]any{}, nil},
+			{ChangeAdded, ".A", nil, nil},
+		}},
+		{"scalars", 1, 2, []Change{{ChangeModified, ".", 1, 2}}},
+		{"root slice", []int{1, 2}, []int{1, 3}, []Change{{ChangeModified, ".[1]", 2, 3}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if changes := Diff(tt.a, tt.b); !reflect.DeepEqual(changes, tt.expected) {
+				t.Errorf("Diff = %v, want %v", changes, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDiff_PathsResolve(t *testing.T) {
+	before := createTestTeam()
+	after := createTestTeam()
+	after["Users"].([]Member)[1].Age = 26
+	for _, change := range Diff(before, after) {
+		if Resolve(change.Path, before, nil) != change.Old || Resolve(change.Path, after, nil) != change.New {
+			t.Errorf("change %v does not match the data", change)
+		}
+	}
+}
+
+func TestChangeKind_String(t *testing.T) {
+	if ChangeAdded.String() != "added" || ChangeKind(9).String() != "ChangeKind(9)" {
+		t.Errorf("unexpected ChangeKind names")
+	}
+}
@@ -14,6 +14,7 @@
 //	.User.Address.City - Access nested fields
 //	.Users[0]          - Access array/slice element by index (zero-based)
 //	.Data["key"]       - Access map element by key
+//	.Zones[{"AZ":"a"}] - Access map element by a struct or array key written as JSON
 //	.GetValue          - Call a zero-argument method
 //
 // String Literals (enclosed in quotes):
@@ -21,25 +22,76 @@
 //	'Hello'            - Single-quoted string
 //	"World"            - Double-quoted string
 //	'It\'s'            - Escaped quotes within strings
+//	'a\nb'             - Escape sequences: \n, \t, \r, \\ and \uXXXX
+//
+// Number, Boolean, and Nil Literals (unquoted):
+//
+//	30, -2, 1.5        - Numbers (int or float64)
+//	true, false        - Booleans
+//	nil                - The nil value; only equal to nil pointers, maps, and
+//	                     slices and missing values, not to ""
+//
+// After a comparison operator, an unquoted token that is none of these is a string
+// literal, so ?.Status==active compares with "active".
 //
 // Negation (starts with '!'):
 //
 //	!.IsActive         - Negate a boolean value
 //	!'true'            - Negate a string "true" -> false
+//	!?.Age>=18         - Negate a comparison
+//	!(.Draft || .Archived) - Negate a group of conditions
 //
 // Comparisons (start with '?'):
 //
 //	?.Age=='18'        - Compare if Age equals 18
+//	?.Age==18          - Numeric comparison with a bare number
 //	?.Status!='active' - Compare if Status is not "active"
+//	?.Status==i'active' - Case-insensitive comparison (also !=i)
+//	?.Age>=18          - Ordering with <, <=, >, >= (numbers by value, otherwise
+//	                     lexically; false if an operand is nil)
+//	?.ExpiresAt < :now - Times (time.Time or RFC 3339 strings) chronologically
+//	?.Version >=v '1.20' - Versions by component, so '1.9' <v '1.10' (also <v, <=v, >v)
+//	?.Name ^= 'Al'     - Starts with (also $= for ends with)
+//	?.Host ~g '*.internal' - Glob match ('*' any characters, '?' one character)
+//	?.Tags contains 'go' - Slice element, map key, or substring check
+//	?.Status in ['a','b'] - Membership in a list literal (stops at first match)
+//	?kind(.Payload)=='map' - Function calls as operands
+//	?.Items | len > 0  - Pipes in operands
+//	?isempty(.Tags)    - A single operand is tested for being true
+//	?.Age>=18 && (.Admin || !.Banned) - Conditions combined with && and || and
+//	                     grouped with parentheses ('&&' binds tighter)
 //
 // External References (start with ':'):
 //
 //	:config            - Resolve using the provided ReferenceResolver
+//	:fmt(.CreatedAt)   - Pass a value to the ReferenceResolver as its data
+//	.CreatedAt | :fmt  - The same with a pipe
+//
+// Variables (start with '$', see ResolveWithVars):
+//
+//	$wanted            - The variable "wanted"
+//	$user.Name         - A model path resolved against a variable
+//	$users[0].Name     - A model path starting with an index
+//	let $u = .Order.Customer; $u.Name ' ' $u.Email - A variable bound in the
+//	                     expression, evaluated once
 //
 // Multiple segments can be combined:
 //
 //	'Hello, ' .User.Name '!'  - Concatenates to "Hello, John!"
 //
+// ResolveString, ResolveBool, ResolveInt, and ResolveFloat convert the result:
+//
+//	age, ok := empaths.ResolveInt(".User.Age", data, nil)
+//
+// ResolveDefault returns a fallback value if a path resolves to nil:
+//
+//	title := empaths.ResolveDefault(".Page.Title", data, "Untitled", nil)
+//
+// ResolveChain tries several data contexts in order and returns the first result
+// that is not nil:
+//
+//	empaths.ResolveChain(".Title", []any{page, layout, globals}, nil)
+//
 // # Array and Slice Access
 //
 // Arrays and slices are accessed using zero-based integer indices:
@@ -51,11 +103,78 @@
 // Out-of-bounds access returns nil rather than panicking.
 // Negative indices are not supported.
 //
+// # Wildcards and Filters
+//
+// A wildcard selects all elements of an array, slice, or map (map values are
+// visited in sorted key order). A filter selects the elements for which a
+// comparison is true. The rest of the path is applied to each selected element
+// and the results are collected into a slice: a typed slice such as []string if all
+// results have the same type, a []any otherwise (see also ResolveSlice):
+//
+//	.Users[*].Name                - Names of all users
+//	.Users[?.Active=='true']      - All active users
+//	.Users[?.Active=='true'].Name - Names of all active users
+//
+// Elements for which the rest of the path cannot be resolved are skipped.
+//
+// Inside a filter, '$' refers to the data passed to Resolve and '^' to the value
+// holding the filtered collection, i.e. the element of the enclosing filter:
+//
+//	.Items[?.Price <= $.Budget]           - Compare with a top-level value
+//	.Orders[*].Items[?.Price > ^.Limit]   - Compare with the order of the items
+//
+// # Functions
+//
+// Built-in functions are called with a name followed by a parenthesized,
+// comma-separated argument list. Each argument is a full expression:
+//
+//	count(.Users)                    - Number of elements in a collection
+//	count(.Users[?.Active=='true'])  - Number of active users
+//	len(.Title)                      - Number of elements or characters
+//	join(.Tags, ', ')                - Joins the elements of a slice into a string
+//	split(.CSV, ',')                 - Splits a string into a []string
+//	substr(.Sku, 0, 3)               - The first three characters of a string
+//	replace(.Title, ' ', '-')        - Replaces all occurrences of a string
+//	padleft(.ID, 8, '0')             - Pads a string to a width (also padright)
+//	mask(.Card, 4)                   - Masks all but the last four letters and digits
+//	round(.Price, 2)                 - Rounds a number to two decimal places
+//	number(.Price, 2, ',')           - Formats a number as "1,234.50"
+//	fmt(.Created, 'date')            - Formats a value with the Resolver's Formatter
+//	urlquery(.Redirect)              - Escapes a value for a URL query
+//	htmlescape(.Comment)             - Escapes a value for HTML
+//	b64(.Payload), b64dec(.Token)    - Encodes or decodes base64
+//	hex(.Hash)                       - Encodes bytes or a string as hexadecimal
+//	sha256(.Email)                   - Hex digest of a value (also sha1 and md5)
+//	typeof(.Value), kind(.Value)     - Go type ("[]string") or kind ("slice")
+//	isnil(.Ptr), isempty(.Tags)      - Tests for nil, or for nil or no elements
+//	first(.Items), last(.Items)      - First or last element of a slice
+//	reverse(.Items)                  - Copy of a slice in reverse order
+//	unique(.Tags)                    - Copy of a slice without duplicates
+//	sort(.Users, '.Age')             - Sorted copy of a collection, by a sub-path
+//	sort(.Users, '-.Age')            - Sorted in descending order
+//	sort(.Tags, 'desc')              - Elements sorted in descending order
+//	groupby(.Orders, '.Status')      - Map from status to the orders with that status
+//	each(.Items, .Name, ', ')        - Evaluates .Name for every item and joins the results
+//	switch(.Status, 'open':'🟢', default:'❓') - Result of the case equal to the status
+//
+// The second argument of each is not evaluated before the call but for every
+// element, with '.' referring to the element and '^' to the data each is called on.
+// Likewise only the result of the matching case of switch is evaluated. Its cases
+// are literals followed by ':', and the default case may appear anywhere.
+//
+// A model path directly following a call is resolved against its result, as in
+// groupby(.Orders, '.Status')['open']. A pipe passes the operand before it to a
+// function as its first argument:
+//
+//	.Users | len                     - Same as len(.Users)
+//	.Tags | sort('desc') | join(',') - Same as join(sort(.Tags, 'desc'), ',')
+//
 // # Map Access
 //
 // Maps can be accessed either with bracket notation or dot notation:
 //
 //	.Data["key"]       - Bracket notation (works for any key type)
+//	.Data['a.b c']     - Quoted keys may contain spaces, dots, and brackets
 //	.Data.key          - Dot notation (string keys only)
 //
 // The library supports maps with various key types:
@@ -74,12 +193,123 @@
 //   - Take no arguments
 //   - Return at least one value (first value is used)
 //
+// A result selector picks another result, e.g. the ok of a comma-ok method, and
+// fields and map values holding a function without arguments are called like
+// methods:
+//
+//	.Lookup#1          - Second result of Lookup()
+//
+// Types that implement PathResolvable resolve segments themselves instead of
+// through reflection, e.g. ordered maps or lazy proxies. IndexResolvable and
+// KeyResolvable add bracket access ("[0]", "['key']") to other collection types.
+// PathAliaser declares alternative names for fields, such as ".display_name" for
+// DisplayName, that keep paths stable when the Go names change.
+// Row, returned by ScanRow and ScanRows, resolves the columns of a database row
+// like fields and decodes JSON columns on first access (".metadata.plan").
+//
 // # Error Handling
 //
 // The library uses graceful failure - invalid paths return nil rather than
 // panicking or returning errors. This design choice simplifies usage in
 // templates and other contexts where nil is an acceptable fallback.
 //
+// A Resolver created with WithStrict reports the reason instead: ResolveErr returns
+// a *SyntaxError (matching ErrSyntax), *FieldNotFoundError, *IndexOutOfRangeError,
+// *NilIntermediateError, or *UnresolvedReferenceError, which can be inspected with
+// errors.As. A *FieldNotFoundError suggests existing names that are close to a
+// misspelled one:
+//
+//	_, err := empaths.NewResolver(empaths.WithStrict()).ResolveErr(".User.Nmae", data, nil)
+//	var notFound *empaths.FieldNotFoundError
+//	if errors.As(err, &notFound) { ... }
+//
+// ResolveTrace evaluates a path leniently and returns these errors for every part
+// that resolved to nil, e.g. to explain why a template rendered empty.
+//
+// ResolvePartial reports how far a path resolved: the deepest resolved value, the
+// resolved part of the path (".User.Address"), and the rest (".Cityy").
+//
+// # Building Paths
+//
+// PathBuilder assembles paths from parts and quotes and escapes keys and literals,
+// so user-supplied values cannot inject path syntax:
+//
+//	path := empaths.Path().Field("Data").Key(userKey).String()
+//	rule := empaths.Path().Field("Status").Compare("==", wanted).String()
+//
+// JoinPath appends one model path to another, and Compile checks a path once and
+// returns a CompiledPath for repeated evaluation:
+//
+//	city := empaths.MustCompile(empaths.JoinPath(".User", "Address.City"))
+//	value := city.Resolve(data, nil)
+//
+// GeneratePaths generates Go source with the paths of a struct type, for use from a
+// go:generate program, so paths can be checked by the compiler:
+//
+//	empaths.Resolve(models.UserPaths.Address.City, user, nil)
+//
+// The analyzer in github.com/authentic-devel/empaths/pathcheck checks constant
+// paths against the static type of the data at build time.
+//
+// # Exploring Data
+//
+// An Explorer resolves expressions against fixed data and completes the model path
+// at the end of a partially typed expression, e.g. for a REPL:
+//
+//	empaths.NewExplorer(config, nil).Complete(".Spec.Re") // [".Spec.Replicas", ...]
+//
+// EnumeratePaths lists all paths of a sample value or type with their Go types:
+//
+//	empaths.EnumeratePaths(reflect.TypeOf(Order{}), 3) // .ID, .Items, .Items[*], ...
+//
+// # Modifying Data
+//
+// Set replaces the value addressed by a plain model path; SetCreate also allocates
+// missing intermediate pointers, maps, map entries, and slice elements:
+//
+//	err := empaths.SetCreate(".Spec.Limits['cpu']", &obj, "500m")
+//
+// Delete removes the value addressed by a plain model path: map entries are
+// deleted, slice elements are removed (later elements shift down), and struct
+// fields and array elements are reset to their zero value. Unlike Resolve, it
+// reports problems as errors:
+//
+//	err := empaths.Delete(".Labels['env']", &config)
+//
+// These functions return a *SyntaxError, *FieldNotFoundError, or
+// *IndexOutOfRangeError for malformed paths, unknown names, and invalid indices.
+//
+// Append and Insert grow the slice addressed by a path:
+//
+//	err := empaths.Append(".Spec.Tags", &config, "blue", "green")
+//	err := empaths.Insert(".Ports", &config, 0, 22)
+//
+// A Patch groups several of these operations; Apply rehearses them on a deep copy
+// first, so a failing patch leaves the data unchanged.
+//
+// Bind sets the values of a form or query string on a struct, keyed by model paths
+// without their leading dot and converted from strings to the types of the fields:
+//
+//	err := empaths.Bind(r.Form, &order) // user.address.city=Berlin&items[0].qty=2
+//
+// # Flattening Data
+//
+// Flatten maps the path of every leaf value to the value, and Unflatten rebuilds
+// nested maps and slices from such a map:
+//
+//	flat := empaths.Flatten(user)    // {".Name": "Alice", ".Tags[0]": "x", ...}
+//	data := empaths.Unflatten(flat)  // map[string]any{"Name": "Alice", "Tags": []any{"x"}, ...}
+//
+// Walk visits data and every value inside it with its path, using the same
+// traversal. Diff compares two values leaf by leaf and returns the changed, added,
+// and removed values with their paths.
+//
+// # Rules
+//
+// The package github.com/authentic-devel/empaths/rules evaluates named boolean
+// rules, written as comparisons and composed with All, Any, and Not, and reports
+// which of them matched.
+//
 // # Example Usage
 //
 //	type User struct {
@@ -108,6 +338,54 @@
 //	// Comparison
 //	isAdult := empaths.Resolve("?.Age=='30'", user, nil)  // true
 //
+// # Resolver Options
+//
+// A Resolver evaluates paths with options, such as limits for untrusted paths:
+//
+//	resolver := empaths.NewResolver(empaths.WithMaxDepth(8), empaths.WithMaxSegments(10000))
+//	value, err := resolver.ResolveErr(userPath, data, nil) // err wraps ErrLimitExceeded
+//
+// ResolveCtx (and Resolver.ResolveCtx) additionally stop when a context is done,
+// checking it before every path segment and method call.
+//
+// WithAllowedPrefixes and WithDeniedFields restrict which fields, methods, and map
+// keys a path may access; other paths are rejected with ErrAccessDenied before they
+// are evaluated:
+//
+//	resolver := empaths.NewResolver(
+//		empaths.WithAllowedPrefixes([]string{".User.Name", ".Orders"}),
+//		empaths.WithDeniedFields([]string{"PasswordHash"}),
+//	)
+//
+// WithFormatter converts the operands of concatenations and the results of the fmt
+// function (as in ".Created | fmt('date')") to strings, e.g. for locale-aware
+// formatting. WithTimeLayouts adds layouts in which strings are compared as times.
+// WithTemplateTruthiness decides truth for '!' and single comparison operands like
+// Go templates, so "!.Tags" is true if there are no tags. WithFloatEpsilon compares
+// numbers with a tolerance. WithBytesEncoding renders byte slices as hex or base64
+// instead of the string they hold. WithInterceptor and WithSegmentInterceptor
+// post-process the final value and the value of every path segment, e.g. to redact
+// fields.
+// WithPrefix binds a custom prefix character to a handler, so "@metric.name" can be
+// resolved by the application.
+// WithEngine delegates references such as ":cel('size(items) > 3')" to a program
+// of another expression language, such as CEL or expr, run against the same data.
+//
+// # Nodes
+//
+// ResolveNode resolves a path once and returns a Node that child paths are resolved
+// against, which avoids walking the model from the root for every child:
+//
+//	user := empaths.ResolveNode(".Users[0]", data)
+//	name := user.Resolve(".Name")
+//	city := user.Node("Address").Resolve(".City")
+//
+// # Tokens
+//
+// Tokens splits a path into typed tokens with their positions and reports malformed
+// paths as a *SyntaxError, for editors and other tools that work with paths without
+// evaluating them. Format uses them to rewrite a path into a canonical form.
+//
 // # Thread Safety
 //
 // All functions in this package are safe for concurrent use.
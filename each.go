@@ -0,0 +1,121 @@
+package empaths
+
+import (
+	"reflect"
+	"strings"
+)
+
+// funcEach implements each(collection, expr, separator). It evaluates expr against
+// every element of an array, slice, map (in sorted key order), or iterator and
+// concatenates the results, separated by the string form of separator, if any, so
+// "each(.Items, '<li>' .Name '</li>')" renders a list. Inside expr, '.' refers to the
+// element and '^' to the data each is called on, as in filters. The results are
+// converted to strings like the operands of a concatenation, and the output limit
+// of the Resolver applies to the result (see WithMaxOutputBytes). each returns ""
+// for an empty collection and nil for values that are not collections.
+func funcEach(rawArgs []string, args []any, data any, state *evalState) any {
+	rawCollection := ""
+	if len(args) == 0 {
+		if len(rawArgs) == 0 {
+			return nil
+		}
+		rawCollection, rawArgs = rawArgs[0], rawArgs[1:]
+		collection, _ := resolveExpressions(rawCollection, data, state, 0)
+		args = append(args, collection)
+	}
+	if len(rawArgs) == 0 || args[0] == nil {
+		return nil
+	}
+	value := reflect.ValueOf(args[0])
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+	elements := collectionElements(value)
+	if elements == nil && value.Kind() != reflect.Array && value.Kind() != reflect.Slice && value.Kind() != reflect.Map {
+		return nil
+	}
+	separator := ""
+	if len(rawArgs) > 1 {
+		sep, _ := resolveExpressions(rawArgs[1], data, state, 0)
+		separator = state.format(sep, "")
+	}
+
+	outerParent := state.parent
+	state.parent = data
+	state.scopeDepth++
+	if state.audit != nil {
+		defer state.audit.rebase(elementAuditBase("each", rawCollection, state.audit.data))()
+	}
+	results := make([]any, 0, 2*len(elements))
+	for i, element := range elements {
+		if i > 0 && separator != "" {
+			results = append(results, separator)
+		}
+		result, _ := resolveExpressions(rawArgs[0], extractValue(element), state, 0)
+		results = append(results, result)
+	}
+	state.scopeDepth--
+	state.parent = outerParent
+	if state.err != nil || len(results) == 0 {
+		return ""
+	}
+	return state.concatenate(results[0], results[1:])
+}
+
+// elementAuditBase returns the path that model paths resolved against the elements
+// of the collection of each, sort, or groupby (the function name) are recorded
+// against (see WithAudit): the elements of the collection if it is given as a model
+// path (".Items[*]" for ".Items" and ".Items[?.Active]"), and the name followed by
+// "()[*]", such as "each()[*]", otherwise. data is the path of the data the function
+// is called on.
+func elementAuditBase(name string, rawCollection string, data string) string {
+	rawCollection = strings.TrimSpace(rawCollection)
+	if strings.HasPrefix(rawCollection, ".") {
+		if tokens, err := Tokens(rawCollection); err == nil && isSingleModelPath(tokens) {
+			// The elements of a wildcard or filter are the selected elements.
+			modelPath := normalizeAuditPath(rawCollection[1:])
+			if strings.HasSuffix(modelPath, "[*]") {
+				return joinAuditPath(data, modelPath)
+			}
+			return joinAuditPath(data, modelPath+"[*]")
+		}
+	}
+	return name + "()[*]"
+}
+
+// isEachCall reports whether tokens[i] is the name of an each call.
+func isEachCall(tokens []Token, i int) bool {
+	return tokens[i].Kind == TokenFunction && tokens[i].Text == "each" && i+1 < len(tokens) && tokens[i+1].Kind == TokenLeftParen
+}
+
+// callArguments splits the tokens of the arguments of the call whose '(' is
+// tokens[open]. It returns the arguments and the index of the closing ')', or
+// len(tokens) if it is missing.
+func callArguments(tokens []Token, open int) ([][]Token, int) {
+	var args [][]Token
+	depth := 0
+	start := open + 1
+	for i := open; i < len(tokens); i++ {
+		switch tokens[i].Kind {
+		case TokenLeftParen, TokenListStart, TokenFilterStart:
+			depth++
+		case TokenRightParen, TokenListEnd, TokenFilterEnd:
+			depth--
+			if depth == 0 {
+				if i > start || len(args) > 0 {
+					args = append(args, tokens[start:i])
+				}
+				return args, i
+			}
+		case TokenComma:
+			if depth == 1 {
+				args = append(args, tokens[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(args, tokens[start:]), len(tokens)
+}
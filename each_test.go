@@ -0,0 +1,60 @@
+package empaths
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFunc_Each(t *testing.T) {
+	team := createTestTeam()
+	team["Title"] = "Team"
+	team["Rows"] = [][]int{{1, 2}, {3}}
+
+	tests := []struct {
+		name     string
+		path     string
+		expected any
+	}{
+		{"slice", "each(.Users, '<li>' .Name '</li>')", "<li>Alice</li><li>Bob</li><li>Carol</li>"},
+		{"separator", "each(.Users, .Name, ', ')", "Alice, Bob, Carol"},
+		{"separator expression", "each(.Users, .Name, :sep)", "Alice; Bob; Carol"},
+		{"piped", ".Users | each(.Name ':' .Age, ' ')", "Alice:30 Bob:25 Carol:35"},
+		{"filtered", "each(.Users[?.Active], .Name, ',')", "Alice,Carol"},
+		{"map in key order", "each(.Scores, ., ',')", "95,88"},
+		{"parent", "each(.Users, ^.Title '/' .Name, ' ')", "Team/Alice Team/Bob Team/Carol"},
+		{"nested", "each(.Rows, '[' each(., ., ',') ']')", "[1,2][3]"},
+		{"comparison", "each(.Users, ?.Age > 28, ',')", "true,false,true"},
+		{"trailing text", "'Users: ' each(.Users, .Name, ', ') '.'", "Users: Alice, Bob, Carol."},
+		{"empty collection", "each(.Users[?.Age > 99], .Name)", ""},
+		{"missing collection", "each(.Missing, .Name)", nil},
+		{"not a collection", "each(.Title, .Name)", nil},
+		{"missing expression", "each(.Users)", nil},
+		{"unterminated call", "each(.Users, .Name", nil},
+	}
+
+	refs := func(name string, data any) any { return "; " }
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := Resolve(tt.path, team, refs); !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("Resolve(%q) = %#v, want %#v", tt.path, result, tt.expected)
+			}
+		})
+	}
+
+	t.Run("audit", func(t *testing.T) {
+		var fields []string
+		resolver := NewResolver(WithAudit(func(record AuditRecord) { fields = record.Fields }))
+		resolver.Resolve("each(.Users[?.Active], .Name ^.Title)", team, nil)
+		expected := []string{".Users[*]", ".Users[*].Active", ".Users[*].Name", ".Title"}
+		if !reflect.DeepEqual(fields, expected) {
+			t.Errorf("audited fields = %v, want %v", fields, expected)
+		}
+	})
+
+	t.Run("output limit", func(t *testing.T) {
+		resolver := NewResolver(WithMaxOutputBytes(8))
+		if _, err := resolver.ResolveErr("each(.Users, .Name)", team, nil); err == nil {
+			t.Errorf("ResolveErr() error = nil, want the output limit error")
+		}
+	})
+}
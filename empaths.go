@@ -5,12 +5,69 @@
 // simple path syntax.
 package empaths
 
+import (
+	"context"
+	"reflect"
+)
+
 // ReferenceResolver is a function type that resolves external references.
 // It takes a reference name and a data context, and returns the resolved value.
 // This can be used to resolve references to templates, configuration values,
 // or any other external data sources.
 type ReferenceResolver func(name string, data any) any
 
+// PathResolvable is implemented by types that resolve path segments themselves
+// instead of through reflection, such as ordered maps, lazy proxies, ORM records,
+// and wrapper types.
+//
+// ResolvePathSegment is called with the name of a field or method segment (".Name")
+// or the unquoted key of a bracket segment ("['a b']" or "[0]"), and returns the
+// value of the segment and whether it exists. Reflection is not used for the
+// segments of a PathResolvable, so it keeps its fields and methods private to path
+// expressions. Wildcards and filters still select elements through reflection.
+//
+// The method is found on values and, for values reached through a pointer, on the
+// pointer, so it may have a pointer receiver.
+type PathResolvable interface {
+	ResolvePathSegment(name string) (any, bool)
+}
+
+// IndexResolvable is implemented by collection types that are not Go slices or
+// arrays, such as ring buffers, to support index access ("[0]"). ResolveIndex is
+// called with the index, which may be negative, and returns the element and whether
+// it exists. Like PathResolvable, the method may have a pointer receiver.
+type IndexResolvable interface {
+	ResolveIndex(i int) (any, bool)
+}
+
+// KeyResolvable is implemented by collection types that are not Go maps, such as
+// ordered maps, to support key access ("['key']" or "[key]"). ResolveKey is called
+// with the unquoted key and returns the element and whether it exists. If a type
+// implements both IndexResolvable and KeyResolvable, unquoted integers are passed
+// to ResolveIndex and all other keys to ResolveKey. Like PathResolvable, the method
+// may have a pointer receiver.
+//
+// Dot notation (".key") is not affected; implement PathResolvable for that.
+type KeyResolvable interface {
+	ResolveKey(k string) (any, bool)
+}
+
+// PathAliaser is implemented by types that declare alternative names for their
+// fields, so that path names stay stable when the Go names change. PathAliases maps
+// a name to the name of a field or method, or to a model path, that the name stands
+// for: {"display_name": "DisplayName", "addr": ".Address", "city": ".Address.City"}.
+//
+// Aliases are consulted before reflection (and before ResolvePathSegment) for the
+// names of dot segments, by path resolution, Set, SetCreate, Delete, Append, and
+// Insert. The target of an alias is not looked up as an alias again. PathAliases is
+// called for every segment resolved against the type, so it should return a map
+// that is built once. Like PathResolvable, the method may have a pointer receiver.
+// The access rules of a Resolver (see WithDeniedFields and WithAllowedPrefixes)
+// apply to the targets of aliases as well as to their names.
+type PathAliaser interface {
+	PathAliases() map[string]string
+}
+
 // Resolve evaluates a path expression against a data model and returns the resolved value.
 //
 // A path can consist of multiple segments and supports various expression types:
@@ -20,9 +77,9 @@ type ReferenceResolver func(name string, data any) any
 //   - External references: Starts with ':' followed by reference name (e.g., ":config")
 //   - Comparisons: Starts with '?' followed by operands and operator (e.g., "?.Age=='18'")
 //
-// Character encoding: Path syntax elements (field names, map keys, reference names) should
-// use ASCII characters only. UTF-8 content within string literals is supported, but non-ASCII
-// characters in path syntax have undefined behavior.
+// Character encoding: Paths are UTF-8. Field names, method names, map keys, reference
+// names, and string literals may contain any Unicode characters; operators, brackets,
+// quotes, and separators are ASCII.
 //
 // Path segments can be combined to form complex expressions, and can include:
 //   - Nested properties: ".User.Address.City"
@@ -49,10 +106,150 @@ func Resolve(path string, data any, refResolver ReferenceResolver) any {
 	if path == "" {
 		return data
 	}
-	result, _ := resolveExpressions(path, data, refResolver, 0)
+	result, _ := resolveExpressions(path, data, &evalState{refResolver: refResolver, root: data}, 0)
 	return result
 }
 
+// ResolveWithVars evaluates a path expression like Resolve, with variables that the
+// expression refers to as '$name'. Variables parameterize an expression without
+// writing values into the path, so values containing quotes or other path syntax
+// need no escaping:
+//
+//	empaths.ResolveWithVars("?.Status==$wanted", order, map[string]any{"wanted": status}, nil)
+//
+// A variable may be followed by a model path that is resolved against its value
+// ("$user.Address.City" or "$users[0].Name"). Undefined variables resolve to nil.
+//
+// Parameters:
+//   - path: The path expression to evaluate
+//   - data: The data model to evaluate the path against
+//   - vars: The variables, by name without the '$'
+//   - refResolver: Optional function to resolve external references (prefixed with ':')
+//
+// Returns:
+//
+//	The resolved value from the data model based on the path expression
+func ResolveWithVars(path string, data any, vars map[string]any, refResolver ReferenceResolver) any {
+	if path == "" {
+		return data
+	}
+	state := &evalState{refResolver: refResolver, root: data, vars: vars}
+	result, _ := resolveExpressions(path, data, state, 0)
+	return result
+}
+
+// ResolveSlice evaluates a path expression like Resolve and returns the result as a
+// []T, e.g. the projected values of a wildcard or filter:
+//
+//	names, ok := empaths.ResolveSlice[string](".Users[*].Name", data, nil)
+//
+// Wildcards and filters already return a typed slice such as []string if all
+// projected values have the same type; ResolveSlice also converts a []any (or any
+// other slice or array) whose elements are all of type T.
+//
+// Parameters:
+//   - path: The path expression to evaluate
+//   - data: The data model to evaluate the path against
+//   - refResolver: Optional function to resolve external references (prefixed with ':')
+//
+// Returns:
+//   - The elements of the result
+//   - false if the result is not a slice or array, or an element is not a T
+func ResolveSlice[T any](path string, data any, refResolver ReferenceResolver) ([]T, bool) {
+	result := Resolve(path, data, refResolver)
+	if typed, ok := result.([]T); ok {
+		return typed, true
+	}
+	value := reflect.ValueOf(result)
+	if value.Kind() != reflect.Slice && value.Kind() != reflect.Array {
+		return nil, false
+	}
+	typed := make([]T, value.Len())
+	for i := range typed {
+		element, ok := extractValue(value.Index(i)).(T)
+		if !ok {
+			return nil, false
+		}
+		typed[i] = element
+	}
+	return typed, true
+}
+
+// ResolveChain resolves a path against several data contexts in order and returns
+// the first result that is not nil, e.g. to look a name up in page data, then in
+// layout data, then in global data:
+//
+//	empaths.ResolveChain(".Title", []any{page, layout, globals}, nil)
+//
+// The path is compiled once (see Compile) and resolved with each context as its
+// data, so '$' refers to the context being tried. A nil context is tried like any
+// other: model paths resolve to nil against it, but literals and references do not.
+// A malformed path is resolved leniently, like Resolve does.
+//
+// Parameters:
+//   - path: The path expression to evaluate
+//   - contexts: The data models to try, in order
+//   - refResolver: Optional function to resolve external references (prefixed with ':')
+//
+// Returns:
+//   - The first non-nil result, or nil if the path resolves to nil in every context
+func ResolveChain(path string, contexts []any, refResolver ReferenceResolver) any {
+	resolve := func(data any) any {
+		return Resolve(path, data, refResolver)
+	}
+	if compiled, err := Compile(path); err == nil {
+		resolve = func(data any) any {
+			return compiled.Resolve(data, refResolver)
+		}
+	}
+	for _, data := range contexts {
+		if result := resolve(data); result != nil {
+			return result
+		}
+	}
+	return nil
+}
+
+// ResolveDefault evaluates a path expression like Resolve and returns def if the
+// result is nil, e.g. because a field is missing or a pointer on the way is nil:
+//
+//	title := empaths.ResolveDefault(".Page.Title", data, "Untitled", nil)
+//
+// Only nil is replaced: an empty string, false, or 0 is returned as it is.
+//
+// Parameters:
+//   - path: The path expression to evaluate
+//   - data: The data model to evaluate the path against
+//   - def: The value to return if the path resolves to nil
+//   - refResolver: Optional function to resolve external references (prefixed with ':')
+//
+// Returns:
+//   - The resolved value, or def if it is nil
+func ResolveDefault(path string, data any, def any, refResolver ReferenceResolver) any {
+	if result := Resolve(path, data, refResolver); result != nil {
+		return result
+	}
+	return def
+}
+
+// ResolveCtx evaluates a path expression like Resolve, but aborts when ctx is done.
+// The context is checked before every model path segment, so long-running method
+// calls and traversals of large collections can be cancelled, e.g. per request in
+// a server.
+//
+// Parameters:
+//   - ctx: The context of the evaluation
+//   - path: The path expression to evaluate
+//   - data: The data model to evaluate the path against
+//   - refResolver: Optional function to resolve external references (prefixed with ':')
+//
+// Returns:
+//   - The resolved value, or nil if the evaluation was aborted
+//   - ctx.Err() if the context was done before the evaluation finished
+func ResolveCtx(ctx context.Context, path string, data any, refResolver ReferenceResolver) (any, error) {
+	return defaultResolver.ResolveCtx(ctx, path, data, refResolver)
+}
+
 // ResolveModel resolves a model reference in a path expression.
 // Model references start with '.' followed by a path to a property or method in the data model.
 // This function can be used directly to resolve a model path against a data object.
@@ -74,5 +271,5 @@ func Resolve(path string, data any, refResolver ReferenceResolver) any {
 //   - The new index after processing
 //   - Error if the path cannot be resolved
 func ResolveModel(path string, data any, index int) (any, int, error) {
-	return resolveModel(path, data, index)
+	return resolveModel(path, data, index, &evalState{root: data})
 }
@@ -1,7 +1,15 @@
 package empaths
 
 import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/netip"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 // Test data structures
@@ -144,6 +152,50 @@ func TestResolve_MapAccess(t *testing.T) {
 	}
 }
 
+func TestResolve_QuotedMapKeys(t *testing.T) {
+	data := map[string]any{
+		"Data": map[string]any{
+			"my key":  "spaced",
+			"a.b[0]":  "dotted",
+			"it's":    "apostrophe",
+			"plain":   "plain",
+			"x]y":     "bracket",
+			"line\nx": "escaped",
+		},
+		"Counts": map[int]string{7: "seven"},
+		"Items":  []string{"zero", "one"},
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		expected any
+	}{
+		{"double quoted with space", ".Data[\"my key\"]", "spaced"},
+		{"single quoted with dots and brackets", ".Data['a.b[0]']", "dotted"},
+		{"escaped quote", ".Data['it\\'s']", "apostrophe"},
+		{"other quote kind inside", ".Data[\"it's\"]", "apostrophe"},
+		{"closing bracket inside quotes", ".Data['x]y']", "bracket"},
+		{"escape sequence", ".Data['line\\nx']", "escaped"},
+		{"quoted plain key", ".Data['plain']", "plain"},
+		{"unquoted key still works", ".Data[plain]", "plain"},
+		{"quoted int key", ".Counts['7']", "seven"},
+		{"quoted index", ".Items['1']", "one"},
+		{"in concatenation", ".Data['my key'] '!'", "spaced!"},
+		{"in comparison", "?.Data['a.b[0]']=='dotted'", true},
+		{"missing quoted key", ".Data['nope']", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Resolve(tt.path, data, nil)
+			if result != tt.expected {
+				t.Errorf("Resolve(%q) = %v, want %v", tt.path, result, tt.expected)
+			}
+		})
+	}
+}
+
 func TestResolve_MapKeyNotFound(t *testing.T) {
 	person := createTestPerson()
 
@@ -175,128 +227,1171 @@ func TestResolve_MethodCall(t *testing.T) {
 	}
 }
 
-func TestResolve_StringLiteral(t *testing.T) {
-	person := createTestPerson()
+// Registry has methods with several results.
+type Registry struct {
+	Admin *Person
+}
+
+func (r Registry) FindAdmin() (*Person, bool) { return r.Admin, r.Admin != nil }
+func (r Registry) Size() (int, error)         { return 1, nil }
+
+func TestResolve_ResultSelector(t *testing.T) {
+	admin := createTestPerson()
+	data := map[string]any{
+		"Registry": Registry{Admin: &admin},
+		"Empty":    Registry{},
+		"Loader":   func() (string, int, error) { return "loaded", 3, nil },
+		"Keys":     map[string]string{"a#1": "hash key"},
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		expected any
+	}{
+		{"first result", ".Registry.FindAdmin#0.Name", "Alice"},
+		{"second result", ".Registry.FindAdmin#1", true},
+		{"ok is false", ".Empty.FindAdmin#1", false},
+		{"without selector", ".Registry.FindAdmin.Name", "Alice"},
+		{"nil error", ".Registry.Size#1", nil},
+		{"function value", ".Loader#1", 3},
+		{"out of range", ".Registry.FindAdmin#2", nil},
+		{"field", ".Registry.Admin#1", nil},
+		{"map key", ".Keys.a#1", "hash key"},
+		{"in comparison", "?.Registry.FindAdmin#1 && .Registry.FindAdmin#0.Age > 18", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := Resolve(tt.path, data, nil); result != tt.expected {
+				t.Errorf("Resolve(%q) = %v, want %v", tt.path, result, tt.expected)
+			}
+		})
+	}
+}
+
+// ViewModel holds lazily computed values.
+type ViewModel struct {
+	Title   func() string
+	Address func() (*Address, error)
+	Missing func() string
+	Format  func(string) string
+}
+
+func TestResolve_FunctionValues(t *testing.T) {
+	calls := 0
+	data := map[string]any{
+		"View": ViewModel{
+			Title:   func() string { return "Orders" },
+			Address: func() (*Address, error) { return &Address{City: "Berlin"}, nil },
+			Format:  func(s string) string { return s },
+		},
+		"Total": func() int { calls++; return 42 },
+		"Items": []func() string{func() string { return "first" }},
+		"Done":  func() {},
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		expected any
+	}{
+		{"field", ".View.Title", "Orders"},
+		{"first of several results", ".View.Address.City", "Berlin"},
+		{"nil function", ".View.Missing", nil},
+		{"map value", ".Total", 42},
+		{"bracket key", ".['Total']", 42},
+		{"slice element", ".Items[0]", "first"},
+		{"comparison", "?.Total > 40", true},
+		{"concatenation", ".View.Title ': ' .Total", "Orders: 42"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := Resolve(tt.path, data, nil); result != tt.expected {
+				t.Errorf("Resolve(%q) = %v, want %v", tt.path, result, tt.expected)
+			}
+		})
+	}
+
+	t.Run("functions with arguments or without results are not called", func(t *testing.T) {
+		for _, path := range []string{".View.Format", ".Done"} {
+			if result := Resolve(path, data, nil); reflect.ValueOf(result).Kind() != reflect.Func {
+				t.Errorf("Resolve(%q) = %v, want the function", path, result)
+			}
+		}
+	})
+
+	t.Run("called on every evaluation", func(t *testing.T) {
+		calls = 0
+		Resolve(".Total", data, nil)
+		Resolve(".Total", data, nil)
+		if calls != 2 {
+			t.Errorf("function called %d times, want 2", calls)
+		}
+	})
+}
+
+func TestResolve_StringLiteral(t *testing.T) {
+	person := createTestPerson()
+
+	tests := []struct {
+		name     string
+		path     string
+		expected any
+	}{
+		{"single quotes", "'Hello'", "Hello"},
+		{"double quotes", "\"World\"", "World"},
+		{"escaped single", "'It\\'s'", "It's"},
+		{"escaped double", "\"Say \\\"Hi\\\"\"", "Say \"Hi\""},
+		{"newline", "'a\\nb'", "a\nb"},
+		{"tab", "'a\\tb'", "a\tb"},
+		{"carriage return", "'a\\rb'", "a\rb"},
+		{"backslash", "'a\\\\b'", "a\\b"},
+		{"unicode", "'caf\\u00e9'", "café"},
+		{"unicode uppercase hex", "'\\u00C9'", "É"},
+		{"surrogate pair", "'\\ud83d\\ude00'", "😀"},
+		{"invalid unicode", "'\\u12'", "u12"},
+		{"unknown escape", "'\\q'", "q"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Resolve(tt.path, person, nil)
+			if result != tt.expected {
+				t.Errorf("Resolve(%q) = %v, want %v", tt.path, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestResolve_Concatenation(t *testing.T) {
+	person := createTestPerson()
+
+	tests := []struct {
+		name     string
+		path     string
+		expected any
+	}{
+		{"string and field", "'Hello, ' .Name", "Hello, Alice"},
+		{"field and string", ".Name ' is here'", "Alice is here"},
+		{"multiple parts", "'Name: ' .Name ', Age: ' .Age", "Name: Alice, Age: 30"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Resolve(tt.path, person, nil)
+			if result != tt.expected {
+				t.Errorf("Resolve(%q) = %v, want %v", tt.path, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestResolve_Negation(t *testing.T) {
+	person := createTestPerson()
+
+	tests := []struct {
+		name     string
+		path     string
+		expected any
+	}{
+		{"negate true", "!.Active", false},
+		{"negate string true", "!'true'", false},
+		{"negate string false", "!'false'", true},
+		{"negate comparison", "!?.Name=='Alice'", false},
+		{"negate false comparison", "!?.Age > 40", true},
+		{"negate group", "!(.Age > 40 || .Active)", false},
+		{"negate whole comparison", "!?.Age > 40 || .Active", false},
+		{"negate group in comparison", "?.Active && !(.Age > 40)", true},
+		{"negate group with spaces", "?.Active && ! ( .Age > 40 )", true},
+		{"concatenated", "'active: ' !?.Active", "active: false"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Resolve(tt.path, person, nil)
+			if result != tt.expected {
+				t.Errorf("Resolve(%q) = %v, want %v", tt.path, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestResolve_Comparison(t *testing.T) {
+	person := createTestPerson()
+
+	tests := []struct {
+		name     string
+		path     string
+		expected any
+	}{
+		{"int equals string literal true", "?.Age=='30'", true},
+		{"int equals string literal false", "?.Age=='25'", false},
+		{"int not equals string literal true", "?.Age!='25'", true},
+		{"int not equals string literal false", "?.Age!='30'", false},
+		{"string comparison", "?.Name=='Alice'", true},
+		{"bool comparison", "?.Active=='true'", true},
+		{"nested int comparison", "?.Address.Zip=='10001'", true},
+		{"single operand true", "?.Active", true},
+		{"single operand not a boolean", "?.Name", false},
+		// Comparisons against string literals convert both sides to strings, so int 30 becomes "30"
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Resolve(tt.path, person, nil)
+			if result != tt.expected {
+				t.Errorf("Resolve(%q) = %v, want %v", tt.path, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestResolve_ComparisonNil(t *testing.T) {
+	blank := ""
+	data := map[string]any{
+		"DeletedAt": (*time.Time)(nil),
+		"Config":    map[string]any{},
+		"NilMap":    map[string]any(nil),
+		"Tags":      []string(nil),
+		"Empty":     "",
+		"Blank":     &blank,
+		"Zero":      0,
+		"Nothing":   nil,
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		expected any
+	}{
+		{"nil pointer", "?.DeletedAt==nil", true},
+		{"nil map", "?.NilMap==nil", true},
+		{"nil slice", "?.Tags==nil", true},
+		{"nil value", "?.Nothing==nil", true},
+		{"missing key", "?.Missing==nil", true},
+		{"nil on the left", "?nil==.Missing", true},
+		{"empty map", "?.Config!=nil", true},
+		{"empty string is not nil", "?.Empty==nil", false},
+		{"empty string is set", "?.Empty!=nil", true},
+		{"nil is not an empty string", "?.Missing==''", false},
+		{"pointer to empty string", "?.Blank==nil", false},
+		{"zero", "?.Zero==nil", false},
+		{"two missing values", "?.Missing==.Other", true},
+		{"missing and empty", "?.Missing!=.Empty", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Resolve(tt.path, data, nil)
+			if result != tt.expected {
+				t.Errorf("Resolve(%q) = %v, want %v", tt.path, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestResolve_ComparisonAffixAndGlob(t *testing.T) {
+	data := map[string]any{
+		"Name":   "Alice",
+		"File":   "report.json",
+		"Host":   "db.eu.internal",
+		"Path":   "a/b/c.txt",
+		"Star":   "*.go",
+		"Prefix": "Ali",
+		"Empty":  "",
+		"Users":  []Member{{Name: "Alice"}, {Name: "Alfred"}, {Name: "Bob"}},
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		expected any
+	}{
+		{"starts with", "?.Name ^= 'Ali'", true},
+		{"starts with no match", "?.Name ^= 'li'", false},
+		{"starts with without spaces", "?.Name^='Ali'", true},
+		{"starts with field", "?.Name ^= .Prefix", true},
+		{"starts with empty", "?.Name ^= ''", true},
+		{"ends with", "?.File $= '.json'", true},
+		{"ends with no match", "?.File $= '.yaml'", false},
+		{"ends with without spaces", "?.File$='.json'", true},
+		{"glob", "?.Host ~g '*.internal'", true},
+		{"glob no match", "?.Host ~g '*.external'", false},
+		{"glob without spaces", "?.Host~g'db.*'", true},
+		{"glob single character", "?.Name ~g 'Al?ce'", true},
+		{"glob whole string", "?.Name ~g 'Ali'", false},
+		{"glob across slashes", "?.Path ~g 'a/*.txt'", true},
+		{"glob several stars", "?.Host ~g '*.*.int*'", true},
+		{"glob escaped star", `?.Star ~g '\\*.go'`, true},
+		{"glob escaped star no match", `?.File ~g '\\*.json'`, false},
+		{"glob empty", "?.Empty ~g '*'", true},
+		{"nil", "?.Missing ^= ''", false},
+		{"filter", "count(.Users[?.Name ^= 'Al'])", 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Resolve(tt.path, data, nil)
+			if result != tt.expected {
+				t.Errorf("Resolve(%q) = %v, want %v", tt.path, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestResolve_ComparisonFold(t *testing.T) {
+	data := map[string]any{
+		"Status": "ACTIVE",
+		"Name":   "Straße",
+		"Other":  "active",
+		"Age":    30,
+		"Empty":  "",
+		"Users":  []Member{{Name: "alice"}, {Name: "ALICE"}, {Name: "Bob"}},
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		expected any
+	}{
+		{"equals", "?.Status ==i 'Active'", true},
+		{"equals without spaces", "?.Status==i'active'", true},
+		{"equals no match", "?.Status ==i 'inactive'", false},
+		{"equals fields", "?.Status ==i .Other", true},
+		{"case sensitive", "?.Status == 'Active'", false},
+		{"not equals", "?.Status !=i 'active'", false},
+		{"not equals match", "?.Status !=i 'inactive'", true},
+		{"unicode", "?.Name ==i 'STRASSE'", false},
+		{"unicode fold", "?.Name ==i 'STRAßE'", true},
+		{"numbers", "?.Age ==i 30.0", true},
+		{"nil", "?.Missing ==i ''", false},
+		{"both nil", "?.Missing ==i nil", true},
+		{"function operand", "?.Empty ==isempty(.Empty)", false},
+		{"filter", "count(.Users[?.Name ==i 'Alice'])", 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Resolve(tt.path, data, nil)
+			if result != tt.expected {
+				t.Errorf("Resolve(%q) = %v, want %v", tt.path, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestResolve_ComparisonVersion(t *testing.T) {
+	data := map[string]any{
+		"Version": "1.10.2",
+		"Tagged":  "v1.9.0",
+		"Short":   "1.2",
+		"RC":      "2.0.0-rc.2",
+		"Build":   "1.10.2+linux.amd64",
+		"Name":    "latest",
+		"Clients": []map[string]any{
+			{"Version": "1.9.9"},
+			{"Version": "1.20.0"},
+			{"Version": "1.20.0-beta"},
+			{"Version": "2.0"},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		expected any
+	}{
+		{"greater or equal", "?.Version >=v '1.9'", true},
+		{"lexically less", "?.Version >= '1.9'", false},
+		{"greater", "?.Version >v '1.10.1'", true},
+		{"less", "?.Version <v '1.10.10'", true},
+		{"less or equal", "?.Version <=v '1.10.2'", true},
+		{"leading v", "?.Tagged <v .Version", true},
+		{"missing components", "?.Short >=v '1.2.0'", true},
+		{"missing components greater", "?.Short >v '1.2.0'", false},
+		{"pre-release below release", "?.RC <v '2.0.0'", true},
+		{"pre-release numeric identifiers", "?.RC >v '2.0.0-rc.10'", false},
+		{"pre-release alphanumeric identifiers", "?.RC >v '2.0.0-beta.5'", true},
+		{"build metadata ignored", "?.Build <=v .Version", true},
+		{"not a version", "?.Name >=v '1.0'", false},
+		{"nil", "?.Missing <v '1.0'", false},
+		{"without spaces", "?.Version>=v'1.10'", true},
+		{"filter", "count(.Clients[?.Version >=v '1.20'])", 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Resolve(tt.path, data, nil)
+			if result != tt.expected {
+				t.Errorf("Resolve(%q) = %v, want %v", tt.path, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestResolve_LogicalOperators(t *testing.T) {
+	person := createTestPerson()
+
+	tests := []struct {
+		name     string
+		path     string
+		expected any
+	}{
+		{"and", "?.Name=='Alice' && .Age==30", true},
+		{"and false", "?.Name=='Alice' && .Age==31", false},
+		{"or", "?.Name=='Bob' || .Age==30", true},
+		{"or false", "?.Name=='Bob' || .Age==31", false},
+		{"without spaces", "?.Name=='Bob'||.Age>=30&&.Active", true},
+		{"and binds tighter", "?.Name=='Alice' || .Age==31 && .Active==false", true},
+		{"and binds tighter false", "?.Name=='Bob' && .Age==30 || .Age==31", false},
+		{"group", "?(.Name=='Alice' || .Age==31) && .Active==false", false},
+		{"nested groups", "?((.Age > 20) && (.Age < 40)) && !(.Tags contains 'admin')", true},
+		{"single operands", "?.Active && .Address.Zip", false},
+		{"in", "?.Name in ['Alice','Bob'] && .Age >= 18", true},
+		{"function operands", "?len(.Tags) == 3 && isempty(.Name) == false", true},
+		{"reference", "?:age > 20 && .Active", true},
+		{"repeated question mark", "?.Age > 20 && ?.Age < 40", true},
+		{"concatenated", "?.Age > 20 && .Active == true ' yes'", "true yes"},
+		{"filter", "count(.Tags[?. ^= 'dev' || . $= 'ster'])", 2},
+	}
+
+	refs := func(name string, data any) any {
+		if name == "age" {
+			return 30
+		}
+		return nil
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Resolve(tt.path, person, refs)
+			if result != tt.expected {
+				t.Errorf("Resolve(%q) = %v, want %v", tt.path, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestResolve_ComparisonFieldToField(t *testing.T) {
+	data := map[string]any{
+		"value":    30,
+		"expected": 30,
+		"other":    25,
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		expected any
+	}{
+		{"field equals field true", "?.value==.expected", true},
+		{"field equals field false", "?.value==.other", false},
+		{"field not equals field true", "?.value!=.other", true},
+		{"field not equals field false", "?.value!=.expected", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Resolve(tt.path, data, nil)
+			if result != tt.expected {
+				t.Errorf("Resolve(%q) = %v, want %v", tt.path, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestResolve_Contains(t *testing.T) {
+	person := createTestPerson()
+	people := []Person{person, {Name: "Bob", Tags: []string{"manager"}}}
+	data := map[string]any{
+		"Values": []any{2, 30, nil},
+		"IDs":    []int64{9007199254740993},
+		"Names":  []string{"", "x"},
+		"Title":  "Hello",
+		"Labels": map[string]string{"": "empty"},
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		data     any
+		expected any
+	}{
+		{"slice element", "?.Tags contains 'gopher'", person, true},
+		{"slice element missing", "?.Tags contains 'rustacean'", person, false},
+		{"substring", "?.Name contains 'lic'", person, true},
+		{"substring missing", "?.Name contains 'Bob'", person, false},
+		{"map key", "?.Scores contains 'math'", person, true},
+		{"map key missing", "?.Scores contains 'art'", person, false},
+		{"int field as string", "?.Age contains '3'", person, true},
+		{"field operand", "?.Tags contains .Tags[1]", person, true},
+		{"nil haystack", "?.Missing contains 'x'", person, false},
+		{"in filter", "count(.[?.Tags contains 'manager'])", people, 1},
+		{"numeric element", "?.Values contains 30", data, true},
+		{"numeric element compared by value", "?.Values contains 2.0", data, true},
+		{"large integers compared exactly", "?.IDs contains 9007199254740993", data, true},
+		{"large integer missing", "?.IDs contains 9007199254740992", data, false},
+		{"nil element", "?.Values contains nil", data, true},
+		{"nil is not an empty string", "?.Names contains nil", data, false},
+		{"missing value is not an empty string", "?.Names contains .Missing", data, false},
+		{"nil is not a substring", "?.Title contains nil", data, false},
+		{"nil is not a map key", "?.Labels contains nil", data, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Resolve(tt.path, tt.data, nil)
+			if result != tt.expected {
+				t.Errorf("Resolve(%q) = %v, want %v", tt.path, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestResolve_In(t *testing.T) {
+	data := map[string]any{
+		"Status":  "trial",
+		"Age":     30,
+		"Allowed": []string{"active", "trial"},
+		"Roles":   map[string]bool{"admin": true},
+		"Role":    "admin",
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		expected any
+	}{
+		{"literal list match", "?.Status in ['active','trial','beta']", true},
+		{"literal list no match", "?.Status in ['active','beta']", false},
+		{"spaces in list", "?.Status in [ 'active' , 'trial' ]", true},
+		{"int against string literal", "?.Age in ['25', '30']", true},
+		{"model operand in list", "?'trial' in ['x', .Status]", true},
+		{"empty list", "?.Status in []", false},
+		{"missing value is not empty string", "?.Missing in ['', 'x']", false},
+		{"empty string is not nil", "?'' in [nil]", false},
+		{"nil in list", "?.Missing in ['x', nil]", true},
+		{"numbers by value", "?.Age in [30.0]", true},
+		{"slice field", "?.Status in .Allowed", true},
+		{"map field", "?.Role in .Roles", true},
+		{"missing haystack", "?.Status in .Missing", false},
+		{"concatenated", "?.Status in ['trial'] ' user'", "true user"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Resolve(tt.path, data, nil)
+			if result != tt.expected {
+				t.Errorf("Resolve(%q) = %v, want %v", tt.path, result, tt.expected)
+			}
+		})
+	}
+
+	t.Run("short-circuits on first match", func(t *testing.T) {
+		calls := 0
+		resolver := func(name string, data any) any {
+			calls++
+			return name
+		}
+		result := Resolve("?.Status in ['trial', :other, :another]", data, resolver)
+		if result != true {
+			t.Errorf("Resolve with in operator = %v, want %v", result, true)
+		}
+		if calls != 0 {
+			t.Errorf("reference resolver called %d times after a match, want 0", calls)
+		}
+	})
+}
+
+func TestResolve_Ordering(t *testing.T) {
+	person := createTestPerson()
+	people := []Person{person, {Name: "Bob", Age: 17}}
+
+	tests := []struct {
+		name     string
+		path     string
+		data     any
+		expected any
+	}{
+		{"less", "?.Age < 31", person, true},
+		{"less equal value", "?.Age < 30", person, false},
+		{"less or equal", "?.Age <= 30", person, true},
+		{"greater", "?.Age > 29.5", person, true},
+		{"greater false", "?.Age > 30", person, false},
+		{"greater or equal", "?.Age >= 30", person, true},
+		{"no spaces", "?.Age>=31", person, false},
+		{"numeric not lexical", "?.Scores.science < 100", person, true},
+		{"number and string compare as strings", "?.Age > '4'", person, false},
+		{"strings", "?.Name < 'Bob'", person, true},
+		{"strings greater", "?.Address.City >= 'NYC'", person, true},
+		{"field to field", "?.Scores.math > .Scores.science", person, true},
+		{"nil left", "?.Missing < 1", person, false},
+		{"nil right", "?.Age > .Missing", person, false},
+		{"nil literal", "?.Age >= nil", person, false},
+		{"in filter", "count(.[?.Age >= 18])", people, 1},
+		{"concatenated", "?.Age > 18 ' adult'", person, "true adult"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Resolve(tt.path, tt.data, nil)
+			if result != tt.expected {
+				t.Errorf("Resolve(%q) = %v, want %v", tt.path, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestResolve_ComparisonFunctionOperands(t *testing.T) {
+	data := map[string]any{
+		"Items": []map[string]any{{"Price": 5}, {"Price": 15}, {"Price": 25}},
+		"Empty": []string{},
+		"Name":  "Alice",
+		"Carts": []map[string]any{
+			{"ID": 1, "Items": []string{"a"}},
+			{"ID": 2, "Items": []string{}},
+			{"ID": 3},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		expected any
+	}{
+		{"len with quoted number", "?len(.Items)>'0'", true},
+		{"len of empty slice", "?len(.Empty)>'0'", false},
+		{"len with number", "?len(.Items) >= 3", true},
+		{"function on the right", "?3 == len(.Items)", true},
+		{"functions on both sides", "?len(.Name) > len(.Items)", true},
+		{"filter in argument", "?count(.Items[?.Price > 10]) == 2", true},
+		{"pipe", "?.Items | len > 0", true},
+		{"pipe on the right", "?5 == .Name | len", true},
+		{"pipe with arguments", "?.Name | substr(0, 2) == 'Al'", true},
+		{"pipes", "?.Items | first | len == 1", true},
+		{"pipe before logical operator", "?.Empty | len == 0 || .Missing", true},
+		{"pipe as single operand", "?.Empty | isempty && .Name", false},
+		{"in filter", "count(.Carts[?len(.Items) > 0])", 1},
+		{"unterminated literal", "?'", false},
+		{"unterminated right operand", "?.Name=='Alice", true},
+		{"unterminated operand after logical operator", "?.Empty | len == 1 || 'x", false},
+		{"unterminated operand of in", "?.Name in 'Alice", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Resolve(tt.path, data, nil)
+			if result != tt.expected {
+				t.Errorf("Resolve(%q) = %v, want %v", tt.path, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestResolve_RootAndParent(t *testing.T) {
+	type item struct {
+		Name  string
+		Price int
+	}
+	type order struct {
+		ID     string
+		Limit  int
+		Items  []item
+		Orders []order
+	}
+	data := map[string]any{
+		"Budget": 10,
+		"Items":  []item{{"pen", 2}, {"book", 12}, {"lamp", 10}},
+		"Orders": []order{
+			{ID: "a", Limit: 5, Items: []item{{"pen", 2}, {"book", 12}}},
+			{ID: "b", Limit: 20, Items: []item{{"book", 12}}},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		expected any
+	}{
+		{"root in filter", "join(.Items[?.Price <= $.Budget].Name, ',')", "pen,lamp"},
+		{"root outside filter", "$.Budget", 10},
+		{"bare root", "count($.Items)", 3},
+		{"parent in nested filter", "join(.Orders[*].Items[?.Price > ^.Limit].Name, ',')", "[book],[]"},
+		{"parent of indexed element", "join(.Orders[0].Items[?.Price > ^.Limit].Name, ',')", "book"},
+		{"parent in filter of filtered elements", "join(.Orders[?.ID == 'b'].Items[?.Price < ^.Limit].Name, ',')", "[book]"},
+		{"parent inside nested filter", "join(.Orders[?.Items[?.Price > ^.Limit].Name contains 'book'].ID, ',')", "a"},
+		{"root in nested filter", "join(.Orders[*].Items[?.Price > $.Budget].Name, ',')", "[book],[book]"},
+		{"parent in top-level filter", "join(.Items[?.Price == ^.Budget].Name, ',')", "lamp"},
+		{"parent outside filter", "^.Budget", nil},
+		{"bare parent outside filter", "^", nil},
+		{"comparison with root", "?$.Budget == 10", true},
+		{"concatenated", "'budget: ' $.Budget", "budget: 10"},
+		{"memoized", ".Budget ' ' join(.Orders[0].Items[?.Price > ^.Limit].Name, ',') ' ' .Orders[0].ID", "10 book a"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Resolve(tt.path, data, nil)
+			if result != tt.expected {
+				t.Errorf("Resolve(%q) = %v, want %v", tt.path, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestResolveWithVars(t *testing.T) {
+	person := createTestPerson()
+	vars := map[string]any{
+		"wanted": "Alice",
+		"quoted": `it's "quoted"`,
+		"minAge": 18,
+		"names":  []string{"Bob", "Alice"},
+		"other":  Person{Name: "Bob", Address: Address{City: "LA"}},
+		"_x1":    1,
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		expected any
+	}{
+		{"comparison", "?.Name==$wanted", true},
+		{"value with quotes", "?$quoted == 'it\\'s \"quoted\"'", true},
+		{"value with quotes does not inject", "?.Name == $quoted", false},
+		{"ordering", "?.Age >= $minAge", true},
+		{"in", "?.Name in $names", true},
+		{"variable alone keeps type", "$minAge", 18},
+		{"model path on variable", "$other.Address.City", "LA"},
+		{"index on variable", "$names[1]", "Alice"},
+		{"index out of range on variable", "$names[5]", nil},
+		{"filter on variable", "count($names[?. == 'Bob'])", 1},
+		{"concatenation", "$other.Name ' and ' .Name", "Bob and Alice"},
+		{"same path on variable and data", "$other.Name .Name $other.Name .Name", "BobAliceBobAlice"},
+		{"in filter", "count(.Tags[?. contains $wanted])", 0},
+		{"function argument", "join($names, '+')", "Bob+Alice"},
+		{"underscore and digit", "$_x1", 1},
+		{"undefined", "$missing", nil},
+		{"undefined path", "$missing.Name", nil},
+		{"root is not a variable", "$.Name", "Alice"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := ResolveWithVars(tt.path, person, vars, nil)
+			if result != tt.expected {
+				t.Errorf("ResolveWithVars(%q) = %v, want %v", tt.path, result, tt.expected)
+			}
+		})
+	}
+
+	if result := Resolve("?.Name==$wanted", person, nil); result != false {
+		t.Errorf("Resolve without variables = %v, want false", result)
+	}
+	if result := ResolveWithVars("", person, vars, nil); result.(Person).Name != "Alice" {
+		t.Errorf("ResolveWithVars(\"\") = %v, want the data", result)
+	}
+}
+
+func TestResolve_Let(t *testing.T) {
+	person := createTestPerson()
+	vars := map[string]any{"a": "outer"}
+
+	tests := []struct {
+		name     string
+		path     string
+		expected any
+	}{
+		{"concatenation", "let $a = .Address; $a.Street ', ' $a.City", "123 Main St, NYC"},
+		{"keeps type", "let $n = .Age; $n", 30},
+		{"index", "let $t = .Tags; $t[0]", "developer"},
+		{"index and field", "let $p = [.Address]; $p[0].City ' ' $p[0].Zip", "NYC 10001"},
+		{"index in comparison", "let $t = .Tags; ?$t[1] == 'gopher'", true},
+		{"several bindings", "let $a = .Address; let $c = $a.City; $c ' ' $a.Zip", "NYC 10001"},
+		{"expression", "let $full = .Name ' from ' .Address.City; $full", "Alice from NYC"},
+		{"semicolon in string", "let $s = 'a;b'; $s", "a;b"},
+		{"function", "let $t = .Tags; count($t) ' ' join($t, ',')", "3 developer,gopher,tester"},
+		{"comparison", "let $min = 18; ?.Age >= $min", true},
+		{"shadows variable", "let $a = .Name; $a", "Alice"},
+		{"in argument", "len(let $c = .Address.City; $c)", 3},
+		{"unterminated", "let $a = .Name", nil},
+		{"not a binding", "let", person},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := ResolveWithVars(tt.path, person, vars, nil)
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("ResolveWithVars(%q) = %v, want %v", tt.path, result, tt.expected)
+			}
+		})
+	}
+
+	t.Run("binding does not leak", func(t *testing.T) {
+		result := ResolveWithVars("join([let $a = .Name; $a, $a], '-')", person, vars, nil)
+		if result != "Alice-outer" {
+			t.Errorf("ResolveWithVars() = %v, want %v", result, "Alice-outer")
+		}
+	})
+}
+
+func TestResolveSlice(t *testing.T) {
+	data := map[string]any{
+		"Team":  createTestTeam(),
+		"Mixed": []any{"a", "b"},
+		"Other": []any{"a", 1},
+		"Array": [2]int{1, 2},
+		"Name":  "x",
+	}
+
+	names, ok := ResolveSlice[string](".Team.Users[?.Active == true].Name", data, nil)
+	if !ok || !reflect.DeepEqual(names, []string{"Alice", "Carol"}) {
+		t.Errorf("ResolveSlice of projection = %v, %v, want [Alice Carol]", names, ok)
+	}
+	members, ok := ResolveSlice[Member](".Team.Users[?.Age < 30]", data, nil)
+	if !ok || len(members) != 1 || members[0].Name != "Bob" {
+		t.Errorf("ResolveSlice of filter = %v, %v, want [Bob]", members, ok)
+	}
+	converted, ok := ResolveSlice[string](".Mixed", data, nil)
+	if !ok || !reflect.DeepEqual(converted, []string{"a", "b"}) {
+		t.Errorf("ResolveSlice of []any = %v, %v, want [a b]", converted, ok)
+	}
+	values, ok := ResolveSlice[any](".Other", data, nil)
+	if !ok || len(values) != 2 {
+		t.Errorf("ResolveSlice[any] = %v, %v, want [a 1]", values, ok)
+	}
+	numbers, ok := ResolveSlice[int](".Array", data, nil)
+	if !ok || !reflect.DeepEqual(numbers, []int{1, 2}) {
+		t.Errorf("ResolveSlice of array = %v, %v, want [1 2]", numbers, ok)
+	}
+	empty, ok := ResolveSlice[string](".Team.Users[?.Age > 99].Name", data, nil)
+	if !ok || len(empty) != 0 {
+		t.Errorf("ResolveSlice of empty projection = %v, %v, want []", empty, ok)
+	}
+
+	for _, path := range []string{".Other", ".Name", ".Missing"} {
+		if result, ok := ResolveSlice[string](path, data, nil); ok {
+			t.Errorf("ResolveSlice(%q) = %v, want false", path, result)
+		}
+	}
+}
+
+func TestResolveChain(t *testing.T) {
+	page := map[string]any{"Title": "Home", "Empty": ""}
+	layout := map[string]any{"Title": "Site", "Footer": "(c) Site", "Missing": nil}
+	globals := createTestPerson()
+	contexts := []any{page, nil, layout, globals}
 
 	tests := []struct {
 		name     string
 		path     string
 		expected any
 	}{
-		{"single quotes", "'Hello'", "Hello"},
-		{"double quotes", "\"World\"", "World"},
-		{"escaped single", "'It\\'s'", "It's"},
-		{"escaped double", "\"Say \\\"Hi\\\"\"", "Say \"Hi\""},
+		{"first context", ".Title", "Home"},
+		{"empty string is not nil", ".Empty", ""},
+		{"second context", ".Footer", "(c) Site"},
+		{"nil value falls through", ".Missing", nil},
+		{"last context", ".Address.City", "NYC"},
+		{"root refers to the context", "?$.Footer == '(c) Site'", false},
+		{"function result is not nil", "count(.Tags)", 0},
+		{"unresolved", ".Nothing", nil},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := Resolve(tt.path, person, nil)
+			result := ResolveChain(tt.path, contexts, nil)
 			if result != tt.expected {
-				t.Errorf("Resolve(%q) = %v, want %v", tt.path, result, tt.expected)
+				t.Errorf("ResolveChain(%q) = %v, want %v", tt.path, result, tt.expected)
 			}
 		})
 	}
+
+	if result := ResolveChain(".Title", nil, nil); result != nil {
+		t.Errorf("ResolveChain without contexts = %v, want nil", result)
+	}
+
+	refResolver := func(name string, data any) any {
+		if data == nil {
+			return "from nil context"
+		}
+		return nil
+	}
+	if result := ResolveChain(":site", []any{nil, page}, refResolver); result != "from nil context" {
+		t.Errorf("ResolveChain with a nil context = %v, want the reference resolved against it", result)
+	}
+	if result := ResolveChain(".Title .Footer[", contexts, nil); result == nil {
+		t.Errorf("ResolveChain with a malformed path = nil, want it resolved leniently")
+	}
 }
 
-func TestResolve_Concatenation(t *testing.T) {
+func TestResolveDefault(t *testing.T) {
 	person := createTestPerson()
+	data := map[string]any{"Person": &person, "Nobody": (*Person)(nil), "Empty": "", "Zero": 0}
 
 	tests := []struct {
 		name     string
 		path     string
 		expected any
 	}{
-		{"string and field", "'Hello, ' .Name", "Hello, Alice"},
-		{"field and string", ".Name ' is here'", "Alice is here"},
-		{"multiple parts", "'Name: ' .Name ', Age: ' .Age", "Name: Alice, Age: 30"},
+		{"resolved", ".Person.Name", "Alice"},
+		{"missing field", ".Person.Nickname", "default"},
+		{"nil pointer on the way", ".Nobody.Name", "default"},
+		{"empty string is not nil", ".Empty", ""},
+		{"zero is not nil", ".Zero", 0},
+		{"false is not nil", "?.Zero == 1", false},
+		{"nil literal", "nil", "default"},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := Resolve(tt.path, person, nil)
+			result := ResolveDefault(tt.path, data, "default", nil)
 			if result != tt.expected {
-				t.Errorf("Resolve(%q) = %v, want %v", tt.path, result, tt.expected)
+				t.Errorf("ResolveDefault(%q) = %v, want %v", tt.path, result, tt.expected)
 			}
 		})
 	}
 }
 
-func TestResolve_Negation(t *testing.T) {
-	person := createTestPerson()
+// OrderedMap resolves its keys itself and hides its fields from paths
+type OrderedMap struct {
+	Keys   []string
+	values map[string]any
+}
+
+func (m OrderedMap) ResolvePathSegment(name string) (any, bool) {
+	value, ok := m.values[name]
+	return value, ok
+}
+
+// LazyRecord resolves its fields on demand with a pointer receiver
+type LazyRecord struct {
+	ID    int
+	loads int
+}
+
+func (r *LazyRecord) ResolvePathSegment(name string) (any, bool) {
+	r.loads++
+	switch name {
+	case "ID":
+		return r.ID, true
+	case "Owner":
+		return OrderedMap{values: map[string]any{"Name": "Alice"}}, true
+	case "Deleted":
+		return nil, true
+	}
+	return nil, false
+}
+
+func TestResolve_PathResolvable(t *testing.T) {
+	record := &LazyRecord{ID: 7}
+	data := map[string]any{
+		"Map": OrderedMap{Keys: []string{"b", "a"}, values: map[string]any{
+			"a": 1, "b": []string{"x", "y"}, "a b": "spaced", "0": "zero",
+		}},
+		"Record":  record,
+		"Records": []*LazyRecord{{ID: 1}, {ID: 2}},
+		"Value":   LazyRecord{ID: 3},
+	}
 
 	tests := []struct {
 		name     string
 		path     string
 		expected any
 	}{
-		{"negate true", "!.Active", false},
-		{"negate string true", "!'true'", false},
-		{"negate string false", "!'false'", true},
+		{"field", ".Map.a", 1},
+		{"nested path", ".Map.b[1]", "y"},
+		{"quoted key", ".Map['a b']", "spaced"},
+		{"index as key", ".Map[0]", "zero"},
+		{"missing segment", ".Map.c", nil},
+		{"fields are hidden", ".Map.Keys", nil},
+		{"pointer receiver", ".Record.ID", 7},
+		{"returned resolvable", ".Record.Owner.Name", "Alice"},
+		{"in filter", "join(.Records[?.ID > 1].ID, ',')", "2"},
+		{"not addressable", ".Value.ID", 3},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := Resolve(tt.path, person, nil)
-			if result != tt.expected {
+			result := Resolve(tt.path, data, nil)
+			if !reflect.DeepEqual(result, tt.expected) {
 				t.Errorf("Resolve(%q) = %v, want %v", tt.path, result, tt.expected)
 			}
 		})
 	}
+
+	if record.loads == 0 {
+		t.Errorf("ResolvePathSegment was not called")
+	}
+
+	strict := NewResolver(WithStrict())
+	if _, err := strict.ResolveErr(".Record.Deleted", data, nil); err != nil {
+		t.Errorf("nil segment reported as error: %v", err)
+	}
+	var notFound *FieldNotFoundError
+	if _, err := strict.ResolveErr(".Map.Keyz", data, nil); !errors.As(err, &notFound) || notFound.Suggestions != nil {
+		t.Errorf("missing segment error = %v, want *FieldNotFoundError without suggestions", err)
+	}
+	var nilErr *NilIntermediateError
+	if _, err := strict.ResolveErr(".Record.Deleted.Name", data, nil); !errors.As(err, &nilErr) || nilErr.Segment != ".Record.Deleted" {
+		t.Errorf("path after nil segment error = %v, want *NilIntermediateError", err)
+	}
 }
 
-func TestResolve_Comparison(t *testing.T) {
-	person := createTestPerson()
+// Ring is a ring buffer whose negative indices count from the newest element
+type Ring struct {
+	items []string
+	start int
+}
+
+func (r Ring) ResolveIndex(i int) (any, bool) {
+	if i < -len(r.items) || i >= len(r.items) {
+		return nil, false
+	}
+	if i < 0 {
+		i += len(r.items)
+	}
+	return r.items[(r.start+i)%len(r.items)], true
+}
+
+// Pairs is an ordered map that supports both keys and indices
+type Pairs struct {
+	Len  int
+	keys []string
+	vals []any
+}
+
+func (p *Pairs) ResolveKey(k string) (any, bool) {
+	for i, key := range p.keys {
+		if key == k {
+			return p.vals[i], true
+		}
+	}
+	return nil, false
+}
+
+func (p *Pairs) ResolveIndex(i int) (any, bool) {
+	if i < 0 || i >= len(p.vals) {
+		return nil, false
+	}
+	return p.vals[i], true
+}
+
+func TestResolve_IndexAndKeyResolvable(t *testing.T) {
+	data := map[string]any{
+		"Ring":  Ring{items: []string{"c", "a", "b"}, start: 1},
+		"Pairs": &Pairs{Len: 2, keys: []string{"x", "7"}, vals: []any{Address{City: "NYC"}, nil}},
+	}
 
 	tests := []struct {
 		name     string
 		path     string
 		expected any
 	}{
-		{"int equals string literal true", "?.Age=='30'", true},
-		{"int equals string literal false", "?.Age=='25'", false},
-		{"int not equals string literal true", "?.Age!='25'", true},
-		{"int not equals string literal false", "?.Age!='30'", false},
-		{"string comparison", "?.Name=='Alice'", true},
-		{"bool comparison", "?.Active=='true'", true},
-		{"nested int comparison", "?.Address.Zip=='10001'", true},
-		// Comparisons convert both sides to strings, so int 30 becomes "30"
-		// Note: bare integer literals (e.g., ?.Age==30) are NOT supported - use string literals
+		{"index", ".Ring[0]", "a"},
+		{"wrapped index", ".Ring[2]", "c"},
+		{"negative index", ".Ring[-1]", "c"},
+		{"out of range", ".Ring[3]", nil},
+		{"key on index-only type", ".Ring['0']", nil},
+		{"key", ".Pairs['x'].City", "NYC"},
+		{"unquoted key", ".Pairs[x].City", "NYC"},
+		{"integer is an index", ".Pairs[0].City", "NYC"},
+		{"quoted integer is a key", ".Pairs['7']", nil},
+		{"missing key", ".Pairs['y']", nil},
+		{"dot notation uses reflection", ".Pairs.Len", 2},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := Resolve(tt.path, person, nil)
-			if result != tt.expected {
+			result := Resolve(tt.path, data, nil)
+			if !reflect.DeepEqual(result, tt.expected) {
 				t.Errorf("Resolve(%q) = %v, want %v", tt.path, result, tt.expected)
 			}
 		})
 	}
+
+	strict := NewResolver(WithStrict())
+	if _, err := strict.ResolveErr(".Pairs['7']", data, nil); err != nil {
+		t.Errorf("nil element reported as error: %v", err)
+	}
+	var notFound *FieldNotFoundError
+	if _, err := strict.ResolveErr(".Pairs['y']", data, nil); !errors.As(err, &notFound) || notFound.Field != "y" {
+		t.Errorf("missing key error = %v, want *FieldNotFoundError", err)
+	}
 }
 
-func TestResolve_ComparisonFieldToField(t *testing.T) {
-	data := map[string]any{
-		"value":    30,
-		"expected": 30,
-		"other":    25,
+func TestResolve_ListLiteral(t *testing.T) {
+	person := createTestPerson()
+
+	result, ok := Resolve("['a', .Name, .Age]", person, nil).([]any)
+	if !ok || len(result) != 3 {
+		t.Fatalf("Resolve of list literal = %v, want 3 elements", result)
 	}
+	if result[0] != "a" || result[1] != "Alice" || result[2] != 30 {
+		t.Errorf("Resolve of list literal = %v, want [a Alice 30]", result)
+	}
+}
+
+func TestResolve_BareLiterals(t *testing.T) {
+	type Record struct {
+		Age     int
+		Score   float64
+		Count   uint8
+		Active  bool
+		Deleted *Address
+		Code    string
+		Status  string
+		Version string
+		ID      int64
+		Serial  uint64
+	}
+	record := Record{Age: 30, Score: 1.5, Count: 7, Active: true, Code: "30", Status: "active", Version: "1.20.0",
+		ID: 9007199254740993, Serial: 18446744073709551615}
 
 	tests := []struct {
 		name     string
 		path     string
 		expected any
 	}{
-		{"field equals field true", "?.value==.expected", true},
-		{"field equals field false", "?.value==.other", false},
-		{"field not equals field true", "?.value!=.other", true},
-		{"field not equals field false", "?.value!=.expected", false},
+		{"int equals", "?.Age==30", true},
+		{"int not equals", "?.Age!=30", false},
+		{"int against float literal", "?.Age==30.0", true},
+		{"float equals", "?.Score==1.5", true},
+		{"uint equals", "?.Count==7", true},
+		{"large ints compared exactly", "?.ID==9007199254740992", false},
+		{"large int equals", "?.ID==9007199254740993", true},
+		{"large ints ordered exactly", "?.ID>9007199254740992", true},
+		{"max uint64 literal", "?.Serial==18446744073709551615", true},
+		{"uint against negative int", "?.Serial>-1", true},
+		{"large int against float", "?.ID==9007199254740992.0", true},
+		{"negative literal", "?.Age==-30", false},
+		{"string field against number", "?.Code==30", true},
+		{"bool true", "?.Active==true", true},
+		{"bool false", "?.Active==false", false},
+		{"nil pointer equals nil", "?.Deleted==nil", true},
+		{"negated literal", "!true", false},
+		{"int literal", "42", 42},
+		{"negative int literal", "-7", -7},
+		{"float literal", "2.5", 2.5},
+		{"exponent literal", "1e3", 1000.0},
+		{"bool literal", "true", true},
+		{"nil literal", "nil", nil},
+		{"number in concatenation", "'v' 2", "v2"},
+		{"literal in list", "?.Age in [25, 30]", true},
+		{"bare word", "?.Status==active", true},
+		{"bare word not equal", "?.Status != inactive", true},
+		{"bare word with dots", "?.Version==1.20.0", true},
+		{"bare word after version operator", "?.Version >=v v1.9", true},
+		{"bare word starting with digits", "?.Code==30a", false},
+		{"bare word after word operator", "?.Status contains act", true},
+		{"bare word before logical operator", "?.Status==active&&.Age==30", true},
+		{"function after operator", "?.Code==len(.Status)", false},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := Resolve(tt.path, data, nil)
+			result := Resolve(tt.path, record, nil)
 			if result != tt.expected {
-				t.Errorf("Resolve(%q) = %v, want %v", tt.path, result, tt.expected)
+				t.Errorf("Resolve(%q) = %v (%T), want %v (%T)", tt.path, result, result, tt.expected, tt.expected)
 			}
 		})
 	}
@@ -311,6 +1406,13 @@ func TestResolve_ExternalReference(t *testing.T) {
 			return "Hello"
 		case "suffix":
 			return "!"
+		case "shout":
+			if s, ok := data.(string); ok {
+				return strings.ToUpper(s)
+			}
+			return nil
+		case "echo":
+			return data
 		default:
 			return nil
 		}
@@ -324,6 +1426,14 @@ func TestResolve_ExternalReference(t *testing.T) {
 		{"simple reference", ":greeting", "Hello"},
 		{"reference with field", ":greeting ', ' .Name", "Hello, Alice"},
 		{"multiple references", ":greeting .Name :suffix", "HelloAlice!"},
+		{"argument", ":shout(.Name)", "ALICE"},
+		{"argument expression", ":shout(.Name ' ' .Address.City)", "ALICE NYC"},
+		{"without argument", ":shout", nil},
+		{"path after argument", ":echo(.Address).City", "NYC"},
+		{"pipe", ".Name | :shout", "ALICE"},
+		{"pipe in concatenation", ":greeting ' ' .Name | :shout :suffix", "Hello ALICE!"},
+		{"pipe into function", ".Name | :shout | len", 5},
+		{"argument in filter", "count(.Tags[?:shout(.) == 'GOPHER'])", 1},
 	}
 
 	for _, tt := range tests {
@@ -553,6 +1663,127 @@ func TestResolve_MapWithBoolKey(t *testing.T) {
 	}
 }
 
+// DeviceID is a map key type that is parsed from hexadecimal text.
+type DeviceID [2]byte
+
+func (id *DeviceID) UnmarshalText(text []byte) error {
+	_, err := hex.Decode(id[:], text)
+	if err == nil && len(text) != 2*len(id) {
+		return errors.New("invalid device ID")
+	}
+	return err
+}
+
+// Region is a string map key type whose text form is case-insensitive.
+type Region string
+
+func (r *Region) UnmarshalText(text []byte) error {
+	*r = Region(strings.ToLower(string(text)))
+	return nil
+}
+
+func TestResolve_MapWithTextUnmarshalerKey(t *testing.T) {
+	data := map[string]any{
+		"Devices": map[DeviceID]string{{0xab, 0xcd}: "sensor"},
+		"Hosts":   map[netip.Addr]string{netip.MustParseAddr("10.0.0.1"): "gateway"},
+		"Regions": map[Region]int{"eu": 3},
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		expected any
+	}{
+		{"array key", ".Devices.abcd", "sensor"},
+		{"quoted key", ".Devices['abcd']", "sensor"},
+		{"missing key", ".Devices.abce", nil},
+		{"invalid key", ".Devices.xyz", nil},
+		{"standard library key", ".Hosts['10.0.0.1']", "gateway"},
+		{"invalid standard library key", ".Hosts['10.0.0']", nil},
+		{"string kind uses UnmarshalText", ".Regions.EU", 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := Resolve(tt.path, data, nil); result != tt.expected {
+				t.Errorf("Resolve(%q) = %v, want %v", tt.path, result, tt.expected)
+			}
+		})
+	}
+}
+
+// Zone is a composite map key.
+type Zone struct {
+	Region string
+	AZ     string `json:"az"`
+}
+
+func TestResolve_MapWithJSONKey(t *testing.T) {
+	data := map[string]any{
+		"Lookup": map[Zone]int{{Region: "us", AZ: "a"}: 3, {Region: "eu"}: 5},
+		"Grid":   map[[2]int]string{{1, 2}: "b1"},
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		expected any
+	}{
+		{"struct key", `.Lookup[{"Region":"us","az":"a"}]`, 3},
+		{"spaces", `.Lookup[{"Region": "us", "az": "a"}]`, 3},
+		{"omitted field", `.Lookup[{"Region":"eu"}]`, 5},
+		{"missing key", `.Lookup[{"Region":"us"}]`, nil},
+		{"invalid JSON", `.Lookup[{Region:us}]`, nil},
+		{"array key", ".Grid[[1,2]]", "b1"},
+		{"concatenation", `'count: ' .Lookup[{"Region":"us","az":"a"}]`, "count: 3"},
+		{"comparison", `?.Lookup[{"Region":"eu"}] > 4`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := Resolve(tt.path, data, nil); result != tt.expected {
+				t.Errorf("Resolve(%q) = %v, want %v", tt.path, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestResolve_SyncMap(t *testing.T) {
+	config := &sync.Map{}
+	config.Store("timeout", 30)
+	config.Store("host", "example.com")
+	config.Store(8080, "http")
+	config.Store("address", Address{City: "Berlin"})
+	data := map[string]any{"config": config}
+
+	tests := []struct {
+		name     string
+		path     string
+		expected any
+	}{
+		{"field", ".config.timeout", 30},
+		{"quoted key", ".config['host']", "example.com"},
+		{"int key", ".config[8080]", "http"},
+		{"nested", ".config.address.City", "Berlin"},
+		{"missing key", ".config.missing", nil},
+		{"as data", ".timeout", 30},
+		{"comparison", "?.config.timeout > 10", true},
+		{"wildcard", "count(.config[*])", 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := any(data)
+			if tt.name == "as data" {
+				input = config
+			}
+			if result := Resolve(tt.path, input, nil); result != tt.expected {
+				t.Errorf("Resolve(%q) = %v, want %v", tt.path, result, tt.expected)
+			}
+		})
+	}
+}
+
 func TestResolve_Array(t *testing.T) {
 	data := [3]string{"a", "b", "c"}
 
@@ -603,6 +1834,156 @@ func TestResolve_Interface(t *testing.T) {
 	}
 }
 
+func TestResolve_Wildcard(t *testing.T) {
+	person := createTestPerson()
+	people := []Person{person, {Name: "Bob", Address: Address{City: "LA"}}}
+
+	tests := []struct {
+		name     string
+		path     string
+		data     any
+		expected any
+	}{
+		{"slice elements", ".Tags[*]", person, []string{"developer", "gopher", "tester"}},
+		{"map values in key order", ".Scores[*]", person, []int{95, 88}},
+		{"integer keys in numeric order", ".[*]", map[int]string{10: "ten", 2: "two", 1: "one", -3: "minus three"}, []string{"minus three", "one", "two", "ten"}},
+		{"unsigned keys in numeric order", ".[*]", map[uint8]int{10: 10, 9: 9}, []int{9, 10}},
+		{"float keys in numeric order", ".[*]", map[float64]string{10.5: "b", 2.25: "a"}, []string{"a", "b"}},
+		{"projected field", ".[*].Name", people, []string{"Alice", "Bob"}},
+		{"projected nested field", ".[*].Address.City", people, []string{"NYC", "LA"}},
+		{"missing fields are skipped", ".[*].Tags[1]", people, []string{"gopher"}},
+		{"mixed types", ".[*]", []any{"a", 1, "b"}, []any{"a", 1, "b"}},
+		{"nil values", ".[*]", []any{"a", nil}, []any{"a", nil}},
+		{"interface values of one type", ".[*]", []any{1.5, 2.5}, []float64{1.5, 2.5}},
+		{"empty", ".[*]", []string{}, []any{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Resolve(tt.path, tt.data, nil)
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("Resolve(%q) = %#v, want %#v", tt.path, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestResolve_Filter(t *testing.T) {
+	people := []Person{
+		{Name: "Alice", Age: 30, Active: true},
+		{Name: "Bob", Age: 25, Active: false},
+		{Name: "Carol", Age: 30, Active: true},
+	}
+	data := map[string]any{"People": people, "Wanted": "Bob"}
+
+	tests := []struct {
+		name     string
+		path     string
+		expected any
+	}{
+		{"equals", ".People[?.Active=='true'].Name", []string{"Alice", "Carol"}},
+		{"not equals", ".People[?.Age!='30'].Name", []string{"Bob"}},
+		{"elements", ".People[?.Age<30]", []Person{people[1]}},
+		{"no match", ".People[?.Name=='Nobody'].Name", []any{}},
+		{"quoted bracket in literal", ".People[?.Name==']'].Name", []any{}},
+		{"single operand", ".People[?.Active].Name", []string{"Alice", "Carol"}},
+		{"bare word", ".People[?.Name==Bob].Age", []int{25}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Resolve(tt.path, data, nil)
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("Resolve(%q) = %#v, want %#v", tt.path, result, tt.expected)
+			}
+		})
+	}
+
+	t.Run("filter on non-collection", func(t *testing.T) {
+		result := Resolve(".Wanted[?.Name=='Bob']", data, nil)
+		if result != nil {
+			t.Errorf("Resolve with filter on a string should return nil, got %v", result)
+		}
+	})
+}
+
+// Straße has non-ASCII field and method names
+type Straße struct {
+	Name   string
+	Größe  int
+	Ünits  []string
+	Daten  map[string]string
+	Nested *Straße
+}
+
+func (s Straße) Länge() int {
+	return len(s.Name)
+}
+
+func TestResolve_UnicodeIdentifiers(t *testing.T) {
+	data := Straße{
+		Name:   "Hauptstraße",
+		Größe:  3,
+		Ünits:  []string{"ä", "ö"},
+		Daten:  map[string]string{"名前": "太郎", "キー": "値"},
+		Nested: &Straße{Name: "Nebenstraße"},
+	}
+	resolver := func(name string, data any) any {
+		if name == "配置" {
+			return "設定"
+		}
+		return nil
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		expected any
+	}{
+		{"field", ".Größe", 3},
+		{"slice field", ".Ünits[1]", "ö"},
+		{"nested field", ".Nested.Name", "Nebenstraße"},
+		{"method", ".Länge", 12},
+		{"map key dot notation", ".Daten.名前", "太郎"},
+		{"map key bracket notation", ".Daten[キー]", "値"},
+		{"reference", ":配置", "設定"},
+		{"concatenation", "'<' .Daten.名前 '>'", "<太郎>"},
+		{"comparison", "?.Größe==3", true},
+		{"word operator followed by letter is not an operator", "?.Name containsß", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Resolve(tt.path, data, resolver)
+			if result != tt.expected {
+				t.Errorf("Resolve(%q) = %v, want %v", tt.path, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestReadIdentifier(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"count(", "count"},
+		{"größe(", "größe"},
+		{"名前 x", "名前"},
+		{"a_1.b", "a_1"},
+		{"é", "é"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result, index := readIdentifier(tt.input, 0)
+			if result != tt.expected || index != len(tt.expected) {
+				t.Errorf("readIdentifier(%q) = %q, %d, want %q, %d", tt.input, result, index, tt.expected, len(tt.expected))
+			}
+		})
+	}
+}
+
 // Test the toString helper function
 func TestToString(t *testing.T) {
 	tests := []struct {
@@ -618,6 +1999,9 @@ func TestToString(t *testing.T) {
 		{"int64", int64(123), "123"},
 		{"float64", 3.14, "3.14"},
 		{"struct", struct{ X int }{X: 1}, "{1}"},
+		{"byte slice", []byte("payload"), "payload"},
+		{"named byte slice", json.RawMessage(`{"a":1}`), `{"a":1}`},
+		{"byte array", [2]byte{1, 2}, "[1 2]"},
 	}
 
 	for _, tt := range tests {
@@ -630,6 +2014,44 @@ func TestToString(t *testing.T) {
 	}
 }
 
+// Counter has methods that cannot be resolved in paths.
+type Counter struct {
+	N int
+}
+
+func (c Counter) Value() int        { return c.N }
+func (c Counter) Add(n int) int     { return c.N + n }
+func (c Counter) Reset()            {}
+func (c *Counter) Incremented() int { return c.N + 1 }
+
+func TestResolveMethod_Cached(t *testing.T) {
+	data := map[string]any{"Counter": Counter{N: 1}, "Pointer": &Counter{N: 2}}
+
+	tests := []struct {
+		name     string
+		path     string
+		expected any
+	}{
+		{"method", ".Counter.Value", 1},
+		{"method with arguments", ".Counter.Add", nil},
+		{"method without results", ".Counter.Reset", nil},
+		{"pointer method on value", ".Counter.Incremented", nil},
+		{"value method through pointer", ".Pointer.Value", 2},
+		{"field", ".Counter.N", 1},
+	}
+
+	// Resolve twice, so the second evaluation uses the cached lookups.
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < 2; i++ {
+				if result := Resolve(tt.path, data, nil); result != tt.expected {
+					t.Errorf("Resolve(%q) = %v, want %v", tt.path, result, tt.expected)
+				}
+			}
+		})
+	}
+}
+
 // Benchmark tests
 func BenchmarkResolve_SimpleField(b *testing.B) {
 	person := createTestPerson()
@@ -679,6 +2101,14 @@ func BenchmarkResolve_Comparison(b *testing.B) {
 	}
 }
 
+func BenchmarkResolve_Method(b *testing.B) {
+	person := createTestPerson()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Resolve(".GetFullName", person, nil)
+	}
+}
+
 // Allocation benchmarks
 func BenchmarkResolve_SimpleField_Allocs(b *testing.B) {
 	person := createTestPerson()
@@ -0,0 +1,104 @@
+package empaths
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Program is an expression of another expression language compiled by an Engine.
+// It is called with the data the reference is evaluated against and must be safe
+// for concurrent use if the Resolver is used concurrently.
+type Program func(data any) (any, error)
+
+// Engine compiles the source of an expression of another expression language, such
+// as CEL or expr, into a Program (see WithEngine).
+type Engine func(source string) (Program, error)
+
+// WithEngine makes a Resolver delegate the reference ":name(source)" to engine, as
+// an escape hatch for conditions beyond the grammar of empaths. The argument is
+// evaluated like the argument of any reference, compiled by engine, and the program
+// is run against the same data the reference would be resolved against (the element
+// inside a filter). A model path after the closing parenthesis is resolved against
+// its result:
+//
+//	resolver := empaths.NewResolver(empaths.WithEngine("expr", func(source string) (empaths.Program, error) {
+//		program, err := expr.Compile(source)
+//		if err != nil {
+//			return nil, err
+//		}
+//		return func(data any) (any, error) { return expr.Run(program, data) }, nil
+//	}))
+//	resolver.Resolve(".Orders[?:expr('len(Items) > 3')].ID", customer, nil)
+//
+// Programs are compiled once per source and cached for the lifetime of the Resolver.
+// A reference named like an engine but without an argument is resolved by the
+// ReferenceResolver as usual. In strict mode, compilation and run errors are reported
+// wrapped in the name of the engine; otherwise the reference resolves to nil.
+//
+// The program reads the data on its own, so the access rules, the Authorizer (see
+// WithAuthorizer), WithSecrets, and WithAudit do not see the fields it reads.
+// Registering an engine again replaces it.
+func WithEngine(name string, engine Engine) Option {
+	return func(r *Resolver) {
+		if engine == nil {
+			return
+		}
+		engines := make(map[string]*engineCache, len(r.engines)+1)
+		for existing, cache := range r.engines {
+			engines[existing] = cache
+		}
+		engines[name] = &engineCache{compile: engine}
+		r.engines = engines
+	}
+}
+
+// engineCache holds an Engine and the programs it compiled, keyed by source.
+type engineCache struct {
+	compile  Engine
+	programs sync.Map
+}
+
+// compiledProgram is the result of compiling a source.
+type compiledProgram struct {
+	program Program
+	err     error
+}
+
+// program returns the program compiled from source.
+func (c *engineCache) program(source string) (Program, error) {
+	if cached, ok := c.programs.Load(source); ok {
+		compiled := cached.(compiledProgram)
+		return compiled.program, compiled.err
+	}
+	program, err := c.compile(source)
+	if err == nil && program == nil {
+		err = fmt.Errorf("no program for %q", source)
+	}
+	c.programs.Store(source, compiledProgram{program: program, err: err})
+	return program, err
+}
+
+// engine returns the cache of the engine registered as name, if any.
+func (s *evalState) engine(name string) (*engineCache, bool) {
+	if s.resolver == nil || s.resolver.engines == nil {
+		return nil, false
+	}
+	cache, ok := s.resolver.engines[name]
+	return cache, ok
+}
+
+// runEngine compiles source with the engine in cache, registered as name, and runs
+// the program against data.
+func (s *evalState) runEngine(cache *engineCache, name string, source any, data any) any {
+	program, err := cache.program(toString(source))
+	if err == nil {
+		var value any
+		if value, err = program(data); err == nil {
+			return value
+		}
+	}
+	if s.diagnose() {
+		s.fail(fmt.Errorf("engine %s: %w", name, err))
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package empaths
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// errProgram is returned by the programs of the test engine.
+var errProgram = errors.New("program failed")
+
+// upperEngine is an Engine of a tiny language: "upper FIELD" upper-cases a field of
+// the data, "older N" reports whether its Age is greater than N, and "fail" fails
+// when run. Everything else does not compile.
+func upperEngine(compiled *int) Engine {
+	return func(source string) (Program, error) {
+		*compiled++
+		command, arg, _ := strings.Cut(source, " ")
+		switch command {
+		case "upper":
+			return func(data any) (any, error) {
+				return strings.ToUpper(toString(Resolve("."+arg, data, nil))), nil
+			}, nil
+		case "older":
+			return func(data any) (any, error) {
+				age, _ := toFloat64(Resolve(".Age", data, nil))
+				limit, _ := strconv.ParseFloat(arg, 64)
+				return age > limit, nil
+			}, nil
+		case "fail":
+			return func(data any) (any, error) { return nil, errProgram }, nil
+		}
+		return nil, errors.New("unknown command " + command)
+	}
+}
+
+func TestResolver_WithEngine(t *testing.T) {
+	team := createTestTeam()
+	compiled := 0
+	resolver := NewResolver(WithEngine("toy", upperEngine(&compiled)))
+
+	tests := []struct {
+		name     string
+		path     string
+		expected any
+	}{
+		{"against the data", ".Users[0].Name ' ' :toy('upper Scores')", "Alice MAP[MATH:95 SCIENCE:88]"},
+		{"in a filter", "join(.Users[?:toy('older 28')].Name, ',')", "Alice,Carol"},
+		{"in concatenation", "'Hi ' :toy('upper Users[1].Name') '!'", "Hi BOB!"},
+		{"source from expression", "count(.Users[?:toy('older ' $.Limit)])", 1},
+		{"trailing path", "first(.Users[?:toy('older 30')]).Name", "Carol"},
+		{"compile error", ":toy('nope')", nil},
+		{"run error", ":toy('fail')", nil},
+		{"without argument", ":toy", "ref toy"},
+	}
+
+	team["Limit"] = 30
+	refs := func(name string, data any) any { return "ref " + name }
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := resolver.Resolve(tt.path, team, refs); !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("Resolve(%q) = %#v, want %#v", tt.path, result, tt.expected)
+			}
+		})
+	}
+
+	t.Run("compiled once per source", func(t *testing.T) {
+		compiled = 0
+		for i := 0; i < 3; i++ {
+			resolver.Resolve("count(.Users[?:toy('older 20')]) count(.Users[?:toy('older 21')])", team, nil)
+		}
+		if compiled != 2 {
+			t.Errorf("compiled %d programs, want 2", compiled)
+		}
+	})
+
+	t.Run("strict", func(t *testing.T) {
+		strict := NewResolver(WithStrict(), WithEngine("toy", upperEngine(&compiled)))
+		if _, err := strict.ResolveErr(":toy('fail')", team, nil); !errors.Is(err, errProgram) || !strings.Contains(err.Error(), "engine toy") {
+			t.Errorf("ResolveErr() error = %v, want the run error of engine toy", err)
+		}
+		if _, err := strict.ResolveErr(":toy('nope')", team, nil); err == nil || !strings.Contains(err.Error(), "unknown command nope") {
+			t.Errorf("ResolveErr() error = %v, want the compile error", err)
+		}
+	})
+}
@@ -0,0 +1,125 @@
+package empaths
+
+import (
+	"reflect"
+)
+
+// PathInfo describes a path returned by EnumeratePaths.
+type PathInfo struct {
+	// Path is the model path, e.g. ".User.Address.City" or ".Users[*].Name".
+	Path string
+	// Type is the Go type of the value at Path: the dynamic type for interfaces
+	// holding a value in the sample, the declared type otherwise. For a method it
+	// is the type of the method's first result.
+	Type reflect.Type
+	// Method is true if the last segment of Path calls a method.
+	Method bool
+}
+
+// EnumeratePaths lists the model paths that can be resolved against sample, e.g. to
+// drive autocompletion in an editor for paths. It visits
+//
+//   - the exported fields of structs, including promoted fields,
+//   - the zero-argument methods with at least one result (not descended into),
+//   - the keys of non-empty maps, in sorted order,
+//   - the elements of slices, arrays, and empty maps, as a "[*]" wildcard whose
+//     children are taken from the first element, if any.
+//
+// sample may be a value or a reflect.Type. Interfaces and nil pointers are followed
+// only as far as sample holds values for them or their type is known. Paths are
+// listed depth-first, fields before methods.
+//
+// Parameters:
+//   - sample: A value or reflect.Type of the data model
+//   - maxDepth: The maximum number of segments of the listed paths, which also ends
+//     recursive types; nothing is listed if it is less than 1
+//
+// Returns:
+//   - The paths, or nil if there are none
+func EnumeratePaths(sample any, maxDepth int) []PathInfo {
+	var value reflect.Value
+	typ, ok := sample.(reflect.Type)
+	if !ok {
+		value = reflect.ValueOf(sample)
+		if !value.IsValid() {
+			return nil
+		}
+		typ = value.Type()
+	}
+	var paths []PathInfo
+	enumeratePaths(&paths, "", typ, value, maxDepth)
+	return paths
+}
+
+// enumeratePaths appends the paths below path to paths. value is the value at path
+// in the sample, or invalid if only the type is known.
+func enumeratePaths(paths *[]PathInfo, path string, typ reflect.Type, value reflect.Value, depth int) {
+	if depth < 1 {
+		return
+	}
+	for typ.Kind() == reflect.Ptr || typ.Kind() == reflect.Interface {
+		if value.IsValid() && !value.IsNil() {
+			value = value.Elem()
+			typ = value.Type()
+			continue
+		}
+		if typ.Kind() == reflect.Interface {
+			return
+		}
+		value = reflect.Value{}
+		typ = typ.Elem()
+	}
+
+	add := func(childPath string, childType reflect.Type, child reflect.Value) {
+		if child.IsValid() && child.Kind() == reflect.Interface && !child.IsNil() {
+			child = child.Elem()
+			childType = child.Type()
+		}
+		*paths = append(*paths, PathInfo{Path: childPath, Type: childType})
+		enumeratePaths(paths, childPath, childType, child, depth-1)
+	}
+
+	switch typ.Kind() {
+	case reflect.Struct:
+		for _, field := range reflect.VisibleFields(typ) {
+			if !field.IsExported() {
+				continue
+			}
+			var child reflect.Value
+			if value.IsValid() {
+				child, _ = value.FieldByIndexErr(field.Index)
+			}
+			add(path+"."+field.Name, field.Type, child)
+		}
+	case reflect.Map:
+		if value.IsValid() && value.Len() > 0 {
+			for _, key := range sortedMapKeys(value) {
+				add(path+keySegment(toString(extractValue(key))), typ.Elem(), value.MapIndex(key))
+			}
+			break
+		}
+		add(path+"[*]", typ.Elem(), reflect.Value{})
+	case reflect.Array, reflect.Slice:
+		var child reflect.Value
+		if value.IsValid() && value.Len() > 0 {
+			child = value.Index(0)
+		}
+		add(path+"[*]", typ.Elem(), child)
+	}
+
+	for i := 0; i < typ.NumMethod(); i++ {
+		method := typ.Method(i)
+		if method.Type.NumIn() == 1 && method.Type.NumOut() > 0 {
+			*paths = append(*paths, PathInfo{Path: path + "." + method.Name, Type: method.Type.Out(0), Method: true})
+		}
+	}
+}
+
+// keySegment returns the path segment for a map key: in dot notation if the key is
+// a plain name, in bracket notation otherwise.
+func keySegment(key string) string {
+	if isPlainName(key) {
+		return "." + key
+	}
+	return formatKey(key)
+}
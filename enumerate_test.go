@@ -0,0 +1,106 @@
+package empaths
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TreeNode is a recursive type for testing
+type TreeNode struct {
+	Label    string
+	Children []*TreeNode
+	Meta     any
+}
+
+func TestEnumeratePaths(t *testing.T) {
+	stringType := reflect.TypeOf("")
+	intType := reflect.TypeOf(0)
+	boolType := reflect.TypeOf(true)
+
+	paths := EnumeratePaths(createTestPerson(), 2)
+	expected := []PathInfo{
+		{".Name", stringType, false},
+		{".Age", intType, false},
+		{".Active", boolType, false},
+		{".Address", reflect.TypeOf(Address{}), false},
+		{".Address.Street", stringType, false},
+		{".Address.City", stringType, false},
+		{".Address.Zip", intType, false},
+		{".Tags", reflect.TypeOf([]string{}), false},
+		{".Tags[*]", stringType, false},
+		{".Scores", reflect.TypeOf(map[string]int{}), false},
+		{".Scores.math", intType, false},
+		{".Scores.science", intType, false},
+		{".GetFullName", stringType, true},
+		{".IsAdult", boolType, true},
+	}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Errorf("EnumeratePaths =\n%v\nwant\n%v", paths, expected)
+	}
+
+	for _, info := range paths {
+		if Resolve(info.Path, createTestPerson(), nil) == nil {
+			t.Errorf("path %q does not resolve", info.Path)
+		}
+	}
+}
+
+func TestEnumeratePaths_Type(t *testing.T) {
+	paths := EnumeratePaths(reflect.TypeOf(&TreeNode{}), 3)
+	var got []string
+	for _, info := range paths {
+		got = append(got, info.Path)
+	}
+	expected := []string{
+		".Label",
+		".Children", ".Children[*]",
+		".Children[*].Label", ".Children[*].Children", ".Children[*].Meta",
+		".Meta",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("EnumeratePaths = %v, want %v", got, expected)
+	}
+}
+
+func TestEnumeratePaths_Values(t *testing.T) {
+	data := map[string]any{
+		"Tree": &TreeNode{Label: "root", Meta: map[string]int{"a b": 1}},
+		"Nil":  (*TreeNode)(nil),
+		"List": []any{Address{City: "NYC"}},
+		"Keys": map[int]string{},
+	}
+
+	paths := EnumeratePaths(data, 3)
+	got := map[string]reflect.Type{}
+	for _, info := range paths {
+		got[info.Path] = info.Type
+	}
+
+	tests := []struct {
+		path     string
+		expected reflect.Type
+	}{
+		{".Tree", reflect.TypeOf(&TreeNode{})},
+		{".Tree.Meta", reflect.TypeOf(map[string]int{})},
+		{".Tree.Meta['a b']", reflect.TypeOf(0)},
+		{".Tree.Children[*]", reflect.TypeOf(&TreeNode{})},
+		{".Nil.Label", reflect.TypeOf("")},
+		{".List[*].City", reflect.TypeOf("")},
+		{".Keys[*]", reflect.TypeOf("")},
+	}
+	for _, tt := range tests {
+		if got[tt.path] != tt.expected {
+			t.Errorf("type of %q = %v, want %v", tt.path, got[tt.path], tt.expected)
+		}
+	}
+	if _, ok := got[".Tree.Children[*].Label"]; ok {
+		t.Errorf("path deeper than maxDepth listed")
+	}
+
+	if paths := EnumeratePaths(nil, 3); paths != nil {
+		t.Errorf("EnumeratePaths(nil) = %v, want nil", paths)
+	}
+	if paths := EnumeratePaths(data, 0); paths != nil {
+		t.Errorf("EnumeratePaths with depth 0 = %v, want nil", paths)
+	}
+}
@@ -0,0 +1,183 @@
+package empaths
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ErrSyntax is matched by every *SyntaxError, so malformed paths can be detected
+// with errors.Is(err, ErrSyntax).
+var ErrSyntax = errors.New("syntax error")
+
+// Is reports whether target is ErrSyntax.
+func (e *SyntaxError) Is(target error) bool {
+	return target == ErrSyntax
+}
+
+// FieldNotFoundError is reported in strict mode when a value has no field, method,
+// or map key of the given name.
+type FieldNotFoundError struct {
+	// Field is the name of the field, method, or map key.
+	Field string
+	// Type is the type of the value the name was looked up on.
+	Type reflect.Type
+	// Suggestions are existing names close to Field, closest first, for misspelled
+	// names.
+	Suggestions []string
+}
+
+// Error implements the error interface.
+func (e *FieldNotFoundError) Error() string {
+	msg := fmt.Sprintf("field %q not found in %v", e.Field, e.Type)
+	if len(e.Suggestions) == 0 {
+		return msg
+	}
+	quoted := make([]string, len(e.Suggestions))
+	for i, name := range e.Suggestions {
+		quoted[i] = strconv.Quote(name)
+	}
+	return msg + "; did you mean " + strings.Join(quoted, " or ") + "?"
+}
+
+// fieldNotFound returns the error for a name that could not be resolved against
+// value, with suggestions from the names value does have.
+func fieldNotFound(name string, value reflect.Value) *FieldNotFoundError {
+	err := &FieldNotFoundError{Field: name, Type: valueType(value)}
+	if !value.IsValid() {
+		return err
+	}
+	if _, ok := asPathResolvable(value); !ok {
+		err.Suggestions = suggestNames(name, memberNames(value))
+	}
+	return err
+}
+
+// IndexOutOfRangeError is reported in strict mode when an index is outside of the
+// bounds of an array or slice.
+type IndexOutOfRangeError struct {
+	// Index is the index in the path.
+	Index int
+	// Len is the length of the array or slice.
+	Len int
+}
+
+// Error implements the error interface.
+func (e *IndexOutOfRangeError) Error() string {
+	return fmt.Sprintf("index [%d] out of range with length %d", e.Index, e.Len)
+}
+
+// NilIntermediateError is reported in strict mode when a model path continues
+// after a value that is nil.
+type NilIntermediateError struct {
+	// Segment is the model path up to and including the segment that was nil
+	// (e.g. ".User.Address"), or "." if the data itself was nil.
+	Segment string
+}
+
+// Error implements the error interface.
+func (e *NilIntermediateError) Error() string {
+	return fmt.Sprintf("%s is nil", e.Segment)
+}
+
+// UnresolvedReferenceError is reported in strict mode when an external reference
+// resolves to nil or no ReferenceResolver is given.
+type UnresolvedReferenceError struct {
+	// Name is the name of the reference, without the ':'.
+	Name string
+}
+
+// Error implements the error interface.
+func (e *UnresolvedReferenceError) Error() string {
+	return fmt.Sprintf("unresolved reference :%s", e.Name)
+}
+
+// WithStrict makes a Resolver report parts of a path that cannot be resolved as
+// errors instead of resolving them to nil: a malformed path (*SyntaxError), a
+// missing field, method, or map key (*FieldNotFoundError), an index out of range
+// (*IndexOutOfRangeError), a path continuing after nil (*NilIntermediateError), and
+// an external reference that resolves to nil (*UnresolvedReferenceError). Use
+// errors.As to inspect the error returned by ResolveErr.
+//
+// A path that resolves to nil without any of these failures, such as a field that
+// holds a nil pointer, is not an error.
+func WithStrict() Option {
+	return func(r *Resolver) {
+		r.strict = true
+	}
+}
+
+// diagnose reports whether failures to resolve a part of a path are reported: as
+// errors in strict mode, or in the trace of ResolveTrace.
+func (s *evalState) diagnose() bool {
+	return s.quiet == 0 && (s.tracing || (s.resolver != nil && s.resolver.strict))
+}
+
+// fail reports a failure to resolve a part of a path. When tracing, err is added to
+// the trace unless an identical failure was already recorded; otherwise it aborts
+// the evaluation with err, unless it has already been aborted. Failures in conditions
+// whose result does not matter are ignored.
+func (s *evalState) fail(err error) {
+	if s.quiet > 0 {
+		return
+	}
+	if s.tracing {
+		for _, recorded := range s.trace {
+			if recorded.Error() == err.Error() {
+				return
+			}
+		}
+		s.trace = append(s.trace, err)
+		return
+	}
+	if s.err == nil {
+		s.err = err
+	}
+}
+
+// failNil reports a model path that continues after a nil value. rest is the part
+// of the model path being resolved that follows the nil value.
+func (s *evalState) failNil(rest string) {
+	consumed := s.modelPath
+	if len(rest) <= len(consumed) {
+		consumed = consumed[:len(consumed)-len(rest)]
+	}
+	for len(consumed) > 0 && consumed[len(consumed)-1] == '.' {
+		consumed = consumed[:len(consumed)-1]
+	}
+	s.fail(&NilIntermediateError{Segment: "." + consumed})
+}
+
+// indexOrKeyError returns the error for an index or key that could not be resolved
+// against value.
+func indexOrKeyError(indexOrKey string, value reflect.Value) error {
+	key := unquoteKey(indexOrKey)
+	if value.Kind() == reflect.Array || value.Kind() == reflect.Slice {
+		if index, err := strconv.Atoi(key); err == nil {
+			return &IndexOutOfRangeError{Index: index, Len: value.Len()}
+		}
+	}
+	return fieldNotFound(key, value)
+}
+
+// valueType returns the type of value, or nil if value is invalid.
+func valueType(value reflect.Value) reflect.Type {
+	if !value.IsValid() {
+		return nil
+	}
+	return value.Type()
+}
+
+// isNilIntermediate reports whether value is a nil pointer or interface, possibly
+// behind other pointers and interfaces.
+func isNilIntermediate(value reflect.Value) bool {
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return true
+		}
+		value = value.Elem()
+	}
+	return false
+}
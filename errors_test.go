@@ -0,0 +1,139 @@
+package empaths
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// StrictUser has a pointer field that may be nil
+type StrictUser struct {
+	Name    string
+	Address *Address
+	Tags    []string
+	Extra   any
+}
+
+func TestResolver_Strict(t *testing.T) {
+	data := map[string]any{
+		"User":    StrictUser{Name: "Alice", Tags: []string{"a", "b"}},
+		"Users":   []*StrictUser{{Name: "Bob", Address: &Address{City: "LA"}}, nil},
+		"Scores":  map[string]int{"math": 95},
+		"Nothing": nil,
+	}
+	refResolver := func(name string, data any) any {
+		if name == "known" {
+			return "value"
+		}
+		return nil
+	}
+	resolver := NewResolver(WithStrict())
+
+	tests := []struct {
+		name     string
+		path     string
+		expected error
+	}{
+		{"unknown field", ".User.Nmae", &FieldNotFoundError{Field: "Nmae", Type: reflect.TypeOf(StrictUser{}), Suggestions: []string{"Name"}}},
+		{"unknown map key", ".Scores.art", &FieldNotFoundError{Field: "art", Type: reflect.TypeOf(map[string]int{})}},
+		{"unknown quoted map key", ".Scores['a b']", &FieldNotFoundError{Field: "a b", Type: reflect.TypeOf(map[string]int{})}},
+		{"unknown top-level key", ".Usr", &FieldNotFoundError{Field: "Usr", Type: reflect.TypeOf(data), Suggestions: []string{"User"}}},
+		{"field on string", ".User.Name.First", &FieldNotFoundError{Field: "First", Type: reflect.TypeOf("")}},
+		{"index out of range", ".User.Tags[2]", &IndexOutOfRangeError{Index: 2, Len: 2}},
+		{"negative index", ".User.Tags[-1]", &IndexOutOfRangeError{Index: -1, Len: 2}},
+		{"non-numeric index", ".User.Tags[x]", &FieldNotFoundError{Field: "x", Type: reflect.TypeOf([]string{})}},
+		{"nil pointer", ".User.Address.City", &NilIntermediateError{Segment: ".User.Address"}},
+		{"nil element", ".Users[1].Name", &NilIntermediateError{Segment: ".Users[1]"}},
+		{"nil interface", ".User.Extra.Foo", &NilIntermediateError{Segment: ".User.Extra"}},
+		{"nil map value", ".Nothing.Foo", &NilIntermediateError{Segment: ".Nothing"}},
+		{"nil in wildcard", "join(.Users[*].Address.City, ',')", &NilIntermediateError{Segment: ".Users[*]"}},
+		{"memoized nil", ".User.Name .User.Address.City.Name", &NilIntermediateError{Segment: ".User.Address"}},
+		{"unresolved reference", "'x' :unknown", &UnresolvedReferenceError{Name: "unknown"}},
+		{"in filter", ".Users[?.Nmae=='Bob']", &FieldNotFoundError{Field: "Nmae", Type: reflect.TypeOf(StrictUser{}), Suggestions: []string{"Name"}}},
+		{"in function", "count(.User.Tagz)", &FieldNotFoundError{Field: "Tagz", Type: reflect.TypeOf(StrictUser{}), Suggestions: []string{"Tags"}}},
+		{"in condition", "?.User.Name == 'Alice' && .User.Address.City == 'LA'", &NilIntermediateError{Segment: ".User.Address"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := resolver.ResolveErr(tt.path, data, refResolver)
+			if result != nil {
+				t.Errorf("ResolveErr(%q) = %v, want nil", tt.path, result)
+			}
+			if !reflect.DeepEqual(err, tt.expected) {
+				t.Errorf("ResolveErr(%q) error = %#v (%v), want %#v", tt.path, err, err, tt.expected)
+			}
+		})
+	}
+}
+
+func TestResolver_StrictResolves(t *testing.T) {
+	data := map[string]any{
+		"User":  StrictUser{Name: "Alice", Tags: []string{"a", "b"}},
+		"Users": []*StrictUser{{Name: "Bob", Address: &Address{City: "LA"}}},
+	}
+	resolver := NewResolver(WithStrict())
+
+	tests := []struct {
+		name     string
+		path     string
+		expected any
+	}{
+		{"field", ".User.Name", "Alice"},
+		{"nil pointer as result", ".User.Address", nil},
+		{"index", ".User.Tags[1]", "b"},
+		{"filter", "count(.Users[?.Name=='Bob'])", 1},
+		{"method", ".Users[0].Address.City", "LA"},
+		{"condition after false and", "?.User.Address != nil && .User.Address.City == 'LA'", false},
+		{"condition after true or", "?.User.Address == nil || .User.Address.City == 'LA'", true},
+		{"empty path", "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := resolver.ResolveErr(tt.path, data, nil)
+			if err != nil {
+				t.Fatalf("ResolveErr(%q) error = %v", tt.path, err)
+			}
+			if tt.path != "" && result != tt.expected {
+				t.Errorf("ResolveErr(%q) = %v, want %v", tt.path, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestErrors(t *testing.T) {
+	resolver := NewResolver(WithStrict())
+	if _, err := resolver.ResolveErr(".Users[0", nil, nil); !errors.Is(err, ErrSyntax) {
+		t.Errorf("ResolveErr of malformed path error = %v, want ErrSyntax", err)
+	}
+	if _, err := Tokens("'open"); !errors.Is(err, ErrSyntax) {
+		t.Errorf("Tokens error = %v, want ErrSyntax", err)
+	}
+
+	_, err := resolver.ResolveErr(".User.Nmae", map[string]any{"User": StrictUser{}}, nil)
+	var notFound *FieldNotFoundError
+	if !errors.As(err, &notFound) || notFound.Field != "Nmae" {
+		t.Fatalf("errors.As(%v, *FieldNotFoundError) failed", err)
+	}
+
+	messages := []struct {
+		err      error
+		expected string
+	}{
+		{notFound, `field "Nmae" not found in empaths.StrictUser; did you mean "Name"?`},
+		{&FieldNotFoundError{Field: "x", Type: reflect.TypeOf(0)}, `field "x" not found in int`},
+		{&IndexOutOfRangeError{Index: 5, Len: 3}, "index [5] out of range with length 3"},
+		{&NilIntermediateError{Segment: ".User.Address"}, ".User.Address is nil"},
+		{&UnresolvedReferenceError{Name: "config"}, "unresolved reference :config"},
+	}
+	for _, m := range messages {
+		if m.err.Error() != m.expected {
+			t.Errorf("Error() = %q, want %q", m.err.Error(), m.expected)
+		}
+	}
+
+	if result, err := NewResolver().ResolveErr(".User.Nmae", nil, nil); result != nil || err != nil {
+		t.Errorf("lenient ResolveErr = %v, %v, want nil, nil", result, err)
+	}
+}
@@ -0,0 +1,224 @@
+package empaths
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Explorer supports interactive exploration of a data structure with path
+// expressions. Besides resolving expressions it completes the field, method, and
+// map key names of the model path being typed, which makes it the basis for REPLs
+// and editor integrations.
+//
+//	explorer := empaths.NewExplorer(config, nil)
+//	explorer.Complete(".Spec.Re")          // [".Spec.Replicas", ".Spec.Resources"]
+//	explorer.Complete("?.Users[?.Na")      // ["?.Users[?.Name"]
+//	explorer.Resolve(".Spec.Replicas")     // 3
+type Explorer struct {
+	data        any
+	refResolver ReferenceResolver
+}
+
+// NewExplorer creates an Explorer for data. refResolver is used for external
+// references, as in Resolve, and may be nil.
+func NewExplorer(data any, refResolver ReferenceResolver) *Explorer {
+	return &Explorer{data: data, refResolver: refResolver}
+}
+
+// Resolve resolves a path expression against the explored data.
+func (e *Explorer) Resolve(path string) any {
+	return Resolve(path, e.data, e.refResolver)
+}
+
+// Complete returns the completions for the model path at the end of input. Each
+// completion is the whole input with the last path segment completed to a field
+// name, zero-argument method name, or map key that exists at that point of the
+// data. Inside an unclosed filter ("[?...") names are completed against the first
+// element of the filtered collection.
+//
+// Parameters:
+//   - input: The expression typed so far (e.g. "'Hello ' .Us")
+//
+// Returns:
+//   - The completions in sorted order, or nil if the input does not end in a model path
+func (e *Explorer) Complete(input string) []string {
+	return completeExpression(input, reflect.ValueOf(e.data), &evalState{refResolver: e.refResolver, root: e.data})
+}
+
+// completeExpression completes the model path at the end of an expression that is
+// evaluated against value.
+func completeExpression(input string, value reflect.Value, state *evalState) []string {
+	start, ok := trailingModelPath(input)
+	if !ok {
+		return nil
+	}
+	path := input[start+1:]
+
+	// Inside an unclosed filter, complete the filter expression against an element.
+	if open := unclosedBracket(path); open != -1 {
+		if open+1 >= len(path) || path[open+1] != '?' {
+			return nil
+		}
+		collection := resolvePathAgainstValue(path[:open], value, state)
+		for collection.Kind() == reflect.Ptr || collection.Kind() == reflect.Interface {
+			collection = collection.Elem()
+		}
+		elements := collectionElements(collection)
+		if len(elements) == 0 {
+			return nil
+		}
+		head := input[:start+1+open+2]
+		completions := completeExpression(path[open+2:], elements[0], state)
+		for i, completion := range completions {
+			completions[i] = head + completion
+		}
+		return completions
+	}
+
+	lastDot := -1
+	depth := 0
+	for i := 0; i < len(path); i++ {
+		switch path[i] {
+		case '[':
+			depth++
+		case ']':
+			depth--
+		case '\'', '"':
+			i = skipQuotedASCII(path, i) - 1
+		case '.':
+			if depth == 0 {
+				lastDot = i
+			}
+		}
+	}
+	parent, partial := "", path
+	if lastDot != -1 {
+		parent, partial = path[:lastDot], path[lastDot+1:]
+	}
+	if strings.ContainsAny(partial, "[]") {
+		return nil
+	}
+
+	resolved := resolvePathAgainstValue(parent, value, state)
+	if strings.Contains(parent, "[*]") || strings.Contains(parent, "[?") {
+		// A projection yields a list; complete against its first element.
+		if projected, ok := extractValue(resolved).([]any); ok && len(projected) > 0 {
+			resolved = reflect.ValueOf(projected[0])
+		}
+	}
+
+	head := input[:len(input)-len(partial)]
+	var completions []string
+	for _, name := range memberNames(resolved) {
+		if strings.HasPrefix(name, partial) {
+			completions = append(completions, head+name)
+		}
+	}
+	return completions
+}
+
+// trailingModelPath returns the position of the '.' that starts the model path at
+// the end of input. Quoted strings, references, identifiers, and numbers are skipped
+// the same way the interpreter skips them.
+func trailingModelPath(input string) (int, bool) {
+	for index := 0; index < len(input); {
+		switch c := input[index]; {
+		case c == '\'' || c == '"':
+			index = skipQuotedASCII(input, index)
+		case c == ':':
+			_, index = readReferenceName(input, index+1)
+		case c == '.':
+			_, end := readModelPathASCII(input, index+1)
+			if end == len(input) {
+				return index, true
+			}
+			index = end
+		case isNumberStart(input, index):
+			index++
+			for index < len(input) && (isDigit(input[index]) || input[index] == '.') {
+				index++
+			}
+		case isIdentStart(input, index):
+			_, index = readIdentifier(input, index)
+		default:
+			index++
+		}
+	}
+	return 0, false
+}
+
+// unclosedBracket returns the position of the outermost '[' in a model path that
+// has no closing bracket, or -1 if all brackets are closed.
+func unclosedBracket(path string) int {
+	var open []int
+	for i := 0; i < len(path); i++ {
+		switch path[i] {
+		case '[':
+			open = append(open, i)
+		case ']':
+			if len(open) > 0 {
+				open = open[:len(open)-1]
+			}
+		case '\'', '"':
+			if len(open) > 0 {
+				i = skipQuotedASCII(path, i) - 1
+			}
+		}
+	}
+	if len(open) == 0 {
+		return -1
+	}
+	return open[0]
+}
+
+// memberNames returns the names that can follow a '.' on value: exported fields
+// and zero-argument methods of structs, the string keys of maps that can be
+// written in dot notation, and the aliases declared by a PathAliaser.
+func memberNames(value reflect.Value) []string {
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+	if !value.IsValid() {
+		return nil
+	}
+
+	var names []string
+	typ := value.Type()
+	for i := 0; i < typ.NumMethod(); i++ {
+		method := typ.Method(i)
+		if method.Type.NumIn() == 1 && method.Type.NumOut() > 0 {
+			names = append(names, method.Name)
+		}
+	}
+	switch value.Kind() {
+	case reflect.Struct:
+		for _, field := range reflect.VisibleFields(typ) {
+			if field.IsExported() {
+				names = append(names, field.Name)
+			}
+		}
+	case reflect.Map:
+		if typ.Key().Kind() == reflect.String {
+			for _, key := range value.MapKeys() {
+				if name := key.String(); isPlainName(name) {
+					names = append(names, name)
+				}
+			}
+		}
+	}
+
+	names = append(names, aliasNames(value)...)
+
+	sort.Strings(names)
+	unique := names[:0]
+	for i, name := range names {
+		if i == 0 || name != names[i-1] {
+			unique = append(unique, name)
+		}
+	}
+	return unique
+}
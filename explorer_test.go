@@ -0,0 +1,83 @@
+package empaths
+
+import (
+	"reflect"
+	"testing"
+)
+
+// ExplorerUser is the element type used by the Explorer tests
+type ExplorerUser struct {
+	Name    string
+	Nick    string
+	Address *ExplorerAddress
+	secret  string
+}
+
+// ExplorerAddress is nested in ExplorerUser
+type ExplorerAddress struct {
+	City    string
+	Country string
+}
+
+func (u ExplorerUser) NameUpper() string     { return u.Name }
+func (u ExplorerUser) Greet(s string) string { return s }
+
+func createExplorerData() map[string]any {
+	return map[string]any{
+		"Users": []ExplorerUser{
+			{Name: "Alice", Address: &ExplorerAddress{City: "NYC"}},
+			{Name: "Bob"},
+		},
+		"Settings":  map[string]any{"theme": "dark", "tabSize": 4, "has space": true},
+		"Subject":   "demo",
+		"ScoreList": []int{1, 2},
+	}
+}
+
+func TestExplorer_Complete(t *testing.T) {
+	explorer := NewExplorer(createExplorerData(), nil)
+
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{"root map keys", ".Se", []string{".Settings"}},
+		{"all root keys", ".", []string{".ScoreList", ".Settings", ".Subject", ".Users"}},
+		{"map keys", ".Settings.t", []string{".Settings.tabSize", ".Settings.theme"}},
+		{"struct fields and methods", ".Users[0].N", []string{".Users[0].Name", ".Users[0].NameUpper", ".Users[0].Nick"}},
+		{"pointer field", ".Users[0].Address.C", []string{".Users[0].Address.City", ".Users[0].Address.Country"}},
+		{"after wildcard", ".Users[*].Address.Ci", []string{".Users[*].Address.City"}},
+		{"inside filter", ".Users[?.Ni", []string{".Users[?.Nick"}},
+		{"inside filter after literal", "?.Users[?.Name == 'x' .Ad", []string{"?.Users[?.Name == 'x' .Address"}},
+		{"after literal", "'Hello ' .Users[1].Na", []string{"'Hello ' .Users[1].Name", "'Hello ' .Users[1].NameUpper"}},
+		{"in function call", "count(.Us", []string{"count(.Users"}},
+		{"after operator", "?.Subject == .Sub", []string{"?.Subject == .Subject"}},
+		{"no match", ".Users[0].Zzz", nil},
+		{"inside string literal", "'.Us", nil},
+		{"after terminated path", ".Users ", nil},
+		{"nil pointer", ".Users[1].Address.C", nil},
+		{"index being typed", ".Users[0", nil},
+		{"number", "1.", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := explorer.Complete(tt.input)
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("Complete(%q) = %q, want %q", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestExplorer_Resolve(t *testing.T) {
+	explorer := NewExplorer(createExplorerData(), func(name string, data any) any { return "ref:" + name })
+
+	if result := explorer.Resolve(".Users[0].Address.City"); result != "NYC" {
+		t.Errorf("Resolve = %v, want NYC", result)
+	}
+	if result := explorer.Resolve(":x"); result != "ref:x" {
+		t.Errorf("Resolve = %v, want ref:x", result)
+	}
+}
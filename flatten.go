@@ -0,0 +1,117 @@
+package empaths
+
+import (
+	"reflect"
+	"strconv"
+)
+
+// Flatten returns the leaf values of data keyed by their model paths, e.g. for
+// diffing, storing data in flat key-value stores, or binding forms:
+//
+//	empaths.Flatten(user) // {".Name": "Alice", ".Tags[0]": "x", ".Address.City": "NYC"}
+//
+// Values are visited like Walk visits them. Leaves are the values without values
+// inside them, including structs without exported fields (such as time.Time), empty
+// maps, slices, and arrays, and nil pointers. If data is itself a leaf, it is stored
+// under ".".
+//
+// Parameters:
+//   - data: The data to flatten
+//
+// Returns:
+//   - A map from the path of every leaf value to the value
+func Flatten(data any) map[string]any {
+	flat := make(map[string]any)
+	walkValue("", reflect.ValueOf(data), make(map[uintptr]bool), func(path string, value reflect.Value, leaf bool) bool {
+		if leaf {
+			flat[path] = extractValue(value)
+		}
+		return true
+	})
+	return flat
+}
+
+// Unflatten rebuilds nested data from a map of model paths to values, as returned by
+// Flatten. Values that are collections of other values become a []any if their
+// keys are exactly the indices 0 to n-1 written in brackets (".Tags[0]",
+// ".Tags[1]"), and a map[string]any otherwise:
+//
+//	empaths.Unflatten(map[string]any{".User.Name": "Alice", ".Tags[0]": "x"})
+//	// map[string]any{"User": map[string]any{"Name": "Alice"}, "Tags": []any{"x"}}
+//
+// Keys that are not plain model paths (see Set) are ignored. A value at a path that
+// other paths continue below (".User" next to ".User.Name") is replaced by the
+// collection built from those paths.
+//
+// Parameters:
+//   - flat: A map from model paths to values
+//
+// Returns:
+//   - The nested data, or the value of "." if no other path is given
+func Unflatten(flat map[string]any) any {
+	root := &unflattenNode{}
+	for path, value := range flat {
+		segments, err := parseSegments(path)
+		if err != nil {
+			continue
+		}
+		node := root
+		for _, segment := range segments {
+			node = node.child(segment)
+		}
+		node.value = value
+	}
+	return root.build()
+}
+
+// unflattenNode is a value being rebuilt by Unflatten.
+type unflattenNode struct {
+	value    any
+	children map[string]*unflattenNode
+	// keyed is true if a child is not written as an index in brackets.
+	keyed bool
+}
+
+// child returns the child of the node for a path segment, creating it if needed.
+func (n *unflattenNode) child(segment pathSegment) *unflattenNode {
+	if n.children == nil {
+		n.children = make(map[string]*unflattenNode)
+	}
+	if !segment.bracket {
+		n.keyed = true
+	}
+	child, ok := n.children[segment.name]
+	if !ok {
+		child = &unflattenNode{}
+		n.children[segment.name] = child
+	}
+	return child
+}
+
+// build returns the value of the node: a []any or map[string]any built from its
+// children, or its own value if it has none.
+func (n *unflattenNode) build() any {
+	if len(n.children) == 0 {
+		return n.value
+	}
+	if !n.keyed {
+		list := make([]any, len(n.children))
+		isList := true
+		for i := range list {
+			child, ok := n.children[strconv.Itoa(i)]
+			if !ok {
+				isList = false
+				break
+			}
+			list[i] = child.build()
+		}
+		if isList {
+			return list
+		}
+	}
+	result := make(map[string]any, len(n.children))
+	for name, child := range n.children {
+		result[name] = child.build()
+	}
+	return result
+}
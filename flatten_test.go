@@ -0,0 +1,125 @@
+package empaths
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// Timestamped embeds Address and has a field without exported fields
+type Timestamped struct {
+	Address
+	Created time.Time
+	Next    *Timestamped
+	hidden  string
+}
+
+func TestFlatten(t *testing.T) {
+	person := createTestPerson()
+	flat := Flatten(person)
+	expected := map[string]any{
+		".Name":           "Alice",
+		".Age":            30,
+		".Active":         true,
+		".Address.Street": "123 Main St",
+		".Address.City":   "NYC",
+		".Address.Zip":    10001,
+		".Tags[0]":        "developer",
+		".Tags[1]":        "gopher",
+		".Tags[2]":        "tester",
+		".Scores.math":    95,
+		".Scores.science": 88,
+	}
+	if !reflect.DeepEqual(flat, expected) {
+		t.Errorf("Flatten =\n%v\nwant\n%v", flat, expected)
+	}
+	for path, value := range flat {
+		if result := Resolve(path, person, nil); result != value {
+			t.Errorf("Resolve(%q) = %v, want %v", path, result, value)
+		}
+	}
+}
+
+func TestFlatten_Leaves(t *testing.T) {
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	node := &Timestamped{Address: Address{City: "NYC"}, Created: created, hidden: "x"}
+	node.Next = node
+
+	tests := []struct {
+		name     string
+		data     any
+		expected map[string]any
+	}{
+		{"scalar", 42, map[string]any{".": 42}},
+		{"nil", nil, map[string]any{".": nil}},
+		{"embedded, struct without exported fields, and cycle", node, map[string]any{
+			".Street": "", ".City": "NYC", ".Zip": 0, ".Created": created,
+		}},
+		{"empty collections and nil pointer", map[string]any{
+			"List": []string{}, "Map": map[string]int{}, "Ptr": (*Address)(nil),
+		}, map[string]any{
+			".List": []string{}, ".Map": map[string]int{}, ".Ptr": nil,
+		}},
+		{"keys that are not names", map[any]any{"a b": 1, 2: "two"}, map[string]any{
+			".['a b']": 1, ".[2]": "two",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if flat := Flatten(tt.data); !reflect.DeepEqual(flat, tt.expected) {
+				t.Errorf("Flatten = %v, want %v", flat, tt.expected)
+			}
+		})
+	}
+}
+
+func TestUnflatten(t *testing.T) {
+	tests := []struct {
+		name     string
+		flat     map[string]any
+		expected any
+	}{
+		{"nested", map[string]any{".User.Name": "Alice", ".User.Tags[0]": "x", ".User.Tags[1]": "y"}, map[string]any{
+			"User": map[string]any{"Name": "Alice", "Tags": []any{"x", "y"}},
+		}},
+		{"root list", map[string]any{".[1]": "b", ".[0]": "a"}, []any{"a", "b"}},
+		{"sparse indices", map[string]any{".Tags[0]": "a", ".Tags[2]": "c"}, map[string]any{
+			"Tags": map[string]any{"0": "a", "2": "c"},
+		}},
+		{"dot and bracket keys", map[string]any{".Data['a.b']": 1, ".Data.c": 2, ".Data[0]": 3}, map[string]any{
+			"Data": map[string]any{"a.b": 1, "c": 2, "0": 3},
+		}},
+		{"root value", map[string]any{".": 42}, 42},
+		{"children replace value", map[string]any{".User": "x", ".User.Name": "Alice"}, map[string]any{
+			"User": map[string]any{"Name": "Alice"},
+		}},
+		{"invalid keys ignored", map[string]any{"Name": 1, ".Users[*].Name": 2, ".Ok": 3}, map[string]any{"Ok": 3}},
+		{"empty", map[string]any{}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := Unflatten(tt.flat); !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("Unflatten = %#v, want %#v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFlatten_RoundTrip(t *testing.T) {
+	data := map[string]any{
+		"Users": []any{
+			map[string]any{"Name": "Alice", "Tags": []any{"a", "b"}},
+			map[string]any{"Name": "Bob", "Tags": []any{}},
+		},
+		"Meta": map[string]any{"a b": 1.5, "ok": true},
+	}
+	if result := Unflatten(Flatten(data)); !reflect.DeepEqual(result, data) {
+		t.Errorf("Unflatten(Flatten(data)) = %#v, want %#v", result, data)
+	}
+	list := []any{"a", map[string]any{"b": 1}}
+	if result := Unflatten(Flatten(list)); !reflect.DeepEqual(result, list) {
+		t.Errorf("Unflatten(Flatten(list)) = %#v, want %#v", result, list)
+	}
+}
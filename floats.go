@@ -0,0 +1,63 @@
+package empaths
+
+import (
+	"math"
+	"strconv"
+)
+
+// WithFloatEpsilon makes a Resolver treat two numbers in a comparison as equal if
+// they differ by at most epsilon, so results of float arithmetic such as
+// 0.1+0.2 compare equal to 0.3. This applies to '==', '!=', and the ordering
+// operators: with an epsilon of 1e-9, "?.Ratio <= 0.3" is also true for a ratio of
+// 0.3000000001. If one operand is a number, the other may also be a string holding a
+// number, as in "?.Ratio=='0.1'". An epsilon of 0 (the default) compares exactly.
+func WithFloatEpsilon(epsilon float64) Option {
+	return func(r *Resolver) {
+		r.floatEpsilon = math.Abs(epsilon)
+	}
+}
+
+// floatEpsilon returns the epsilon configured with WithFloatEpsilon.
+func (s *evalState) floatEpsilon() float64 {
+	if s.resolver == nil {
+		return 0
+	}
+	return s.resolver.floatEpsilon
+}
+
+// asApproximateNumbers prepares the operands of a comparison for comparing numbers
+// with an epsilon. If one operand is a number and the other is a number or a string
+// holding one, both are converted to float64, and if they differ by at most epsilon
+// both are set to the same value. Otherwise, or if epsilon is 0, the operands are
+// returned unchanged.
+func asApproximateNumbers(left any, right any, epsilon float64) (any, any) {
+	if epsilon == 0 {
+		return left, right
+	}
+	leftNum, leftOk := toFloat64(left)
+	rightNum, rightOk := toFloat64(right)
+	switch {
+	case leftOk && rightOk:
+	case leftOk:
+		rightNum, rightOk = parseFloat(right)
+	case rightOk:
+		leftNum, leftOk = parseFloat(left)
+	}
+	if !leftOk || !rightOk {
+		return left, right
+	}
+	if math.Abs(leftNum-rightNum) <= epsilon {
+		return leftNum, leftNum
+	}
+	return leftNum, rightNum
+}
+
+// parseFloat converts a string holding a number to float64.
+func parseFloat(v any) (float64, bool) {
+	s, ok := v.(string)
+	if !ok {
+		return 0, false
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	return f, err == nil
+}
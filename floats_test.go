@@ -0,0 +1,49 @@
+package empaths
+
+import (
+	"testing"
+)
+
+func TestResolver_WithFloatEpsilon(t *testing.T) {
+	// Variables, so that the arithmetic is done in float64 and not exactly on constants.
+	a, b := 0.1, 0.2
+	data := map[string]any{
+		"Sum":   a + b,
+		"Ratio": (a + b) / 3,
+		"Count": 3,
+		"Name":  "0.3",
+	}
+	resolver := NewResolver(WithFloatEpsilon(1e-9))
+
+	tests := []struct {
+		name     string
+		path     string
+		expected any
+		exact    any
+	}{
+		{"equals", "?.Sum==0.3", true, false},
+		{"equals string", "?.Ratio=='0.1'", true, false},
+		{"string on the left", "?'0.1'==.Ratio", true, false},
+		{"not equals", "?.Sum!=0.3", false, true},
+		{"less", "?.Sum<0.3", false, false},
+		{"less or equal", "?.Sum<=0.3", true, false},
+		{"greater", "?.Sum>0.3", false, true},
+		{"outside epsilon", "?.Sum==0.31", false, false},
+		{"integers", "?.Count==3", true, true},
+		{"ordering with string", "?.Count>'10'", false, true},
+		{"both strings", "?.Name=='0.30000000001'", false, false},
+		{"not a number", "?.Sum=='x'", false, false},
+		{"nil", "?.Missing==0", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := resolver.Resolve(tt.path, data, nil); result != tt.expected {
+				t.Errorf("Resolve(%q) = %v, want %v", tt.path, result, tt.expected)
+			}
+			if result := Resolve(tt.path, data, nil); result != tt.exact {
+				t.Errorf("Resolve(%q) without epsilon = %v, want %v", tt.path, result, tt.exact)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package empaths
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Format returns the canonical form of a path expression, so that paths which are
+// written differently but mean the same compare equal. The canonical form
+//
+//   - separates operands, operators, pipes, and list and function arguments by
+//     exactly one space (".Name ' ' .Age", "?.Age == 30 && .Active",
+//     "join(.Tags, ', ')", ".Tags | len"), with no space after '?', '!', '(' and '['
+//     or before ')', ']', and the ':' of a case of switch ("'open': 'green'"),
+//   - writes all string literals in single quotes with the escapes of QuoteLiteral,
+//   - writes map keys that are plain names in dot notation (".Data.key" instead of
+//     ".Data['key']" or ".Data[\"key\"]"), integer indices unquoted ("[0]"), and all
+//     other keys quoted in brackets (".Data['a.b']").
+//
+// Formatting is idempotent: Format(Format(p)) == Format(p).
+//
+// Parameters:
+//   - path: The path expression
+//
+// Returns:
+//   - The canonical path expression
+//   - A *SyntaxError if the path is malformed (see Tokens)
+func Format(path string) (string, error) {
+	tokens, err := Tokens(path)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	sb.Grow(len(path))
+	for i, token := range tokens {
+		text := formatToken(token)
+		if i > 0 {
+			prev := tokens[i-1]
+			if needsSpace(prev, token) {
+				sb.WriteByte(' ')
+			} else if prev.Text == "." && prev.Kind == TokenField && text[0] == '.' {
+				// The root path followed by a key in dot notation (".['key']" -> ".key").
+				text = text[1:]
+			}
+		}
+		sb.WriteString(text)
+	}
+	return sb.String(), nil
+}
+
+// formatToken returns the canonical text of a token.
+func formatToken(token Token) string {
+	switch token.Kind {
+	case TokenString:
+		return QuoteLiteral(token.Value.(string))
+	case TokenField:
+		name := token.Text[1:]
+		if base, _, ok := splitResultSelector(name); name == "" || isPlainName(name) || ok && isPlainName(base) {
+			return token.Text
+		}
+		return formatKey(name)
+	case TokenIndex:
+		key := unquoteKey(token.Text[1 : len(token.Text)-1])
+		if isPlainName(key) {
+			return "." + key
+		}
+		return formatKey(key)
+	default:
+		return token.Text
+	}
+}
+
+// formatKey returns a key in bracket notation: unquoted if it is an integer index,
+// quoted otherwise.
+func formatKey(key string) string {
+	if n, err := strconv.Atoi(key); err == nil && n >= 0 && strconv.Itoa(n) == key {
+		return "[" + key + "]"
+	}
+	return "[" + QuoteLiteral(key) + "]"
+}
+
+// needsSpace reports whether the canonical form has a space between two tokens.
+func needsSpace(prev Token, next Token) bool {
+	switch prev.Kind {
+	case TokenComparison, TokenNegation, TokenLeftParen, TokenListStart, TokenFilterStart:
+		return false
+	}
+	switch next.Kind {
+	case TokenComma, TokenRightParen, TokenListEnd, TokenFilterEnd, TokenSemicolon, TokenCase:
+		return false
+	case TokenLeftParen:
+		// The '(' of a function call or reference argument follows its name, a group
+		// is separated.
+		return prev.Kind != TokenFunction && prev.Kind != TokenReference
+	}
+	// Segments of the same model path are adjacent in the source, as is a model path
+	// resolved against the result of a function call.
+	adjacent := next.Pos == prev.Pos+len(prev.Text)
+	switch prev.Kind {
+	case TokenFunction, TokenRightParen:
+		return !(isPathToken(next) && adjacent)
+	}
+	return !(isPathToken(prev) && isPathToken(next) && adjacent)
+}
+
+// isPathToken reports whether a token is part of a model path.
+func isPathToken(token Token) bool {
+	switch token.Kind {
+	case TokenField, TokenIndex, TokenWildcard, TokenFilterStart, TokenFilterEnd, TokenRoot, TokenParent, TokenVariable:
+		return true
+	default:
+		return false
+	}
+}
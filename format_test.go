@@ -0,0 +1,113 @@
+package empaths
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		expected string
+	}{
+		{"already canonical", ".User.Name", ".User.Name"},
+		{"root", ".", "."},
+		{"whitespace between operands", "  .Name    ' '   .Age ", ".Name ' ' .Age"},
+		{"comparison spacing", "?.Age==30", "?.Age == 30"},
+		{"comparison with spaces", "? .Age   !=  30", "?.Age != 30"},
+		{"word operator", "?.Tags contains   'go'", "?.Tags contains 'go'"},
+		{"negation", "! .Active", "!.Active"},
+		{"double quotes", `"Hello" .Name`, "'Hello' .Name"},
+		{"escapes", `"it's\n"`, `'it\'s\n'`},
+		{"quoted plain key", ".Data['key']", ".Data.key"},
+		{"double quoted plain key", `.Data["key"]`, ".Data.key"},
+		{"unquoted plain key", ".Data[key]", ".Data.key"},
+		{"quoted key with dot", `.Data["a.b"]`, ".Data['a.b']"},
+		{"dot key that is not a name", ".Data.a-b", ".Data['a-b']"},
+		{"result selector", ".Find#1 .Data['a#1'] .Data.x#y", ".Find#1 .Data['a#1'] .Data['x#y']"},
+		{"quoted index", ".Items['0']", ".Items[0]"},
+		{"index", ".Items[ 0 ]", ".Items[' 0 ']"},
+		{"root key", ".['key'].x", ".key.x"},
+		{"root index", ".[0]", ".[0]"},
+		{"function", "join( .Tags ,', ' )", "join(.Tags, ', ')"},
+		{"nested function", "count( .Users[ * ] )", "count(.Users[' * '])"},
+		{"pipe", ".Tags|len", ".Tags | len"},
+		{"pipe with arguments", ".Tags  |  join( ', ' )", ".Tags | join(', ')"},
+		{"trailing path", "groupby(.Users, '.Active')['true'] [0]", "groupby(.Users, '.Active').true [0]"},
+		{"trailing path after pipe", ".Users | first.Name", ".Users | first.Name"},
+		{"word after pipe", ".Users | first .Name", ".Users | first .Name"},
+		{"reference argument", ":fmt( .At ).Year|:shout", ":fmt(.At).Year | :shout"},
+		{"binding", "let   $u=.Customer ;$u.Name ' '$u.Email", "let $u = .Customer; $u.Name ' ' $u.Email"},
+		{"list literal", "?.Role in [ 'a','b' ]", "?.Role in ['a', 'b']"},
+		{"filter", ".Users[? .Age==30 ].Name", ".Users[?.Age == 30].Name"},
+		{"wildcard", ".Users[*].Name", ".Users[*].Name"},
+		{"filter key inside", ".Users[?.Data[\"k\"]=='v']", ".Users[?.Data.k == 'v']"},
+		{"reference", ":config  .Name", ":config .Name"},
+		{"ordering operator", "?.Age>=30", "?.Age >= 30"},
+		{"affix operator", "?.Name^='Al'", "?.Name ^= 'Al'"},
+		{"glob operator", "?.Host~g'*.internal'", "?.Host ~g '*.internal'"},
+		{"case-insensitive operator", "?.Status==i'active'", "?.Status ==i 'active'"},
+		{"version operator", "?.Version>=v'1.20.0'", "?.Version >=v '1.20.0'"},
+		{"logical operators", "?.A==1&&.B||.C", "?.A == 1 && .B || .C"},
+		{"groups", "! ( .A||(.B&&len(.C)) )", "!(.A || (.B && len(.C)))"},
+		{"negated comparison", "! ? .A==1", "!?.A == 1"},
+		{"bare word after operator", "?.Version>=v  1.20.0", "?.Version >=v 1.20.0"},
+		{"pipe in comparison", "?.Items|len>0||len(.Tags)>'0'", "?.Items | len > 0 || len(.Tags) > '0'"},
+		{"bare word starting with operator suffix", "?.Version>=v1.20.0", "?.Version >= v1.20.0"},
+		{"root and parent", ".Items[?.Price<$.Budget]  ^ .X", ".Items[?.Price < $.Budget] ^ .X"},
+		{"root data key", "$.['key']", "$.key"},
+		{"variables", "?.Name==$wanted  $user.['a b']", "?.Name == $wanted $user.['a b']"},
+		{"switch", "switch(.S,'a':'x' , default :\"y\")", "switch(.S, 'a': 'x', default: 'y')"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Format(tt.path)
+			if err != nil {
+				t.Fatalf("Format(%q) returned error: %v", tt.path, err)
+			}
+			if result != tt.expected {
+				t.Errorf("Format(%q) = %q, want %q", tt.path, result, tt.expected)
+			}
+			again, err := Format(result)
+			if err != nil || again != result {
+				t.Errorf("Format is not idempotent for %q: %q, %v", result, again, err)
+			}
+		})
+	}
+}
+
+func TestFormat_PreservesMeaning(t *testing.T) {
+	data := map[string]any{
+		"Users": []Member{{Name: "Alice", Active: true, Age: 30}, {Name: "Bob", Age: 25}},
+		"Data":  map[string]any{"key": "v", "a.b": "dotted", "it's": 1},
+	}
+	paths := []string{
+		`"Hi " .Users[0].Name`,
+		`.Data["a.b"] .Data['key'] .Data["it's"]`,
+		"count( .Users[? .Active==true] )",
+		"join(.Users[*].Name,'-')",
+		"?.Users[0].Age  in [ 25,30 ]",
+		"count(.Users[?.Age>$.Users[1].Age])",
+		"groupby(.Users,'.Active')['true'] | len",
+		".Users|first.Name",
+		"switch(.Users[0].Name,'Alice':'A',default:'?')",
+	}
+	for _, path := range paths {
+		formatted, err := Format(path)
+		if err != nil {
+			t.Fatalf("Format(%q) returned error: %v", path, err)
+		}
+		if before, after := Resolve(path, data, nil), Resolve(formatted, data, nil); before != after {
+			t.Errorf("Resolve(%q) = %v, but Resolve(%q) = %v", path, before, formatted, after)
+		}
+	}
+}
+
+func TestFormat_Error(t *testing.T) {
+	var syntaxErr *SyntaxError
+	if _, err := Format(".Users[0"); !errors.As(err, &syntaxErr) {
+		t.Errorf("Format error = %v, want *SyntaxError", err)
+	}
+}
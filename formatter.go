@@ -0,0 +1,153 @@
+package empaths
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode/utf8"
+)
+
+// Formatter converts a value to a string for output, e.g. to format numbers, dates,
+// and plurals for a locale. hint is the hint passed to the fmt function, or "" when a
+// value is converted as part of a concatenation. A Formatter returns false to leave
+// the value to the default conversion.
+type Formatter func(v any, hint string) (string, bool)
+
+// WithFormatter makes a Resolver convert values to strings with f: the operands of a
+// concatenation, such as ".Price" in "'Total: ' .Price", and the results of the fmt
+// function, as in "fmt(.CreatedAt, 'date')" or ".CreatedAt | fmt('date')". Values
+// f does not format, and values that are not converted to strings (such as the
+// result of a path that is a single operand), are not affected.
+//
+//	printer := message.NewPrinter(language.German)
+//	resolver := empaths.NewResolver(empaths.WithFormatter(func(v any, hint string) (string, bool) {
+//		if f, ok := v.(float64); ok {
+//			return printer.Sprintf("%.2f", f), true
+//		}
+//		return "", false
+//	}))
+//	resolver.Resolve("'Summe: ' .Total", order, nil) // "Summe: 1.234,50"
+func WithFormatter(f Formatter) Option {
+	return func(r *Resolver) {
+		r.formatter = f
+	}
+}
+
+// format converts v to a string with the Formatter of the evaluation, falling back
+// to the bytes encoding of the Resolver, JSON for composite values (see formatJSON),
+// and toString.
+func (s *evalState) format(v any, hint string) string {
+	if s.resolver != nil && s.resolver.formatter != nil {
+		if str, ok := s.resolver.formatter(v, hint); ok {
+			return str
+		}
+	}
+	if s.resolver != nil {
+		if str, ok := encodeBytes(v, s.resolver.bytesEncoding); ok {
+			return str
+		}
+	}
+	if str, ok := formatJSON(v); ok {
+		return str
+	}
+	return toString(v)
+}
+
+// concatenate converts the operands of a concatenation to strings (see format) and
+// joins them, honoring the output limit of the Resolver (see WithMaxOutputBytes).
+//
+// Parameters:
+//   - first: The first operand
+//   - rest: The other operands
+//
+// Returns:
+//   - The concatenated string, or nil if the evaluation was aborted because the
+//     limit was exceeded
+func (s *evalState) concatenate(first any, rest []any) any {
+	limit := 0
+	if s.resolver != nil {
+		limit = s.resolver.maxOutputBytes
+	}
+	var sb strings.Builder
+	for _, v := range append([]any{first}, rest...) {
+		str := s.format(v, "")
+		if limit > 0 && sb.Len()+len(str) > limit {
+			if !s.resolver.truncateOutput {
+				s.err = fmt.Errorf("%w: concatenation longer than %d bytes", ErrLimitExceeded, limit)
+				return nil
+			}
+			sb.WriteString(truncateUTF8(str, limit-sb.Len()))
+			break
+		}
+		sb.WriteString(str)
+	}
+	return sb.String()
+}
+
+// truncateUTF8 returns the longest prefix of str that is at most n bytes long and
+// does not end inside a UTF-8 encoded character. n must be less than len(str).
+func truncateUTF8(str string, n int) string {
+	for n > 0 && !utf8.RuneStart(str[n]) {
+		n--
+	}
+	return str[:n]
+}
+
+// formatJSON converts a map, slice, array, or struct to compact JSON, the way
+// composite values are rendered in concatenations. It returns false for other
+// values, for byte slices (see toString), for values that implement fmt.Stringer or
+// error (such as time.Time), for structs without exported fields, and for values
+// that cannot be encoded as JSON.
+func formatJSON(v any) (string, bool) {
+	switch v.(type) {
+	case nil, fmt.Stringer, error:
+		return "", false
+	}
+	value := reflect.ValueOf(v)
+	switch value.Kind() {
+	case reflect.Slice:
+		if value.Type().Elem().Kind() == reflect.Uint8 {
+			return "", false
+		}
+	case reflect.Struct:
+		if !hasExportedField(value.Type()) {
+			// JSON would render it as "{}".
+			return "", false
+		}
+	case reflect.Map, reflect.Array:
+	default:
+		return "", false
+	}
+	var sb strings.Builder
+	encoder := json.NewEncoder(&sb)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		return "", false
+	}
+	return strings.TrimSuffix(sb.String(), "\n"), true
+}
+
+// funcFmt implements fmt(value, hint). It converts the value to a string with the
+// Formatter of the Resolver (see WithFormatter), passing the hint (e.g. 'date'),
+// or like a concatenation if there is no Formatter or it does not format the value.
+func funcFmt(args []any, state *evalState) any {
+	if len(args) == 0 {
+		return ""
+	}
+	hint := ""
+	if len(args) > 1 {
+		hint = toString(args[1])
+	}
+	return state.format(args[0], hint)
+}
+
+// hasExportedField reports whether the struct type typ has an exported field.
+func hasExportedField(typ reflect.Type) bool {
+	for i := 0; i < typ.NumField(); i++ {
+		if typ.Field(i).IsExported() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,103 @@
+package empaths
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResolver_WithFormatter(t *testing.T) {
+	data := map[string]any{
+		"Total":   1234.5,
+		"Count":   3,
+		"Name":    "Alice",
+		"Created": time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+	}
+	r := NewResolver(WithFormatter(func(v any, hint string) (string, bool) {
+		switch v := v.(type) {
+		case float64:
+			return strings.Replace(fmt.Sprintf("%.2f", v), ".", ",", 1), true
+		case time.Time:
+			if hint == "date" {
+				return v.Format("02.01.2006"), true
+			}
+			return v.Format("02.01.2006 15:04"), true
+		case int:
+			if hint == "items" {
+				return fmt.Sprintf("%d Artikel", v), true
+			}
+		}
+		return "", false
+	}))
+
+	tests := []struct {
+		name     string
+		path     string
+		expected any
+	}{
+		{"concatenation", "'Summe: ' .Total", "Summe: 1234,50"},
+		{"single operand", ".Total", 1234.5},
+		{"not formatted", "'Hallo ' .Name ' ' .Count", "Hallo Alice 3"},
+		{"fmt with hint", "fmt(.Created, 'date')", "01.03.2024"},
+		{"fmt without hint", "fmt(.Created)", "01.03.2024 00:00"},
+		{"fmt piped", ".Count | fmt('items')", "3 Artikel"},
+		{"fmt not formatted", "fmt(.Name, 'date')", "Alice"},
+		{"fmt in concatenation", "'Am ' fmt(.Created, 'date')", "Am 01.03.2024"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := r.Resolve(tt.path, data, nil)
+			if result != tt.expected {
+				t.Errorf("Resolve(%q) = %#v, want %#v", tt.path, result, tt.expected)
+			}
+		})
+	}
+
+	t.Run("without formatter", func(t *testing.T) {
+		if result := Resolve("fmt(.Total, 'money') ' ' .Total", data, nil); result != "1234.5 1234.5" {
+			t.Errorf("Resolve = %#v, want %#v", result, "1234.5 1234.5")
+		}
+	})
+}
+
+func TestResolve_ConcatenationJSON(t *testing.T) {
+	created := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	data := map[string]any{
+		"Tags":    []string{"go", "<b>"},
+		"Scores":  map[string]int{"math": 95, "art": 70},
+		"Address": &Address{City: "Berlin", Zip: 10115},
+		"Pair":    [2]int{1, 2},
+		"Created": created,
+		"Err":     errors.New("failed"),
+		"Payload": []byte("text"),
+		"Chan":    map[string]any{"c": make(chan int)},
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		expected any
+	}{
+		{"slice", "'tags: ' .Tags", `tags: ["go","<b>"]`},
+		{"map", "'scores: ' .Scores", `scores: {"art":70,"math":95}`},
+		{"struct", "'address: ' .Address", `address: {"Street":"","City":"Berlin","Zip":10115}`},
+		{"array", "'pair: ' .Pair", "pair: [1,2]"},
+		{"stringer", "'created: ' .Created", "created: " + created.String()},
+		{"unexported fields", "'error: ' .Err", "error: {failed}"},
+		{"bytes", "'payload: ' .Payload", "payload: text"},
+		{"not encodable", "'chan: ' .Chan", "chan: " + fmt.Sprintf("%v", data["Chan"])},
+		{"fmt", "fmt(.Tags)", `["go","<b>"]`},
+		{"single operand", ".Pair", [2]int{1, 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := Resolve(tt.path, data, nil); result != tt.expected {
+				t.Errorf("Resolve(%q) = %#v, want %#v", tt.path, result, tt.expected)
+			}
+		})
+	}
+}
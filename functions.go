@@ -0,0 +1,934 @@
+package empaths
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"hash"
+	"html"
+	"math"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// builtinFunc implements a function that can be called from a path expression,
+// e.g. "count(.Users)". It receives the already resolved argument values.
+type builtinFunc func(args []any) any
+
+// builtinFuncs holds the functions available in path expressions, keyed by name.
+var builtinFuncs = map[string]builtinFunc{
+	"count":      funcCount,
+	"len":        funcLen,
+	"join":       funcJoin,
+	"split":      funcSplit,
+	"first":      funcFirst,
+	"last":       funcLast,
+	"reverse":    funcReverse,
+	"unique":     funcUnique,
+	"substr":     funcSubstr,
+	"replace":    funcReplace,
+	"padleft":    funcPadLeft,
+	"padright":   funcPadRight,
+	"mask":       funcMask,
+	"round":      funcRound,
+	"number":     funcNumber,
+	"urlquery":   funcURLQuery,
+	"htmlescape": funcHTMLEscape,
+	"b64":        funcBase64,
+	"b64dec":     funcBase64Decode,
+	"hex":        funcHex,
+	"sha256":     hashFunc(sha256.New),
+	"sha1":       hashFunc(sha1.New),
+	"md5":        hashFunc(md5.New),
+	"typeof":     funcTypeOf,
+	"kind":       funcKind,
+	"isnil":      funcIsNil,
+	"isempty":    funcIsEmpty,
+}
+
+// stateFuncs holds the functions that depend on the options of the evaluation, keyed
+// by name.
+var stateFuncs = map[string]func(args []any, state *evalState) any{
+	"fmt": funcFmt,
+}
+
+// specialForms holds the functions whose arguments are not all evaluated before the
+// call, keyed by name. They receive the arguments as written in the path, after the
+// values of piped operands (see resolvePipe) in args. They are registered in init.
+var specialForms = map[string]func(rawArgs []string, args []any, data any, state *evalState) any{}
+
+func init() {
+	specialForms["each"] = funcEach
+	specialForms["switch"] = funcSwitch
+	specialForms["sort"] = keyedForm("sort", funcSort)
+	specialForms["groupby"] = keyedForm("groupby", funcGroupBy)
+}
+
+// resolveFunctionCall evaluates a function call such as "count(.Users)".
+// Each comma-separated argument is evaluated as a full expression against data.
+// Unknown functions resolve to nil. A model path directly following the call, as in
+// "groupby(.Orders, '.Status')['open']", is resolved against its result.
+//
+// Parameters:
+//   - path: The path expression as a string
+//   - data: The data model to evaluate against
+//   - name: The name of the function
+//   - index: The index of the opening parenthesis
+//   - state: The state of the current evaluation
+//
+// Returns:
+//   - The result of the function call
+//   - The new index after processing
+func resolveFunctionCall(path string, data any, name string, index int, state *evalState) (any, int) {
+	return callFunction(path, data, name, nil, index, state)
+}
+
+// resolvePipe evaluates a pipe such as "| len" or "| join(', ')", which calls a
+// function with the preceding operand as its first argument, followed by the
+// arguments in parentheses, if any. A pipe into an external reference
+// ("| :formatDate") passes the operand to the ReferenceResolver as its data. A pipe
+// without a function name yields the input unchanged.
+//
+// Parameters:
+//   - path: The path expression as a string
+//   - data: The data model to evaluate against
+//   - input: The value of the operand preceding the pipe
+//   - index: The index of the '|' character
+//   - state: The state of the current evaluation
+//
+// Returns:
+//   - The result of the function call
+//   - The new index after processing
+func resolvePipe(path string, data any, input any, index int, state *evalState) (any, int) {
+	index++
+	for index < len(path) && path[index] == ' ' {
+		index++
+	}
+	if index < len(path) && path[index] == ':' {
+		name, newIndex := readReferenceName(path, index+1)
+		return callReference(name, input, state), newIndex
+	}
+	if index == len(path) || !isIdentStart(path, index) {
+		return input, index
+	}
+	name, index := readIdentifier(path, index)
+	return callFunction(path, data, name, []any{input}, index, state)
+}
+
+// callFunction calls the function name with args, followed by the arguments in
+// parentheses if index points to a '(', and resolves a model path directly following
+// the call against the result.
+func callFunction(path string, data any, name string, args []any, index int, state *evalState) (any, int) {
+	if form, ok := specialForms[name]; ok {
+		var rawArgs []string
+		if index < len(path) && path[index] == '(' {
+			closeIndex := findClosingASCII(path, index)
+			if closeIndex == -1 {
+				return nil, len(path)
+			}
+			rawArgs = splitArgumentsASCII(path[index+1 : closeIndex])
+			index = closeIndex + 1
+		}
+		return resolveTrailingPath(path, name, form(rawArgs, args, data, state), index, state)
+	}
+	if index < len(path) && path[index] == '(' {
+		closeIndex := findClosingASCII(path, index)
+		if closeIndex == -1 {
+			// Unterminated call, consume the rest of the path
+			return nil, len(path)
+		}
+		for _, rawArg := range splitArgumentsASCII(path[index+1 : closeIndex]) {
+			arg, _ := resolveExpressions(rawArg, data, state, 0)
+			args = append(args, arg)
+		}
+		index = closeIndex + 1
+	}
+
+	var result any
+	if fn, ok := builtinFuncs[name]; ok {
+		result = fn(args)
+	} else if fn, ok := stateFuncs[name]; ok {
+		result = fn(args, state)
+	}
+	return resolveTrailingPath(path, name, result, index, state)
+}
+
+// resolveTrailingPath resolves a model path that directly follows a function call
+// (".Name", "[0]", or "['open'].Total") against the result of the call. If there is
+// none, the result is returned unchanged. name is the name of the function.
+func resolveTrailingPath(path string, name string, result any, index int, state *evalState) (any, int) {
+	if index >= len(path) || (path[index] != '.' && path[index] != '[') {
+		return result, index
+	}
+	start := index
+	if path[index] == '.' {
+		start++
+	}
+	modelPath, index := readModelPathASCII(path, start)
+	if state.audit != nil {
+		defer state.audit.visit(name+"()", modelPath)()
+	}
+	if result == nil {
+		return nil, index
+	}
+	value := reflect.ValueOf(result)
+	outerOwner, outerPath := state.owner, state.modelPath
+	state.owner, state.modelPath = value, modelPath
+	resolved := resolvePathAgainstValue(modelPath, value, state)
+	state.owner, state.modelPath = outerOwner, outerPath
+	return extractValue(resolved), index
+}
+
+// splitArgumentsASCII splits the content of a function call on commas that are
+// not nested inside brackets, parentheses, or quotes.
+//
+// Parameters:
+//   - argList: The text between the parentheses of a function call
+//
+// Returns:
+//   - The raw argument expressions, or nil if there are none
+func splitArgumentsASCII(argList string) []string {
+	var args []string
+	start := 0
+	depth := 0
+	index := 0
+	for index < len(argList) {
+		c := argList[index]
+		switch c {
+		case '\'', '"':
+			index = skipQuotedASCII(argList, index)
+			continue
+		case '[', '(':
+			depth++
+		case ']', ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				args = append(args, argList[start:index])
+				start = index + 1
+			}
+		}
+		index++
+	}
+	if len(args) == 0 && isBlank(argList) {
+		return nil
+	}
+	return append(args, argList[start:])
+}
+
+// funcCount implements count(collection). It returns the number of elements of
+// an array, slice, map, or iterator (for example the result of a wildcard or
+// filter), 0 for nil, and 1 for any other single value.
+func funcCount(args []any) any {
+	if len(args) != 1 || args[0] == nil {
+		return 0
+	}
+	value := reflect.ValueOf(args[0])
+	switch value.Kind() {
+	case reflect.Array, reflect.Slice, reflect.Map:
+		return value.Len()
+	default:
+		if elements := collectionElements(value); elements != nil {
+			return len(elements)
+		}
+		return 1
+	}
+}
+
+// funcLen implements len(x). It returns the number of elements of an array, slice,
+// map, or iterator, the number of characters of a string, and 0 for nil and any other
+// value.
+func funcLen(args []any) any {
+	if len(args) != 1 || args[0] == nil {
+		return 0
+	}
+	value := reflect.Indirect(reflect.ValueOf(args[0]))
+	switch value.Kind() {
+	case reflect.Array, reflect.Slice, reflect.Map:
+		return value.Len()
+	case reflect.String:
+		return utf8.RuneCountInString(value.String())
+	default:
+		return len(collectionElements(value))
+	}
+}
+
+// sequenceElements returns the elements of an array, slice, or iterator, in order.
+// It returns false for any other value.
+func sequenceElements(value reflect.Value) ([]reflect.Value, bool) {
+	switch value.Kind() {
+	case reflect.Array, reflect.Slice:
+		return collectionElements(value), true
+	case reflect.Func:
+		elements := iteratorElements(value)
+		return elements, elements != nil
+	default:
+		return nil, false
+	}
+}
+
+// funcJoin implements join(collection, separator). It converts each element of an
+// array, slice, or iterator to a string and joins them with the separator. A single
+// non-collection value is converted to a string; nil yields an empty string.
+func funcJoin(args []any) any {
+	if len(args) == 0 || args[0] == nil {
+		return ""
+	}
+	separator := ""
+	if len(args) > 1 {
+		separator = toString(args[1])
+	}
+	elements, ok := sequenceElements(reflect.ValueOf(args[0]))
+	if !ok {
+		return toString(args[0])
+	}
+	var sb strings.Builder
+	for i, element := range elements {
+		if i > 0 {
+			sb.WriteString(separator)
+		}
+		sb.WriteString(toString(extractValue(element)))
+	}
+	return sb.String()
+}
+
+// funcSplit implements split(string, separator). It splits the string form of the
+// first argument around each occurrence of the separator and returns a []string.
+// An empty input yields an empty slice.
+func funcSplit(args []any) any {
+	if len(args) == 0 {
+		return []string{}
+	}
+	str := toString(args[0])
+	if str == "" {
+		return []string{}
+	}
+	separator := ""
+	if len(args) > 1 {
+		separator = toString(args[1])
+	}
+	return strings.Split(str, separator)
+}
+
+// keyResolver resolves the key paths of sort and groupby against the elements of
+// their collection, with the options of the evaluation. As in the expression of each,
+// '.' refers to the element and '^' to the data the function is called on.
+type keyResolver struct {
+	state *evalState
+	// data is the data the function is called on.
+	data any
+	// base is the path that the fields read by key paths are recorded against (see
+	// elementAuditBase).
+	base string
+}
+
+// resolve resolves the key path key against element.
+func (k keyResolver) resolve(key string, element any) any {
+	state := k.state
+	outerParent := state.parent
+	state.parent = k.data
+	state.scopeDepth++
+	if state.audit != nil {
+		defer state.audit.rebase(k.base)()
+	}
+	result, _ := resolveExpressions(key, element, state, 0)
+	state.scopeDepth--
+	state.parent = outerParent
+	return result
+}
+
+// keyedForm returns the special form of the function name, implemented by fn, whose
+// key paths are resolved against the elements of its collection (see keyedFuncs).
+// The arguments are evaluated like those of any other function.
+func keyedForm(name string, fn func(args []any, keys keyResolver) any) func(rawArgs []string, args []any, data any, state *evalState) any {
+	return func(rawArgs []string, args []any, data any, state *evalState) any {
+		rawCollection := ""
+		if len(args) == 0 && len(rawArgs) > 0 {
+			rawCollection = rawArgs[0]
+		}
+		for _, rawArg := range rawArgs {
+			arg, _ := resolveExpressions(rawArg, data, state, 0)
+			args = append(args, arg)
+		}
+		keys := keyResolver{state: state, data: data}
+		if state.audit != nil {
+			keys.base = elementAuditBase(name, rawCollection, state.audit.data)
+		}
+		return fn(args, keys)
+	}
+}
+
+// funcSort implements sort(collection, key, order). It returns a sorted copy of the
+// elements of an array, slice, or map (map values in sorted key order), as a typed
+// slice if all elements have the same type (see typedSlice).
+//
+// The optional key is a path expression resolved against each element with keys,
+// e.g. '.Age'; without a key, or with the key '.', the elements themselves are
+// compared. Values
+// are compared like the ordering operators: numbers by value, everything else by its
+// string representation. Elements whose key is nil come last. The sort is stable
+// and ascending; a key prefixed with '-' ('-.Age') or the order 'desc' sorts in
+// descending order. The order may also be given instead of a key:
+// sort(.Tags, 'desc'). A value that is not a collection is returned unchanged.
+func funcSort(args []any, keys keyResolver) any {
+	if len(args) == 0 || args[0] == nil {
+		return nil
+	}
+	elements := collectionElements(reflect.ValueOf(args[0]))
+	if elements == nil {
+		return args[0]
+	}
+
+	key, descending := ".", false
+	for _, arg := range args[1:] {
+		switch option := toString(arg); option {
+		case "asc":
+			descending = false
+		case "desc":
+			descending = true
+		default:
+			if strings.HasPrefix(option, "-") {
+				option, descending = option[1:], true
+			}
+			if option != "" {
+				key = option
+			}
+		}
+	}
+
+	values := make([]any, len(elements))
+	sortKeys := make([]any, len(elements))
+	for i, element := range elements {
+		values[i] = extractValue(element)
+		sortKeys[i] = values[i]
+		if key != "." {
+			sortKeys[i] = keys.resolve(key, values[i])
+		}
+	}
+
+	order := make([]int, len(values))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		left, right := sortKeys[order[i]], sortKeys[order[j]]
+		if left == nil || right == nil {
+			return right == nil && left != nil
+		}
+		cmp, _ := orderValues(left, right)
+		if descending {
+			return cmp > 0
+		}
+		return cmp < 0
+	})
+
+	sorted := make([]any, len(values))
+	for i, index := range order {
+		sorted[i] = values[index]
+	}
+	return extractValue(typedSlice(sorted))
+}
+
+// funcFirst implements first(collection). It returns the first element of an array,
+// slice, or iterator, or nil if it is empty; an iterator is consumed only up to its
+// first element. Any other value is returned unchanged.
+func funcFirst(args []any) any {
+	if len(args) == 0 || args[0] == nil {
+		return nil
+	}
+	value := reflect.ValueOf(args[0])
+	switch value.Kind() {
+	case reflect.Array, reflect.Slice:
+		if value.Len() == 0 {
+			return nil
+		}
+		return extractValue(value.Index(0))
+	case reflect.Func:
+		if iteratorArity(value.Type()) == 0 {
+			return args[0]
+		}
+		return extractValue(firstIteratorElement(value))
+	default:
+		return args[0]
+	}
+}
+
+// funcLast implements last(collection). It returns the last element of an array,
+// slice, or iterator, or nil if it is empty. Any other value is returned unchanged.
+func funcLast(args []any) any {
+	if len(args) == 0 || args[0] == nil {
+		return nil
+	}
+	elements, ok := sequenceElements(reflect.ValueOf(args[0]))
+	if !ok {
+		return args[0]
+	}
+	if len(elements) == 0 {
+		return nil
+	}
+	return extractValue(elements[len(elements)-1])
+}
+
+// funcReverse implements reverse(collection). It returns a copy of an array, slice,
+// or iterator with the elements in reverse order, as a slice of the same element
+// type. Any other value is returned unchanged.
+func funcReverse(args []any) any {
+	if len(args) == 0 || args[0] == nil {
+		return nil
+	}
+	value := reflect.ValueOf(args[0])
+	elements, ok := sequenceElements(value)
+	if !ok {
+		return args[0]
+	}
+	length := len(elements)
+	reversed := reflect.MakeSlice(reflect.SliceOf(collectionElemType(value)), length, length)
+	for i, element := range elements {
+		reversed.Index(length - 1 - i).Set(element)
+	}
+	return reversed.Interface()
+}
+
+// funcUnique implements unique(collection). It returns a copy of an array, slice, or
+// iterator without duplicate elements, keeping the first occurrence of each, as a
+// slice of the same element type. Elements are duplicates if reflect.DeepEqual
+// reports them as equal. Any other value is returned unchanged.
+func funcUnique(args []any) any {
+	if len(args) == 0 || args[0] == nil {
+		return nil
+	}
+	value := reflect.ValueOf(args[0])
+	elements, ok := sequenceElements(value)
+	if !ok {
+		return args[0]
+	}
+	unique := reflect.MakeSlice(reflect.SliceOf(collectionElemType(value)), 0, len(elements))
+	seen := make(map[any]bool)
+	for _, element := range elements {
+		item := extractValue(element)
+		if item != nil && reflect.ValueOf(item).Comparable() {
+			if seen[item] {
+				continue
+			}
+			seen[item] = true
+		} else if containsEqual(unique, item) {
+			continue
+		}
+		unique = reflect.Append(unique, element)
+	}
+	return unique.Interface()
+}
+
+// containsEqual reports whether slice has an element that reflect.DeepEqual reports
+// as equal to item.
+func containsEqual(slice reflect.Value, item any) bool {
+	for i := 0; i < slice.Len(); i++ {
+		if reflect.DeepEqual(extractValue(slice.Index(i)), item) {
+			return true
+		}
+	}
+	return false
+}
+
+// funcGroupBy implements groupby(collection, key). It groups the elements of an
+// array, slice, map, or iterator (map values in sorted key order) by the string form
+// of the key path resolved against each element with keys, e.g. '.Status'. The
+// result maps each key to a slice of the elements with that key, in their original
+// order, and can be resolved further: groupby(.Orders, '.Status')['open']. Elements
+// whose key is nil are grouped under "". A value that is not a collection yields nil.
+func funcGroupBy(args []any, keys keyResolver) any {
+	if len(args) == 0 || args[0] == nil {
+		return nil
+	}
+	value := reflect.ValueOf(args[0])
+	elements := collectionElements(value)
+	if elements == nil {
+		return nil
+	}
+	key := "."
+	if len(args) > 1 {
+		key = toString(args[1])
+	}
+
+	groupType := reflect.SliceOf(collectionElemType(value))
+	groups := reflect.MakeMap(reflect.MapOf(reflect.TypeOf(""), groupType))
+	for _, element := range elements {
+		groupKey := reflect.ValueOf(toString(keys.resolve(key, extractValue(element))))
+		group := groups.MapIndex(groupKey)
+		if !group.IsValid() {
+			group = reflect.MakeSlice(groupType, 0, 1)
+		}
+		groups.SetMapIndex(groupKey, reflect.Append(group, element))
+	}
+	return groups.Interface()
+}
+
+// funcSubstr implements substr(string, start, length). It returns length characters
+// of the string form of the first argument, starting at the character at start. A
+// negative start counts from the end of the string; without a length, or with a
+// negative one, the rest of the string is returned. Positions beyond the string are
+// clamped to its bounds, so substr never fails.
+func funcSubstr(args []any) any {
+	if len(args) == 0 {
+		return ""
+	}
+	runes := []rune(toString(args[0]))
+	start := intArg(args, 1, 0)
+	if start < 0 {
+		start += len(runes)
+	}
+	start = min(max(start, 0), len(runes))
+	end := len(runes)
+	if length := intArg(args, 2, -1); length >= 0 {
+		end = min(start+length, len(runes))
+	}
+	return string(runes[start:end])
+}
+
+// funcReplace implements replace(string, old, new). It replaces all occurrences of
+// old in the string form of the first argument with new.
+func funcReplace(args []any) any {
+	if len(args) == 0 {
+		return ""
+	}
+	str := toString(args[0])
+	if len(args) < 2 {
+		return str
+	}
+	replacement := ""
+	if len(args) > 2 {
+		replacement = toString(args[2])
+	}
+	return strings.ReplaceAll(str, toString(args[1]), replacement)
+}
+
+// funcPadLeft implements padleft(string, width, pad). It prepends the pad string
+// (a space by default) to the string form of the first argument until it is at least
+// width characters long, e.g. padleft(.ID, 8, '0') turns 42 into "00000042".
+func funcPadLeft(args []any) any {
+	str, padding := pad(args)
+	return padding + str
+}
+
+// funcPadRight implements padright(string, width, pad). It is like padleft, but
+// appends the padding.
+func funcPadRight(args []any) any {
+	str, padding := pad(args)
+	return str + padding
+}
+
+// pad returns the string form of the first argument and the padding that brings it
+// to the width in args[1], made of repetitions of args[2] (a space by default). The
+// padding is cut to the exact width if the pad string has multiple characters.
+func pad(args []any) (string, string) {
+	if len(args) == 0 {
+		return "", ""
+	}
+	str := toString(args[0])
+	missing := intArg(args, 1, 0) - utf8.RuneCountInString(str)
+	padString := " "
+	if len(args) > 2 {
+		padString = toString(args[2])
+	}
+	if missing <= 0 || padString == "" {
+		return str, ""
+	}
+	padRunes := []rune(strings.Repeat(padString, missing/utf8.RuneCountInString(padString)+1))
+	return str, string(padRunes[:missing])
+}
+
+// funcMask implements mask(string, keep, mask). It replaces the letters and digits
+// in the string form of the first argument with the mask string ("•" by default),
+// except for the last keep letters and digits (4 by default), so
+// mask('4242 4242 4242 4242') is "•••• •••• •••• 4242". Other characters, such as
+// spaces and dashes, are kept. If there are no more than keep letters and digits,
+// all of them are masked, so that short values are never shown in full.
+func funcMask(args []any) any {
+	if len(args) == 0 {
+		return ""
+	}
+	str := toString(args[0])
+	keep := intArg(args, 1, 4)
+	mask := "•"
+	if len(args) > 2 {
+		mask = toString(args[2])
+	}
+	masked := 0
+	for _, r := range str {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			masked++
+		}
+	}
+	if masked > keep {
+		masked -= max(keep, 0)
+	}
+	var sb strings.Builder
+	for _, r := range str {
+		if masked > 0 && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			sb.WriteString(mask)
+			masked--
+		} else {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// intArg returns args[i] as an int: numbers are truncated and numeric strings are
+// parsed. It returns fallback if there is no such argument or it is not a number.
+func intArg(args []any, i int, fallback int) int {
+	if i >= len(args) {
+		return fallback
+	}
+	if f, ok := toFloat64(args[i]); ok {
+		return int(f)
+	}
+	if n, err := strconv.Atoi(strings.TrimSpace(toString(args[i]))); err == nil {
+		return n
+	}
+	return fallback
+}
+
+// funcRound implements round(number, decimals). It rounds a number, or a string
+// holding one, to the given number of decimal places (0 by default), with halves
+// rounded away from zero, and returns it as a float64. Anything else yields nil.
+func funcRound(args []any) any {
+	if len(args) == 0 {
+		return nil
+	}
+	f, ok := toNumber(args[0])
+	if !ok {
+		return nil
+	}
+	scale := math.Pow10(intArg(args, 1, 0))
+	return math.Round(f*scale) / scale
+}
+
+// funcNumber implements number(number, decimals, thousands, point). It formats a
+// number, or a string holding one, with exactly the given number of decimal places
+// (rounded like round), groups the digits of the integer part with the thousands
+// separator (none by default), and uses point as the decimal separator ("." by
+// default): number(1234.5, 2, ',') yields "1,234.50". Without decimals, integers are
+// formatted without decimal places and floats with as many as needed. Values that are
+// not numbers are returned as strings unchanged.
+func funcNumber(args []any) any {
+	if len(args) == 0 {
+		return ""
+	}
+	decimals := intArg(args, 1, -1)
+	thousands, point := "", "."
+	if len(args) > 2 {
+		thousands = toString(args[2])
+	}
+	if len(args) > 3 {
+		point = toString(args[3])
+	}
+
+	var formatted string
+	switch value := reflect.ValueOf(args[0]); value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		formatted = strconv.FormatInt(value.Int(), 10)
+		if decimals > 0 {
+			formatted += "." + strings.Repeat("0", decimals)
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		formatted = strconv.FormatUint(value.Uint(), 10)
+		if decimals > 0 {
+			formatted += "." + strings.Repeat("0", decimals)
+		}
+	default:
+		f, ok := toNumber(args[0])
+		if !ok {
+			return toString(args[0])
+		}
+		if decimals >= 0 {
+			f = funcRound([]any{f, decimals}).(float64)
+		}
+		if f == 0 {
+			// Avoid "-0.00" for small negative numbers.
+			f = 0
+		}
+		formatted = strconv.FormatFloat(f, 'f', decimals, 64)
+	}
+	return groupDigits(formatted, thousands, point)
+}
+
+// groupDigits inserts the thousands separator between each group of three digits of
+// the integer part of a formatted number and replaces its decimal point.
+func groupDigits(formatted string, thousands string, point string) string {
+	sign := ""
+	if strings.HasPrefix(formatted, "-") {
+		sign, formatted = "-", formatted[1:]
+	}
+	integer, fraction, hasFraction := strings.Cut(formatted, ".")
+	if len(integer) <= 3 && !hasFraction {
+		return sign + integer
+	}
+
+	var sb strings.Builder
+	sb.WriteString(sign)
+	for i, digit := range integer {
+		if i > 0 && (len(integer)-i)%3 == 0 {
+			sb.WriteString(thousands)
+		}
+		sb.WriteRune(digit)
+	}
+	if hasFraction {
+		sb.WriteString(point)
+		sb.WriteString(fraction)
+	}
+	return sb.String()
+}
+
+// toNumber converts a number, or a string holding one, to a float64.
+func toNumber(v any) (float64, bool) {
+	if f, ok := toFloat64(v); ok {
+		return f, true
+	}
+	if str, ok := v.(string); ok {
+		f, err := strconv.ParseFloat(strings.TrimSpace(str), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// funcURLQuery implements urlquery(value). It escapes the string form of the value
+// so it can be placed in a URL query, e.g. "a b&c" becomes "a+b%26c".
+func funcURLQuery(args []any) any {
+	if len(args) == 0 {
+		return ""
+	}
+	return url.QueryEscape(toString(args[0]))
+}
+
+// funcHTMLEscape implements htmlescape(value). It escapes the characters <, >, &, '
+// and " in the string form of the value so it can be placed in HTML text or a quoted
+// attribute.
+func funcHTMLEscape(args []any) any {
+	if len(args) == 0 {
+		return ""
+	}
+	return html.EscapeString(toString(args[0]))
+}
+
+// funcBase64 implements b64(value). It encodes a byte slice, or the string form of
+// any other value, with standard base64 encoding.
+func funcBase64(args []any) any {
+	if len(args) == 0 {
+		return ""
+	}
+	return base64.StdEncoding.EncodeToString(toBytes(args[0]))
+}
+
+// funcBase64Decode implements b64dec(value). It decodes a base64 encoded byte slice
+// or string, with or without padding and in the standard or URL-safe alphabet, and
+// returns the result as a string. Invalid input yields nil.
+func funcBase64Decode(args []any) any {
+	if len(args) == 0 {
+		return nil
+	}
+	encoded := strings.TrimSpace(string(toBytes(args[0])))
+	for _, encoding := range []*base64.Encoding{
+		base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding,
+	} {
+		if decoded, err := encoding.DecodeString(encoded); err == nil {
+			return string(decoded)
+		}
+	}
+	return nil
+}
+
+// funcHex implements hex(value). It encodes a byte slice, or the string form of any
+// other value, as lowercase hexadecimal.
+func funcHex(args []any) any {
+	if len(args) == 0 {
+		return ""
+	}
+	return hex.EncodeToString(toBytes(args[0]))
+}
+
+// toBytes returns the bytes of a byte slice or array (including named types such as
+// json.RawMessage), or of the string form of any other value.
+func toBytes(v any) []byte {
+	if v == nil {
+		return nil
+	}
+	value := reflect.ValueOf(v)
+	if (value.Kind() == reflect.Slice || value.Kind() == reflect.Array) && value.Type().Elem().Kind() == reflect.Uint8 {
+		b := make([]byte, value.Len())
+		reflect.Copy(reflect.ValueOf(b), value)
+		return b
+	}
+	return []byte(toString(v))
+}
+
+// hashFunc returns a function that hashes a byte slice, or the string form of any
+// other value, and returns the digest as lowercase hexadecimal, e.g. sha256(.Email).
+// md5 and sha1 are only suitable for cache keys and checksums, not for security.
+func hashFunc(newHash func() hash.Hash) builtinFunc {
+	return func(args []any) any {
+		h := newHash()
+		if len(args) > 0 {
+			h.Write(toBytes(args[0]))
+		}
+		return hex.EncodeToString(h.Sum(nil))
+	}
+}
+
+// funcTypeOf implements typeof(value). It returns the Go type of the value as
+// formatted by reflect.Type.String, e.g. "map[string]interface {}" or
+// "*models.User", or "nil" for nil.
+func funcTypeOf(args []any) any {
+	if len(args) == 0 || args[0] == nil {
+		return "nil"
+	}
+	return reflect.TypeOf(args[0]).String()
+}
+
+// funcKind implements kind(value). It returns the kind of the value as named by
+// reflect.Kind.String, e.g. "string", "int", "float64", "slice", "map", "struct", or
+// "ptr", or "nil" for nil.
+func funcKind(args []any) any {
+	if len(args) == 0 || args[0] == nil {
+		return "nil"
+	}
+	return reflect.TypeOf(args[0]).Kind().String()
+}
+
+// funcIsNil implements isnil(value). It reports whether the value is nil, which
+// includes nil pointers, maps, and slices and fields or keys that do not exist.
+func funcIsNil(args []any) any {
+	return len(args) == 0 || isNil(args[0])
+}
+
+// funcIsEmpty implements isempty(value). It reports whether the value is nil, an
+// empty string, or an array, slice, map, or iterator without elements, following
+// pointers; an iterator is consumed only up to its first element. Other values,
+// including 0 and false, are not empty.
+func funcIsEmpty(args []any) any {
+	if funcIsNil(args).(bool) {
+		return true
+	}
+	value := reflect.ValueOf(args[0])
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return true
+		}
+		value = value.Elem()
+	}
+	switch value.Kind() {
+	case reflect.String, reflect.Array, reflect.Slice, reflect.Map:
+		return value.Len() == 0
+	case reflect.Func:
+		return iteratorArity(value.Type()) != 0 && !firstIteratorElement(value).IsValid()
+	default:
+		return false
+	}
+}
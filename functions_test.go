@@ -0,0 +1,751 @@
+package empaths
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// Member is used by the function tests
+type Member struct {
+	Name   string
+	Active bool
+	Age    int
+}
+
+// createTestTeam returns a map holding a slice of members for testing
+func createTestTeam() map[string]any {
+	return map[string]any{
+		"Users": []Member{
+			{Name: "Alice", Active: true, Age: 30},
+			{Name: "Bob", Active: false, Age: 25},
+			{Name: "Carol", Active: true, Age: 35},
+		},
+		"Scores": map[string]int{"math": 95, "science": 88},
+	}
+}
+
+func TestFunc_Count(t *testing.T) {
+	team := createTestTeam()
+
+	tests := []struct {
+		name     string
+		path     string
+		expected any
+	}{
+		{"slice", "count(.Users)", 3},
+		{"map", "count(.Scores)", 2},
+		{"filtered", "count(.Users[?.Active=='true'])", 2},
+		{"filter without matches", "count(.Users[?.Name=='Nobody'])", 0},
+		{"missing field", "count(.Missing)", 0},
+		{"single value", "count(.Users[0].Name)", 1},
+		{"no arguments", "count()", 0},
+		{"in concatenation", "count(.Users[?.Active=='true']) ' of ' count(.Users) ' users active'", "2 of 3 users active"},
+		{"unknown function", "nope(.Users)", nil},
+		{"unterminated call", "count(.Users", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Resolve(tt.path, team, nil)
+			if result != tt.expected {
+				t.Errorf("Resolve(%q) = %v, want %v", tt.path, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFunc_Join(t *testing.T) {
+	data := map[string]any{
+		"Tags":   []string{"go", "rust", "zig"},
+		"Empty":  []string{},
+		"Ints":   []int{1, 2, 3},
+		"Single": "solo",
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		expected any
+	}{
+		{"string slice", "join(.Tags, ', ')", "go, rust, zig"},
+		{"no separator", "join(.Tags)", "gorustzig"},
+		{"int slice", "join(.Ints, '-')", "1-2-3"},
+		{"empty slice", "join(.Empty, ', ')", ""},
+		{"nil", "join(.Missing, ', ')", ""},
+		{"single value", "join(.Single, ', ')", "solo"},
+		{"projection", "join(.Tags[*], '|')", "go|rust|zig"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Resolve(tt.path, data, nil)
+			if result != tt.expected {
+				t.Errorf("Resolve(%q) = %v, want %v", tt.path, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFunc_Split(t *testing.T) {
+	data := map[string]any{"CSV": "a,b,,c", "Empty": ""}
+
+	tests := []struct {
+		name     string
+		path     string
+		expected []string
+	}{
+		{"comma separated", "split(.CSV, ',')", []string{"a", "b", "", "c"}},
+		{"empty string", "split(.Empty, ',')", []string{}},
+		{"missing value", "split(.Missing, ',')", []string{}},
+		{"round trip", "split(join(split(.CSV, ','), ';'), ';')", []string{"a", "b", "", "c"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, ok := Resolve(tt.path, data, nil).([]string)
+			if !ok || len(result) != len(tt.expected) {
+				t.Fatalf("Resolve(%q) = %v, want %v", tt.path, result, tt.expected)
+			}
+			for i := range result {
+				if result[i] != tt.expected[i] {
+					t.Errorf("Resolve(%q)[%d] = %q, want %q", tt.path, i, result[i], tt.expected[i])
+				}
+			}
+		})
+	}
+
+	t.Run("split result is resolvable", func(t *testing.T) {
+		result := Resolve("split(.CSV, ',')", data, nil)
+		if second := Resolve(".[1]", result, nil); second != "b" {
+			t.Errorf("Resolve(.[1]) on split result = %v, want %v", second, "b")
+		}
+	})
+}
+
+func TestFunc_Sort(t *testing.T) {
+	team := createTestTeam()
+	team["Tags"] = []string{"zig", "go", "rust"}
+	team["Mixed"] = []any{"b", 2, nil, "a", 10}
+	users := team["Users"].([]Member)
+	alice, bob, carol := users[0], users[1], users[2]
+
+	tests := []struct {
+		name     string
+		path     string
+		expected any
+	}{
+		{"by key", "sort(.Users, '.Age')", []Member{bob, alice, carol}},
+		{"by key descending", "sort(.Users, '-.Age')", []Member{carol, alice, bob}},
+		{"by key with order", "sort(.Users, '.Name', 'desc')", []Member{carol, bob, alice}},
+		{"stable", "sort(.Users, '.Active')", []Member{bob, alice, carol}},
+		{"elements", "sort(.Tags)", []string{"go", "rust", "zig"}},
+		{"elements descending", "sort(.Tags, 'desc')", []string{"zig", "rust", "go"}},
+		{"map values", "sort(.Scores)", []int{88, 95}},
+		{"filtered", "sort(.Users[?.Active == true], '-.Age')", []Member{carol, alice}},
+		{"nil last", "sort(.Mixed)", []any{2, 10, "a", "b", nil}},
+		{"missing key", "sort(.Users, '.Missing')", []Member{alice, bob, carol}},
+		{"joined", "join(sort(.Users[*].Name, 'desc'), ', ')", "Carol, Bob, Alice"},
+		{"single value", "sort(.Users[0].Name)", "Alice"},
+		{"nil", "sort(.Missing)", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Resolve(tt.path, team, nil)
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("Resolve(%q) = %#v, want %#v", tt.path, result, tt.expected)
+			}
+		})
+	}
+
+	t.Run("keys use the options of the evaluation", func(t *testing.T) {
+		ranks := map[string]int{"Alice": 3, "Bob": 1, "Carol": 2}
+		refResolver := func(name string, data any) any {
+			return ranks[data.(Member).Name]
+		}
+		if result := Resolve("sort(.Users, ':rank')", team, refResolver); !reflect.DeepEqual(result, []Member{bob, carol, alice}) {
+			t.Errorf("Resolve() with a reference as key = %#v, want Bob, Carol, Alice", result)
+		}
+
+		if _, err := NewResolver(WithMaxSegments(2)).ResolveErr("sort(.Users, '.Name')", team, nil); !errors.Is(err, ErrLimitExceeded) {
+			t.Errorf("ResolveErr() error = %v, want ErrLimitExceeded", err)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		calls := 0
+		counter := slowCounter{calls: &calls, cancel: cancel}
+		data := map[string]any{"Counters": []slowCounter{counter, counter, counter}}
+		if _, err := ResolveCtx(ctx, "sort(.Counters, '.Next.Next')", data, nil); !errors.Is(err, context.Canceled) || calls != 2 {
+			t.Errorf("ResolveCtx() error = %v after %d calls, want context.Canceled after 2", err, calls)
+		}
+
+		var fields []string
+		resolver := NewResolver(WithAudit(func(record AuditRecord) { fields = record.Fields }))
+		resolver.Resolve("sort(.Users[?.Active == true], '.Age')", team, nil)
+		if want := []string{".Users[*]", ".Users[*].Active", ".Users[*].Age"}; !reflect.DeepEqual(fields, want) {
+			t.Errorf("audited fields = %v, want %v", fields, want)
+		}
+	})
+
+	t.Run("does not modify the collection", func(t *testing.T) {
+		Resolve("sort(.Tags)", team, nil)
+		if first := Resolve(".Tags[0]", team, nil); first != "zig" {
+			t.Errorf("Resolve(.Tags[0]) after sort = %v, want %v", first, "zig")
+		}
+	})
+}
+
+func TestFunc_Collections(t *testing.T) {
+	team := createTestTeam()
+	team["Tags"] = []string{"go", "rust", "go", "zig", "rust"}
+	team["Empty"] = []string{}
+	team["Corners"] = [3]int{1, 2, 3}
+	team["Mixed"] = []any{1, "1", nil, []int{1}, 1, []int{1}, nil}
+	users := team["Users"].([]Member)
+
+	tests := []struct {
+		name     string
+		path     string
+		expected any
+	}{
+		{"first", "first(.Users)", users[0]},
+		{"first of empty", "first(.Empty)", nil},
+		{"first of array", "first(.Corners)", 1},
+		{"first of projection", "first(.Users[*].Name)", "Alice"},
+		{"first of filter", "first(.Users[?.Active == false])", users[1]},
+		{"first single value", "first(.Users[1].Name)", "Bob"},
+		{"first of nil", "first(.Missing)", nil},
+		{"last", "last(.Users)", users[2]},
+		{"last of empty", "last(.Empty)", nil},
+		{"last of array", "last(.Corners)", 3},
+		{"reverse", "reverse(.Tags)", []string{"rust", "zig", "go", "rust", "go"}},
+		{"reverse array", "reverse(.Corners)", []int{3, 2, 1}},
+		{"reverse empty", "reverse(.Empty)", []string{}},
+		{"reverse of nil", "reverse(.Missing)", nil},
+		{"unique", "unique(.Tags)", []string{"go", "rust", "zig"}},
+		{"unique mixed", "unique(.Mixed)", []any{1, "1", nil, []int{1}}},
+		{"unique projection", "join(unique(.Users[*].Active), ',')", "true,false"},
+		{"reverse sorted", "join(reverse(sort(.Users[*].Name)), ', ')", "Carol, Bob, Alice"},
+		{"single value", "unique(.Users[0].Name)", "Alice"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Resolve(tt.path, team, nil)
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("Resolve(%q) = %#v, want %#v", tt.path, result, tt.expected)
+			}
+		})
+	}
+
+	t.Run("does not modify the collection", func(t *testing.T) {
+		Resolve("reverse(.Tags)", team, nil)
+		if first := Resolve(".Tags[0]", team, nil); first != "go" {
+			t.Errorf("Resolve(.Tags[0]) after reverse = %v, want %v", first, "go")
+		}
+	})
+}
+
+func TestFunc_Len(t *testing.T) {
+	data := map[string]any{
+		"Tags":  []string{"go", "rust"},
+		"Title": "Grüße",
+		"Age":   30,
+		"Ptr":   &[]int{1, 2, 3},
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		expected any
+	}{
+		{"slice", "len(.Tags)", 2},
+		{"map", "len(.)", 4},
+		{"string", "len(.Title)", 5},
+		{"pointer to slice", "len(.Ptr)", 3},
+		{"number", "len(.Age)", 0},
+		{"nil", "len(.Missing)", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Resolve(tt.path, data, nil)
+			if result != tt.expected {
+				t.Errorf("Resolve(%q) = %v, want %v", tt.path, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFunc_GroupBy(t *testing.T) {
+	team := createTestTeam()
+	users := team["Users"].([]Member)
+	alice, bob, carol := users[0], users[1], users[2]
+
+	tests := []struct {
+		name     string
+		path     string
+		expected any
+	}{
+		{"groups", "groupby(.Users, '.Active')", map[string][]Member{"true": {alice, carol}, "false": {bob}}},
+		{"group by key", "groupby(.Users, '.Active')['true']", []Member{alice, carol}},
+		{"group in dot notation", "groupby(.Users, '.Active').false[0].Name", "Bob"},
+		{"group size", "groupby(.Users, '.Active')['true'] | len", 2},
+		{"missing group", "groupby(.Users, '.Active')['maybe']", nil},
+		{"map values", "groupby(.Scores, '.')", map[string][]int{"95": {95}, "88": {88}}},
+		{"nil keys", "groupby(.Users, '.Missing')", map[string][]Member{"": {alice, bob, carol}}},
+		{"not a collection", "groupby(.Users[0], '.Name')", nil},
+		{"nil", "groupby(.Missing, '.Name')", nil},
+		{"piped", ".Users | groupby('.Active')['false']", []Member{bob}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Resolve(tt.path, team, nil)
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("Resolve(%q) = %#v, want %#v", tt.path, result, tt.expected)
+			}
+		})
+	}
+
+	t.Run("keys use the options of the evaluation", func(t *testing.T) {
+		refResolver := func(name string, data any) any {
+			return data.(Member).Age >= 30
+		}
+		result := Resolve("groupby(.Users, ':senior')", team, refResolver)
+		if want := map[string][]Member{"true": {alice, carol}, "false": {bob}}; !reflect.DeepEqual(result, want) {
+			t.Errorf("Resolve() with a reference as key = %#v, want %#v", result, want)
+		}
+
+		if _, err := NewResolver(WithMaxSegments(2)).ResolveErr("groupby(.Users, '.Name')", team, nil); !errors.Is(err, ErrLimitExceeded) {
+			t.Errorf("ResolveErr() error = %v, want ErrLimitExceeded", err)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		calls := 0
+		counter := slowCounter{calls: &calls, cancel: cancel}
+		data := map[string]any{"Counters": []slowCounter{counter, counter, counter}}
+		if _, err := ResolveCtx(ctx, "groupby(.Counters, '.Next.Next')", data, nil); !errors.Is(err, context.Canceled) || calls != 2 {
+			t.Errorf("ResolveCtx() error = %v after %d calls, want context.Canceled after 2", err, calls)
+		}
+
+		var fields []string
+		resolver := NewResolver(WithAudit(func(record AuditRecord) { fields = record.Fields }))
+		resolver.Resolve("groupby(.Users, '.Active')", team, nil)
+		if want := []string{".Users", ".Users[*].Active"}; !reflect.DeepEqual(fields, want) {
+			t.Errorf("audited fields = %v, want %v", fields, want)
+		}
+	})
+}
+
+func TestPipe(t *testing.T) {
+	team := createTestTeam()
+
+	tests := []struct {
+		name     string
+		path     string
+		expected any
+	}{
+		{"without arguments", ".Users | len", 3},
+		{"with arguments", ".Users[*].Name | join(', ')", "Alice, Bob, Carol"},
+		{"chained", ".Users[*].Name | sort('desc') | join(',')", "Carol,Bob,Alice"},
+		{"without spaces", ".Users|len", 3},
+		{"function result", "sort(.Users[*].Age) | last", 35},
+		{"trailing path", ".Users | last.Name", "Carol"},
+		{"trailing path after arguments", ".Users | sort('-.Age')[0].Name", "Carol"},
+		{"applies to the preceding operand", "'Users: ' .Users | len", "Users: 3"},
+		{"in a function argument", "join(.Users[*].Name | reverse, ',')", "Carol,Bob,Alice"},
+		{"unknown function", ".Users | nope", nil},
+		{"missing function", ".Users | ", team["Users"]},
+		{"nothing to pipe", "| len", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Resolve(tt.path, team, nil)
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("Resolve(%q) = %#v, want %#v", tt.path, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFunc_TrailingPath(t *testing.T) {
+	team := createTestTeam()
+
+	tests := []struct {
+		name     string
+		path     string
+		expected any
+	}{
+		{"field", "first(.Users).Name", "Alice"},
+		{"index", "sort(.Users, '-.Age')[0].Name", "Carol"},
+		{"projection", "reverse(.Users)[*].Name", []string{"Carol", "Bob", "Alice"}},
+		{"filter", "reverse(.Users)[?.Active == true].Name", []string{"Carol", "Alice"}},
+		{"concatenated", "first(.Users).Name ' and ' last(.Users).Name", "Alice and Carol"},
+		{"separated by a space", "first(.Users[*].Name) .Scores.math", "Alice95"},
+		{"nil result", "first(.Missing).Name", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Resolve(tt.path, team, nil)
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("Resolve(%q) = %#v, want %#v", tt.path, result, tt.expected)
+			}
+		})
+	}
+
+	t.Run("strict", func(t *testing.T) {
+		r := NewResolver(WithStrict())
+		_, err := r.ResolveErr("first(.Users).Nmae", team, nil)
+		var notFound *FieldNotFoundError
+		if !errors.As(err, &notFound) || notFound.Field != "Nmae" || notFound.Type != reflect.TypeOf(Member{}) {
+			t.Errorf("ResolveErr error = %v, want field Nmae not found in Member", err)
+		}
+	})
+}
+
+func TestFunc_Strings(t *testing.T) {
+	data := map[string]any{
+		"Sku":   "ABC-12345",
+		"Card":  "4242 4242 4242 4242",
+		"Email": "alice@example.com",
+		"Title": "Hello big world",
+		"Name":  "Grüße",
+		"ID":    42,
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		expected string
+	}{
+		{"substr", "substr(.Sku, 0, 3)", "ABC"},
+		{"substr to end", "substr(.Sku, 4)", "12345"},
+		{"substr negative start", "substr(.Sku, -5, 2)", "12"},
+		{"substr beyond end", "substr(.Sku, 7, 10)", "45"},
+		{"substr start beyond end", "substr(.Sku, 20, 2)", ""},
+		{"substr characters", "substr(.Name, 1, 3)", "rüß"},
+		{"substr string arguments", "substr(.Sku, '4', '2')", "12"},
+		{"substr number", "substr(.ID, 1)", "2"},
+		{"replace", "replace(.Title, ' ', '-')", "Hello-big-world"},
+		{"replace with nothing", "replace(.Title, 'big ')", "Hello world"},
+		{"replace without match", "replace(.Title, 'x', 'y')", "Hello big world"},
+		{"padleft", "padleft(.ID, 8, '0')", "00000042"},
+		{"padleft with spaces", "padleft(.ID, 4)", "  42"},
+		{"padleft long enough", "padleft(.Sku, 4, '0')", "ABC-12345"},
+		{"padleft multi-character pad", "padleft(.ID, 7, 'ab')", "ababa42"},
+		{"padleft characters", "padleft(.Name, 7, '.')", "..Grüße"},
+		{"padright", "padright(.ID, 5, '.')", "42..."},
+		{"mask", "mask(.Card)", "•••• •••• •••• 4242"},
+		{"mask keep", "mask(.Sku, 2)", "•••-•••45"},
+		{"mask character", "mask(.Email, 3, '*')", "*****@*******.com"},
+		{"mask everything", "mask(.Sku, 0)", "•••-•••••"},
+		{"mask short value", "mask(.ID)", "••"},
+		{"mask characters", "mask(.Name, 1)", "••••e"},
+		{"mask piped", "'Card ' .Card | replace(' ', '') | mask", "Card ••••••••••••4242"},
+		{"mask missing value", "mask(.Missing)", ""},
+		{"missing value", "padleft(.Missing, 3, '0')", "000"},
+		{"piped", ".Title | replace(' ', '_') | substr(0, 9)", "Hello_big"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Resolve(tt.path, data, nil)
+			if result != tt.expected {
+				t.Errorf("Resolve(%q) = %#v, want %q", tt.path, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFunc_Numbers(t *testing.T) {
+	data := map[string]any{
+		"Price":    1234.5,
+		"Small":    0.125,
+		"Negative": -1234567.891,
+		"Tiny":     -0.001,
+		"Count":    1234567,
+		"Big":      uint64(18446744073709551615),
+		"Text":     "2.675",
+		"Name":     "n/a",
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		expected any
+	}{
+		{"round", "round(.Price)", 1235.0},
+		{"round decimals", "round(.Small, 2)", 0.13},
+		{"round negative", "round(.Negative, 1)", -1234567.9},
+		{"round string", "round(.Text, 1)", 2.7},
+		{"round piped", ".Small | round(1)", 0.1},
+		{"round not a number", "round(.Name)", nil},
+		{"number", "number(.Price, 2)", "1234.50"},
+		{"number with thousands", "number(.Price, 2, ',')", "1,234.50"},
+		{"number with decimal point", "number(.Negative, 2, '.', ',')", "-1.234.567,89"},
+		{"number without decimals", "number(.Price)", "1234.5"},
+		{"number rounded to integer", "number(.Price, 0, ',')", "1,235"},
+		{"number integer", "number(.Count, 0, ',')", "1,234,567"},
+		{"integer with decimals", "number(.Count, 2, ' ')", "1 234 567.00"},
+		{"unsigned integer", "number(.Big, 0, ',')", "18,446,744,073,709,551,615"},
+		{"negative zero", "number(.Tiny, 2)", "0.00"},
+		{"short number", "number(.Small, 3, ',')", "0.125"},
+		{"string", "number(.Text, 2)", "2.68"},
+		{"not a number", "number(.Name, 2)", "n/a"},
+		{"missing value", "number(.Missing, 2)", ""},
+		{"piped", "'$' .Price | number(2, ',')", "$1,234.50"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Resolve(tt.path, data, nil)
+			if result != tt.expected {
+				t.Errorf("Resolve(%q) = %#v, want %#v", tt.path, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFunc_Escape(t *testing.T) {
+	data := map[string]any{
+		"Redirect": "/search?q=a b&lang=de",
+		"Input":    `<script>alert("x & 'y'")</script>`,
+		"ID":       42,
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		expected string
+	}{
+		{"urlquery", "urlquery(.Redirect)", "%2Fsearch%3Fq%3Da+b%26lang%3Dde"},
+		{"urlquery in concatenation", "'/login?next=' urlquery(.Redirect)", "/login?next=%2Fsearch%3Fq%3Da+b%26lang%3Dde"},
+		{"urlquery number", "urlquery(.ID)", "42"},
+		{"htmlescape", "htmlescape(.Input)", "&lt;script&gt;alert(&#34;x &amp; &#39;y&#39;&#34;)&lt;/script&gt;"},
+		{"htmlescape piped", "'<p>' .Input | htmlescape '</p>'", "<p>&lt;script&gt;alert(&#34;x &amp; &#39;y&#39;&#34;)&lt;/script&gt;</p>"},
+		{"missing value", "htmlescape(.Missing)", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Resolve(tt.path, data, nil)
+			if result != tt.expected {
+				t.Errorf("Resolve(%q) = %#v, want %q", tt.path, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFunc_Encoding(t *testing.T) {
+	data := map[string]any{
+		"Payload": []byte{0xde, 0xad, 0xbe, 0xef},
+		"Raw":     json.RawMessage(`{"a":1}`),
+		"Hash":    [4]byte{0x01, 0x02, 0xfe, 0xff},
+		"Text":    "hello?",
+		"Token":   "aGVsbG8/",
+		"URLSafe": "aGVsbG8_",
+		"Bytes":   []byte("aGk="),
+		"Number":  255,
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		expected any
+	}{
+		{"b64 bytes", "b64(.Payload)", "3q2+7w=="},
+		{"b64 string", "b64(.Text)", "aGVsbG8/"},
+		{"b64 named byte slice", "b64(.Raw)", "eyJhIjoxfQ=="},
+		{"b64 number", "b64(.Number)", "MjU1"},
+		{"b64dec", "b64dec(.Token)", "hello?"},
+		{"b64dec URL-safe", "b64dec(.URLSafe)", "hello?"},
+		{"b64dec unpadded", "b64dec('aGk')", "hi"},
+		{"b64dec bytes", "b64dec(.Bytes)", "hi"},
+		{"b64dec invalid", "b64dec('not base64!')", nil},
+		{"round trip", ".Text | b64 | b64dec", "hello?"},
+		{"hex bytes", "hex(.Payload)", "deadbeef"},
+		{"hex array", "hex(.Hash)", "0102feff"},
+		{"hex string", "hex(.Text)", "68656c6c6f3f"},
+		{"missing value", "hex(.Missing)", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Resolve(tt.path, data, nil)
+			if result != tt.expected {
+				t.Errorf("Resolve(%q) = %#v, want %#v", tt.path, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFunc_Hash(t *testing.T) {
+	data := map[string]any{
+		"Email": "alice@example.com",
+		"Bytes": []byte("alice@example.com"),
+		"ID":    42,
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		expected string
+	}{
+		{"sha256", "sha256(.Email)", "ff8d9819fc0e12bf0d24892e45987e249a28dce836a85cad60e28eaaa8c6d976"},
+		{"sha256 bytes", "sha256(.Bytes)", "ff8d9819fc0e12bf0d24892e45987e249a28dce836a85cad60e28eaaa8c6d976"},
+		{"sha256 number", "sha256(.ID)", "73475cb40a568e8da8a045ced110137e159f890ac4da883b6b17dc651b3a8049"},
+		{"sha1", "sha1(.Email)", "fc2398a73dd54d6237c4fdb58fd7d75347cf5af3"},
+		{"md5", "md5(.Email)", "c160f8cc69a4f0bf2b0362752353d060"},
+		{"missing value", "md5(.Missing)", "d41d8cd98f00b204e9800998ecf8427e"},
+		{"in concatenation", "'user-' .Email | sha1 | substr(0, 8)", "user-fc2398a7"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Resolve(tt.path, data, nil)
+			if result != tt.expected {
+				t.Errorf("Resolve(%q) = %#v, want %q", tt.path, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFunc_Types(t *testing.T) {
+	var decoded any
+	if err := json.Unmarshal([]byte(`{"Items": [{"Payload": {"a": 1}}, {"Payload": "text"}, {"Payload": 1.5}, {"Payload": null}]}`), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	person := createTestPerson()
+
+	tests := []struct {
+		name     string
+		path     string
+		data     any
+		expected any
+	}{
+		{"typeof map", "typeof(.Items[0].Payload)", decoded, "map[string]interface {}"},
+		{"typeof string", "typeof(.Items[1].Payload)", decoded, "string"},
+		{"typeof nil", "typeof(.Items[3].Payload)", decoded, "nil"},
+		{"typeof struct", "typeof(.Address)", person, "empaths.Address"},
+		{"typeof slice", "typeof(.Tags)", person, "[]string"},
+		{"kind map", "kind(.Items[0].Payload)", decoded, "map"},
+		{"kind float", "kind(.Items[2].Payload)", decoded, "float64"},
+		{"kind nil", "kind(.Items[3].Payload)", decoded, "nil"},
+		{"kind struct", "kind(.Address)", person, "struct"},
+		{"kind pointer", "kind($)", &person, "ptr"},
+		{"kind int", ".Age | kind", person, "int"},
+		{"comparison", "?typeof(.Items[0].Payload)=='map[string]interface {}'", decoded, true},
+		{"comparison on the right", "?'string' == kind(.Items[1].Payload)", decoded, true},
+		{"filter", "count(.Items[?kind(.Payload) == 'map'])", decoded, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Resolve(tt.path, tt.data, nil)
+			if result != tt.expected {
+				t.Errorf("Resolve(%q) = %#v, want %#v", tt.path, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFunc_Predicates(t *testing.T) {
+	blank := ""
+	var nilMap map[string]int
+	data := map[string]any{
+		"Tags":      []string{},
+		"Full":      []string{"go"},
+		"Nil":       []string(nil),
+		"NilMap":    nilMap,
+		"Name":      "",
+		"Zero":      0,
+		"False":     false,
+		"Ptr":       (*Address)(nil),
+		"Blank":     &blank,
+		"Address":   &Address{City: "NYC"},
+		"Empty":     Address{},
+		"Corners":   [0]int{},
+		"Users":     []*Member{{Name: "Alice"}, nil, {Name: "Bob"}},
+		"Scores":    map[string]int{},
+		"Interface": any(nil),
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		expected any
+	}{
+		{"isnil missing", "isnil(.Missing)", true},
+		{"isnil nil pointer", "isnil(.Ptr)", true},
+		{"isnil nil slice", "isnil(.Nil)", true},
+		{"isnil nil map", "isnil(.NilMap)", true},
+		{"isnil nil interface", "isnil(.Interface)", true},
+		{"isnil empty slice", "isnil(.Tags)", false},
+		{"isnil empty string", "isnil(.Name)", false},
+		{"isnil pointer", "isnil(.Address)", false},
+		{"isempty missing", "isempty(.Missing)", true},
+		{"isempty empty slice", "isempty(.Tags)", true},
+		{"isempty slice", "isempty(.Full)", false},
+		{"isempty empty map", "isempty(.Scores)", true},
+		{"isempty empty array", "isempty(.Corners)", true},
+		{"isempty empty string", "isempty(.Name)", true},
+		{"isempty pointer to empty string", "isempty(.Blank)", true},
+		{"isempty zero", "isempty(.Zero)", false},
+		{"isempty false", "isempty(.False)", false},
+		{"isempty zero struct", "isempty(.Empty)", false},
+		{"comparison", "?isempty(.Tags)", true},
+		{"comparison false", "?isempty(.Full)", false},
+		{"comparison with spaces", "? isnil(.Ptr)  ", true},
+		{"negated", "!isempty(.Full)", true},
+		{"compared", "?isnil(.Ptr) == false", false},
+		{"filter", "count(.Users[?isnil(.)])", 1},
+		{"filter on field", "count(.Users[?isempty(.Name)])", 1},
+		{"bare boolean", "?.False", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Resolve(tt.path, data, nil)
+			if result != tt.expected {
+				t.Errorf("Resolve(%q) = %#v, want %#v", tt.path, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSplitArgumentsASCII(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{"empty", "", nil},
+		{"blank", "  ", nil},
+		{"single", ".Users", []string{".Users"}},
+		{"two", ".Tags, ', '", []string{".Tags", " ', '"}},
+		{"nested brackets", ".M[a,b], 'x'", []string{".M[a,b]", " 'x'"}},
+		{"nested call", "count(.A, .B), .C", []string{"count(.A, .B)", " .C"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := splitArgumentsASCII(tt.input)
+			if len(result) != len(tt.expected) {
+				t.Fatalf("splitArgumentsASCII(%q) = %q, want %q", tt.input, result, tt.expected)
+			}
+			for i := range result {
+				if result[i] != tt.expected[i] {
+					t.Errorf("splitArgumentsASCII(%q)[%d] = %q, want %q", tt.input, i, result[i], tt.expected[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package empaths
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"reflect"
+	"unicode"
+	"unicode/utf8"
+)
+
+// GeneratePaths generates Go source code declaring a variable that holds the path of
+// every exported struct field reachable from typ, so code can refer to paths in a
+// way the compiler checks against the model:
+//
+//	empaths.Resolve(UserPaths.Address.City, user, nil) // ".Address.City"
+//
+// Fields whose type is a struct (or pointer to struct) with exported fields become
+// nested values; their own path is available through their String method. All other
+// fields, including slices and maps, are plain string constants. A field named String
+// is generated as String_ (with further underscores if that name is taken), since
+// the name is used by the method. Recursive types end at the first repetition.
+//
+// GeneratePaths is meant to be called from a small generator program, e.g.:
+//
+//	//go:build ignore
+//
+//	package main
+//
+//	func main() {
+//	    src, err := empaths.GeneratePaths("models", "UserPaths", reflect.TypeOf(models.User{}))
+//	    if err != nil {
+//	        log.Fatal(err)
+//	    }
+//	    os.WriteFile("user_paths.go", src, 0o644)
+//	}
+//
+// together with a "//go:generate go run gen_paths.go" directive next to the model.
+//
+// Parameters:
+//   - pkg: The package name of the generated file
+//   - name: The name of the generated variable (e.g. "UserPaths")
+//   - typ: The model type (a struct or pointer to struct)
+//
+// Returns:
+//   - The formatted Go source code
+//   - Error if typ is not a struct type or name is not a valid identifier
+func GeneratePaths(pkg string, name string, typ reflect.Type) ([]byte, error) {
+	for typ != nil && typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ == nil || typ.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("GeneratePaths needs a struct type, got %v", typ)
+	}
+	if !isPlainName(name) || !isPlainName(pkg) {
+		return nil, fmt.Errorf("invalid package or variable name %q, %q", pkg, name)
+	}
+
+	g := &pathGenerator{typeNames: map[string]bool{name: true}}
+	rootType := lowerFirst(name)
+	if rootType == name {
+		rootType += "Node"
+	}
+	rootType = g.typeName(rootType)
+	var value bytes.Buffer
+	g.generateNode(&value, rootType, "", typ, map[reflect.Type]bool{})
+
+	var src bytes.Buffer
+	fmt.Fprintf(&src, "// Code generated by empaths.GeneratePaths. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&src, "package %s\n\n", pkg)
+	fmt.Fprintf(&src, "// %s holds the empaths paths of %s.\n", name, typ)
+	fmt.Fprintf(&src, "var %s = %s\n\n", name, value.String())
+	src.Write(g.types.Bytes())
+	return format.Source(src.Bytes())
+}
+
+// pathGenerator collects the type declarations emitted by GeneratePaths.
+type pathGenerator struct {
+	types bytes.Buffer
+	// typeNames holds the names of the declared types and the variable.
+	typeNames map[string]bool
+}
+
+// typeName returns name, or name with the smallest numeric suffix that makes it
+// unique among the declared types, and reserves it. The type of a nested node is
+// named after its parent and field, so "AddressCity" on the root and "City" on
+// "Address" would otherwise both be named "...AddressCity".
+func (g *pathGenerator) typeName(name string) string {
+	unique := name
+	for i := 2; g.typeNames[unique]; i++ {
+		unique = fmt.Sprintf("%s%d", name, i)
+	}
+	g.typeNames[unique] = true
+	return unique
+}
+
+// generateNode writes the composite literal for a struct node to value and the
+// corresponding type declaration to g.types.
+func (g *pathGenerator) generateNode(value *bytes.Buffer, typeName string, path string, typ reflect.Type, visiting map[reflect.Type]bool) {
+	visiting[typ] = true
+	defer delete(visiting, typ)
+
+	nodePath := path
+	if nodePath == "" {
+		nodePath = "."
+	}
+
+	var decl bytes.Buffer
+	fmt.Fprintf(&decl, "type %s struct {\n\tpath string\n", typeName)
+	fmt.Fprintf(value, "%s{\n\tpath: %q,\n", typeName, nodePath)
+
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		fieldPath := path + "." + field.Name
+		goName := generatedFieldName(field.Name, typ)
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if fieldType.Kind() == reflect.Struct && hasExportedFields(fieldType) && !visiting[fieldType] {
+			childType := g.typeName(typeName + field.Name)
+			fmt.Fprintf(&decl, "\t%s %s\n", goName, childType)
+			fmt.Fprintf(value, "\t%s: ", goName)
+			g.generateNode(value, childType, fieldPath, fieldType, visiting)
+			value.WriteString(",\n")
+			continue
+		}
+		fmt.Fprintf(&decl, "\t%s string\n", goName)
+		fmt.Fprintf(value, "\t%s: %q,\n", goName, fieldPath)
+	}
+
+	decl.WriteString("}\n\n")
+	fmt.Fprintf(&decl, "// String returns the path of the node.\nfunc (p %s) String() string {\n\treturn p.path\n}\n\n", typeName)
+	g.types.Write(decl.Bytes())
+	value.WriteString("}")
+}
+
+// generatedFieldName returns the name of the generated field for the field name of
+// the struct type typ: name itself, or for "String", which is the name of the method
+// of the node, name followed by as many underscores as make it unused in typ.
+func generatedFieldName(name string, typ reflect.Type) string {
+	if name != "String" {
+		return name
+	}
+	for {
+		name += "_"
+		if _, taken := typ.FieldByName(name); !taken {
+			return name
+		}
+	}
+}
+
+// hasExportedFields reports whether a struct type has at least one exported field.
+func hasExportedFields(typ reflect.Type) bool {
+	for i := 0; i < typ.NumField(); i++ {
+		if typ.Field(i).IsExported() {
+			return true
+		}
+	}
+	return false
+}
+
+// lowerFirst returns s with its first letter in lower case.
+func lowerFirst(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	return string(unicode.ToLower(r)) + s[size:]
+}
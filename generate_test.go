@@ -0,0 +1,151 @@
+package empaths
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// GenUser is the model used by the generator tests
+type GenUser struct {
+	Name     string
+	Address  *GenAddress
+	Tags     []string
+	Scores   map[string]int
+	Manager  *GenUser
+	Contact  struct{ Email string }
+	internal string
+}
+
+// GenAddress is nested in GenUser
+type GenAddress struct {
+	City string
+	Geo  GenGeo
+}
+
+// GenGeo is nested in GenAddress
+type GenGeo struct {
+	Lat float64
+}
+
+func TestGeneratePaths(t *testing.T) {
+	src, err := GeneratePaths("models", "UserPaths", reflect.TypeOf(&GenUser{}))
+	if err != nil {
+		t.Fatalf("GeneratePaths returned error: %v", err)
+	}
+
+	file, err := parser.ParseFile(token.NewFileSet(), "user_paths.go", src, 0)
+	if err != nil {
+		t.Fatalf("generated source does not parse: %v\n%s", err, src)
+	}
+	if file.Name.Name != "models" {
+		t.Errorf("package = %q, want models", file.Name.Name)
+	}
+
+	for _, want := range []string{
+		"var UserPaths = userPaths{",
+		`City: ".Address.City",`,
+		`Lat:  ".Address.Geo.Lat",`,
+		`Email: ".Contact.Email",`,
+		`Manager: ".Manager",`,
+		`Tags:    ".Tags",`,
+		"func (p userPathsAddress) String() string",
+	} {
+		if !strings.Contains(string(src), want) {
+			t.Errorf("generated source does not contain %q:\n%s", want, src)
+		}
+	}
+	if strings.Contains(string(src), "internal") {
+		t.Errorf("generated source should not contain unexported fields:\n%s", src)
+	}
+
+	user := GenUser{
+		Name:    "Alice",
+		Address: &GenAddress{City: "NYC", Geo: GenGeo{Lat: 1}},
+		Tags:    []string{"a"},
+		Scores:  map[string]int{"a": 1},
+		Manager: &GenUser{},
+	}
+	user.Contact.Email = "a@example.com"
+
+	// Every generated path must resolve against a populated model.
+	ast.Inspect(file, func(node ast.Node) bool {
+		lit, ok := node.(*ast.BasicLit)
+		if !ok || lit.Kind != token.STRING {
+			return true
+		}
+		path, _ := strconv.Unquote(lit.Value)
+		if Resolve(path, user, nil) == nil {
+			t.Errorf("generated path %q does not resolve", path)
+		}
+		return true
+	})
+}
+
+// GenNamed has fields whose generated names need care.
+type GenNamed struct {
+	String      string
+	String_     int
+	Address     GenAddress
+	AddressGeo  GenGeo
+	Description struct{ String string }
+}
+
+func TestGeneratePaths_Names(t *testing.T) {
+	src, err := GeneratePaths("models", "NamedPaths", reflect.TypeOf(GenNamed{}))
+	if err != nil {
+		t.Fatalf("GeneratePaths returned error: %v", err)
+	}
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "named_paths.go", src, 0)
+	if err != nil {
+		t.Fatalf("generated source does not parse: %v\n%s", err, src)
+	}
+	if _, err := new(types.Config).Check("models", fset, []*ast.File{file}, nil); err != nil {
+		t.Fatalf("generated source does not compile: %v\n%s", err, src)
+	}
+
+	for _, want := range []string{
+		`String__: ".String",`,
+		`String_:  ".String_",`,
+		`String_: ".Description.String",`,
+		"Address     namedPathsAddress\n",
+		"AddressGeo  namedPathsAddressGeo2\n",
+		"Geo  namedPathsAddressGeo\n",
+		"type namedPathsAddressGeo2 struct",
+	} {
+		if !strings.Contains(string(src), want) {
+			t.Errorf("generated source does not contain %q:\n%s", want, src)
+		}
+	}
+	if strings.Count(string(src), "type namedPathsAddressGeo struct") != 1 {
+		t.Errorf("generated type names are not unique:\n%s", src)
+	}
+}
+
+func TestGeneratePaths_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		pkg  string
+		varN string
+		typ  reflect.Type
+	}{
+		{"not a struct", "models", "Paths", reflect.TypeOf(1)},
+		{"nil type", "models", "Paths", nil},
+		{"invalid variable name", "models", "my paths", reflect.TypeOf(GenUser{})},
+		{"invalid package name", "", "Paths", reflect.TypeOf(GenUser{})},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := GeneratePaths(tt.pkg, tt.varN, tt.typ); err == nil {
+				t.Errorf("GeneratePaths should return an error")
+			}
+		})
+	}
+}
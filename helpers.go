@@ -1,13 +1,21 @@
 package empaths
 
 import (
+	"cmp"
+	"encoding"
+	"encoding/json"
 	"fmt"
 	"reflect"
+	"sort"
 	"strconv"
+	"strings"
+	"sync"
 )
 
 // toString converts a value to its string representation efficiently.
 // It uses type switches for common types to avoid the overhead of fmt.Sprintf.
+// Byte slices (including named types such as json.RawMessage) are converted to the
+// string they hold.
 func toString(v any) string {
 	if v == nil {
 		return ""
@@ -44,13 +52,24 @@ func toString(v any) string {
 		return strconv.FormatFloat(val, 'f', -1, 64)
 	case float32:
 		return strconv.FormatFloat(float64(val), 'f', -1, 32)
+	case []byte:
+		return string(val)
 	default:
+		if value := reflect.ValueOf(v); value.Kind() == reflect.Slice && value.Type().Elem().Kind() == reflect.Uint8 {
+			return string(value.Bytes())
+		}
 		return fmt.Sprintf("%v", v)
 	}
 }
 
+// textUnmarshalerType is the type of encoding.TextUnmarshaler.
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
 // parseMapKey parses a string into a reflect.Value of the specified key type.
-// It handles string, int, uint, bool, and float key types.
+// It handles key types implementing encoding.TextUnmarshaler (such as UUIDs or
+// net/netip.Addr) with their UnmarshalText method, struct and array key types
+// written as JSON (such as {"Region":"us","AZ":"a"}), and string, int, uint, bool,
+// and float key types.
 //
 // Parameters:
 //   - keyStr: The string representation of the key
@@ -59,6 +78,14 @@ func toString(v any) string {
 // Returns:
 //   - The parsed key as a reflect.Value, or an invalid Value if parsing fails
 func parseMapKey(keyStr string, keyType reflect.Type) reflect.Value {
+	if reflect.PointerTo(keyType).Implements(textUnmarshalerType) {
+		key := reflect.New(keyType)
+		if err := key.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(keyStr)); err != nil {
+			return reflect.Value{}
+		}
+		return key.Elem()
+	}
+
 	key := reflect.New(keyType).Elem()
 
 	switch keyType.Kind() {
@@ -88,6 +115,10 @@ func parseMapKey(keyStr string, keyType reflect.Type) reflect.Value {
 			return reflect.Value{}
 		}
 		key.SetFloat(floatVal)
+	case reflect.Struct, reflect.Array:
+		if err := json.Unmarshal([]byte(keyStr), key.Addr().Interface()); err != nil {
+			return reflect.Value{}
+		}
 	default:
 		return reflect.Value{}
 	}
@@ -164,3 +195,155 @@ func extractValue(value reflect.Value) any {
 
 	return value.Interface()
 }
+
+// isTrue reports whether a resolved value counts as true, which is the case for
+// the boolean true and for the string "true" (case-insensitive).
+func isTrue(v any) bool {
+	if b, ok := v.(bool); ok {
+		return b
+	}
+	return strings.EqualFold(toString(v), "true")
+}
+
+// isNil reports whether v is nil or a nil pointer, map, slice, interface, channel, or
+// function.
+func isNil(v any) bool {
+	if v == nil {
+		return true
+	}
+	value := reflect.ValueOf(v)
+	switch value.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface, reflect.Chan, reflect.Func:
+		return value.IsNil()
+	default:
+		return false
+	}
+}
+
+// isBlank reports whether s consists only of spaces.
+func isBlank(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] != ' ' {
+			return false
+		}
+	}
+	return true
+}
+
+// toFloat64 converts a value of any integer or floating point kind to a float64.
+// It reports false for all other values, including numeric strings.
+func toFloat64(v any) (float64, bool) {
+	if v == nil {
+		return 0, false
+	}
+	value := reflect.ValueOf(v)
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(value.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(value.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return value.Float(), true
+	default:
+		return 0, false
+	}
+}
+
+// compareIntegers compares two operands exactly if both are integers, signed or
+// unsigned, which float64 cannot represent beyond 2^53.
+//
+// Returns:
+//   - -1, 0, or +1 if left is less than, equal to, or greater than right
+//   - false if an operand is not an integer
+func compareIntegers(left any, right any) (int, bool) {
+	if left == nil || right == nil {
+		return 0, false
+	}
+	l, r := reflect.ValueOf(left), reflect.ValueOf(right)
+	lSigned, lOK := integerKind(l.Kind())
+	rSigned, rOK := integerKind(r.Kind())
+	if !lOK || !rOK {
+		return 0, false
+	}
+	switch {
+	case lSigned && rSigned:
+		return cmp.Compare(l.Int(), r.Int()), true
+	case !lSigned && !rSigned:
+		return cmp.Compare(l.Uint(), r.Uint()), true
+	case lSigned:
+		if l.Int() < 0 {
+			return -1, true
+		}
+		return cmp.Compare(uint64(l.Int()), r.Uint()), true
+	default:
+		if r.Int() < 0 {
+			return 1, true
+		}
+		return cmp.Compare(l.Uint(), uint64(r.Int())), true
+	}
+}
+
+// integerKind reports whether kind is an integer kind and whether it is signed.
+func integerKind(kind reflect.Kind) (signed bool, ok bool) {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return true, true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return false, true
+	default:
+		return false, false
+	}
+}
+
+// syncMapType is the type of sync.Map, whose entries are accessed like map entries.
+var syncMapType = reflect.TypeOf((*sync.Map)(nil)).Elem()
+
+// asSyncMap returns value as a *sync.Map if it holds an addressable sync.Map.
+func asSyncMap(value reflect.Value) (*sync.Map, bool) {
+	if value.Type() != syncMapType || !value.CanAddr() || !value.Addr().CanInterface() {
+		return nil, false
+	}
+	return value.Addr().Interface().(*sync.Map), true
+}
+
+// getSyncMapValue retrieves a value from a sync.Map using a string key. Keys that
+// are not stored as strings are looked up as an int if the key is a number, so both
+// ".config.timeout" and ".ports[8080]" can be resolved.
+//
+// Parameters:
+//   - keyStr: The string representation of the key
+//   - m: The sync.Map to retrieve the value from
+//
+// Returns:
+//   - The value as a reflect.Value, or an invalid Value if the key doesn't exist
+func getSyncMapValue(keyStr string, m *sync.Map) reflect.Value {
+	result, ok := m.Load(keyStr)
+	if !ok {
+		if intKey, err := strconv.Atoi(keyStr); err == nil {
+			result, ok = m.Load(intKey)
+		}
+	}
+	return segmentValue(result, ok)
+}
+
+// syncMapValues returns the values of a sync.Map sorted by the string
+// representation of their keys.
+func syncMapValues(m *sync.Map) []reflect.Value {
+	type entry struct {
+		key   string
+		value any
+	}
+	var entries []entry
+	m.Range(func(key, value any) bool {
+		entries = append(entries, entry{key: toString(key), value: value})
+		return true
+	})
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].key < entries[j].key
+	})
+	values := make([]reflect.Value, len(entries))
+	for i, e := range entries {
+		values[i] = segmentValue(e.value, true)
+	}
+	return values
+}
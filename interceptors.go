@@ -0,0 +1,75 @@
+package empaths
+
+import (
+	"reflect"
+)
+
+// Interceptor post-processes a value resolved by a Resolver. It receives the value
+// and where it came from, and returns the value to use instead; returning v
+// unchanged leaves the result as it is.
+type Interceptor func(path string, v any) any
+
+// WithInterceptor adds an interceptor that is applied to the final value of every
+// evaluation, with the evaluated path expression as its path. This allows
+// cross-cutting concerns such as unit conversion or redaction to be handled in one
+// place instead of at every call site:
+//
+//	resolver := empaths.NewResolver(empaths.WithInterceptor(func(path string, v any) any {
+//		if strings.HasSuffix(path, ".Password") {
+//			return "***"
+//		}
+//		return v
+//	}))
+//
+// Interceptors are applied in the order they were added, each receiving the result
+// of the previous one. They are not applied if the evaluation is aborted.
+func WithInterceptor(fn Interceptor) Option {
+	return func(r *Resolver) {
+		r.interceptors = append(r.interceptors, fn)
+	}
+}
+
+// WithSegmentInterceptor adds an interceptor that is applied to the value of every
+// model path segment as soon as it is resolved, before the rest of the path is
+// resolved against it. Its path is the name of the field, method, or map key, or
+// the index, so ".Users[0].Name" calls it with "Users", "0", and "Name". Values are
+// passed as they are stored, without dereferencing pointers, which allows lazy
+// proxies to be unwrapped before the path continues into them. Segments that
+// cannot be resolved are not passed to it.
+//
+// Segment interceptors are applied in the order they were added, each receiving the
+// result of the previous one.
+func WithSegmentInterceptor(fn Interceptor) Option {
+	return func(r *Resolver) {
+		r.segmentInterceptors = append(r.segmentInterceptors, fn)
+	}
+}
+
+// intercept applies the interceptors of the Resolver to the final value of an
+// evaluation.
+func (r *Resolver) intercept(path string, v any) any {
+	for _, fn := range r.interceptors {
+		v = fn(path, v)
+	}
+	return v
+}
+
+// interceptSegment applies the segment interceptors of the evaluation to the value
+// of a resolved model path segment.
+//
+// Parameters:
+//   - name: The field, method, or map key name, or the index, of the segment
+//   - value: The resolved value of the segment
+//
+// Returns:
+//   - The value returned by the interceptors, or value itself if there are none
+func (s *evalState) interceptSegment(name string, value reflect.Value) reflect.Value {
+	if s.resolver == nil || len(s.resolver.segmentInterceptors) == 0 || !value.IsValid() || !value.CanInterface() {
+		return value
+	}
+	v := value.Interface()
+	for _, fn := range s.resolver.segmentInterceptors {
+		v = fn(name, v)
+	}
+	return segmentValue(v, true)
+}
@@ -0,0 +1,111 @@
+package empaths
+
+import (
+	"strings"
+	"testing"
+)
+
+// lazyScores stands in for a lazily loaded value that must be unwrapped before a
+// path can continue into it.
+type lazyScores struct {
+	load func() map[string]int
+}
+
+func TestResolver_WithInterceptor(t *testing.T) {
+	team := createTestTeam()
+	redact := WithInterceptor(func(path string, v any) any {
+		if strings.HasSuffix(path, ".Age") {
+			return "redacted"
+		}
+		return v
+	})
+	upper := WithInterceptor(func(path string, v any) any {
+		if s, ok := v.(string); ok {
+			return strings.ToUpper(s)
+		}
+		return v
+	})
+	resolver := NewResolver(redact, upper)
+
+	tests := []struct {
+		name     string
+		path     string
+		expected any
+	}{
+		{"unchanged", ".Scores.math", 95},
+		{"redacted", ".Users[0].Age", "REDACTED"},
+		{"chained", ".Users[0].Name", "ALICE"},
+		{"concatenation", "'hi ' .Users[1].Name", "HI BOB"},
+		{"nil result", ".Missing", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := resolver.Resolve(tt.path, team, nil); result != tt.expected {
+				t.Errorf("Resolve(%q) = %v, want %v", tt.path, result, tt.expected)
+			}
+		})
+	}
+
+	t.Run("not applied when aborted", func(t *testing.T) {
+		called := false
+		resolver := NewResolver(WithMaxSegments(1), WithInterceptor(func(path string, v any) any {
+			called = true
+			return v
+		}))
+		if _, err := resolver.ResolveErr(".Users[0].Name", team, nil); err == nil {
+			t.Fatal("ResolveErr() error = nil, want limit exceeded")
+		}
+		if called {
+			t.Error("interceptor called for an aborted evaluation")
+		}
+	})
+}
+
+func TestResolver_WithSegmentInterceptor(t *testing.T) {
+	data := map[string]any{
+		"Team": createTestTeam(),
+		"Lazy": &lazyScores{load: func() map[string]int { return map[string]int{"math": 70} }},
+	}
+	var segments []string
+	resolver := NewResolver(
+		WithSegmentInterceptor(func(name string, v any) any {
+			segments = append(segments, name)
+			return v
+		}),
+		WithSegmentInterceptor(func(name string, v any) any {
+			if lazy, ok := v.(*lazyScores); ok {
+				return lazy.load()
+			}
+			if name == "Name" {
+				return "***"
+			}
+			return v
+		}),
+	)
+
+	tests := []struct {
+		name     string
+		path     string
+		expected any
+		segments []string
+	}{
+		{"fields and index", ".Team.Users[0].Name", "***", []string{"Team", "Users", "0", "Name"}},
+		{"quoted key", ".Team.Scores['math']", 95, []string{"Team", "Scores", "math"}},
+		{"unwrapped proxy", ".Lazy.math", 70, []string{"Lazy", "math"}},
+		{"missing segment", ".Team.Missing.Name", nil, []string{"Team"}},
+		{"wildcard elements", "join(.Team.Users[*].Name, ',')", "***,***,***", []string{"Team", "Users", "Name", "Name", "Name"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			segments = nil
+			if result := resolver.Resolve(tt.path, data, nil); result != tt.expected {
+				t.Errorf("Resolve(%q) = %v, want %v", tt.path, result, tt.expected)
+			}
+			if strings.Join(segments, " ") != strings.Join(tt.segments, " ") {
+				t.Errorf("Resolve(%q) intercepted %v, want %v", tt.path, segments, tt.segments)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package empaths
+
+import (
+	"strings"
+)
+
+// Interpolate replaces the placeholders in text, path expressions enclosed in "{{"
+// and "}}", with their values. See Resolver.Interpolate.
+//
+// Parameters:
+//   - text: The text with placeholders
+//   - data: The data model to evaluate the expressions against
+//   - refResolver: Optional function to resolve external references (prefixed with ':')
+//
+// Returns:
+//   - The text with the placeholders replaced
+func Interpolate(text string, data any, refResolver ReferenceResolver) string {
+	return defaultResolver.Interpolate(text, data, refResolver)
+}
+
+// Interpolate replaces the placeholders in text, path expressions enclosed in "{{"
+// and "}}", with their values, converted to strings like the operands of a
+// concatenation:
+//
+//	resolver.Interpolate("Hello {{ .User.Name }}, you have {{ count(.Messages) }} new messages.", data, nil)
+//	// "Hello Alice, you have 3 new messages."
+//
+// Each placeholder is evaluated with the options of the Resolver, like a separate
+// call of ResolveString; a placeholder that resolves to nil is replaced with "".
+// A "}}" inside a string literal does not end the placeholder, so "{{ '}}' }}"
+// produces "}}", and "{{ '{{' }}" produces "{{". A "{{" without a closing "}}" is
+// kept as it is.
+//
+// Parameters:
+//   - text: The text with placeholders
+//   - data: The data model to evaluate the expressions against
+//   - refResolver: Optional function to resolve external references (prefixed with ':')
+//
+// Returns:
+//   - The text with the placeholders replaced
+func (r *Resolver) Interpolate(text string, data any, refResolver ReferenceResolver) string {
+	var sb strings.Builder
+	for {
+		start := strings.Index(text, "{{")
+		if start == -1 {
+			break
+		}
+		end := placeholderEnd(text, start+2)
+		if end == -1 {
+			break
+		}
+		sb.WriteString(text[:start])
+		if expr := strings.TrimSpace(text[start+2 : end]); expr != "" {
+			sb.WriteString(r.ResolveString(expr, data, refResolver))
+		}
+		text = text[end+2:]
+	}
+	sb.WriteString(text)
+	return sb.String()
+}
+
+// placeholderEnd returns the index of the "}}" that ends the placeholder whose
+// expression starts at index, skipping string literals, or -1 if there is none.
+func placeholderEnd(text string, index int) int {
+	for index < len(text) {
+		switch c := text[index]; c {
+		case '\'', '"':
+			index++
+			for index < len(text) && text[index] != c {
+				if text[index] == '\\' {
+					index++
+				}
+				index++
+			}
+			index++
+		case '}':
+			if index+1 < len(text) && text[index+1] == '}' {
+				return index
+			}
+			index++
+		default:
+			index++
+		}
+	}
+	return -1
+}
@@ -0,0 +1,57 @@
+package empaths
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestInterpolate(t *testing.T) {
+	team := createTestTeam()
+	refs := func(name string, data any) any {
+		if name == "greeting" {
+			return "Hello"
+		}
+		return nil
+	}
+
+	tests := []struct {
+		name     string
+		text     string
+		expected string
+	}{
+		{"no placeholders", "Hello world", "Hello world"},
+		{"placeholder", "Hello {{ .Users[0].Name }}!", "Hello Alice!"},
+		{"several placeholders", "{{.Users[0].Name}} and {{ .Users[1].Name }}", "Alice and Bob"},
+		{"expression", "{{ count(.Users[?.Active]) }} active, {{ .Users[2].Name ' (' .Users[2].Age ')' }}", "2 active, Carol (35)"},
+		{"reference", "{{ :greeting }}, {{ .Users[1].Name | substr(0, 2) }}", "Hello, Bo"},
+		{"comparison", "admin: {{ ?.Users[0].Age > 18 }}", "admin: true"},
+		{"nil value", "[{{ .Missing }}]", "[]"},
+		{"empty placeholder", "[{{ }}]", "[]"},
+		{"braces in string literal", "{{ '}}' }} and {{ \"{{\" }}", "}} and {{"},
+		{"escaped quote", `{{ 'it\'s }}' }}`, "it's }}"},
+		{"unterminated", "Hello {{ .Users[0].Name", "Hello {{ .Users[0].Name"},
+		{"unterminated after placeholder", "{{ .Users[0].Name }} {{ x", "Alice {{ x"},
+		{"single braces", "{ .Users[0].Name }", "{ .Users[0].Name }"},
+		{"characters", "Grüße, {{ .Users[0].Name }} ✓", "Grüße, Alice ✓"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := Interpolate(tt.text, team, refs); result != tt.expected {
+				t.Errorf("Interpolate(%q) = %q, want %q", tt.text, result, tt.expected)
+			}
+		})
+	}
+
+	t.Run("resolver options", func(t *testing.T) {
+		resolver := NewResolver(WithFormatter(func(v any, hint string) (string, bool) {
+			if n, ok := v.(int); ok {
+				return fmt.Sprintf("#%d", n), true
+			}
+			return "", false
+		}))
+		if result := resolver.Interpolate("Age {{ .Users[1].Age }}", team, nil); result != "Age #25" {
+			t.Errorf("Interpolate() = %q, want %q", result, "Age #25")
+		}
+	})
+}
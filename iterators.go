@@ -0,0 +1,122 @@
+package empaths
+
+import (
+	"reflect"
+	"strconv"
+)
+
+// Iterators are recognized by the shape of their type rather than by importing the
+// iter package, so any function of the form of iter.Seq[V] (func(yield func(V) bool))
+// or iter.Seq2[K, V] (func(yield func(K, V) bool)) can be used, including named
+// types and iterators written before Go 1.23.
+
+// iteratorArity returns 1 if typ has the form of iter.Seq, 2 if it has the form of
+// iter.Seq2, and 0 otherwise.
+func iteratorArity(typ reflect.Type) int {
+	if typ.Kind() != reflect.Func || typ.NumIn() != 1 || typ.NumOut() != 0 {
+		return 0
+	}
+	yield := typ.In(0)
+	if yield.Kind() != reflect.Func || yield.NumOut() != 1 || yield.Out(0).Kind() != reflect.Bool {
+		return 0
+	}
+	if n := yield.NumIn(); n == 1 || n == 2 {
+		return n
+	}
+	return 0
+}
+
+// iterate calls fn for the elements of an iterator until fn returns false or the
+// iterator is exhausted. The key of an element of an iter.Seq is its position.
+//
+// Parameters:
+//   - value: The iterator, a function of the form of iter.Seq or iter.Seq2
+//   - fn: Called with the key and value of each element
+func iterate(value reflect.Value, fn func(key reflect.Value, elem reflect.Value) bool) {
+	if value.IsNil() || !value.CanInterface() {
+		return
+	}
+	yieldType := value.Type().In(0)
+	position := 0
+	yield := reflect.MakeFunc(yieldType, func(args []reflect.Value) []reflect.Value {
+		var more bool
+		if len(args) == 1 {
+			more = fn(reflect.ValueOf(position), args[0])
+			position++
+		} else {
+			more = fn(args[0], args[1])
+		}
+		return []reflect.Value{reflect.ValueOf(more).Convert(yieldType.Out(0))}
+	})
+	value.Call([]reflect.Value{yield})
+}
+
+// iteratorElement returns an element of an iterator, consuming it only up to that
+// element: the element at an index of an iter.Seq, or the value for a key of an
+// iter.Seq2, where keys are compared by their string representation.
+//
+// Parameters:
+//   - key: The index or key
+//   - value: The iterator
+//
+// Returns:
+//   - The element, or an invalid reflect.Value if there is no such element
+func iteratorElement(key string, value reflect.Value) reflect.Value {
+	var result reflect.Value
+	switch iteratorArity(value.Type()) {
+	case 1:
+		index, err := strconv.Atoi(key)
+		if err != nil || index < 0 {
+			return reflect.Value{}
+		}
+		iterate(value, func(position reflect.Value, elem reflect.Value) bool {
+			if int(position.Int()) < index {
+				return true
+			}
+			result = elem
+			return false
+		})
+	case 2:
+		iterate(value, func(k reflect.Value, elem reflect.Value) bool {
+			if toString(extractValue(k)) != key {
+				return true
+			}
+			result = elem
+			return false
+		})
+	}
+	return result
+}
+
+// iteratorElements returns all elements of an iterator in the order they are
+// produced (the values of an iter.Seq2), or nil if value is not an iterator.
+func iteratorElements(value reflect.Value) []reflect.Value {
+	if iteratorArity(value.Type()) == 0 {
+		return nil
+	}
+	elements := []reflect.Value{}
+	iterate(value, func(_ reflect.Value, elem reflect.Value) bool {
+		elements = append(elements, elem)
+		return true
+	})
+	return elements
+}
+
+// iteratorElemType returns the type of the elements of an iterator type (the values
+// of an iter.Seq2).
+func iteratorElemType(typ reflect.Type) reflect.Type {
+	yield := typ.In(0)
+	return yield.In(yield.NumIn() - 1)
+}
+
+// firstIteratorElement returns the first element of an iterator (the first value of
+// an iter.Seq2), consuming only that element, or an invalid reflect.Value if the
+// iterator is empty.
+func firstIteratorElement(value reflect.Value) reflect.Value {
+	var first reflect.Value
+	iterate(value, func(_ reflect.Value, elem reflect.Value) bool {
+		first = elem
+		return false
+	})
+	return first
+}
@@ -0,0 +1,148 @@
+package empaths
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Seq has the form of iter.Seq, which cannot be imported with the minimum Go version
+// of this module.
+type Seq[V any] func(yield func(V) bool)
+
+// seqOf returns an iterator over values that records how many were produced.
+func seqOf[V any](produced *int, values ...V) Seq[V] {
+	return func(yield func(V) bool) {
+		for _, v := range values {
+			*produced++
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// pairsOf returns an iterator of the form of iter.Seq2 over the given keys and values.
+func pairsOf[K any, V any](keys []K, values []V) func(yield func(K, V) bool) {
+	return func(yield func(K, V) bool) {
+		for i, k := range keys {
+			if !yield(k, values[i]) {
+				return
+			}
+		}
+	}
+}
+
+func TestResolve_Iterators(t *testing.T) {
+	produced := 0
+	data := map[string]any{
+		"Users":  seqOf(&produced, Member{Name: "Alice", Active: true}, Member{Name: "Bob"}, Member{Name: "Carol", Active: true}),
+		"Scores": pairsOf([]string{"math", "science"}, []int{95, 88}),
+		"ByID":   pairsOf([]int{7, 9}, []string{"seven", "nine"}),
+		"Nil":    Seq[int](nil),
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		expected any
+		produced int
+	}{
+		{"index", ".Users[1].Name", "Bob", 2},
+		{"first index", ".Users[0].Name", "Alice", 1},
+		{"index out of range", ".Users[5]", nil, 3},
+		{"negative index", ".Users[-1]", nil, 0},
+		{"wildcard", "join(.Users[*].Name, ',')", "Alice,Bob,Carol", 3},
+		{"filter", "count(.Users[?.Active==true])", 2, 3},
+		{"seq2 field", ".Scores.science", 88, 0},
+		{"seq2 key", ".Scores['math']", 95, 0},
+		{"seq2 int key", ".ByID[9]", "nine", 0},
+		{"seq2 missing key", ".Scores.art", nil, 0},
+		{"seq2 wildcard", "join(.ByID[*], ',')", "seven,nine", 0},
+		{"nil iterator", ".Nil[0]", nil, 0},
+		{"nil iterator wildcard", "count(.Nil[*])", 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			produced = 0
+			if result := Resolve(tt.path, data, nil); result != tt.expected {
+				t.Errorf("Resolve(%q) = %v, want %v", tt.path, result, tt.expected)
+			}
+			if produced != tt.produced {
+				t.Errorf("Resolve(%q) consumed %d elements, want %d", tt.path, produced, tt.produced)
+			}
+		})
+	}
+}
+
+func TestFunctions_Iterators(t *testing.T) {
+	produced := 0
+	data := map[string]any{
+		"Users":  seqOf(&produced, Member{Name: "Alice", Active: true}, Member{Name: "Bob"}, Member{Name: "Carol", Active: true}),
+		"Tags":   seqOf(&produced, "b", "a", "b"),
+		"Scores": pairsOf([]string{"math", "science", "art"}, []int{95, 88, 95}),
+		"Nil":    Seq[int](nil),
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		expected any
+		produced int
+	}{
+		{"count", "count(.Users)", 3, 3},
+		{"count seq2", "count(.Scores)", 3, 0},
+		{"count nil iterator", "count(.Nil)", 0, 0},
+		{"len", "len(.Tags)", 3, 3},
+		{"first", "first(.Users).Name", "Alice", 1},
+		{"first seq2", "first(.Scores)", 95, 0},
+		{"first nil iterator", "first(.Nil)", nil, 0},
+		{"last", "last(.Users).Name", "Carol", 3},
+		{"join", "join(.Tags, ',')", "b,a,b", 3},
+		{"reverse", "join(reverse(.Tags), ',')", "b,a,b", 3},
+		{"unique", "join(unique(.Tags), ',')", "b,a", 3},
+		{"unique seq2", "join(unique(.Scores), ',')", "95,88", 0},
+		{"isempty", "isempty(.Users)", false, 1},
+		{"isempty nil iterator", "isempty(.Nil)", true, 0},
+		{"sort", "join(sort(.Tags), ',')", "a,b,b", 3},
+		{"groupby", "count(groupby(.Users, '.Active')['true'])", 2, 3},
+		{"groupby seq2", "count(groupby(.Scores, '.')['95'])", 2, 0},
+		{"groupby element", "groupby(.Users, '.Active')['false'][0].Name", "Bob", 3},
+		{"each", "each(.Tags, .)", "bab", 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			produced = 0
+			if result := Resolve(tt.path, data, nil); result != tt.expected {
+				t.Errorf("Resolve(%q) = %v, want %v", tt.path, result, tt.expected)
+			}
+			if produced != tt.produced {
+				t.Errorf("Resolve(%q) consumed %d elements, want %d", tt.path, produced, tt.produced)
+			}
+		})
+	}
+}
+
+func TestIteratorArity(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    any
+		expected int
+	}{
+		{"seq", Seq[int](nil), 1},
+		{"seq2", func(yield func(string, int) bool) {}, 2},
+		{"thunk", func() int { return 1 }, 0},
+		{"yield without result", func(yield func(int)) {}, 0},
+		{"iterator with result", func(yield func(int) bool) bool { return true }, 0},
+		{"three values", func(yield func(int, int, int) bool) {}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if arity := iteratorArity(reflect.TypeOf(tt.value)); arity != tt.expected {
+				t.Errorf("iteratorArity(%T) = %d, want %d", tt.value, arity, tt.expected)
+			}
+		})
+	}
+}
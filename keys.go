@@ -0,0 +1,86 @@
+package empaths
+
+import (
+	"reflect"
+)
+
+// KeyResolver converts a map key written in a path into a key of a map's key type.
+// It returns false if it does not handle the key or the key type, in which case the
+// key is parsed as usual.
+//
+// Parameters:
+//   - raw: The key as written in the path, without quotes
+//   - keyType: The key type of the map
+//
+// Returns:
+//   - The key, which must be assignable or convertible to keyType
+//   - Whether the key was resolved
+type KeyResolver func(raw string, keyType reflect.Type) (reflect.Value, bool)
+
+// WithKeyResolver adds a KeyResolver that is consulted for the keys of maps in model
+// paths (".Map.key" and ".Map[key]") before they are parsed as usual. This makes maps
+// with key types empaths cannot parse by itself reachable, such as maps keyed by
+// pointers or interfaces, or by enums with custom names:
+//
+//	resolver := empaths.NewResolver(empaths.WithKeyResolver(func(raw string, keyType reflect.Type) (reflect.Value, bool) {
+//		if keyType != reflect.TypeOf(Priority(0)) {
+//			return reflect.Value{}, false
+//		}
+//		p, err := ParsePriority(raw)
+//		return reflect.ValueOf(p), err == nil
+//	}))
+//	resolver.Resolve(".Queues.high", data, nil)
+//
+// Key resolvers are consulted in the order they were added until one resolves the
+// key. A key that is resolved but not stored in the map resolves to nil; keys of the
+// wrong type are ignored.
+func WithKeyResolver(fn KeyResolver) Option {
+	return func(r *Resolver) {
+		if fn != nil {
+			r.keyResolvers = append(r.keyResolvers, fn)
+		}
+	}
+}
+
+// resolveKey looks up a map key with the key resolvers of the Resolver.
+//
+// Parameters:
+//   - raw: The key as written in the path, without quotes
+//   - value: The value the key is resolved against
+//
+// Returns:
+//   - The map value, or an invalid reflect.Value if the key is not in the map
+//   - Whether a key resolver resolved the key; if not, the key is resolved as usual
+func (s *evalState) resolveKey(raw string, value reflect.Value) (reflect.Value, bool) {
+	if s.resolver == nil || len(s.resolver.keyResolvers) == 0 {
+		return reflect.Value{}, false
+	}
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return reflect.Value{}, false
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Map {
+		return reflect.Value{}, false
+	}
+	if _, ok := asPathResolvable(value); ok {
+		return reflect.Value{}, false
+	}
+
+	keyType := value.Type().Key()
+	for _, fn := range s.resolver.keyResolvers {
+		key, ok := fn(raw, keyType)
+		if !ok || !key.IsValid() {
+			continue
+		}
+		if !key.Type().AssignableTo(keyType) {
+			if !key.Type().ConvertibleTo(keyType) {
+				continue
+			}
+			key = key.Convert(keyType)
+		}
+		return value.MapIndex(key), true
+	}
+	return reflect.Value{}, false
+}
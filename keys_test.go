@@ -0,0 +1,81 @@
+package empaths
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+// Priority is an enum whose keys are written by name in paths.
+type Priority int
+
+var priorityNames = map[string]Priority{"low": 1, "high": 2}
+
+func TestResolver_WithKeyResolver(t *testing.T) {
+	admin := &Member{Name: "Alice"}
+	data := map[string]any{
+		"Queues":  map[Priority]int{1: 10, 2: 3},
+		"Roles":   map[*Member]string{admin: "admin"},
+		"ByAny":   map[any]string{42: "answer", "x": "letter"},
+		"Numbers": map[int]string{7: "seven"},
+	}
+	resolver := NewResolver(
+		WithKeyResolver(func(raw string, keyType reflect.Type) (reflect.Value, bool) {
+			if keyType != reflect.TypeOf(Priority(0)) {
+				return reflect.Value{}, false
+			}
+			p, ok := priorityNames[raw]
+			return reflect.ValueOf(p), ok
+		}),
+		WithKeyResolver(func(raw string, keyType reflect.Type) (reflect.Value, bool) {
+			if keyType.Kind() == reflect.Ptr && raw == "admin" {
+				return reflect.ValueOf(admin), true
+			}
+			return reflect.Value{}, false
+		}),
+		WithKeyResolver(func(raw string, keyType reflect.Type) (reflect.Value, bool) {
+			if keyType.Kind() != reflect.Interface {
+				return reflect.Value{}, false
+			}
+			if n, err := strconv.Atoi(raw); err == nil {
+				return reflect.ValueOf(n), true
+			}
+			return reflect.ValueOf(raw), true
+		}),
+		WithKeyResolver(func(raw string, keyType reflect.Type) (reflect.Value, bool) {
+			// Keys of the wrong type are ignored.
+			return reflect.ValueOf(struct{}{}), true
+		}),
+	)
+
+	tests := []struct {
+		name     string
+		path     string
+		expected any
+	}{
+		{"enum name", ".Queues.high", 3},
+		{"enum in brackets", ".Queues['low']", 10},
+		{"unknown enum name", ".Queues.urgent", nil},
+		{"enum number", ".Queues[2]", 3},
+		{"pointer key", ".Roles.admin", "admin"},
+		{"interface key", ".ByAny[42]", "answer"},
+		{"interface string key", ".ByAny.x", "letter"},
+		{"resolved key not in map", ".ByAny.y", nil},
+		{"parsed as usual", ".Numbers[7]", "seven"},
+		{"comparison", "?.Queues.high < .Queues.low", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := resolver.Resolve(tt.path, data, nil); result != tt.expected {
+				t.Errorf("Resolve(%q) = %v, want %v", tt.path, result, tt.expected)
+			}
+		})
+	}
+
+	t.Run("package-level Resolve parses keys as usual", func(t *testing.T) {
+		if result := Resolve(".Queues.high", data, nil); result != nil {
+			t.Errorf("Resolve() = %v, want nil", result)
+		}
+	})
+}
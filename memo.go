@@ -0,0 +1,118 @@
+package empaths
+
+import "reflect"
+
+// Memoization of model paths
+//
+// An expression often refers to the same object several times, e.g.
+// ".User.Profile.FirstName ' ' .User.Profile.LastName". Without memoization every
+// model path is walked from the root again, calling methods and copying map values
+// each time. When an expression contains more than one model path, the value of
+// every path prefix ("User", "User.Profile", ...) is therefore remembered for the
+// rest of the evaluation, so each prefix is resolved (and each method called) only
+// once. Expressions with a single model path are resolved without the cache.
+//
+// Only paths resolved against the data passed to Resolve are memoized. Paths inside
+// filters and after variables are resolved against other values and are never
+// cached.
+
+// resolveMemoized resolves a model path (without its leading '.') against the
+// top-level data, reusing and recording the values of its prefixes in state.memo.
+// Wildcards and filters end the memoized part: the rest of the path, starting at
+// the first projection, is resolved normally.
+//
+// Parameters:
+//   - path: The model path (e.g. "User.Profile.Name")
+//   - value: The top-level data
+//   - state: The state of the current evaluation
+//
+// Returns:
+//   - The resolved reflect.Value
+func resolveMemoized(path string, value reflect.Value, state *evalState) reflect.Value {
+	start := 0
+	// owner is the value holding the collection of the current bracket segments.
+	owner := value
+	for start < len(path) {
+		end := nextSegmentEnd(path, start)
+		if path[start] == '[' && end-start > 2 && (path[start+1] == '*' || path[start+1] == '?') {
+			state.owner = owner
+			return resolvePathAgainstValue(path[start:], value, state)
+		}
+		if path[start] != '[' {
+			owner = value
+		}
+
+		prefix := path[:end]
+		if cached, ok := state.memo[prefix]; ok {
+			value = cached
+			state.memoHits++
+		} else {
+			if start > 0 && state.diagnose() && isNilIntermediate(value) {
+				// Report the nil value with the rest of the whole path.
+				state.failNil(path[start:])
+				return reflect.Value{}
+			}
+			// The segment ends the model path while it is resolved, so that errors and
+			// the Authorizer see the path up to it.
+			modelPath := state.modelPath
+			state.modelPath = modelPath[:len(modelPath)-len(path)+end]
+			value = resolvePathAgainstValue(path[start:end], value, state)
+			state.modelPath = modelPath
+			if state.err != nil {
+				return reflect.Value{}
+			}
+			state.memo[prefix] = value
+		}
+		if !value.IsValid() {
+			return value
+		}
+		start = end
+	}
+	return value
+}
+
+// nextSegmentEnd returns the index just after the model path segment that starts
+// at start: a bracket segment including its closing bracket, or a name up to the
+// next '.' or '['.
+func nextSegmentEnd(path string, start int) int {
+	if path[start] == '[' {
+		closeIndex := findClosingASCII(path, start)
+		if closeIndex == -1 {
+			return len(path)
+		}
+		return closeIndex + 1
+	}
+	index := start
+	if path[index] == '.' {
+		index++
+	}
+	for index < len(path) && path[index] != '.' && path[index] != '[' {
+		index++
+	}
+	return index
+}
+
+// countModelPaths counts the model paths in an expression that are resolved against
+// its data, stopping once limit is reached. Model paths inside brackets (filters) are
+// part of the enclosing model path and not counted separately.
+func countModelPaths(path string, limit int) int {
+	count := 0
+	for index := 0; index < len(path) && count < limit; {
+		switch c := path[index]; {
+		case c == '\'' || c == '"':
+			index = skipQuotedASCII(path, index)
+		case c == ':':
+			_, index = readReferenceName(path, index+1)
+		case c == '.':
+			count++
+			_, index = readModelPathASCII(path, index+1)
+		case isNumberStart(path, index):
+			_, index = resolveNumberLiteralASCII(path, index)
+		case isIdentStart(path, index):
+			_, index = readIdentifier(path, index)
+		default:
+			index++
+		}
+	}
+	return count
+}
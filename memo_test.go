@@ -0,0 +1,78 @@
+package empaths
+
+import (
+	"testing"
+)
+
+// MemoUser counts how often its Profile method is called
+type MemoUser struct {
+	calls *int
+	Tags  []string
+}
+
+// MemoProfile is returned by MemoUser.Profile
+type MemoProfile struct {
+	FirstName string
+	LastName  string
+	Friends   []MemoUser
+}
+
+// Profile returns a new profile on every call
+func (u MemoUser) Profile() MemoProfile {
+	*u.calls++
+	return MemoProfile{FirstName: "Ada", LastName: "Lovelace", Friends: []MemoUser{u, u}}
+}
+
+func TestResolve_Memoization(t *testing.T) {
+	tests := []struct {
+		name          string
+		path          string
+		expected      any
+		expectedCalls int
+	}{
+		{"single path", ".User.Profile.FirstName", "Ada", 1},
+		{"concatenation", ".User.Profile.FirstName ' ' .User.Profile.LastName", "Ada Lovelace", 1},
+		{"comparison", "?.User.Profile.FirstName == .User.Profile.FirstName", true, 1},
+		{"function arguments", "join([.User.Profile.FirstName, .User.Profile.LastName], '-')", "Ada-Lovelace", 1},
+		{"index segments", ".User.Profile.Friends[0].Profile.FirstName .User.Profile.Friends[1].Tags", "Ada", 2},
+		{"projection after memoized prefix", "count(.User.Profile.Friends[*]) .User.Profile.LastName", "2Lovelace", 1},
+		{"filters are not memoized", "count(.User.Profile.Friends[?.Profile.FirstName=='Ada'])", 2, 3},
+		{"missing prefix", ".User.Nope.X .User.Nope.Y", "", 0},
+		{"binding", "let $p = .User.Profile; $p.FirstName ' ' $p.LastName", "Ada Lovelace", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			data := map[string]any{"User": MemoUser{calls: &calls}}
+			result := Resolve(tt.path, data, nil)
+			if result != tt.expected {
+				t.Errorf("Resolve(%q) = %v, want %v", tt.path, result, tt.expected)
+			}
+			if calls != tt.expectedCalls {
+				t.Errorf("Resolve(%q) called Profile %d times, want %d", tt.path, calls, tt.expectedCalls)
+			}
+		})
+	}
+}
+
+func TestCountModelPaths(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected int
+	}{
+		{".User.Name", 1},
+		{".A .B", 2},
+		{"'.A' .B", 1},
+		{".Users[?.Name=='x'].Age", 1},
+		{"count(.A) ' of ' count(.B)", 2},
+		{"1.5 :ref.x", 0},
+		{".A .B .C .D", 3},
+	}
+
+	for _, tt := range tests {
+		if result := countModelPaths(tt.path, 3); result != tt.expected {
+			t.Errorf("countModelPaths(%q) = %d, want %d", tt.path, result, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package empaths
+
+import (
+	"time"
+)
+
+// Metrics describes a single evaluation of a path expression by a Resolver.
+type Metrics struct {
+	// Path is the evaluated path expression.
+	Path string
+	// Duration is the time the evaluation took.
+	Duration time.Duration
+	// Segments is the number of model path segments (field, method, index, and key
+	// accesses) that were resolved, as counted for WithMaxSegments.
+	Segments int
+	// CacheHit reports whether the value of a model path prefix was reused instead
+	// of being resolved again, as for ".User.First ' ' .User.Last".
+	CacheHit bool
+	// Err is the error the evaluation failed with, as returned by ResolveErr, or nil
+	// if it succeeded.
+	Err error
+	// Secret reports whether the evaluation read a secret value (see WithSecrets).
+	Secret bool
+}
+
+// Observer receives the Metrics of evaluations. It is called synchronously after
+// each evaluation and must be safe for concurrent use if the Resolver is used
+// concurrently.
+type Observer func(m Metrics)
+
+// WithMetrics makes a Resolver report the Metrics of every evaluation through
+// Resolve, ResolveErr, ResolveCtx, and the typed variants such as ResolveString to
+// observer, so evaluation latency can be recorded without wrapping every call site:
+//
+//	resolver := empaths.NewResolver(empaths.WithMetrics(func(m empaths.Metrics) {
+//		evalSeconds.WithLabelValues(m.Path).Observe(m.Duration.Seconds())
+//	}))
+//
+// Paths that are rejected before evaluation, e.g. by the access rules, are reported
+// with zero segments and their error.
+func WithMetrics(observer Observer) Option {
+	return func(r *Resolver) {
+		r.observer = observer
+	}
+}
+
+// observe reports the metrics of an evaluation that started at start. state is nil
+// if the path was not evaluated.
+func (r *Resolver) observe(path string, start time.Time, state *evalState, err error) {
+	m := Metrics{Path: path, Duration: time.Since(start), Err: err}
+	if state != nil {
+		m.Segments = state.segments
+		m.CacheHit = state.memoHits > 0
+		m.Secret = state.readSecret()
+	}
+	r.observer(m)
+}
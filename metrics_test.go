@@ -0,0 +1,51 @@
+package empaths
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestResolver_WithMetrics(t *testing.T) {
+	team := createTestTeam()
+	var observed []Metrics
+	observe := WithMetrics(func(m Metrics) {
+		observed = append(observed, m)
+	})
+
+	tests := []struct {
+		name     string
+		opts     []Option
+		path     string
+		segments int
+		cacheHit bool
+		err      error
+	}{
+		{"single path", nil, ".Users[0].Name", 3, false, nil},
+		{"memoized prefix", nil, ".Users[0].Name ' ' .Users[0].Age", 4, true, nil},
+		{"unresolved path", nil, ".Missing", 1, false, nil},
+		{"limit exceeded", []Option{WithMaxSegments(1)}, ".Users[0].Name", 2, false, ErrLimitExceeded},
+		{"access denied", []Option{WithDeniedFields([]string{"Users"})}, ".Users[0].Name", 0, false, ErrAccessDenied},
+		{"empty path", nil, "", 0, false, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			observed = nil
+			resolver := NewResolver(append(tt.opts, observe)...)
+			resolver.Resolve(tt.path, team, nil)
+			if len(observed) != 1 {
+				t.Fatalf("Resolve(%q) observed %d evaluations, want 1", tt.path, len(observed))
+			}
+			m := observed[0]
+			if m.Path != tt.path || m.Segments != tt.segments || m.CacheHit != tt.cacheHit {
+				t.Errorf("Resolve(%q) observed %+v, want %d segments and cache hit %v", tt.path, m, tt.segments, tt.cacheHit)
+			}
+			if !errors.Is(m.Err, tt.err) || (tt.err == nil) != (m.Err == nil) {
+				t.Errorf("Resolve(%q) observed error %v, want %v", tt.path, m.Err, tt.err)
+			}
+			if m.Duration < 0 {
+				t.Errorf("Resolve(%q) observed duration %v", tt.path, m.Duration)
+			}
+		})
+	}
+}
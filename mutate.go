@@ -0,0 +1,520 @@
+package empaths
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+// Delete removes the value addressed by a model path from data.
+//
+// What "remove" means depends on the container holding the value:
+//   - Map entries are deleted.
+//   - Slice elements are removed and the following elements shift down by one.
+//   - Struct fields and array elements are set to their zero value.
+//
+// Struct fields, array elements, and slice lengths can only be changed if they are
+// addressable, so data usually has to be a pointer (maps can be modified in place).
+// Map values are copied, modified, and stored back, so paths may lead through maps
+// holding structs. The path must be a plain model path; wildcards and filters are
+// not supported.
+//
+// Parameters:
+//   - path: The model path of the value to delete (e.g. ".Spec.Labels['app']")
+//   - data: The data to modify
+//
+// Returns:
+//   - Error if the path is invalid or cannot be resolved, or if the value cannot be
+//     modified: a *SyntaxError for a path that is not a plain model path, a
+//     *FieldNotFoundError for an unknown field or missing map key, and an
+//     *IndexOutOfRangeError for an index outside a slice or array
+func Delete(path string, data any) error {
+	segments, err := parseSegments(path)
+	if err != nil {
+		return err
+	}
+	if len(segments) == 0 {
+		return errors.New("cannot delete the root value")
+	}
+	if data == nil {
+		return errors.New("cannot delete from nil data")
+	}
+	return mutatePath(reflect.ValueOf(data), segments, false, deleteSegment)
+}
+
+// Set replaces the value addressed by a model path with value.
+// The path "." replaces data itself, which then has to be a non-nil pointer.
+// value must be assignable or convertible (between numeric types or types with the
+// same kind) to the type of the target; nil is accepted for types that can be nil.
+//
+// Every segment of the path must already exist; use SetCreate to allocate missing
+// intermediate values. Like Delete, Set requires struct fields and array elements to
+// be addressable, so data usually has to be a pointer, and reports malformed paths,
+// unknown names, and invalid indices with the same error types.
+//
+// Parameters:
+//   - path: The model path of the value to set (e.g. ".Spec.Replicas")
+//   - data: The data to modify
+//   - value: The new value
+//
+// Returns:
+//   - Error if the path cannot be resolved, the target cannot be modified, or the
+//     value has the wrong type
+func Set(path string, data any, value any) error {
+	return setPath(path, data, value, false)
+}
+
+// SetCreate works like Set, but allocates missing intermediate values on the way:
+// nil pointers and maps are allocated, missing map keys are added, slices are grown
+// to contain the addressed index, and nil interfaces are filled with a map[string]any
+// (or a []any if the next segment is a numeric index). This makes it possible to set
+// deeply nested values on zero-valued data:
+//
+//	var obj Deployment
+//	err := empaths.SetCreate(".Spec.Limits['cpu']", &obj, "500m")
+//
+// Parameters:
+//   - path: The model path of the value to set
+//   - data: The data to modify
+//   - value: The new value
+//
+// Returns:
+//   - Error if the path cannot be resolved, the target cannot be modified, or the
+//     value has the wrong type
+func SetCreate(path string, data any, value any) error {
+	return setPath(path, data, value, true)
+}
+
+// setPath implements Set and SetCreate.
+func setPath(path string, data any, value any, create bool) error {
+	segments, err := parseSegments(path)
+	if err != nil {
+		return err
+	}
+	if data == nil {
+		return errors.New("cannot modify nil data")
+	}
+	if len(segments) == 0 {
+		root := reflect.ValueOf(data)
+		if root.Kind() != reflect.Ptr || root.IsNil() {
+			return errors.New("setting the root value requires a non-nil pointer")
+		}
+		return assignValue(root.Elem(), value)
+	}
+	return mutatePath(reflect.ValueOf(data), segments, create, func(container reflect.Value, last pathSegment) error {
+		if container.Kind() == reflect.Map {
+			key := parseMapKey(last.name, container.Type().Key())
+			if !key.IsValid() {
+				return fieldNotFound(last.name, container)
+			}
+			converted, err := convertValue(value, container.Type().Elem())
+			if err != nil {
+				return err
+			}
+			container.SetMapIndex(key, converted)
+			return nil
+		}
+		child, err := childValue(container, last, create)
+		if err != nil {
+			return err
+		}
+		return assignValue(child, value)
+	})
+}
+
+// assignValue converts value to the type of target and stores it.
+func assignValue(target reflect.Value, value any) error {
+	if !target.CanSet() {
+		return errors.New("target is not addressable")
+	}
+	converted, err := convertValue(value, target.Type())
+	if err != nil {
+		return err
+	}
+	target.Set(converted)
+	return nil
+}
+
+// Append appends values to the slice addressed by a model path.
+// The path "." appends to data itself, which then has to be a pointer to a slice.
+// Each value must be assignable or convertible to the slice's element type; nil is
+// accepted for element types that can be nil.
+//
+// Like Delete, Append requires the slice to be addressable (or reachable through a
+// map), so data usually has to be a pointer, and reports errors with the same types.
+//
+// Parameters:
+//   - path: The model path of the slice (e.g. ".Spec.Tags")
+//   - data: The data to modify
+//   - values: The values to append
+//
+// Returns:
+//   - Error if the path cannot be resolved, the target is not a slice, or a value
+//     has the wrong type
+func Append(path string, data any, values ...any) error {
+	return updateSliceAt(path, data, func(slice reflect.Value) (reflect.Value, error) {
+		elems, err := convertValues(values, slice.Type().Elem())
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return reflect.Append(slice, elems...), nil
+	})
+}
+
+// Insert inserts value into the slice addressed by a model path at the given index,
+// shifting later elements up by one. index may range from 0 to the length of the
+// slice (inclusive), where the length appends at the end.
+//
+// Parameters:
+//   - path: The model path of the slice (e.g. ".Spec.Tags")
+//   - data: The data to modify
+//   - index: The position to insert at
+//   - value: The value to insert
+//
+// Returns:
+//   - Error if the path cannot be resolved, the target is not a slice, the index is
+//     out of range (an *IndexOutOfRangeError), or the value has the wrong type
+func Insert(path string, data any, index int, value any) error {
+	return updateSliceAt(path, data, func(slice reflect.Value) (reflect.Value, error) {
+		length := slice.Len()
+		if index < 0 || index > length {
+			return reflect.Value{}, &IndexOutOfRangeError{Index: index, Len: length}
+		}
+		elem, err := convertValue(value, slice.Type().Elem())
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		grown := reflect.Append(slice, reflect.Zero(slice.Type().Elem()))
+		reflect.Copy(grown.Slice(index+1, length+1), grown.Slice(index, length))
+		grown.Index(index).Set(elem)
+		return grown, nil
+	})
+}
+
+// updateSliceAt resolves the slice addressed by path and replaces it with the result of fn.
+func updateSliceAt(path string, data any, fn func(slice reflect.Value) (reflect.Value, error)) error {
+	segments, err := parseSegments(path)
+	if err != nil {
+		return err
+	}
+	if data == nil {
+		return errors.New("cannot modify nil data")
+	}
+	update := func(target reflect.Value) error {
+		return updateSlice(target, fn)
+	}
+	if len(segments) == 0 {
+		return update(reflect.ValueOf(data))
+	}
+	return mutatePath(reflect.ValueOf(data), segments, false, func(container reflect.Value, last pathSegment) error {
+		return updateChild(container, last, update)
+	})
+}
+
+// updateSlice dereferences target down to a slice and replaces it with the result of fn.
+func updateSlice(target reflect.Value, fn func(slice reflect.Value) (reflect.Value, error)) error {
+	switch target.Kind() {
+	case reflect.Ptr:
+		if target.IsNil() {
+			return errors.New("target is a nil pointer")
+		}
+		return updateSlice(target.Elem(), fn)
+	case reflect.Interface:
+		if target.IsNil() {
+			return errors.New("target is a nil interface")
+		}
+		if !target.CanSet() {
+			return errors.New("target is not addressable")
+		}
+		copyValue := reflect.New(target.Elem().Type()).Elem()
+		copyValue.Set(target.Elem())
+		if err := updateSlice(copyValue, fn); err != nil {
+			return err
+		}
+		target.Set(copyValue)
+		return nil
+	case reflect.Slice:
+		if !target.CanSet() {
+			return errors.New("target slice is not addressable")
+		}
+		updated, err := fn(target)
+		if err != nil {
+			return err
+		}
+		target.Set(updated)
+		return nil
+	default:
+		return fmt.Errorf("target is a %s, not a slice", target.Type())
+	}
+}
+
+// updateChild calls fn with a settable value for the child of container addressed by
+// last. Map values are copied before calling fn and stored back afterwards.
+func updateChild(container reflect.Value, last pathSegment, fn func(child reflect.Value) error) error {
+	if container.Kind() != reflect.Map {
+		child, err := childValue(container, last, false)
+		if err != nil {
+			return err
+		}
+		return fn(child)
+	}
+
+	key := parseMapKey(last.name, container.Type().Key())
+	if !key.IsValid() {
+		return fieldNotFound(last.name, container)
+	}
+	elem := container.MapIndex(key)
+	if !elem.IsValid() {
+		return fieldNotFound(last.name, container)
+	}
+	copyValue := reflect.New(elem.Type()).Elem()
+	copyValue.Set(elem)
+	if err := fn(copyValue); err != nil {
+		return err
+	}
+	container.SetMapIndex(key, copyValue)
+	return nil
+}
+
+// convertValues converts each value to typ using convertValue.
+func convertValues(values []any, typ reflect.Type) ([]reflect.Value, error) {
+	converted := make([]reflect.Value, len(values))
+	for i, value := range values {
+		elem, err := convertValue(value, typ)
+		if err != nil {
+			return nil, err
+		}
+		converted[i] = elem
+	}
+	return converted, nil
+}
+
+// convertValue converts value to a reflect.Value of type typ. Values that are
+// assignable to typ are used as is; numeric values are converted between numeric
+// kinds, and named types with the same underlying type are converted. nil becomes
+// the zero value of types that can be nil.
+//
+// Parameters:
+//   - value: The value to convert
+//   - typ: The target type
+//
+// Returns:
+//   - The converted value
+//   - Error if value cannot be represented as typ
+func convertValue(value any, typ reflect.Type) (reflect.Value, error) {
+	if value == nil {
+		switch typ.Kind() {
+		case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+			return reflect.Zero(typ), nil
+		default:
+			return reflect.Value{}, fmt.Errorf("cannot use nil as %s", typ)
+		}
+	}
+
+	v := reflect.ValueOf(value)
+	if v.Type().AssignableTo(typ) {
+		return v, nil
+	}
+	_, fromNumber := toFloat64(value)
+	_, toNumber := toFloat64(reflect.Zero(typ).Interface())
+	sameKind := v.Kind() == typ.Kind() && v.Kind() != reflect.Struct
+	if (fromNumber && toNumber) || sameKind {
+		if v.Type().ConvertibleTo(typ) {
+			return v.Convert(typ), nil
+		}
+	}
+	return reflect.Value{}, fmt.Errorf("cannot use %T as %s", value, typ)
+}
+
+// mutateFunc modifies the element addressed by last within container.
+// container is dereferenced and is settable whenever the data allows it.
+type mutateFunc func(container reflect.Value, last pathSegment) error
+
+// mutatePath walks all but the last segment and calls fn with the container holding
+// the final element. Values that cannot be modified in place (map values and values
+// stored in interfaces) are copied, modified, and written back.
+//
+// If create is true, nil pointers, nil maps, and nil interfaces on the way are
+// allocated, missing map keys are added, and slices that are too short are grown
+// so that every segment can be resolved. Nil interfaces are filled with a []any if
+// the next segment is a numeric index and with a map[string]any otherwise.
+//
+// Parameters:
+//   - value: The value to walk from
+//   - segments: The remaining path segments (at least one)
+//   - create: Whether missing intermediate values are created
+//   - fn: The modification to apply to the final container
+//
+// Returns:
+//   - Error if a segment cannot be resolved or the data cannot be modified
+func mutatePath(value reflect.Value, segments []pathSegment, create bool, fn mutateFunc) error {
+	switch value.Kind() {
+	case reflect.Ptr:
+		if value.IsNil() {
+			if !create || !value.CanSet() {
+				return fmt.Errorf("nil pointer before %s", segments[0])
+			}
+			value.Set(reflect.New(value.Type().Elem()))
+		}
+		return mutatePath(value.Elem(), segments, create, fn)
+	case reflect.Interface:
+		if value.IsNil() {
+			if !create || !value.CanSet() {
+				return fmt.Errorf("nil interface before %s", segments[0])
+			}
+			value.Set(newContainerFor(segments[0]))
+		}
+		elem := value.Elem()
+		if elem.Kind() == reflect.Ptr || elem.Kind() == reflect.Map {
+			return mutatePath(elem, segments, create, fn)
+		}
+		if !value.CanSet() {
+			return fmt.Errorf("value before %s is not addressable", segments[0])
+		}
+		copyValue := reflect.New(elem.Type()).Elem()
+		copyValue.Set(elem)
+		if err := mutatePath(copyValue, segments, create, fn); err != nil {
+			return err
+		}
+		value.Set(copyValue)
+		return nil
+	case reflect.Map:
+		if value.IsNil() {
+			if !create || !value.CanSet() {
+				return fmt.Errorf("nil map before %s", segments[0])
+			}
+			value.Set(reflect.MakeMap(value.Type()))
+		}
+	default:
+	}
+
+	segments = expandSegmentAlias(value, segments)
+	if len(segments) == 1 {
+		return fn(value, segments[0])
+	}
+
+	segment := segments[0]
+	switch value.Kind() {
+	case reflect.Map:
+		key := parseMapKey(segment.name, value.Type().Key())
+		if !key.IsValid() {
+			return fieldNotFound(segment.name, value)
+		}
+		elem := value.MapIndex(key)
+		if !elem.IsValid() {
+			if !create {
+				return fieldNotFound(segment.name, value)
+			}
+			elem = reflect.Zero(value.Type().Elem())
+		}
+		copyValue := reflect.New(elem.Type()).Elem()
+		copyValue.Set(elem)
+		if err := mutatePath(copyValue, segments[1:], create, fn); err != nil {
+			return err
+		}
+		value.SetMapIndex(key, copyValue)
+		return nil
+	case reflect.Struct, reflect.Slice, reflect.Array:
+		elem, err := childValue(value, segment, create)
+		if err != nil {
+			return err
+		}
+		return mutatePath(elem, segments[1:], create, fn)
+	default:
+		return fieldNotFound(segment.name, value)
+	}
+}
+
+// newContainerFor returns the container created for a nil interface that the given
+// segment is resolved against: a []any for numeric indices, a map[string]any otherwise.
+func newContainerFor(segment pathSegment) reflect.Value {
+	if _, err := strconv.Atoi(segment.name); err == nil && segment.bracket {
+		return reflect.ValueOf([]any{})
+	}
+	return reflect.ValueOf(map[string]any{})
+}
+
+// childValue returns the struct field or array/slice element addressed by segment.
+// If create is true, a slice that is too short is grown (with zero values) to
+// contain the index.
+func childValue(value reflect.Value, segment pathSegment, create bool) (reflect.Value, error) {
+	switch value.Kind() {
+	case reflect.Struct:
+		field := value.FieldByName(segment.name)
+		if !field.IsValid() {
+			return reflect.Value{}, fieldNotFound(segment.name, value)
+		}
+		if !field.CanInterface() {
+			return reflect.Value{}, fmt.Errorf("field %s of %s is unexported", segment, value.Type())
+		}
+		return field, nil
+	case reflect.Slice, reflect.Array:
+		length := value.Len()
+		if create && value.Kind() == reflect.Slice && value.CanSet() {
+			if index, err := strconv.Atoi(segment.name); err == nil && index >= length {
+				grown := reflect.MakeSlice(value.Type(), index+1, index+1)
+				reflect.Copy(grown, value)
+				value.Set(grown)
+				length = index + 1
+			}
+		}
+		index, err := sliceIndex(segment, value)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return value.Index(index), nil
+	default:
+		return reflect.Value{}, fieldNotFound(segment.name, value)
+	}
+}
+
+// sliceIndex parses segment as an index into value, a slice or array.
+func sliceIndex(segment pathSegment, value reflect.Value) (int, error) {
+	index, err := strconv.Atoi(segment.name)
+	if err != nil {
+		return 0, fieldNotFound(segment.name, value)
+	}
+	if index < 0 || index >= value.Len() {
+		return 0, &IndexOutOfRangeError{Index: index, Len: value.Len()}
+	}
+	return index, nil
+}
+
+// deleteSegment is the mutateFunc used by Delete.
+func deleteSegment(container reflect.Value, last pathSegment) error {
+	switch container.Kind() {
+	case reflect.Map:
+		key := parseMapKey(last.name, container.Type().Key())
+		if !key.IsValid() || !container.MapIndex(key).IsValid() {
+			return fieldNotFound(last.name, container)
+		}
+		container.SetMapIndex(key, reflect.Value{})
+		return nil
+	case reflect.Slice:
+		index, err := sliceIndex(last, container)
+		if err != nil {
+			return err
+		}
+		if !container.CanSet() {
+			return fmt.Errorf("slice holding %s is not addressable", last)
+		}
+		length := container.Len()
+		reflect.Copy(container.Slice(index, length), container.Slice(index+1, length))
+		container.Index(length - 1).Set(reflect.Zero(container.Type().Elem()))
+		container.Set(container.Slice(0, length-1))
+		return nil
+	case reflect.Struct, reflect.Array:
+		elem, err := childValue(container, last, false)
+		if err != nil {
+			return err
+		}
+		if !elem.CanSet() {
+			return fmt.Errorf("%s is not addressable", last)
+		}
+		elem.Set(reflect.Zero(elem.Type()))
+		return nil
+	default:
+		return fieldNotFound(last.name, container)
+	}
+}
@@ -0,0 +1,423 @@
+package empaths
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// Config is used by the mutation tests
+type Config struct {
+	Name   string
+	Labels map[string]string
+	Ports  []int
+	Limits [2]int
+	Spec   *ConfigSpec
+	Extra  any
+	Groups map[string]ConfigSpec
+}
+
+// ConfigSpec is nested in Config
+type ConfigSpec struct {
+	Replicas int
+	Tags     []string
+}
+
+// createTestConfig returns a fully populated Config for testing
+func createTestConfig() *Config {
+	return &Config{
+		Name:   "app",
+		Labels: map[string]string{"env": "prod", "team": "core"},
+		Ports:  []int{80, 443, 8080},
+		Limits: [2]int{1, 2},
+		Spec:   &ConfigSpec{Replicas: 3, Tags: []string{"a", "b", "c"}},
+		Extra:  map[string]any{"debug": true, "list": []any{1, 2, 3}},
+		Groups: map[string]ConfigSpec{"web": {Replicas: 2, Tags: []string{"x", "y"}}},
+	}
+}
+
+func TestDelete(t *testing.T) {
+	tests := []struct {
+		name  string
+		path  string
+		check string
+		want  any
+	}{
+		{"map key", ".Labels.env", ".Labels", map[string]string{"team": "core"}},
+		{"map key bracket", ".Labels['team']", ".Labels", map[string]string{"env": "prod"}},
+		{"struct field", ".Name", ".Name", ""},
+		{"slice element shifts", ".Ports[0]", ".Ports", []int{443, 8080}},
+		{"last slice element", ".Ports[2]", ".Ports", []int{80, 443}},
+		{"array element is zeroed", ".Limits[1]", ".Limits", [2]int{1, 0}},
+		{"through pointer", ".Spec.Tags[1]", ".Spec.Tags", []string{"a", "c"}},
+		{"pointer field", ".Spec", ".Spec", nil},
+		{"map in interface", ".Extra.debug", ".Extra", map[string]any{"list": []any{1, 2, 3}}},
+		{"slice in map in interface", ".Extra.list[1]", ".Extra.list", []any{1, 3}},
+		{"struct in map", ".Groups.web.Replicas", ".Groups.web.Replicas", 0},
+		{"slice in struct in map", ".Groups.web.Tags[0]", ".Groups.web.Tags", []string{"y"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := createTestConfig()
+			if err := Delete(tt.path, config); err != nil {
+				t.Fatalf("Delete(%q) returned error: %v", tt.path, err)
+			}
+			result := Resolve(tt.check, config, nil)
+			if !reflect.DeepEqual(result, tt.want) {
+				t.Errorf("after Delete(%q), Resolve(%q) = %v, want %v", tt.path, tt.check, result, tt.want)
+			}
+		})
+	}
+}
+
+func TestDelete_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		data any
+	}{
+		{"root", ".", createTestConfig()},
+		{"nil data", ".Name", nil},
+		{"missing leading dot", "Name", createTestConfig()},
+		{"unknown field", ".Nope", createTestConfig()},
+		{"missing map key", ".Labels.nope", createTestConfig()},
+		{"index out of range", ".Ports[9]", createTestConfig()},
+		{"invalid index", ".Ports[x]", createTestConfig()},
+		{"wildcard", ".Ports[*]", createTestConfig()},
+		{"filter", ".Ports[?.==80]", createTestConfig()},
+		{"expression syntax", ".Name 'x'", createTestConfig()},
+		{"nil intermediate pointer", ".Spec.Replicas", &Config{}},
+		{"struct passed by value", ".Name", *createTestConfig()},
+		{"slice passed by value", ".[0]", []int{1, 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Delete(tt.path, tt.data); err == nil {
+				t.Errorf("Delete(%q) should return an error", tt.path)
+			}
+		})
+	}
+}
+
+func TestDelete_MapPassedByValue(t *testing.T) {
+	data := map[string]any{"a": 1, "b": 2}
+	if err := Delete(".a", data); err != nil {
+		t.Fatalf("Delete(.a) returned error: %v", err)
+	}
+	if _, ok := data["a"]; ok {
+		t.Errorf("Delete(.a) did not remove the key")
+	}
+}
+
+func TestParseSegments(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected []pathSegment
+	}{
+		{".", nil},
+		{".Name", []pathSegment{{name: "Name"}}},
+		{".User.Tags[0]", []pathSegment{{name: "User"}, {name: "Tags"}, {name: "0", bracket: true}}},
+		{".[1][2]", []pathSegment{{name: "1", bracket: true}, {name: "2", bracket: true}}},
+		{".Data['a.b[0]'].x", []pathSegment{{name: "Data"}, {name: "a.b[0]", bracket: true}, {name: "x"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			result, err := parseSegments(tt.path)
+			if err != nil {
+				t.Fatalf("parseSegments(%q) returned error: %v", tt.path, err)
+			}
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("parseSegments(%q) = %v, want %v", tt.path, result, tt.expected)
+			}
+		})
+	}
+
+	for _, invalid := range []string{"", "Name", "..Name", ".Name.", ".Name[0", ".Name[]", ".a b"} {
+		if _, err := parseSegments(invalid); err == nil {
+			t.Errorf("parseSegments(%q) should return an error", invalid)
+		}
+	}
+}
+
+func TestAppend(t *testing.T) {
+	tests := []struct {
+		name   string
+		path   string
+		values []any
+		check  string
+		want   any
+	}{
+		{"struct field", ".Ports", []any{9090}, ".Ports", []int{80, 443, 8080, 9090}},
+		{"several values", ".Spec.Tags", []any{"d", "e"}, ".Spec.Tags", []string{"a", "b", "c", "d", "e"}},
+		{"no values", ".Ports", nil, ".Ports", []int{80, 443, 8080}},
+		{"numeric conversion", ".Ports", []any{int64(22), 1.0}, ".Ports", []int{80, 443, 8080, 22, 1}},
+		{"slice in map in interface", ".Extra.list", []any{"x"}, ".Extra.list", []any{1, 2, 3, "x"}},
+		{"slice in struct in map", ".Groups.web.Tags", []any{"z"}, ".Groups.web.Tags", []string{"x", "y", "z"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := createTestConfig()
+			if err := Append(tt.path, config, tt.values...); err != nil {
+				t.Fatalf("Append(%q) returned error: %v", tt.path, err)
+			}
+			result := Resolve(tt.check, config, nil)
+			if !reflect.DeepEqual(result, tt.want) {
+				t.Errorf("after Append(%q), Resolve(%q) = %v, want %v", tt.path, tt.check, result, tt.want)
+			}
+		})
+	}
+
+	t.Run("root slice", func(t *testing.T) {
+		items := []string{"a"}
+		if err := Append(".", &items, "b"); err != nil {
+			t.Fatalf("Append(.) returned error: %v", err)
+		}
+		if !reflect.DeepEqual(items, []string{"a", "b"}) {
+			t.Errorf("after Append(.), items = %v, want [a b]", items)
+		}
+	})
+
+	t.Run("nil slice", func(t *testing.T) {
+		config := &Config{}
+		if err := Append(".Ports", config, 1); err != nil {
+			t.Fatalf("Append(.Ports) on nil slice returned error: %v", err)
+		}
+		if !reflect.DeepEqual(config.Ports, []int{1}) {
+			t.Errorf("after Append(.Ports), Ports = %v, want [1]", config.Ports)
+		}
+	})
+}
+
+func TestAppend_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		path   string
+		data   any
+		values []any
+	}{
+		{"not a slice", ".Name", createTestConfig(), []any{"x"}},
+		{"wrong element type", ".Ports", createTestConfig(), []any{"x"}},
+		{"nil for int", ".Ports", createTestConfig(), []any{nil}},
+		{"missing field", ".Nope", createTestConfig(), []any{1}},
+		{"struct passed by value", ".Ports", *createTestConfig(), []any{1}},
+		{"root slice passed by value", ".", []int{1}, []any{2}},
+		{"nil data", ".Ports", nil, []any{1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Append(tt.path, tt.data, tt.values...); err == nil {
+				t.Errorf("Append(%q) should return an error", tt.path)
+			}
+		})
+	}
+}
+
+func TestInsert(t *testing.T) {
+	tests := []struct {
+		name  string
+		index int
+		value any
+		want  []int
+	}{
+		{"at start", 0, 1, []int{1, 80, 443, 8080}},
+		{"in middle", 2, 1, []int{80, 443, 1, 8080}},
+		{"at end", 3, 1, []int{80, 443, 8080, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := createTestConfig()
+			if err := Insert(".Ports", config, tt.index, tt.value); err != nil {
+				t.Fatalf("Insert(.Ports, %d) returned error: %v", tt.index, err)
+			}
+			if !reflect.DeepEqual(config.Ports, tt.want) {
+				t.Errorf("after Insert(.Ports, %d), Ports = %v, want %v", tt.index, config.Ports, tt.want)
+			}
+		})
+	}
+
+	for _, index := range []int{-1, 4} {
+		if err := Insert(".Ports", createTestConfig(), index, 1); err == nil {
+			t.Errorf("Insert(.Ports, %d) should return an error", index)
+		}
+	}
+}
+
+func TestSet(t *testing.T) {
+	tests := []struct {
+		name  string
+		path  string
+		value any
+		check string
+		want  any
+	}{
+		{"struct field", ".Name", "web", ".Name", "web"},
+		{"map value", ".Labels.env", "dev", ".Labels.env", "dev"},
+		{"new map key", ".Labels['new key']", "x", ".Labels['new key']", "x"},
+		{"slice element", ".Ports[1]", 8443, ".Ports", []int{80, 8443, 8080}},
+		{"array element", ".Limits[0]", 5, ".Limits", [2]int{5, 2}},
+		{"through pointer", ".Spec.Replicas", 7, ".Spec.Replicas", 7},
+		{"numeric conversion", ".Spec.Replicas", 7.0, ".Spec.Replicas", 7},
+		{"nil pointer", ".Spec", nil, ".Spec", nil},
+		{"interface value", ".Extra", "plain", ".Extra", "plain"},
+		{"map in interface", ".Extra.debug", false, ".Extra.debug", false},
+		{"slice in map in interface", ".Extra.list[0]", "first", ".Extra.list[0]", "first"},
+		{"struct in map", ".Groups.web.Replicas", 9, ".Groups.web.Replicas", 9},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := createTestConfig()
+			if err := Set(tt.path, config, tt.value); err != nil {
+				t.Fatalf("Set(%q) returned error: %v", tt.path, err)
+			}
+			result := Resolve(tt.check, config, nil)
+			if !reflect.DeepEqual(result, tt.want) {
+				t.Errorf("after Set(%q), Resolve(%q) = %v, want %v", tt.path, tt.check, result, tt.want)
+			}
+		})
+	}
+
+	t.Run("root", func(t *testing.T) {
+		value := 1
+		if err := Set(".", &value, 2); err != nil {
+			t.Fatalf("Set(.) returned error: %v", err)
+		}
+		if value != 2 {
+			t.Errorf("after Set(.), value = %v, want 2", value)
+		}
+	})
+}
+
+func TestSet_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		path  string
+		data  any
+		value any
+	}{
+		{"wrong type", ".Name", createTestConfig(), 1},
+		{"nil for string", ".Name", createTestConfig(), nil},
+		{"index out of range", ".Ports[5]", createTestConfig(), 1},
+		{"nil pointer intermediate", ".Spec.Replicas", &Config{}, 1},
+		{"nil map", ".Labels.env", &Config{}, "x"},
+		{"missing key intermediate", ".Groups.api.Replicas", createTestConfig(), 1},
+		{"struct passed by value", ".Name", *createTestConfig(), "x"},
+		{"root without pointer", ".", 1, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Set(tt.path, tt.data, tt.value); err == nil {
+				t.Errorf("Set(%q) should return an error", tt.path)
+			}
+		})
+	}
+}
+
+func TestSetCreate(t *testing.T) {
+	type Limits map[string]string
+	type Spec struct {
+		Limits   Limits
+		Replicas *int
+		Ports    []int
+	}
+	type Deployment struct {
+		Spec  *Spec
+		Meta  map[string]*Spec
+		Extra any
+	}
+
+	tests := []struct {
+		name  string
+		path  string
+		value any
+		check string
+		want  any
+	}{
+		{"nil pointer and nil map", ".Spec.Limits['cpu']", "500m", ".Spec.Limits.cpu", "500m"},
+		{"pointer leaf", ".Spec.Replicas", nil, ".Spec.Replicas", nil},
+		{"grown slice", ".Spec.Ports[2]", 80, ".Spec.Ports", []int{0, 0, 80}},
+		{"missing map key with pointer value", ".Meta.web.Ports[0]", 443, ".Meta.web.Ports", []int{443}},
+		{"nil interface becomes map", ".Extra.a.b", 1, ".Extra.a.b", 1},
+		{"nil interface becomes slice", ".Extra[1]", "x", ".Extra", []any{nil, "x"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var deployment Deployment
+			if err := SetCreate(tt.path, &deployment, tt.value); err != nil {
+				t.Fatalf("SetCreate(%q) returned error: %v", tt.path, err)
+			}
+			result := Resolve(tt.check, &deployment, nil)
+			if !reflect.DeepEqual(result, tt.want) {
+				t.Errorf("after SetCreate(%q), Resolve(%q) = %v, want %v", tt.path, tt.check, result, tt.want)
+			}
+		})
+	}
+
+	t.Run("unknown field is still an error", func(t *testing.T) {
+		var deployment Deployment
+		if err := SetCreate(".Spec.Nope", &deployment, 1); err == nil {
+			t.Errorf("SetCreate(.Spec.Nope) should return an error")
+		}
+	})
+}
+
+func TestSet_UnexportedField(t *testing.T) {
+	type Secret struct {
+		labels map[string]string
+		name   string
+	}
+	secret := &Secret{labels: map[string]string{"a": "b"}}
+
+	for _, path := range []string{".labels.a", ".name"} {
+		if err := Set(path, secret, "x"); err == nil {
+			t.Errorf("Set(%q) on an unexported field should return an error", path)
+		}
+		if err := Delete(path, secret); err == nil {
+			t.Errorf("Delete(%q) on an unexported field should return an error", path)
+		}
+	}
+}
+
+func TestMutate_TypedErrors(t *testing.T) {
+	configType := reflect.TypeOf(Config{})
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"Set unknown field", Set(".Nmae", createTestConfig(), "x"), &FieldNotFoundError{Field: "Nmae", Type: configType, Suggestions: []string{"Name"}}},
+		{"Set field of a number", Set(".Spec.Replicas.x", createTestConfig(), 1), &FieldNotFoundError{Field: "x", Type: reflect.TypeOf(0)}},
+		{"Delete missing map key", Delete(".Labels.nope", createTestConfig()), &FieldNotFoundError{Field: "nope", Type: reflect.TypeOf(map[string]string{})}},
+		{"Delete invalid index", Delete(".Ports[x]", createTestConfig()), &FieldNotFoundError{Field: "x", Type: reflect.TypeOf([]int{})}},
+		{"Set index out of range", Set(".Ports[9]", createTestConfig(), 1), &IndexOutOfRangeError{Index: 9, Len: 3}},
+		{"Delete index out of range", Delete(".Limits[2]", createTestConfig()), &IndexOutOfRangeError{Index: 2, Len: 2}},
+		{"Insert index out of range", Insert(".Ports", createTestConfig(), 5, 1), &IndexOutOfRangeError{Index: 5, Len: 3}},
+		{"Apply index out of range", Apply(Patch{{Kind: OpSet, Path: ".Spec.Tags[7]", Value: "x"}}, createTestConfig()), &IndexOutOfRangeError{Index: 7, Len: 3}},
+		{"missing leading dot", Delete("Name", createTestConfig()), &SyntaxError{Pos: 0, Msg: "path must start with '.'"}},
+		{"wildcard", Set(".Ports[*]", createTestConfig(), 1), &SyntaxError{Pos: 6, Msg: "wildcards and filters are not supported here"}},
+		{"missing bracket", Append(".Ports[0", createTestConfig(), 1), &SyntaxError{Pos: 6, Msg: "missing ']'"}},
+		{"empty segment", SetCreate(".Name..x", createTestConfig(), 1), &SyntaxError{Pos: 5, Msg: "empty path segment"}},
+		{"expression syntax", Set(".Name 'x'", createTestConfig(), 1), &SyntaxError{Pos: 5, Msg: `unexpected ' '`}},
+		{"Apply syntax", Apply(Patch{{Kind: OpDelete, Path: ".Labels[]"}}, createTestConfig()), &SyntaxError{Pos: 7, Msg: "empty brackets"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := reflect.New(reflect.TypeOf(tt.want))
+			if !errors.As(tt.err, target.Interface()) {
+				t.Fatalf("error = %v (%T), want a %T", tt.err, tt.err, tt.want)
+			}
+			if got := target.Elem().Interface(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("error = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
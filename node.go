@@ -0,0 +1,95 @@
+package empaths
+
+import (
+	"reflect"
+)
+
+// Node is a value resolved from a data model that further paths can be resolved
+// against, without walking the model from the root again. Resolve a parent object
+// once with ResolveNode and then resolve its children relative to it:
+//
+//	user := empaths.ResolveNode(".Users[0]", data)
+//	name := user.Resolve(".Name")
+//	city := user.Node(".Address").Resolve(".City")
+//
+// All methods may be called on a nil *Node, which represents a path that did not
+// resolve, and return nil.
+type Node struct {
+	value reflect.Value
+	path  string
+}
+
+// ResolveNode resolves a path against data and returns the result as a Node.
+//
+// Parameters:
+//   - path: The path expression to resolve, usually a model path such as ".Users[0]".
+//     An empty path returns a Node for data itself.
+//   - data: The data model to resolve the path against
+//
+// Returns:
+//   - The resolved Node, or nil if the path resolves to nil
+func ResolveNode(path string, data any) *Node {
+	return newNode(path, resolveNodeValue(path, reflect.ValueOf(data)))
+}
+
+// newNode returns a Node for value, or nil if value is nil.
+func newNode(path string, value reflect.Value) *Node {
+	if extractValue(value) == nil {
+		return nil
+	}
+	return &Node{value: value, path: path}
+}
+
+// resolveNodeValue resolves a path against value. A single model path is resolved
+// directly on the reflect.Value; other expressions are evaluated like Resolve.
+func resolveNodeValue(path string, value reflect.Value) reflect.Value {
+	if path == "" {
+		return value
+	}
+	if path[0] == '.' {
+		if modelPath, end := readModelPathASCII(path, 1); end == len(path) {
+			return resolvePathAgainstValue(modelPath, value, &evalState{root: extractValue(value), owner: value})
+		}
+	}
+	var data any
+	if value.IsValid() && value.CanInterface() {
+		data = value.Interface()
+	}
+	return reflect.ValueOf(Resolve(path, data, nil))
+}
+
+// Value returns the value of the node, like Resolve would return it.
+func (n *Node) Value() any {
+	if n == nil {
+		return nil
+	}
+	return extractValue(n.value)
+}
+
+// Path returns the path of the node relative to the data passed to ResolveNode: the
+// paths passed to ResolveNode and Node, joined with JoinPath.
+func (n *Node) Path() string {
+	if n == nil {
+		return ""
+	}
+	return n.path
+}
+
+// Resolve resolves a path expression relative to the node, like the package-level
+// Resolve with the node's value as data. External references resolve to nil.
+func (n *Node) Resolve(path string) any {
+	if n == nil {
+		return nil
+	}
+	return extractValue(resolveNodeValue(path, n.value))
+}
+
+// Node resolves a model path relative to the node and returns the result as a Node,
+// or nil if it resolves to nil. The path is written as for JoinPath, so the leading
+// dot is optional ("Address", ".Address", and "[0]" are all accepted).
+func (n *Node) Node(path string) *Node {
+	if n == nil {
+		return nil
+	}
+	return newNode(JoinPath(n.path, path), resolveNodeValue(JoinPath("", path), n.value))
+}
@@ -0,0 +1,99 @@
+package empaths
+
+import (
+	"testing"
+)
+
+// NodeAccount is held by pointer in the test data
+type NodeAccount struct {
+	Owner   Member
+	Balance int
+}
+
+func TestResolveNode(t *testing.T) {
+	team := createTestTeam()
+
+	tests := []struct {
+		name     string
+		path     string
+		sub      string
+		expected any
+	}{
+		{"field", ".Users[0]", ".Name", "Alice"},
+		{"index", ".Users", ".[1].Name", "Bob"},
+		{"map key", ".Scores", ".science", 88},
+		{"expression", ".Users[2]", ".Name ' is ' .Age", "Carol is 35"},
+		{"comparison", ".Users[1]", "?.Active==false", true},
+		{"function", ".", "count(.Users)", 3},
+		{"empty sub-path", ".Scores.math", "", 95},
+		{"empty path", "", ".Users[0].Age", 30},
+		{"missing field", ".Users[0]", ".Unknown", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := ResolveNode(tt.path, team)
+			if node == nil {
+				t.Fatalf("ResolveNode(%q) = nil", tt.path)
+			}
+			if result := node.Resolve(tt.sub); result != tt.expected {
+				t.Errorf("ResolveNode(%q).Resolve(%q) = %v, want %v", tt.path, tt.sub, result, tt.expected)
+			}
+			if tt.expected != nil && Resolve(tt.path, team, nil) != nil {
+				if result := Resolve(tt.sub, Resolve(tt.path, team, nil), nil); result != tt.expected {
+					t.Errorf("Resolve(%q) on the resolved value = %v, want %v", tt.sub, result, tt.expected)
+				}
+			}
+		})
+	}
+}
+
+func TestNode_Node(t *testing.T) {
+	team := createTestTeam()
+
+	users := ResolveNode(".Users", team)
+	carol := users.Node("[2]")
+	if carol == nil || carol.Path() != ".Users[2]" {
+		t.Fatalf("Node([2]) = %v, want node at .Users[2]", carol)
+	}
+	if name := carol.Resolve(".Name"); name != "Carol" {
+		t.Errorf("Resolve(.Name) = %v, want Carol", name)
+	}
+	age := ResolveNode(".", team).Node(".Users").Node(".[0]").Node(".Age")
+	if age.Path() != ".Users[0].Age" || age.Value() != 30 {
+		t.Errorf("Node chain = %q %v, want .Users[0].Age 30", age.Path(), age.Value())
+	}
+}
+
+func TestNode_Nil(t *testing.T) {
+	team := createTestTeam()
+
+	for _, path := range []string{".Unknown", ".Users[9]", ".Scores.art"} {
+		if node := ResolveNode(path, team); node != nil {
+			t.Errorf("ResolveNode(%q) = %v, want nil", path, node)
+		}
+	}
+
+	var node *Node
+	if node.Value() != nil || node.Resolve(".Name") != nil || node.Node(".Name") != nil || node.Path() != "" {
+		t.Errorf("methods of a nil *Node should return nil")
+	}
+	if ResolveNode("", nil) != nil {
+		t.Errorf("ResolveNode of nil data should be nil")
+	}
+}
+
+func TestNode_Pointer(t *testing.T) {
+	data := map[string]any{"Account": &NodeAccount{Owner: Member{Name: "Dave"}, Balance: -5}}
+
+	account := ResolveNode(".Account", data)
+	if result := account.Resolve("?.Balance==-5"); result != true {
+		t.Errorf("Resolve(?.Balance==-5) = %v, want true", result)
+	}
+	if result := account.Node(".Owner").Resolve(".Name"); result != "Dave" {
+		t.Errorf("Resolve(.Owner.Name) = %v, want Dave", result)
+	}
+	if _, ok := account.Value().(NodeAccount); !ok {
+		t.Errorf("Value() = %T, want NodeAccount", account.Value())
+	}
+}
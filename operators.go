@@ -4,106 +4,714 @@ import (
 	"errors"
 	"reflect"
 	"strings"
+	"time"
 )
 
+// comparisonOperator identifies the operator of a comparison expression.
+type comparisonOperator int
+
+const (
+	// opEquals is the '==' operator.
+	opEquals comparisonOperator = iota
+	// opNotEquals is the '!=' operator.
+	opNotEquals
+	// opContains is the 'contains' operator.
+	opContains
+	// opIn is the 'in' operator.
+	opIn
+	// opLess is the '<' operator.
+	opLess
+	// opLessOrEqual is the '<=' operator.
+	opLessOrEqual
+	// opGreater is the '>' operator.
+	opGreater
+	// opGreaterOrEqual is the '>=' operator.
+	opGreaterOrEqual
+	// opStartsWith is the '^=' operator.
+	opStartsWith
+	// opEndsWith is the '$=' operator.
+	opEndsWith
+	// opGlob is the '~g' operator.
+	opGlob
+	// opEqualsFold is the '==i' operator.
+	opEqualsFold
+	// opNotEqualsFold is the '!=i' operator.
+	opNotEqualsFold
+	// opVersionLess is the '<v' operator.
+	opVersionLess
+	// opVersionLessOrEqual is the '<=v' operator.
+	opVersionLessOrEqual
+	// opVersionGreater is the '>v' operator.
+	opVersionGreater
+	// opVersionGreaterOrEqual is the '>=v' operator.
+	opVersionGreaterOrEqual
+)
+
+// wordOperators maps operators that are written as words to their comparisonOperator.
+var wordOperators = map[string]comparisonOperator{
+	"contains": opContains,
+	"in":       opIn,
+}
+
 // resolveComparison evaluates a comparison expression in a path.
-// Comparison expressions start with '?' and compare two operands with either '==' or '!=' operators.
+// Comparison expressions start with '?' and consist of conditions combined with '&&'
+// and '||', where '&&' binds tighter than '||' (see resolveCondition).
 //
 // Parameters:
 //   - path: The path expression as a string
 //   - data: The data model to evaluate against
 //   - index: The current index in the path
-//   - refResolver: Function to resolve external references
+//   - state: The state of the current evaluation
 //
 // Returns:
 //   - The boolean result of the comparison
 //   - The new index after processing
-func resolveComparison(path string, data any, index int, refResolver ReferenceResolver) (bool, int) {
+func resolveComparison(path string, data any, index int, state *evalState) (bool, int) {
 	// skip over the ? prefix
-	index++
-	leftOperand, index := resolveOperand(path, data, refResolver, index)
-	equalsOperator, index, err := parseOperator(path, index)
+	return resolveDisjunction(path, data, index+1, state)
+}
+
+// resolveDisjunction evaluates conditions combined with '||'. Once a condition is
+// true, the remaining ones are still evaluated to find the end of the expression, but
+// do not report failures (see evalState.quiet), so "?.User == nil || .User.Active"
+// is not an error in strict mode.
+func resolveDisjunction(path string, data any, index int, state *evalState) (bool, int) {
+	result, index := resolveConjunction(path, data, index, state)
+	for {
+		next, ok := logicalOperatorEnd(path, index, '|')
+		if !ok {
+			return result, index
+		}
+		if result {
+			state.quiet++
+		}
+		operand, newIndex := resolveConjunction(path, data, next, state)
+		if result {
+			state.quiet--
+		}
+		result = result || operand
+		index = newIndex
+	}
+}
+
+// resolveConjunction evaluates conditions combined with '&&'. Once a condition is
+// false, the remaining ones do not report failures, like in resolveDisjunction.
+func resolveConjunction(path string, data any, index int, state *evalState) (bool, int) {
+	result, index := resolveCondition(path, data, index, state)
+	for {
+		next, ok := logicalOperatorEnd(path, index, '&')
+		if !ok {
+			return result, index
+		}
+		if !result {
+			state.quiet++
+		}
+		operand, newIndex := resolveCondition(path, data, next, state)
+		if !result {
+			state.quiet--
+		}
+		result = result && operand
+		index = newIndex
+	}
+}
+
+// logicalOperatorEnd skips spaces and reports whether the logical operator '&&' or
+// '||' (written with the character op) follows, and returns the index after it.
+func logicalOperatorEnd(path string, index int, op byte) (int, bool) {
+	for index < len(path) && path[index] == ' ' {
+		index++
+	}
+	if index+1 < len(path) && path[index] == op && path[index+1] == op {
+		return index + 2, true
+	}
+	return index, false
+}
+
+// resolveCondition evaluates a single condition of a comparison expression: a group
+// of conditions in parentheses, as in "(.A == 1 || .B == 2)", or two operands
+// compared with one of the operators '==', '!=', '==i', '!=i', '<', '<=', '>', '>=',
+// '<v', '<=v', '>v', '>=v', '^=', '$=', '~g', 'contains', or 'in'. A single operand
+// at the end of the condition, as in "?isempty(.Tags)" or "?!(.A || .B)", is tested
+// for being true.
+//
+// Parameters:
+//   - path: The path expression as a string
+//   - data: The data model to evaluate against
+//   - index: The index of the condition in the path
+//   - state: The state of the current evaluation
+//
+// Returns:
+//   - The boolean result of the condition
+//   - The new index after processing
+func resolveCondition(path string, data any, index int, state *evalState) (bool, int) {
+	for index < len(path) && path[index] == ' ' {
+		index++
+	}
+	if index < len(path) && path[index] == '(' {
+		closeIndex := findClosingASCII(path, index)
+		if closeIndex == -1 {
+			return false, len(path)
+		}
+		result, _ := resolveDisjunction(path[:closeIndex], data, index+1, state)
+		return result, closeIndex + 1
+	}
+	leftOperand, index := resolveOperand(path, data, state, index)
+	leftOperand, index = resolveOperandPipes(path, data, leftOperand, index, state)
+	if isConditionEnd(path, index) {
+		return state.truthy(leftOperand), index
+	}
+	operator, index, err := parseOperator(path, index)
 	if err != nil {
 		// Invalid operator - return false as comparison result
 		return false, index
 	}
 
-	leftStr := toString(leftOperand)
+	if operator == opIn {
+		return resolveInOperand(path, data, leftOperand, index, state)
+	}
 
-	rightOperand, index := resolveOperand(path, data, refResolver, index)
-	rightStr := toString(rightOperand)
+	rightOperand, index := resolveRightOperand(path, data, state, index)
+	leftOperand, rightOperand = asTimes(leftOperand, rightOperand, state.timeLayouts())
+	switch operator {
+	case opEquals, opNotEquals, opLess, opLessOrEqual, opGreater, opGreaterOrEqual:
+		leftOperand, rightOperand = asApproximateNumbers(leftOperand, rightOperand, state.floatEpsilon())
+	}
+	return compareValues(leftOperand, operator, rightOperand), index
+}
+
+// resolveRightOperand evaluates the right operand of a comparison like
+// resolveOperand, except that an unquoted token that is not a keyword, number, or
+// function call is a string literal (see bareLiteralEnd), so "?.Status==active"
+// compares with "active" instead of ignoring the word.
+func resolveRightOperand(path string, data any, state *evalState, index int) (any, int) {
+	for index < len(path) && path[index] == ' ' {
+		index++
+	}
+	if end := bareLiteralEnd(path, index); end > index {
+		return resolveOperandPipes(path, data, path[index:end], end, state)
+	}
+	value, index := resolveOperand(path, data, state, index)
+	return resolveOperandPipes(path, data, value, index, state)
+}
+
+// resolveOperandPipes applies the pipes following an operand of a comparison, as in
+// "?.Items | len > 0". A '||' is a logical operator, not a pipe.
+func resolveOperandPipes(path string, data any, value any, index int, state *evalState) (any, int) {
+	for {
+		next := index
+		for next < len(path) && path[next] == ' ' {
+			next++
+		}
+		if next == len(path) || path[next] != '|' || next+1 < len(path) && path[next+1] == '|' {
+			return value, index
+		}
+		value, index = resolvePipe(path, data, value, next, state)
+	}
+}
 
-	if equalsOperator {
-		return leftStr == rightStr, index
+// isConditionEnd reports whether only spaces follow index before the end of the path
+// or a logical operator.
+func isConditionEnd(path string, index int) bool {
+	for index < len(path) && path[index] == ' ' {
+		index++
 	}
-	return leftStr != rightStr, index
+	return index == len(path) || index+1 < len(path) &&
+		(path[index] == '&' && path[index+1] == '&' || path[index] == '|' && path[index+1] == '|')
 }
 
-// parseOperator determines the comparison operator (== or !=) in a comparison expression.
-// Returns true for equals (==) and false for not equals (!=).
+// resolveInOperand evaluates the right-hand side of an 'in' comparison.
+// If the operand is a list literal such as ['a','b'], its elements are evaluated one
+// at a time and the evaluation stops at the first element equal to the needle, as
+// compared by '=='.
+// Any other operand is resolved first and then searched like with 'contains'.
+//
+// Parameters:
+//   - path: The path expression as a string
+//   - data: The data model to evaluate against
+//   - needle: The resolved left operand
+//   - index: The current index in the path (just after the operator)
+//   - state: The state of the current evaluation
+//
+// Returns:
+//   - true if the needle is found
+//   - The new index after processing
+func resolveInOperand(path string, data any, needle any, index int, state *evalState) (bool, int) {
+	for index < len(path) && path[index] == ' ' {
+		index++
+	}
+	if index >= len(path) || path[index] != '[' {
+		haystack, newIndex := resolveRightOperand(path, data, state, index)
+		return containsValue(haystack, needle), newIndex
+	}
+
+	closeIndex := findClosingASCII(path, index)
+	if closeIndex == -1 {
+		return false, len(path)
+	}
+	for _, rawElement := range splitArgumentsASCII(path[index+1 : closeIndex]) {
+		element, _ := resolveExpressions(rawElement, data, state, 0)
+		if valuesEqual(needle, element) {
+			return true, closeIndex + 1
+		}
+	}
+	return false, closeIndex + 1
+}
+
+// compareValues applies a comparison operator to two resolved operands.
+// Equality and ordering operators compare numerically if both operands are numbers
+// and compare the string representations of both operands otherwise.
+//
+// Parameters:
+//   - left: The resolved left operand
+//   - operator: The comparison operator
+//   - right: The resolved right operand
+//
+// Returns:
+//   - The boolean result of the comparison
+func compareValues(left any, operator comparisonOperator, right any) bool {
+	switch operator {
+	case opEquals:
+		return valuesEqual(left, right)
+	case opNotEquals:
+		return !valuesEqual(left, right)
+	case opEqualsFold:
+		return valuesEqualFold(left, right)
+	case opNotEqualsFold:
+		return !valuesEqualFold(left, right)
+	case opContains:
+		return containsValue(left, right)
+	case opIn:
+		return containsValue(right, left)
+	case opStartsWith, opEndsWith, opGlob:
+		if isNil(left) || isNil(right) {
+			return false
+		}
+		str, pattern := toString(left), toString(right)
+		switch operator {
+		case opStartsWith:
+			return strings.HasPrefix(str, pattern)
+		case opEndsWith:
+			return strings.HasSuffix(str, pattern)
+		default:
+			return matchGlob(pattern, str)
+		}
+	case opLess, opLessOrEqual, opGreater, opGreaterOrEqual:
+		order, ok := orderValues(left, right)
+		if !ok {
+			return false
+		}
+		switch operator {
+		case opLess:
+			return order < 0
+		case opLessOrEqual:
+			return order <= 0
+		case opGreater:
+			return order > 0
+		default:
+			return order >= 0
+		}
+	case opVersionLess, opVersionLessOrEqual, opVersionGreater, opVersionGreaterOrEqual:
+		order, ok := compareVersions(left, right)
+		if !ok {
+			return false
+		}
+		switch operator {
+		case opVersionLess:
+			return order < 0
+		case opVersionLessOrEqual:
+			return order <= 0
+		case opVersionGreater:
+			return order > 0
+		default:
+			return order >= 0
+		}
+	default:
+		return false
+	}
+}
+
+// valuesEqual reports whether two resolved operands are equal. nil (including nil
+// pointers, maps, and slices and missing values) is only equal to nil, so a value
+// that is not set can be told apart from an empty string. Two numbers are compared
+// by value (so 30 equals 30.0), exactly if both are integers, and two times by the
+// instant they denote (see asTimes); everything else is compared by its string
+// representation.
+func valuesEqual(left any, right any) bool {
+	if leftNil, rightNil := isNil(left), isNil(right); leftNil || rightNil {
+		return leftNil && rightNil
+	}
+	if leftTime, ok := left.(time.Time); ok {
+		if rightTime, ok := right.(time.Time); ok {
+			return leftTime.Equal(rightTime)
+		}
+	}
+	if order, ok := compareIntegers(left, right); ok {
+		return order == 0
+	}
+	if leftNum, ok := toFloat64(left); ok {
+		if rightNum, ok := toFloat64(right); ok {
+			return leftNum == rightNum
+		}
+	}
+	return toString(left) == toString(right)
+}
+
+// valuesEqualFold is like valuesEqual, but compares string representations with
+// Unicode case folding, so "Active" equals "ACTIVE".
+func valuesEqualFold(left any, right any) bool {
+	if isNil(left) || isNil(right) {
+		return valuesEqual(left, right)
+	}
+	return valuesEqual(left, right) || strings.EqualFold(toString(left), toString(right))
+}
+
+// orderValues compares two resolved operands for the ordering operators. Two numbers
+// are compared by value (exactly if both are integers), two times chronologically,
+// and everything else by its string representation. A nil operand cannot be
+// ordered, so every ordering comparison with nil is false.
+//
+// Returns:
+//   - -1, 0, or +1 if left is less than, equal to, or greater than right
+//   - false if the operands cannot be ordered
+func orderValues(left any, right any) (int, bool) {
+	if left == nil || right == nil {
+		return 0, false
+	}
+	if leftTime, ok := left.(time.Time); ok {
+		if rightTime, ok := right.(time.Time); ok {
+			return leftTime.Compare(rightTime), true
+		}
+	}
+	if order, ok := compareIntegers(left, right); ok {
+		return order, true
+	}
+	if leftNum, ok := toFloat64(left); ok {
+		if rightNum, ok := toFloat64(right); ok {
+			switch {
+			case leftNum < rightNum:
+				return -1, true
+			case leftNum > rightNum:
+				return 1, true
+			case leftNum == rightNum:
+				return 0, true
+			default:
+				// At least one operand is NaN.
+				return 0, false
+			}
+		}
+	}
+	return strings.Compare(toString(left), toString(right)), true
+}
+
+// containsValue implements the 'contains' operator. For arrays and slices it reports
+// whether an element equals the needle like '==' does (see valuesEqual), for maps
+// whether the needle is a key, and for any other value whether its string
+// representation contains the needle as a substring. A nil haystack contains
+// nothing, and a nil needle is neither a key nor a substring.
+//
+// Parameters:
+//   - haystack: The value to search in
+//   - needle: The value to search for
+//
+// Returns:
+//   - true if the haystack contains the needle
+func containsValue(haystack any, needle any) bool {
+	if haystack == nil {
+		return false
+	}
+	value := reflect.ValueOf(haystack)
+	if value.Kind() == reflect.Array || value.Kind() == reflect.Slice {
+		for i := 0; i < value.Len(); i++ {
+			if valuesEqual(extractValue(value.Index(i)), needle) {
+				return true
+			}
+		}
+		return false
+	}
+	if isNil(needle) {
+		return false
+	}
+	needleStr := toString(needle)
+	if value.Kind() == reflect.Map {
+		key := parseMapKey(needleStr, value.Type().Key())
+		return key.IsValid() && value.MapIndex(key).IsValid()
+	}
+	return strings.Contains(toString(haystack), needleStr)
+}
+
+// parseOperator determines the comparison operator in a comparison expression.
+// Spaces before the operator are skipped. Symbolic operators ('==', '!=', '<', '<=',
+// '>', '>=', and the variants with an 'i' or 'v' suffix) and word
+// operators ('contains', 'in') are recognized; word operators must be followed by a
+// character that cannot be part of an identifier.
 //
 // Parameters:
 //   - path: The path expression as a string
 //   - index: The current index in the path
 //
 // Returns:
-//   - true for equals operator (==), false for not equals operator (!=)
+//   - The comparison operator
 //   - The new index after processing
 //   - Error if an invalid operator is found
-func parseOperator(path string, index int) (bool, int, error) {
+func parseOperator(path string, index int) (comparisonOperator, int, error) {
+	for index < len(path) && path[index] == ' ' {
+		index++
+	}
 	if index >= len(path)-1 {
-		return false, index + 1, errors.New("no operator found for comparison")
+		return opEquals, index + 1, errors.New("no operator found for comparison")
+	}
+	if (path[index] == '!' || path[index] == '=') && path[index+1] == '=' {
+		folded := isOperatorSuffix(path, index+2, 'i')
+		switch {
+		case path[index] == '!' && folded:
+			return opNotEqualsFold, index + 3, nil
+		case path[index] == '!':
+			return opNotEquals, index + 2, nil
+		case folded:
+			return opEqualsFold, index + 3, nil
+		default:
+			return opEquals, index + 2, nil
+		}
+	}
+	if isAffixOperator(path, index) {
+		if path[index] == '^' {
+			return opStartsWith, index + 2, nil
+		}
+		return opEndsWith, index + 2, nil
 	}
-	if path[index] == '!' && path[index+1] == '=' {
-		return false, index + 2, nil
+	if path[index] == '~' && path[index+1] == 'g' {
+		return opGlob, index + 2, nil
 	}
-	if path[index] == '=' && path[index+1] == '=' {
-		return true, index + 2, nil
+	if path[index] == '<' || path[index] == '>' {
+		orEqual := path[index+1] == '='
+		end := index + 1
+		if orEqual {
+			end++
+		}
+		version := isOperatorSuffix(path, end, 'v')
+		if version {
+			end++
+		}
+		switch {
+		case path[index] == '<' && orEqual && version:
+			return opVersionLessOrEqual, end, nil
+		case path[index] == '<' && orEqual:
+			return opLessOrEqual, end, nil
+		case path[index] == '<' && version:
+			return opVersionLess, end, nil
+		case path[index] == '<':
+			return opLess, end, nil
+		case orEqual && version:
+			return opVersionGreaterOrEqual, end, nil
+		case orEqual:
+			return opGreaterOrEqual, end, nil
+		case version:
+			return opVersionGreater, end, nil
+		default:
+			return opGreater, end, nil
+		}
+	}
+	if isIdentStart(path, index) {
+		word, newIndex := readIdentifier(path, index)
+		if operator, ok := wordOperators[word]; ok {
+			return operator, newIndex, nil
+		}
+	}
+	return opEquals, index + 1, errors.New("invalid operator")
+}
+
+// isOperatorSuffix reports whether the suffix of an operator such as '==i' or '>=v'
+// is at index, that is, the suffix character not starting a longer word such as
+// "isnil".
+func isOperatorSuffix(path string, index int, suffix byte) bool {
+	if index >= len(path) || path[index] != suffix {
+		return false
 	}
-	return false, index + 1, errors.New("invalid operator")
+	return index+1 == len(path) || !isIdentStart(path, index+1) && !isDigit(path[index+1])
 }
 
 // resolveReference processes an external reference.
-// External references start with ':' followed by the reference name.
+// External references start with ':' followed by the reference name. The
+// ReferenceResolver receives the data of the evaluation, or, if the name is followed
+// by an argument in parentheses (":formatDate(.CreatedAt)"), the value of the
+// argument. A model path directly following the argument is resolved against the
+// value of the reference.
 //
 // Parameters:
 //   - path: The path expression as a string
 //   - data: The data model to evaluate against
 //   - index: The current index in the path
-//   - refResolver: Function to resolve external references
+//   - state: The state of the current evaluation
 //
 // Returns:
 //   - The resolved value from the external reference
 //   - The new index after processing
-func resolveReference(path string, data any, index int, refResolver ReferenceResolver) (any, int) {
+func resolveReference(path string, data any, index int, state *evalState) (any, int) {
 	// Skip over the ':' prefix
 	index++
-	referenceName, index := readUntilTerminatorASCII(path, index)
+	referenceName, index := readReferenceName(path, index)
+	if index >= len(path) || path[index] != '(' {
+		return callReference(referenceName, data, state), index
+	}
+
+	closeIndex := findClosingASCII(path, index)
+	if closeIndex == -1 {
+		// Unterminated argument, consume the rest of the path
+		return nil, len(path)
+	}
+	arg, _ := resolveExpressions(path[index+1:closeIndex], data, state, 0)
+	if cache, ok := state.engine(referenceName); ok {
+		return resolveTrailingPath(path, ":"+referenceName, state.runEngine(cache, referenceName, arg, data), closeIndex+1, state)
+	}
+	return resolveTrailingPath(path, ":"+referenceName, callReference(referenceName, arg, state), closeIndex+1, state)
+}
+
+// callReference resolves the external reference name with the ReferenceResolver of
+// the evaluation, passing it value as its data.
+func callReference(name string, value any, state *evalState) any {
+	if state.audit != nil {
+		state.audit.reference(name)
+	}
+	var referenceValue any
+	if state.refResolver != nil {
+		referenceValue = state.refResolver(name, value)
+	}
+	if referenceValue == nil && state.diagnose() {
+		state.fail(&UnresolvedReferenceError{Name: name})
+	}
+	return referenceValue
+}
+
+// resolveScope processes a root reference ('$'), which refers to the data the
+// evaluation started with, a variable ('$name'), which refers to a value passed to
+// ResolveWithVars, or a parent reference ('^'), which inside a filter refers to the
+// value holding the filtered collection (in ".Orders[?.Items[?.Price > ^.Limit]]"
+// the order whose items are filtered). The reference may be followed by a model
+// path that is resolved against the referenced value, as in "$.Budget",
+// "$user.Name", or "^.Limit"; after a variable, the model path may also start with
+// a bracket segment, as in "$users[0].Name".
+//
+// Parameters:
+//   - path: The path expression as a string
+//   - index: The current index in the path (should point to the '$' or '^' character)
+//   - state: The state of the current evaluation
+//
+// Returns:
+//   - The referenced value, or the value of the model path resolved against it
+//   - The new index after processing
+func resolveScope(path string, index int, state *evalState) (any, int) {
+	start := index
+	scope := state.root
+	isVariable := false
+	if path[index] == '^' {
+		scope = state.parent
+		index++
+	} else if index+1 < len(path) && isIdentStart(path, index+1) {
+		var name string
+		name, index = readIdentifier(path, index+1)
+		scope = state.vars[name]
+		isVariable = true
+	} else {
+		index++
+	}
+	if index >= len(path) || path[index] != '.' && (!isVariable || path[index] != '[') {
+		return scope, index
+	}
+	if isVariable {
+		// The path is resolved against the variable, not the top-level data.
+		state.scopeDepth++
+		defer func() { state.scopeDepth-- }()
+	}
+	if state.audit != nil {
+		base := ""
+		if path[start] == '^' {
+			base = state.audit.parent
+		} else if isVariable {
+			base = path[start:index]
+		}
+		defer state.audit.rebase(base)()
+	}
+	result, newIndex, err := resolveModel(path, scope, index, state)
+	if err != nil {
+		return nil, newIndex
+	}
+	return result, newIndex
+}
 
-	if refResolver == nil {
-		return nil, index
+// resolveLet processes a binding such as "let $u = .Order.Customer; $u.Name ' <'
+// $u.Email '>'". The bound expression is evaluated once and its value is available
+// as a variable in the rest of the expression, which may start with further
+// bindings. The binding shadows a variable of the same name passed to
+// ResolveWithVars.
+//
+// Parameters:
+//   - path: The path expression as a string
+//   - data: The data model to evaluate against
+//   - index: The current index in the path (should point to the keyword "let")
+//   - state: The state of the current evaluation
+//
+// Returns:
+//   - The value of the rest of the expression
+//   - The new index after processing
+func resolveLet(path string, data any, index int, state *evalState) (any, int) {
+	name, _, valueStart, _ := readLetBinding(path, index)
+	end := bindingEnd(path, valueStart)
+	if end == -1 {
+		// Unterminated binding, consume the rest of the path
+		return nil, len(path)
+	}
+	// The bound value is not part of the output of ResolveTo.
+	out := state.out
+	state.out = nil
+	value, _ := resolveExpressions(path[valueStart:end], data, state, 0)
+	state.out = out
+
+	outerVars := state.vars
+	state.vars = make(map[string]any, len(outerVars)+1)
+	for key, outer := range outerVars {
+		state.vars[key] = outer
 	}
-	referenceValue := refResolver(referenceName, data)
-	return referenceValue, index
+	state.vars[name] = value
+	defer func() { state.vars = outerVars }()
+	return resolveExpressions(path, data, state, end+1)
 }
 
 // resolveNegation processes a negation expression in a path.
 // Negation expressions start with '!' and negate a boolean value or convert a value to its boolean opposite.
+// A '!' before a comparison ("!?.Active=='true'") or a group of conditions
+// ("!(.A == 1 && .B == 2)") negates its result. WithTemplateTruthiness changes which
+// values count as true.
 //
 // Parameters:
 //   - path: The path expression as a string
 //   - data: The data model to evaluate against
 //   - index: The current index in the path
-//   - refResolver: Function to resolve external references
+//   - state: The state of the current evaluation
 //
 // Returns:
 //   - The negated boolean value
 //   - The new index after processing
-func resolveNegation(path string, data any, index int, refResolver ReferenceResolver) (any, int) {
+func resolveNegation(path string, data any, index int, state *evalState) (any, int) {
 	// skip over the ! prefix
 	index++
+	for index < len(path) && path[index] == ' ' {
+		index++
+	}
+	if index < len(path) && (path[index] == '?' || path[index] == '(') {
+		// A negated comparison or group of conditions.
+		var result bool
+		if path[index] == '?' {
+			result, index = resolveComparison(path, data, index, state)
+		} else {
+			result, index = resolveCondition(path, data, index, state)
+		}
+		return !result, index
+	}
 
-	value, newIndex := resolveOperand(path, data, refResolver, index)
+	value, newIndex := resolveOperand(path, data, state, index)
+	if state.resolver != nil && state.resolver.templateTruthiness {
+		return !isTemplateTrue(value), newIndex
+	}
 	// If it's already a boolean, just negate it
 	if boolValue, ok := value.(bool); ok {
 		return !boolValue, newIndex
@@ -128,21 +736,98 @@ func resolveNegation(path string, data any, index int, refResolver ReferenceReso
 // Parameters:
 //   - path: The path expression as a string
 //   - data: The data model to evaluate against
-//   - index: The current index in the path (should point to the '.' character)
+//   - index: The current index in the path (should point to the '.' character, or
+//     to the '[' of a model path starting with a bracket segment)
+//   - state: The state of the current evaluation
 //
 // Returns:
 //   - The resolved value from the data model
 //   - The new index after processing
 //   - Error if the path cannot be resolved
-func resolveModel(path string, data any, index int) (any, int, error) {
+func resolveModel(path string, data any, index int, state *evalState) (any, int, error) {
 	// skip over the '.'
-	index++
-	modelPath, index := readUntilTerminatorASCII(path, index)
+	if path[index] == '.' {
+		index++
+	}
+	modelPath, index := readModelPathASCII(path, index)
 	if data == nil {
+		if modelPath != "" && state.diagnose() {
+			state.fail(&NilIntermediateError{Segment: "."})
+		}
 		return nil, index, nil
 	}
 	value := reflect.ValueOf(data)
-	result := resolvePathAgainstValue(modelPath, value)
+	outerOwner, outerPath := state.owner, state.modelPath
+	state.owner, state.modelPath = value, modelPath
+	if state.audit != nil {
+		defer state.audit.visit(state.audit.data, modelPath)()
+	}
+	var result reflect.Value
+	if state.memo != nil && state.scopeDepth == 0 {
+		result = resolveMemoized(modelPath, value, state)
+	} else {
+		result = resolvePathAgainstValue(modelPath, value, state)
+	}
+	state.owner, state.modelPath = outerOwner, outerPath
 
 	return extractValue(result), index, nil
 }
+
+// resolveListLiteral processes a list literal such as ['a', 'b', .Name].
+// Each comma-separated element is evaluated as a full expression.
+//
+// Parameters:
+//   - path: The path expression as a string
+//   - data: The data model to evaluate against
+//   - index: The current index in the path (should point to the '[' character)
+//   - state: The state of the current evaluation
+//
+// Returns:
+//   - The evaluated elements as a []any
+//   - The new index after processing
+func resolveListLiteral(path string, data any, index int, state *evalState) (any, int) {
+	closeIndex := findClosingASCII(path, index)
+	if closeIndex == -1 {
+		return nil, len(path)
+	}
+	rawElements := splitArgumentsASCII(path[index+1 : closeIndex])
+	elements := make([]any, len(rawElements))
+	for i, rawElement := range rawElements {
+		elements[i], _ = resolveExpressions(rawElement, data, state, 0)
+	}
+	return elements, closeIndex + 1
+}
+
+// matchGlob reports whether str matches the glob pattern, in which '*' matches any
+// sequence of characters (including '/' and '.'), '?' matches a single character, and
+// '\' escapes the character that follows it. The whole string must match.
+func matchGlob(pattern string, str string) bool {
+	p, s := []rune(pattern), []rune(str)
+	// starP and starS record the position after the last '*' and the position in str it
+	// was tried at, so a failed match can backtrack to let the '*' match one more rune.
+	starP, starS := -1, 0
+	i, j := 0, 0
+	for j < len(s) {
+		switch {
+		case i < len(p) && p[i] == '*':
+			i++
+			starP, starS = i, j
+			continue
+		case i < len(p) && p[i] == '\\' && i+1 < len(p) && p[i+1] == s[j]:
+			i, j = i+2, j+1
+			continue
+		case i < len(p) && p[i] != '\\' && (p[i] == '?' || p[i] == s[j]):
+			i, j = i+1, j+1
+			continue
+		}
+		if starP == -1 {
+			return false
+		}
+		starS++
+		i, j = starP, starS
+	}
+	for i < len(p) && p[i] == '*' {
+		i++
+	}
+	return i == len(p)
+}
@@ -0,0 +1,342 @@
+package empaths
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"time"
+)
+
+// ErrLimitExceeded is returned (wrapped) by Resolver.ResolveErr when the evaluation
+// of a path exceeds one of the limits configured with WithMaxDepth or
+// WithMaxSegments.
+var ErrLimitExceeded = errors.New("resolution limit exceeded")
+
+// Resolver evaluates path expressions with a fixed set of options. The package-level
+// Resolve function behaves like a Resolver without options.
+//
+// A Resolver is immutable once created and safe for concurrent use.
+//
+//	resolver := empaths.NewResolver(empaths.WithMaxDepth(8), empaths.WithMaxSegments(1000))
+//	value := resolver.Resolve(userSuppliedPath, data, nil)
+type Resolver struct {
+	maxDepth    int
+	maxSegments int
+	// maxOutputBytes limits the length of concatenations (see WithMaxOutputBytes).
+	maxOutputBytes int
+	// truncateOutput truncates concatenations instead of aborting (see
+	// WithTruncatedOutput).
+	truncateOutput bool
+	// allowed holds the names of the allowed prefixes; nil allows all paths.
+	allowed [][]string
+	// denied holds the denied field, method, and map key names.
+	denied map[string]bool
+	// allowedMethods holds the methods that may be called per type; nil allows all
+	// methods (see WithAllowedMethods).
+	allowedMethods map[reflect.Type]map[string]bool
+	// strict reports paths that cannot be resolved as errors (see WithStrict).
+	strict bool
+	// formatter converts values to strings (see WithFormatter); it may be nil.
+	formatter Formatter
+	// timeLayouts are the layouts of strings compared as times (see WithTimeLayouts).
+	timeLayouts []string
+	// templateTruthiness decides truth like Go templates (see WithTemplateTruthiness).
+	templateTruthiness bool
+	// floatEpsilon is the tolerance for comparing numbers (see WithFloatEpsilon).
+	floatEpsilon float64
+	// interceptors post-process the final value (see WithInterceptor).
+	interceptors []Interceptor
+	// segmentInterceptors post-process every segment value (see WithSegmentInterceptor).
+	segmentInterceptors []Interceptor
+	// prefixes holds the handlers of custom prefixes (see WithPrefix); it may be nil.
+	prefixes map[byte]PrefixHandler
+	// bytesEncoding converts byte slices to strings (see WithBytesEncoding).
+	bytesEncoding BytesEncoding
+	// keyResolvers convert map keys written in paths (see WithKeyResolver).
+	keyResolvers []KeyResolver
+	// unexportedFields makes unexported struct fields readable (see WithUnexportedFields).
+	unexportedFields bool
+	// observer receives the metrics of every evaluation (see WithMetrics); it may be nil.
+	observer Observer
+	// auditor receives the references and fields read by every evaluation (see
+	// WithAudit); it may be nil.
+	auditor Auditor
+	// secrets holds the names of the secret paths (see WithSecrets).
+	secrets [][]string
+	// authorizer decides which segments may be read (see WithAuthorizer); it may be
+	// nil.
+	authorizer Authorizer
+	// engines holds the engines of other expression languages by reference name (see
+	// WithEngine); it may be nil.
+	engines map[string]*engineCache
+}
+
+// defaultResolver is a Resolver without options.
+var defaultResolver = &Resolver{}
+
+// Option configures a Resolver.
+type Option func(*Resolver)
+
+// NewResolver creates a Resolver with the given options.
+func NewResolver(opts ...Option) *Resolver {
+	r := &Resolver{}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// WithMaxDepth limits how deeply expressions may be nested. Every filter, function
+// argument, and list element is evaluated one level deeper than the expression that
+// contains it, so ".Users[?.Tags[?.Name=='x']]" needs a depth of 3. Evaluation is
+// aborted when the limit is exceeded. A limit of 0 (the default) means no limit.
+func WithMaxDepth(n int) Option {
+	return func(r *Resolver) {
+		r.maxDepth = n
+	}
+}
+
+// WithMaxSegments limits the total number of model path segments (field, method,
+// index, and key accesses) a single evaluation may perform. Segments applied to the
+// elements of a wildcard or filter count once per element, so this also bounds the
+// work done for large collections. Evaluation is aborted when the limit is exceeded.
+// A limit of 0 (the default) means no limit.
+func WithMaxSegments(n int) Option {
+	return func(r *Resolver) {
+		r.maxSegments = n
+	}
+}
+
+// WithMaxOutputBytes limits the length of the strings built by concatenating
+// operands, such as "'Hello ' .Name", to n bytes. Evaluation is aborted when a
+// concatenation would exceed the limit, unless WithTruncatedOutput is also given.
+// Values that are not concatenated are not limited. A limit of 0 (the default)
+// means no limit.
+func WithMaxOutputBytes(n int) Option {
+	return func(r *Resolver) {
+		r.maxOutputBytes = n
+	}
+}
+
+// WithTruncatedOutput makes concatenations that exceed the limit set with
+// WithMaxOutputBytes end at the limit instead of aborting the evaluation. Strings
+// are truncated at a UTF-8 character boundary.
+func WithTruncatedOutput() Option {
+	return func(r *Resolver) {
+		r.truncateOutput = true
+	}
+}
+
+// Resolve evaluates a path expression like the package-level Resolve function,
+// honoring the Resolver's options. It returns nil if a limit is exceeded or the
+// path is not allowed.
+func (r *Resolver) Resolve(path string, data any, refResolver ReferenceResolver) any {
+	result, _ := r.ResolveErr(path, data, refResolver)
+	return result
+}
+
+// ResolveErr evaluates a path expression like Resolve, but reports why evaluation
+// was aborted.
+//
+// Parameters:
+//   - path: The path expression to evaluate
+//   - data: The data model to evaluate the path against
+//   - refResolver: Optional function to resolve external references (prefixed with ':')
+//
+// Returns:
+//   - The resolved value, or nil if evaluation was aborted
+//   - Error wrapping ErrLimitExceeded if a limit was exceeded, or ErrAccessDenied
+//     if the path is not allowed
+func (r *Resolver) ResolveErr(path string, data any, refResolver ReferenceResolver) (any, error) {
+	return r.ResolveCtx(context.Background(), path, data, refResolver)
+}
+
+// ResolveCtx evaluates a path expression like ResolveErr and additionally aborts
+// when ctx is done. The context is checked before every model path segment, and
+// therefore before every method call and for every element of a wildcard or filter.
+//
+// Parameters:
+//   - ctx: The context of the evaluation
+//   - path: The path expression to evaluate
+//   - data: The data model to evaluate the path against
+//   - refResolver: Optional function to resolve external references (prefixed with ':')
+//
+// Returns:
+//   - The resolved value, or nil if evaluation was aborted
+//   - ctx.Err() if the context is done, or an error wrapping ErrLimitExceeded if a
+//     limit was exceeded or ErrAccessDenied if the path is not allowed
+func (r *Resolver) ResolveCtx(ctx context.Context, path string, data any, refResolver ReferenceResolver) (any, error) {
+	result, _, err := r.run(ctx, path, data, refResolver, nil)
+	return result, err
+}
+
+// run implements ResolveCtx and ResolveTo: it evaluates a path expression, writing
+// its result to out if it is not nil, and reports the evaluation to the observer and
+// auditor of the Resolver. It also returns the state of the evaluation (see
+// evaluate).
+func (r *Resolver) run(ctx context.Context, path string, data any, refResolver ReferenceResolver, out io.Writer) (any, *evalState, error) {
+	if r.observer == nil && r.auditor == nil {
+		return r.evaluate(ctx, path, data, refResolver, out)
+	}
+	start := time.Now()
+	result, state, err := r.evaluate(ctx, path, data, refResolver, out)
+	if r.observer != nil {
+		r.observe(path, start, state, err)
+	}
+	if r.auditor != nil {
+		r.audit(path, state)
+	}
+	return result, state, err
+}
+
+// evaluate implements run. It also returns the state of the evaluation, which is nil
+// if the path was rejected or is empty.
+func (r *Resolver) evaluate(ctx context.Context, path string, data any, refResolver ReferenceResolver, out io.Writer) (any, *evalState, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	if r.hasAccessRules() {
+		if err := r.checkAccess(path); err != nil {
+			return nil, nil, err
+		}
+	}
+	if r.strict {
+		if _, err := r.tokens(path); err != nil {
+			return nil, nil, err
+		}
+	}
+	if path == "" {
+		return r.intercept(path, data), nil, nil
+	}
+	state := &evalState{refResolver: refResolver, resolver: r, root: data}
+	if r.tracksFields() {
+		state.audit = newAuditLog()
+	}
+	if r.authorizer != nil {
+		state.subject = ctx.Value(subjectKey{})
+	}
+	if out != nil {
+		state.out = &output{w: out}
+	}
+	if ctx.Done() != nil {
+		// Contexts that can never be cancelled are not checked at all.
+		state.ctx = ctx
+	}
+	result, _ := resolveExpressions(path, data, state, 0)
+	state.redactErrors()
+	if state.err != nil {
+		return nil, state, state.err
+	}
+	return r.intercept(path, result), state, nil
+}
+
+// evalState holds the state of a single evaluation of a path expression. It is
+// passed through all resolution functions.
+type evalState struct {
+	// refResolver resolves external references; it may be nil.
+	refResolver ReferenceResolver
+	// resolver holds the options of the evaluation; it is nil for the package-level
+	// functions.
+	resolver *Resolver
+	// ctx cancels the evaluation; it may be nil.
+	ctx context.Context
+	// root is the data the evaluation started with, referred to by '$'.
+	root any
+	// subject is the subject passed to the Authorizer (see WithSubject).
+	subject any
+	// vars holds the variables referred to by '$name'; it may be nil.
+	vars map[string]any
+	// modelPath is the model path being resolved (without its leading '.'), for
+	// error messages.
+	modelPath string
+	// owner is the value holding the collection that the next bracket segment is
+	// applied to: the struct or map of the preceding field, or the data of the
+	// model path if it starts with a bracket.
+	owner reflect.Value
+	// parent is the value referred to by '^': inside a filter, the owner of the
+	// filtered collection; nil outside of filters.
+	parent any
+	// depth is the current nesting depth of expressions.
+	depth int
+	// segments is the number of model path segments resolved so far.
+	segments int
+	// memoHits is the number of model path prefixes taken from memo.
+	memoHits int
+	// memo holds the values of the model path prefixes resolved against the
+	// top-level data (see resolveMemoized); it is nil if memoization is not used.
+	memo map[string]reflect.Value
+	// scopeDepth is the number of filters and variable paths being evaluated. Model
+	// paths inside them are not resolved against the top-level data and are not
+	// memoized.
+	scopeDepth int
+	// tracing records failures to resolve parts of the path in trace instead of
+	// aborting the evaluation (see ResolveTrace).
+	tracing bool
+	// trace holds the failures recorded while tracing.
+	trace []error
+	// quiet is the number of conditions being evaluated whose result does not matter,
+	// such as the right-hand side of '&&' after a false condition. Failures are not
+	// reported while it is positive.
+	quiet int
+	// readOnly is positive while the rest of a model path is resolved below an
+	// unexported field, where no methods or functions are called (see
+	// WithUnexportedFields).
+	readOnly int
+	// audit collects the references and fields read (see WithAudit); it is nil if
+	// they are not recorded.
+	audit *auditLog
+	// secret is set when the evaluation read a value that is secret (see
+	// WithSecrets); readSecret also checks the fields recorded in audit.
+	secret bool
+	// out receives the operands of the top-level expression (see ResolveTo); it is
+	// nil if the result is returned instead, and while nested expressions are
+	// evaluated.
+	out *output
+	// err is set when the evaluation is aborted. Once it is set, all resolution
+	// functions return immediately.
+	err error
+}
+
+// enter is called when a nested expression is evaluated. It returns false if the
+// evaluation has been aborted or the maximum depth is exceeded. Every successful
+// call must be paired with a call to leave.
+func (s *evalState) enter() bool {
+	if s.err != nil {
+		return false
+	}
+	s.depth++
+	if s.resolver != nil && s.resolver.maxDepth > 0 && s.depth > s.resolver.maxDepth {
+		s.depth--
+		s.err = fmt.Errorf("%w: expressions nested deeper than %d levels", ErrLimitExceeded, s.resolver.maxDepth)
+		return false
+	}
+	return true
+}
+
+// leave is called when the evaluation of a nested expression is finished.
+func (s *evalState) leave() {
+	s.depth--
+}
+
+// countSegment is called before a model path segment is resolved. It returns false
+// if the evaluation has been aborted, the context is done, or the maximum number of
+// segments is exceeded.
+func (s *evalState) countSegment() bool {
+	if s.err != nil {
+		return false
+	}
+	if s.ctx != nil {
+		if err := s.ctx.Err(); err != nil {
+			s.err = err
+			return false
+		}
+	}
+	s.segments++
+	if s.resolver != nil && s.resolver.maxSegments > 0 && s.segments > s.resolver.maxSegments {
+		s.err = fmt.Errorf("%w: more than %d path segments", ErrLimitExceeded, s.resolver.maxSegments)
+		return false
+	}
+	return true
+}
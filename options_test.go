@@ -0,0 +1,172 @@
+package empaths
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestResolver_MaxDepth(t *testing.T) {
+	team := createTestTeam()
+
+	tests := []struct {
+		name     string
+		maxDepth int
+		path     string
+		expected any
+		exceeded bool
+	}{
+		{"no limit", 0, "count(.Users[?.Active==true])", 2, false},
+		{"flat path within limit", 1, ".Users[0].Name", "Alice", false},
+		{"concatenation is not nested", 1, ".Users[0].Name ' ' .Users[1].Name", "Alice Bob", false},
+		{"function argument is nested", 1, "count(.Users)", nil, true},
+		{"function argument within limit", 2, "count(.Users)", 3, false},
+		{"filter inside function", 2, "count(.Users[?.Active==true])", nil, true},
+		{"filter inside function within limit", 3, "count(.Users[?.Active==true])", 2, false},
+		{"list literal is nested", 1, "?'a' in ['a']", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolver := NewResolver(WithMaxDepth(tt.maxDepth))
+			result, err := resolver.ResolveErr(tt.path, team, nil)
+			if tt.exceeded != errors.Is(err, ErrLimitExceeded) {
+				t.Fatalf("ResolveErr(%q) error = %v, want limit exceeded: %v", tt.path, err, tt.exceeded)
+			}
+			if result != tt.expected {
+				t.Errorf("ResolveErr(%q) = %v, want %v", tt.path, result, tt.expected)
+			}
+			if result := resolver.Resolve(tt.path, team, nil); result != tt.expected {
+				t.Errorf("Resolve(%q) = %v, want %v", tt.path, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestResolver_MaxSegments(t *testing.T) {
+	team := createTestTeam()
+
+	tests := []struct {
+		name        string
+		maxSegments int
+		path        string
+		expected    any
+		exceeded    bool
+	}{
+		{"no limit", 0, "join(.Users[*].Name, ',')", "Alice,Bob,Carol", false},
+		{"within limit", 2, ".Scores.math", 95, false},
+		{"map key counts as segment", 1, ".Scores.math", nil, true},
+		{"index counts as segment", 1, ".Users[0]", nil, true},
+		{"concatenation counts all paths", 2, ".Scores.math .Scores.science", nil, true},
+		{"memoized segments count once", 2, ".Scores.math .Scores.math", "9595", false},
+		{"wildcard counts per element", 4, "join(.Users[*].Name, ',')", nil, true},
+		{"wildcard within limit", 5, "join(.Users[*].Name, ',')", "Alice,Bob,Carol", false},
+		{"filter counts per element", 4, "count(.Users[?.Active==true])", nil, true},
+		{"filter within limit", 5, "count(.Users[?.Active==true])", 2, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolver := NewResolver(WithMaxSegments(tt.maxSegments))
+			result, err := resolver.ResolveErr(tt.path, team, nil)
+			if tt.exceeded != errors.Is(err, ErrLimitExceeded) {
+				t.Fatalf("ResolveErr(%q) error = %v, want limit exceeded: %v", tt.path, err, tt.exceeded)
+			}
+			if result != tt.expected {
+				t.Errorf("ResolveErr(%q) = %v, want %v", tt.path, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestResolver_MaxOutputBytes(t *testing.T) {
+	team := createTestTeam()
+
+	tests := []struct {
+		name     string
+		opts     []Option
+		path     string
+		expected any
+		exceeded bool
+	}{
+		{"no limit", nil, "'Hello, ' .Users[0].Name", "Hello, Alice", false},
+		{"within limit", []Option{WithMaxOutputBytes(12)}, "'Hello, ' .Users[0].Name", "Hello, Alice", false},
+		{"exceeded", []Option{WithMaxOutputBytes(11)}, "'Hello, ' .Users[0].Name", nil, true},
+		{"single value not limited", []Option{WithMaxOutputBytes(3)}, ".Users[0].Name", "Alice", false},
+		{"nested concatenation", []Option{WithMaxOutputBytes(4)}, "len(.Users[0].Name ' ' .Users[1].Name)", nil, true},
+		{"truncated", []Option{WithMaxOutputBytes(9), WithTruncatedOutput()}, "'Hello, ' .Users[0].Name ' and more'", "Hello, Al", false},
+		{"truncated at character boundary", []Option{WithMaxOutputBytes(4), WithTruncatedOutput()}, "'Grüße' ' x'", "Grü", false},
+		{"truncation without limit", []Option{WithTruncatedOutput()}, "'Hello, ' .Users[0].Name", "Hello, Alice", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := NewResolver(tt.opts...).ResolveErr(tt.path, team, nil)
+			if tt.exceeded != errors.Is(err, ErrLimitExceeded) {
+				t.Fatalf("ResolveErr(%q) error = %v, want limit exceeded: %v", tt.path, err, tt.exceeded)
+			}
+			if result != tt.expected {
+				t.Errorf("ResolveErr(%q) = %v, want %v", tt.path, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestResolver_References(t *testing.T) {
+	resolver := NewResolver(WithMaxDepth(4))
+	refResolver := func(name string, data any) any { return "ref:" + name }
+	if result := resolver.Resolve("'x' :name", nil, refResolver); result != "xref:name" {
+		t.Errorf("Resolve = %v, want xref:name", result)
+	}
+	if result := resolver.Resolve("", 42, nil); result != 42 {
+		t.Errorf("Resolve(\"\") = %v, want 42", result)
+	}
+}
+
+// slowCounter counts how often its method is called
+type slowCounter struct {
+	calls  *int
+	cancel context.CancelFunc
+}
+
+// Next cancels the context on the second call
+func (c slowCounter) Next() slowCounter {
+	*c.calls++
+	if *c.calls == 2 {
+		c.cancel()
+	}
+	return c
+}
+
+func TestResolveCtx(t *testing.T) {
+	team := createTestTeam()
+
+	result, err := ResolveCtx(context.Background(), "count(.Users[?.Active==true])", team, nil)
+	if err != nil || result != 2 {
+		t.Errorf("ResolveCtx = %v, %v, want 2, nil", result, err)
+	}
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if result, err := ResolveCtx(cancelled, ".Users[0].Name", team, nil); result != nil || !errors.Is(err, context.Canceled) {
+		t.Errorf("ResolveCtx with cancelled context = %v, %v, want nil, context.Canceled", result, err)
+	}
+
+	// Cancellation during evaluation stops before the next method call.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	calls := 0
+	counter := slowCounter{calls: &calls, cancel: cancel}
+	result, err = ResolveCtx(ctx, ".Next.Next.Next.Next", counter, nil)
+	if result != nil || !errors.Is(err, context.Canceled) {
+		t.Errorf("ResolveCtx = %v, %v, want nil, context.Canceled", result, err)
+	}
+	if calls != 2 {
+		t.Errorf("method was called %d times, want 2", calls)
+	}
+
+	resolver := NewResolver(WithMaxSegments(1))
+	if _, err := resolver.ResolveCtx(context.Background(), ".Users[0]", team, nil); !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("Resolver.ResolveCtx error = %v, want ErrLimitExceeded", err)
+	}
+}
@@ -0,0 +1,96 @@
+package empaths
+
+import "reflect"
+
+// Overlay returns data that resolves paths against several layers of data, such as
+// the layers of a configuration, where later layers take precedence over earlier
+// ones:
+//
+//	config := empaths.Overlay(defaults, fileConfig, envConfig, flagConfig)
+//	empaths.Resolve(".Server.Port", config, nil) // from flags, else env, file, defaults
+//
+// Layers are merged segment by segment: a field or key is looked up in every layer,
+// and the value of the last layer that has it is used. If that value is a map or a
+// struct with exported fields, and earlier layers hold maps or structs at the same
+// path as well, the rest of the path is resolved against an overlay of those values,
+// so a layer only needs to hold the values it overrides. Fields and keys whose value
+// is nil are treated as missing, so a layer can leave a value unset.
+//
+// Layers may be structs, maps, pointers to them, or any other data. Slices are not
+// merged; the slice of the last layer that has one is used. Path segments that are
+// resolved against an overlay itself, rather than against a value from a layer,
+// only support field and key access, not wildcards and filters. A path that ends at
+// maps or structs of several layers resolves to an overlay of them, which further
+// paths can be resolved against.
+//
+// Parameters:
+//   - layers: The layers, in increasing order of precedence; nil layers are ignored
+//
+// Returns:
+//   - Data that paths can be resolved against with Resolve and the other functions
+func Overlay(layers ...any) any {
+	o := overlay{layers: make([]any, 0, len(layers))}
+	for _, layer := range layers {
+		if layer != nil {
+			o.layers = append(o.layers, layer)
+		}
+	}
+	return o
+}
+
+// overlay is the data returned by Overlay.
+type overlay struct {
+	// layers are the layers, in increasing order of precedence.
+	layers []any
+}
+
+// ResolvePathSegment resolves name against the layers (see Overlay).
+func (o overlay) ResolvePathSegment(name string) (any, bool) {
+	segment := keySegment(name)
+	if segment[0] == '[' {
+		segment = "." + segment
+	}
+	var nested []any
+	for i := len(o.layers) - 1; i >= 0; i-- {
+		value := Resolve(segment, o.layers[i], nil)
+		if value == nil {
+			continue
+		}
+		if !isOverlayContainer(value) {
+			if len(nested) == 0 {
+				return value, true
+			}
+			// Shadowed by a map or struct of a layer with a higher precedence.
+			continue
+		}
+		nested = append(nested, value)
+	}
+	switch len(nested) {
+	case 0:
+		return nil, false
+	case 1:
+		return nested[0], true
+	}
+	// nested is in decreasing order of precedence.
+	for i, j := 0, len(nested)-1; i < j; i, j = i+1, j-1 {
+		nested[i], nested[j] = nested[j], nested[i]
+	}
+	return overlay{layers: nested}, true
+}
+
+// isOverlayContainer reports whether the values inside v are merged with the values
+// of other layers: v is a map, a struct with exported fields, or an overlay.
+func isOverlayContainer(v any) bool {
+	if _, ok := v.(overlay); ok {
+		return true
+	}
+	value := reflect.ValueOf(v)
+	switch value.Kind() {
+	case reflect.Map:
+		return true
+	case reflect.Struct:
+		return hasExportedFields(value.Type())
+	default:
+		return false
+	}
+}
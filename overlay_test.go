@@ -0,0 +1,80 @@
+package empaths
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestOverlay(t *testing.T) {
+	type server struct {
+		Host string
+		Port int
+		TLS  *bool
+	}
+	type config struct {
+		Server server
+		Tags   []string
+		Labels map[string]string
+	}
+	tls := true
+	defaults := config{
+		Server: server{Host: "localhost", Port: 80},
+		Tags:   []string{"a", "b"},
+		Labels: map[string]string{"env": "dev", "team": "core"},
+	}
+	file := map[string]any{
+		"Server": map[string]any{"Port": 8080},
+		"Labels": map[string]any{"env": "prod"},
+		"Name":   "file",
+	}
+	env := map[string]any{
+		"Server": &server{Host: "example.com", TLS: &tls},
+		"Tags":   []string{"c"},
+		"Name":   nil,
+	}
+	flags := map[string]any{"Server": map[string]any{"Port": 9090}}
+	data := Overlay(defaults, file, nil, env, flags)
+
+	tests := []struct {
+		name     string
+		path     string
+		expected any
+	}{
+		{"highest layer", ".Server.Port", 9090},
+		{"zero value of a struct field overrides", ".Server.Host", "example.com"},
+		{"pointer field", ".Server.TLS", true},
+		{"only in lowest layer", ".Labels['team']", "core"},
+		{"slice is not merged", ".Tags", []string{"c"}},
+		{"slice index", ".Tags[0]", "c"},
+		{"nil is missing", ".Name", "file"},
+		{"missing", ".Missing", nil},
+		{"concatenation", ".Server.Host ':' .Server.Port", "example.com:9090"},
+		{"comparison", "?.Labels.env == 'prod'", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := Resolve(tt.path, data, nil); !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("Resolve(%q) = %#v, want %#v", tt.path, result, tt.expected)
+			}
+		})
+	}
+
+	t.Run("leaf shadowed by a map", func(t *testing.T) {
+		data := Overlay(map[string]any{"Labels": map[string]any{"env": "dev"}}, map[string]any{"Labels": "flat"})
+		if result := Resolve(".Labels", data, nil); result != "flat" {
+			t.Errorf("Resolve(%q) = %v, want %v", ".Labels", result, "flat")
+		}
+		data = Overlay(map[string]any{"Labels": "flat"}, map[string]any{"Labels": map[string]any{"env": "dev"}})
+		if result := Resolve(".Labels.env", data, nil); result != "dev" {
+			t.Errorf("Resolve(%q) = %v, want %v", ".Labels.env", result, "dev")
+		}
+	})
+
+	t.Run("nested overlay", func(t *testing.T) {
+		server := Resolve(".Server", data, nil)
+		if result := Resolve(".Host ':' .Port", server, nil); result != "example.com:9090" {
+			t.Errorf("Resolve() = %v, want %v", result, "example.com:9090")
+		}
+	})
+}
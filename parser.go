@@ -2,18 +2,23 @@ package empaths
 
 // NOTE: Path Expression Character Encoding
 //
-// This parser is optimized for ASCII path expressions and processes paths byte-by-byte
-// rather than as Unicode code points. This is a deliberate performance optimization
-// since the vast majority of path expressions use ASCII-only syntax.
+// This parser processes paths byte-by-byte rather than as Unicode code points. This is a
+// deliberate performance optimization: all path syntax (operators, brackets, quotes, and
+// separators) is ASCII, and the bytes of a multi-byte UTF-8 sequence are always >= 0x80,
+// so they can never be mistaken for syntax. Field names, map keys, and reference names
+// are therefore read as raw byte ranges and may contain any UTF-8 text.
 //
-// Supported: ASCII field names, operators, brackets, quotes, and UTF-8 string literal content.
-// Undefined behavior: Non-ASCII characters in field names, map keys, or reference names.
-//
-// If full Unicode support is needed in the future, the parser would need to be rewritten
-// to use []rune instead of direct byte indexing, which would incur a performance cost.
+// Where the parser has to classify characters (identifiers such as function names and
+// word operators), it checks ASCII bytes directly and only decodes a rune for non-ASCII
+// input, so ASCII paths never pay for UTF-8 decoding.
 
 import (
+	"reflect"
+	"strconv"
 	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
 )
 
 // resolveExpressions processes a path expression and evaluates it against the provided data.
@@ -26,7 +31,7 @@ import (
 // Parameters:
 //   - path: The path expression as a string
 //   - data: The data model to evaluate against
-//   - refResolver: Function to resolve external references
+//   - state: The state of the current evaluation
 //   - startIndex: The starting index in the path string
 //
 // Returns:
@@ -35,14 +40,30 @@ import (
 func resolveExpressions(
 	path string,
 	data any,
-	refResolver ReferenceResolver,
+	state *evalState,
 	startIndex int,
 ) (any, int) {
 	if len(path) == 0 {
 		return data, startIndex
 	}
+	if !state.enter() {
+		return nil, len(path)
+	}
+	defer state.leave()
+	// Memoized prefixes would lose whether they are below an unexported field, so
+	// paths are not memoized if unexported fields are read.
+	readsUnexported := state.resolver != nil && state.resolver.unexportedFields
+	if state.depth == 1 && state.memo == nil && !readsUnexported && countModelPaths(path[startIndex:], 2) == 2 {
+		state.memo = make(map[string]reflect.Value)
+	}
 
 	index := startIndex
+	// Only the operands of the top-level expression are written to the output of
+	// ResolveTo; nested expressions are evaluated as usual.
+	out := state.out
+	state.out = nil
+	// flushed is true once first has been written to out.
+	flushed := false
 
 	// Optimization: most paths resolve to a single value.
 	// Use stack-allocated first value to avoid slice allocation in the common case.
@@ -50,11 +71,22 @@ func resolveExpressions(
 	var hasFirst bool
 	var rest []any // only allocated if we have multiple values
 
-	for index < len(path) {
+	for index < len(path) && state.err == nil {
+		if out != nil && len(rest) > 0 {
+			// All operands but the last are complete; the last may still be piped.
+			if !flushed {
+				state.write(out, first, true)
+				flushed = true
+			}
+			for _, v := range rest[:len(rest)-1] {
+				state.write(out, v, true)
+			}
+			rest = rest[len(rest)-1:]
+		}
 		c := path[index]
 		switch c {
 		case '.':
-			modelResult, newIndex, err := resolveModel(path, data, index)
+			modelResult, newIndex, err := resolveModel(path, data, index, state)
 			if err != nil {
 				return nil, index
 			}
@@ -84,7 +116,7 @@ func resolveExpressions(
 				rest = append(rest, stringResult)
 			}
 		case '!':
-			negResult, newIndex := resolveNegation(path, data, index, refResolver)
+			negResult, newIndex := resolveNegation(path, data, index, state)
 			index = newIndex
 			if !hasFirst {
 				first = negResult
@@ -93,7 +125,7 @@ func resolveExpressions(
 				rest = append(rest, negResult)
 			}
 		case ':':
-			referenceResult, newIndex := resolveReference(path, data, index, refResolver)
+			referenceResult, newIndex := resolveReference(path, data, index, state)
 			index = newIndex
 			if !hasFirst {
 				first = referenceResult
@@ -102,7 +134,7 @@ func resolveExpressions(
 				rest = append(rest, referenceResult)
 			}
 		case '?':
-			comparisonResult, newIndex := resolveComparison(path, data, index, refResolver)
+			comparisonResult, newIndex := resolveComparison(path, data, index, state)
 			index = newIndex
 			if !hasFirst {
 				first = comparisonResult
@@ -110,22 +142,110 @@ func resolveExpressions(
 			} else {
 				rest = append(rest, comparisonResult)
 			}
+		case '[':
+			listResult, newIndex := resolveListLiteral(path, data, index, state)
+			index = newIndex
+			if !hasFirst {
+				first = listResult
+				hasFirst = true
+			} else {
+				rest = append(rest, listResult)
+			}
+		case '$', '^':
+			scopeResult, newIndex := resolveScope(path, index, state)
+			index = newIndex
+			if !hasFirst {
+				first = scopeResult
+				hasFirst = true
+			} else {
+				rest = append(rest, scopeResult)
+			}
+		case '|':
+			// A pipe passes the preceding operand to a function.
+			if len(rest) > 0 {
+				rest[len(rest)-1], index = resolvePipe(path, data, rest[len(rest)-1], index, state)
+			} else {
+				first, index = resolvePipe(path, data, first, index, state)
+				hasFirst = true
+			}
 		case ' ':
 			index++
 		default:
+			if handler, ok := state.prefixHandler(c); ok {
+				prefixResult, newIndex := resolveCustomPrefix(path, data, handler, index, state)
+				index = newIndex
+				if !hasFirst {
+					first = prefixResult
+					hasFirst = true
+				} else {
+					rest = append(rest, prefixResult)
+				}
+				continue
+			}
+			if isIdentStart(path, index) {
+				if !hasFirst && c == 'l' && isLetBinding(path, index) {
+					state.out = out
+					return resolveLet(path, data, index, state)
+				}
+				name, newIndex := readIdentifier(path, index)
+				if newIndex < len(path) && path[newIndex] == '(' {
+					funcResult, funcIndex := resolveFunctionCall(path, data, name, newIndex, state)
+					index = funcIndex
+					if !hasFirst {
+						first = funcResult
+						hasFirst = true
+					} else {
+						rest = append(rest, funcResult)
+					}
+					continue
+				}
+				if literal, ok := keywordLiterals[name]; ok {
+					if !hasFirst {
+						first = literal
+						hasFirst = true
+					} else {
+						rest = append(rest, literal)
+					}
+				}
+				index = newIndex
+				continue
+			}
+			if isNumberStart(path, index) {
+				numberResult, newIndex := resolveNumberLiteralASCII(path, index)
+				index = newIndex
+				if !hasFirst {
+					first = numberResult
+					hasFirst = true
+				} else {
+					rest = append(rest, numberResult)
+				}
+				continue
+			}
 			index++
 		}
 	}
 
+	if out != nil {
+		if len(rest) == 0 {
+			if !hasFirst {
+				first = data
+			}
+			state.write(out, first, false)
+			return nil, index
+		}
+		if !flushed {
+			state.write(out, first, true)
+		}
+		for _, v := range rest {
+			state.write(out, v, true)
+		}
+		return nil, index
+	}
+
 	// Return the result. If there's only one element, return it directly (no allocation).
 	// If there are multiple elements, concatenate them as strings.
 	if len(rest) > 0 {
-		var sb strings.Builder
-		sb.WriteString(toString(first))
-		for _, v := range rest {
-			sb.WriteString(toString(v))
-		}
-		return sb.String(), index
+		return state.concatenate(first, rest), index
 	}
 	if hasFirst {
 		return first, index
@@ -134,12 +254,14 @@ func resolveExpressions(
 }
 
 // resolveOperand evaluates a single operand in a path expression.
-// An operand can be a model reference, string literal, negation, or external reference.
+// An operand can be a model reference, string literal, number, true, false, nil,
+// negation, external reference, root or parent reference, list literal, function
+// call, or an expression with a custom prefix (see WithPrefix).
 //
 // Parameters:
 //   - path: The path expression as a string
 //   - data: The data model to evaluate against
-//   - refResolver: Function to resolve external references
+//   - state: The state of the current evaluation
 //   - startIndex: The starting index in the path string
 //
 // Returns:
@@ -148,7 +270,7 @@ func resolveExpressions(
 func resolveOperand(
 	path string,
 	data any,
-	refResolver ReferenceResolver,
+	state *evalState,
 	startIndex int,
 ) (any, int) {
 	if len(path) == 0 {
@@ -159,7 +281,7 @@ func resolveOperand(
 		c := path[index]
 		switch c {
 		case '.':
-			modelResult, newIndex, err := resolveModel(path, data, index)
+			modelResult, newIndex, err := resolveModel(path, data, index, state)
 			if err != nil {
 				return nil, index
 			}
@@ -171,14 +293,37 @@ func resolveOperand(
 			stringResult, newIndex := resolveStringLiteralASCII(path, index, '"')
 			return stringResult, newIndex
 		case '!':
-			negResult, newIndex := resolveNegation(path, data, index, refResolver)
+			negResult, newIndex := resolveNegation(path, data, index, state)
 			return negResult, newIndex
 		case ':':
-			referenceResult, newIndex := resolveReference(path, data, index, refResolver)
+			referenceResult, newIndex := resolveReference(path, data, index, state)
 			return referenceResult, newIndex
+		case '[':
+			listResult, newIndex := resolveListLiteral(path, data, index, state)
+			return listResult, newIndex
+		case '$', '^':
+			scopeResult, newIndex := resolveScope(path, index, state)
+			return scopeResult, newIndex
 		case ' ':
 			index++
 		default:
+			if handler, ok := state.prefixHandler(c); ok {
+				return resolveCustomPrefix(path, data, handler, index, state)
+			}
+			if isIdentStart(path, index) {
+				name, newIndex := readIdentifier(path, index)
+				if newIndex < len(path) && path[newIndex] == '(' {
+					return resolveFunctionCall(path, data, name, newIndex, state)
+				}
+				if literal, ok := keywordLiterals[name]; ok {
+					return literal, newIndex
+				}
+				index = newIndex
+				continue
+			}
+			if isNumberStart(path, index) {
+				return resolveNumberLiteralASCII(path, index)
+			}
 			index++
 		}
 	}
@@ -188,6 +333,8 @@ func resolveOperand(
 // resolveStringLiteralASCII processes a string literal working directly with bytes.
 // This is optimized for ASCII-only paths which is the common case.
 // String literals are enclosed in single (') or double (") quotes and can include escaped characters.
+// The escape sequences \n, \t, \r, \\ and \uXXXX are translated; any other escaped
+// character (such as an escaped quote) stands for itself.
 //
 // Parameters:
 //   - path: The path expression as a string
@@ -196,7 +343,8 @@ func resolveOperand(
 //
 // Returns:
 //   - The string literal value
-//   - The new index after processing
+//   - The new index after processing, which is the end of the path if the literal
+//     is not terminated
 func resolveStringLiteralASCII(path string, index int, quoteChar byte) (string, int) {
 	// skip over the opening quote
 	index++
@@ -223,35 +371,196 @@ func resolveStringLiteralASCII(path string, index int, quoteChar byte) (string,
 		}
 		index++
 	}
+	end := index + 1
+	if index == len(path) {
+		end = len(path)
+	}
 
 	// If no escapes, we can return a substring directly (no allocation for the content)
 	if !hasEscapes {
-		return path[start:index], index + 1
+		return path[start:index], end
 	}
 
 	// With escapes, we need to build the string
 	var sb strings.Builder
 	sb.Grow(index - start)
-	escaping = false
 	for i := start; i < index; i++ {
 		c := path[i]
-		if escaping {
-			escaping = false
+		if c != '\\' || i+1 >= index {
 			sb.WriteByte(c)
 			continue
 		}
-		if c == '\\' {
-			escaping = true
-			continue
+		i++
+		switch path[i] {
+		case 'n':
+			sb.WriteByte('\n')
+		case 't':
+			sb.WriteByte('\t')
+		case 'r':
+			sb.WriteByte('\r')
+		case 'u':
+			r, consumed := decodeUnicodeEscape(path[i+1 : index])
+			if consumed == 0 {
+				// Not a valid \uXXXX sequence, keep the 'u' verbatim
+				sb.WriteByte('u')
+				continue
+			}
+			sb.WriteRune(r)
+			i += consumed
+		default:
+			sb.WriteByte(path[i])
 		}
-		sb.WriteByte(c)
 	}
-	return sb.String(), index + 1
+	return sb.String(), end
+}
+
+// decodeUnicodeEscape decodes the four hex digits following a \u escape. A UTF-16
+// surrogate pair written as two consecutive \uXXXX escapes is combined into one rune.
+//
+// Parameters:
+//   - s: The literal content directly after the 'u'
+//
+// Returns:
+//   - The decoded rune
+//   - The number of bytes consumed from s, or 0 if s does not start with four hex digits
+func decodeUnicodeEscape(s string) (rune, int) {
+	if len(s) < 4 {
+		return 0, 0
+	}
+	code, err := strconv.ParseUint(s[:4], 16, 16)
+	if err != nil {
+		return 0, 0
+	}
+	r := rune(code)
+	if utf16.IsSurrogate(r) && len(s) >= 10 && s[4] == '\\' && s[5] == 'u' {
+		if low, err := strconv.ParseUint(s[6:10], 16, 16); err == nil {
+			if combined := utf16.DecodeRune(r, rune(low)); combined != unicode.ReplacementChar {
+				return combined, 10
+			}
+		}
+	}
+	return r, 4
+}
+
+// keywordLiterals maps the bare keywords that can be used as literals to their values.
+var keywordLiterals = map[string]any{
+	"true":  true,
+	"false": false,
+	"nil":   nil,
+}
+
+// bareLiteralEnd returns the index after the unquoted token starting at index if the
+// token is a string literal, or index if it is not. Such tokens are only allowed as the right operand of a
+// comparison, as in "?.Status==active" or "?.Version >=v 1.20.0". A token starts with
+// a letter, a digit, or a minus sign followed by a digit and ends at a space, ',',
+// ')', ']', '|', or '&&'; it is a string literal unless it is a keyword, a number, or
+// the name of a called function.
+//
+// Parameters:
+//   - path: The path expression as a string
+//   - index: The current index in the path
+//
+// Returns:
+//   - The index after the token, or index if no token starts there or the token is
+//     not a string literal
+func bareLiteralEnd(path string, index int) int {
+	if index >= len(path) || !isIdentStart(path, index) && !isNumberStart(path, index) {
+		return index
+	}
+	end := index
+	for end < len(path) && !strings.ContainsRune(" ,)]|", rune(path[end])) && !isAndOperator(path, end) {
+		end++
+	}
+	if isIdentStart(path, index) {
+		name, nameEnd := readIdentifier(path, index)
+		if nameEnd < len(path) && path[nameEnd] == '(' {
+			return index
+		}
+		if _, ok := keywordLiterals[name]; ok && nameEnd == end {
+			return index
+		}
+	} else if _, numberEnd := resolveNumberLiteralASCII(path, index); numberEnd == end {
+		return index
+	}
+	return end
+}
+
+// isNumberStart reports whether a number literal starts at index, which is the case
+// for a digit or for a minus sign followed by a digit.
+func isNumberStart(path string, index int) bool {
+	c := path[index]
+	if c == '-' {
+		return index+1 < len(path) && isDigit(path[index+1])
+	}
+	return isDigit(c)
+}
+
+// isDigit reports whether c is an ASCII decimal digit.
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// resolveNumberLiteralASCII processes a bare number literal such as 30, -2 or 1.5e3.
+// Numbers without a fraction or exponent that fit into an int are returned as int,
+// all other numbers as float64.
+//
+// Parameters:
+//   - path: The path expression as a string
+//   - index: The current index in the path (should point to a digit or '-')
+//
+// Returns:
+//   - The number as int or float64
+//   - The new index after processing
+func resolveNumberLiteralASCII(path string, index int) (any, int) {
+	start := index
+	if path[index] == '-' {
+		index++
+	}
+	for index < len(path) && isDigit(path[index]) {
+		index++
+	}
+	isFloat := false
+	if index+1 < len(path) && path[index] == '.' && isDigit(path[index+1]) {
+		isFloat = true
+		index++
+		for index < len(path) && isDigit(path[index]) {
+			index++
+		}
+	}
+	if index < len(path) && (path[index] == 'e' || path[index] == 'E') {
+		expIndex := index + 1
+		if expIndex < len(path) && (path[expIndex] == '+' || path[expIndex] == '-') {
+			expIndex++
+		}
+		if expIndex < len(path) && isDigit(path[expIndex]) {
+			isFloat = true
+			index = expIndex
+			for index < len(path) && isDigit(path[index]) {
+				index++
+			}
+		}
+	}
+
+	literal := path[start:index]
+	if !isFloat {
+		if intVal, err := strconv.Atoi(literal); err == nil {
+			return intVal, index
+		}
+		if uintVal, err := strconv.ParseUint(literal, 10, 64); err == nil {
+			return uintVal, index
+		}
+	}
+	floatVal, err := strconv.ParseFloat(literal, 64)
+	if err != nil {
+		return nil, index
+	}
+	return floatVal, index
 }
 
 // readUntilTerminatorASCII reads characters from a path until a terminator character is found.
 // This works directly with string bytes for efficiency.
-// Terminator characters include space, exclamation mark, and equals sign.
+// Terminator characters are space, exclamation mark, equals sign, the angle
+// brackets of the ordering operators, '~', and the operators '^=', '$=', and '&&'.
 //
 // Parameters:
 //   - path: The path expression as a string
@@ -264,10 +573,244 @@ func readUntilTerminatorASCII(path string, index int) (string, int) {
 	start := index
 	for index < len(path) {
 		c := path[index]
-		if c == ' ' || c == '!' || c == '=' {
+		if c == ' ' || c == '!' || c == '=' || c == '<' || c == '>' || c == '~' || isAffixOperator(path, index) || isAndOperator(path, index) {
 			break
 		}
 		index++
 	}
 	return path[start:index], index
 }
+
+// readReferenceName reads the name of an external reference like
+// readUntilTerminatorASCII, but also stops at the '(' that starts the argument of
+// the reference, as in ":formatDate(.CreatedAt)", and at the ';' that ends a
+// binding.
+func readReferenceName(path string, index int) (string, int) {
+	name, end := readUntilTerminatorASCII(path, index)
+	if stop := strings.IndexAny(name, "(;"); stop != -1 {
+		return name[:stop], index + stop
+	}
+	return name, end
+}
+
+// isLetBinding reports whether a binding such as "let $u = .Order.Customer;" starts
+// at index.
+func isLetBinding(path string, index int) bool {
+	_, _, _, ok := readLetBinding(path, index)
+	return ok
+}
+
+// readLetBinding reads the head of a binding ("let $u =") starting at index.
+//
+// Parameters:
+//   - path: The path expression as a string
+//   - index: The index of the keyword "let"
+//
+// Returns:
+//   - The name of the bound variable (without its '$')
+//   - The index of the '$' of the variable
+//   - The index after the '='
+//   - false if no binding starts at index
+func readLetBinding(path string, index int) (string, int, int, bool) {
+	if !strings.HasPrefix(path[index:], "let ") {
+		return "", 0, 0, false
+	}
+	index += len("let")
+	for index < len(path) && path[index] == ' ' {
+		index++
+	}
+	if index+1 >= len(path) || path[index] != '$' || !isIdentStart(path, index+1) {
+		return "", 0, 0, false
+	}
+	nameStart := index
+	name, index := readIdentifier(path, index+1)
+	for index < len(path) && path[index] == ' ' {
+		index++
+	}
+	if index >= len(path) || path[index] != '=' || index+1 < len(path) && path[index+1] == '=' {
+		return "", 0, 0, false
+	}
+	return name, nameStart, index + 1, true
+}
+
+// bindingEnd returns the index of the ';' that ends the bound expression starting at
+// index, skipping quoted strings, brackets, and parentheses, or -1 if there is none.
+func bindingEnd(path string, index int) int {
+	for index < len(path) {
+		switch path[index] {
+		case '\'', '"':
+			index = skipQuotedASCII(path, index)
+		case '[', '(':
+			closeIndex := findClosingASCII(path, index)
+			if closeIndex == -1 {
+				return -1
+			}
+			index = closeIndex + 1
+		case ';':
+			return index
+		default:
+			index++
+		}
+	}
+	return -1
+}
+
+// readModelPathASCII reads a model path (without its leading '.') from a path expression.
+// Unlike readUntilTerminatorASCII it keeps track of brackets and quotes, so that
+// filter expressions such as "Users[?.Active=='true']" are read as part of the path.
+// Outside of brackets the path ends at a space, '!', '=', '<', '>', '~', ',', ')',
+// '|', ';', or one of the operators '^=', '$=', and '&&'.
+//
+// Parameters:
+//   - path: The path expression as a string
+//   - index: The starting index in the path
+//
+// Returns:
+//   - The model path read from the expression
+//   - The new index after processing
+func readModelPathASCII(path string, index int) (string, int) {
+	start := index
+	depth := 0
+	for index < len(path) {
+		c := path[index]
+		switch c {
+		case '[':
+			depth++
+		case ']':
+			if depth > 0 {
+				depth--
+			}
+		case '\'', '"':
+			if depth > 0 {
+				index = skipQuotedASCII(path, index)
+				continue
+			}
+		case ' ', '!', '=', '<', '>', '~', ',', ')', '|', ';':
+			if depth == 0 {
+				return path[start:index], index
+			}
+		case '^', '$':
+			if depth == 0 && isAffixOperator(path, index) {
+				return path[start:index], index
+			}
+		case '&':
+			if depth == 0 && isAndOperator(path, index) {
+				return path[start:index], index
+			}
+		}
+		index++
+	}
+	return path[start:index], index
+}
+
+// isAffixOperator reports whether the operator '^=' or '$=' starts at index.
+func isAffixOperator(path string, index int) bool {
+	return (path[index] == '^' || path[index] == '$') && index+1 < len(path) && path[index+1] == '='
+}
+
+// isAndOperator reports whether the logical operator '&&' starts at index.
+func isAndOperator(path string, index int) bool {
+	return path[index] == '&' && index+1 < len(path) && path[index+1] == '&'
+}
+
+// skipQuotedASCII skips over a quoted string starting at index (which must point to the
+// opening quote) and returns the index just after the closing quote. Escaped characters
+// are honored. If the string is unterminated, the length of the path is returned.
+func skipQuotedASCII(path string, index int) int {
+	quoteChar := path[index]
+	index++
+	for index < len(path) {
+		c := path[index]
+		if c == '\\' {
+			index += 2
+			continue
+		}
+		index++
+		if c == quoteChar {
+			return index
+		}
+	}
+	return len(path)
+}
+
+// findClosingASCII returns the index of the closer matching the opener at index,
+// skipping over nested pairs and quoted strings. It returns -1 if there is no match.
+//
+// Parameters:
+//   - path: The path expression as a string
+//   - index: The index of the opening character ('[' or '(')
+//
+// Returns:
+//   - The index of the matching closing character, or -1
+func findClosingASCII(path string, index int) int {
+	opener := path[index]
+	closer := byte(']')
+	if opener == '(' {
+		closer = ')'
+	}
+	depth := 0
+	for index < len(path) {
+		c := path[index]
+		switch c {
+		case '\'', '"':
+			index = skipQuotedASCII(path, index)
+			continue
+		case opener:
+			depth++
+		case closer:
+			depth--
+			if depth == 0 {
+				return index
+			}
+		}
+		index++
+	}
+	return -1
+}
+
+// isIdentStart reports whether the character at index can start an identifier such
+// as a function name. ASCII letters and '_' are checked directly; for non-ASCII
+// input the UTF-8 encoded rune is decoded and any Unicode letter is accepted.
+func isIdentStart(path string, index int) bool {
+	c := path[index]
+	if c < utf8.RuneSelf {
+		return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+	}
+	r, _ := utf8.DecodeRuneInString(path[index:])
+	return unicode.IsLetter(r)
+}
+
+// identCharWidth returns the byte width of the identifier character at index, or 0
+// if the character cannot be part of an identifier.
+func identCharWidth(path string, index int) int {
+	c := path[index]
+	if c < utf8.RuneSelf {
+		if c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || isDigit(c) {
+			return 1
+		}
+		return 0
+	}
+	r, width := utf8.DecodeRuneInString(path[index:])
+	if unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r) {
+		return width
+	}
+	return 0
+}
+
+// readIdentifier reads an identifier starting at index. Identifiers consist of
+// letters, digits, and '_' and may contain any Unicode letters.
+//
+// Returns:
+//   - The identifier
+//   - The index of the first character after the identifier
+func readIdentifier(path string, index int) (string, int) {
+	start := index
+	for index < len(path) {
+		width := identCharWidth(path, index)
+		if width == 0 {
+			break
+		}
+		index += width
+	}
+	return path[start:index], index
+}
@@ -0,0 +1,107 @@
+package empaths
+
+import "context"
+
+// PartialResult describes how far a path resolved. It is returned by
+// ResolvePartial, e.g. to show users of a template where their path went wrong.
+type PartialResult struct {
+	// Value is the value of the deepest part of the path that resolved: the
+	// result of the whole path if it resolved, otherwise the value of Resolved. It
+	// is Redacted if the value is secret (see WithSecrets).
+	Value any
+	// Resolved is the part of the path that resolved, a prefix of the path (e.g.
+	// ".User.Address"). It is empty if not even the first segment resolved.
+	Resolved string
+	// Remaining is the rest of the path, starting with the segment that could not
+	// be resolved (e.g. ".Cityy"). It is empty if the whole path resolved.
+	Remaining string
+	// Err is the reason why Remaining could not be resolved, as reported in strict
+	// mode (see WithStrict), or nil if the whole path resolved.
+	Err error
+}
+
+// ResolvePartial resolves a path like Resolve and reports how far it got. See
+// Resolver.ResolvePartial.
+//
+// Parameters:
+//   - path: The path expression to resolve
+//   - data: The data model to resolve the path against
+//   - refResolver: Resolves external references (:name); may be nil
+//
+// Returns:
+//   - The deepest resolved value, the resolved and remaining parts of the path, and
+//     the reason the remaining part failed
+func ResolvePartial(path string, data any, refResolver ReferenceResolver) PartialResult {
+	return defaultResolver.ResolvePartial(path, data, refResolver)
+}
+
+// ResolvePartial resolves a path in strict mode (see WithStrict) and, if it fails,
+// reports the deepest value that could be resolved together with the rest of the
+// path:
+//
+//	p := resolver.ResolvePartial(".User.Address.Cityy", data, nil)
+//	// p.Resolved == ".User.Address", p.Remaining == ".Cityy", p.Err is a *FieldNotFoundError
+//
+// A single model path is resolved one segment at a time, where a filter or wildcard
+// together with its brackets counts as one segment. Any other expression either
+// resolves completely or not at all; if it fails, Remaining is the whole path.
+//
+// Parameters:
+//   - path: The path expression to resolve
+//   - data: The data model to resolve the path against
+//   - refResolver: Resolves external references (:name); may be nil
+//
+// Returns:
+//   - The deepest resolved value, the resolved and remaining parts of the path, and
+//     the reason the remaining part failed
+func (r *Resolver) ResolvePartial(path string, data any, refResolver ReferenceResolver) PartialResult {
+	strict := *r
+	strict.strict = true
+
+	tokens, err := Tokens(path)
+	if err != nil {
+		return PartialResult{Remaining: path, Err: err}
+	}
+	if !isSingleModelPath(tokens) {
+		value, state, err := strict.run(context.Background(), path, data, refResolver, nil)
+		if err != nil {
+			return PartialResult{Remaining: path, Err: err}
+		}
+		return PartialResult{Value: redact(value, state), Resolved: path}
+	}
+
+	result := PartialResult{Value: data}
+	for _, end := range segmentEnds(tokens) {
+		value, state, err := strict.run(context.Background(), path[:end], data, refResolver, nil)
+		if err != nil {
+			result.Remaining = path[len(result.Resolved):]
+			result.Err = err
+			return result
+		}
+		result.Value = redact(value, state)
+		result.Resolved = path[:end]
+	}
+	result.Resolved = path
+	return result
+}
+
+// segmentEnds returns the positions in the path after each segment of a single
+// model path. The root "." is not a segment of its own, and a filter counts as one
+// segment.
+func segmentEnds(tokens []Token) []int {
+	var ends []int
+	depth := 0
+	for _, token := range tokens {
+		switch token.Kind {
+		case TokenFilterStart:
+			depth++
+		case TokenFilterEnd:
+			depth--
+		}
+		if depth > 0 || token.Text == "." {
+			continue
+		}
+		ends = append(ends, token.Pos+len(token.Text))
+	}
+	return ends
+}
@@ -0,0 +1,82 @@
+package empaths
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestResolvePartial(t *testing.T) {
+	person := createTestPerson()
+	team := createTestTeam()
+
+	tests := []struct {
+		name      string
+		path      string
+		data      any
+		value     any
+		resolved  string
+		remaining string
+		err       error
+	}{
+		{"complete", ".Address.City", person, "NYC", ".Address.City", "", nil},
+		{"root", ".", team, team, ".", "", nil},
+		{"missing leaf", ".Address.Cityy", person, person.Address, ".Address", ".Cityy", &FieldNotFoundError{Field: "Cityy", Type: reflect.TypeOf(Address{}), Suggestions: []string{"City"}}},
+		{"missing first segment", ".Nmae", person, person, "", ".Nmae", &FieldNotFoundError{Field: "Nmae", Type: reflect.TypeOf(Person{}), Suggestions: []string{"Name"}}},
+		{"missing middle segment", ".Adress.City", person, person, "", ".Adress.City", &FieldNotFoundError{Field: "Adress", Type: reflect.TypeOf(Person{}), Suggestions: []string{"Address"}}},
+		{"index out of range", ".Tags[5]", person, person.Tags, ".Tags", "[5]", &IndexOutOfRangeError{Index: 5, Len: len(person.Tags)}},
+		{"map key", ".Scores.art", team, team["Scores"], ".Scores", ".art", &FieldNotFoundError{Field: "art", Type: reflect.TypeOf(team["Scores"])}},
+		{"after filter", ".Users[?.Active==true].Nmae", team, []Member{{Name: "Alice", Active: true, Age: 30}, {Name: "Carol", Active: true, Age: 35}}, ".Users[?.Active==true]", ".Nmae", &FieldNotFoundError{Field: "Nmae", Type: reflect.TypeOf(Member{}), Suggestions: []string{"Name"}}},
+		{"nil data", ".Name", nil, nil, "", ".Name", &NilIntermediateError{Segment: "."}},
+		{"expression", "'Hi ' .Nmae", person, nil, "", "'Hi ' .Nmae", &FieldNotFoundError{Field: "Nmae", Type: reflect.TypeOf(Person{}), Suggestions: []string{"Name"}}},
+		{"complete expression", "'Hi ' .Name", person, "Hi Alice", "'Hi ' .Name", "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := ResolvePartial(tt.path, tt.data, nil)
+			if !reflect.DeepEqual(result.Value, tt.value) {
+				t.Errorf("Value = %v, want %v", result.Value, tt.value)
+			}
+			if result.Resolved != tt.resolved || result.Remaining != tt.remaining {
+				t.Errorf("Resolved, Remaining = %q, %q, want %q, %q", result.Resolved, result.Remaining, tt.resolved, tt.remaining)
+			}
+			if !reflect.DeepEqual(result.Err, tt.err) {
+				t.Errorf("Err = %v, want %v", result.Err, tt.err)
+			}
+		})
+	}
+}
+
+func TestResolvePartial_Syntax(t *testing.T) {
+	result := ResolvePartial(".Users[0", createTestTeam(), nil)
+	if !errors.Is(result.Err, ErrSyntax) || result.Resolved != "" || result.Remaining != ".Users[0" {
+		t.Errorf("ResolvePartial = %+v, want syntax error for the whole path", result)
+	}
+}
+
+func TestResolvePartial_References(t *testing.T) {
+	refResolver := func(name string, data any) any {
+		if name == "user" {
+			return createTestPerson()
+		}
+		return nil
+	}
+
+	if result := ResolvePartial(":user", nil, refResolver); result.Err != nil || result.Resolved != ":user" {
+		t.Errorf("ResolvePartial(:user) = %+v, want the resolved reference", result)
+	}
+	result := ResolvePartial(":missing", nil, refResolver)
+	var unresolved *UnresolvedReferenceError
+	if !errors.As(result.Err, &unresolved) || unresolved.Name != "missing" {
+		t.Errorf("ResolvePartial(:missing) = %+v, want an UnresolvedReferenceError", result)
+	}
+}
+
+func TestResolver_ResolvePartial(t *testing.T) {
+	resolver := NewResolver(WithDeniedFields([]string{"Age"}))
+	result := resolver.ResolvePartial(".Age", createTestPerson(), nil)
+	if !errors.Is(result.Err, ErrAccessDenied) || result.Remaining != ".Age" {
+		t.Errorf("ResolvePartial = %+v, want ErrAccessDenied", result)
+	}
+}
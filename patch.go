@@ -0,0 +1,206 @@
+package empaths
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// OpKind identifies the kind of a patch operation.
+type OpKind int
+
+const (
+	// OpSet replaces a value, like Set.
+	OpSet OpKind = iota
+	// OpSetCreate replaces a value and allocates missing intermediate values, like SetCreate.
+	OpSetCreate
+	// OpDelete removes a value, like Delete.
+	OpDelete
+	// OpAppend appends Value to a slice, like Append.
+	OpAppend
+)
+
+// String returns the name of the operation kind.
+func (k OpKind) String() string {
+	switch k {
+	case OpSet:
+		return "set"
+	case OpSetCreate:
+		return "setcreate"
+	case OpDelete:
+		return "delete"
+	case OpAppend:
+		return "append"
+	default:
+		return fmt.Sprintf("OpKind(%d)", int(k))
+	}
+}
+
+// Op is a single operation of a Patch.
+type Op struct {
+	// Kind is the kind of operation.
+	Kind OpKind
+	// Path is the plain model path the operation applies to.
+	Path string
+	// Value is the value to set or append. It is ignored for OpDelete.
+	Value any
+}
+
+// Patch is a sequence of operations that Apply performs in order.
+type Patch []Op
+
+// Apply performs all operations of a patch on data, and leaves data unchanged if one
+// of them fails.
+//
+// All paths are parsed first. The patch is then applied to a deep copy of data; only
+// if every operation succeeds on the copy is it applied to data itself, in place, so
+// pointers into data stay valid. The copy shares pointers, maps, and slices among
+// its values wherever data does (see deepCopy), so an operation fails on the copy if
+// it would fail on data. This does not hold for data that the copy shares with the
+// original (functions, channels, and unexported fields) or for slices that overlap
+// without being identical; a patch whose success depends on these can fail on data
+// after earlier operations were applied.
+//
+// data must be a non-nil pointer (or a map), as with Set and Delete.
+//
+// Parameters:
+//   - patch: The operations to perform
+//   - data: The data to modify
+//
+// Returns:
+//   - Error describing the first operation that failed, wrapping the error of Set,
+//     SetCreate, Delete, or Append (such as a *FieldNotFoundError)
+func Apply(patch Patch, data any) error {
+	if data == nil {
+		return errors.New("cannot apply a patch to nil data")
+	}
+	for i, op := range patch {
+		if _, err := parseSegments(op.Path); err != nil {
+			return fmt.Errorf("patch op %d (%s): %w", i, op.Kind, err)
+		}
+	}
+
+	dryRun := deepCopy(reflect.ValueOf(data), map[copyKey]reflect.Value{})
+	if err := applyOps(patch, dryRun.Interface()); err != nil {
+		return err
+	}
+	return applyOps(patch, data)
+}
+
+// applyOps performs the operations of a patch in order and stops at the first error.
+func applyOps(patch Patch, data any) error {
+	for i, op := range patch {
+		var err error
+		switch op.Kind {
+		case OpSet:
+			err = Set(op.Path, data, op.Value)
+		case OpSetCreate:
+			err = SetCreate(op.Path, data, op.Value)
+		case OpDelete:
+			err = Delete(op.Path, data)
+		case OpAppend:
+			err = Append(op.Path, data, op.Value)
+		default:
+			err = errors.New("unknown operation")
+		}
+		if err != nil {
+			return fmt.Errorf("patch op %d (%s %s): %w", i, op.Kind, op.Path, err)
+		}
+	}
+	return nil
+}
+
+// copyKey identifies a pointer, map, or slice that deepCopy has copied: by its type,
+// the address of the data it refers to, and for slices its length and capacity.
+type copyKey struct {
+	typ      reflect.Type
+	ptr      uintptr
+	len, cap int
+}
+
+// deepCopy returns a copy of value that shares no pointers, maps, or slices with it.
+// Pointers, maps, and slices that occur several times are copied once, so aliasing
+// (and cycles) are preserved. Unexported struct fields are copied shallowly, which
+// is sufficient because paths can never modify data through them. Functions and
+// channels are shared.
+//
+// Parameters:
+//   - value: The value to copy
+//   - seen: The copies of the pointers, maps, and slices that were already visited
+//
+// Returns:
+//   - The copy, which is settable wherever value's kind permits
+func deepCopy(value reflect.Value, seen map[copyKey]reflect.Value) reflect.Value {
+	if !value.IsValid() {
+		return value
+	}
+	switch value.Kind() {
+	case reflect.Ptr:
+		if value.IsNil() {
+			return reflect.Zero(value.Type())
+		}
+		key := copyKey{typ: value.Type(), ptr: value.Pointer()}
+		if copied, ok := seen[key]; ok {
+			return copied
+		}
+		copied := reflect.New(value.Type().Elem())
+		seen[key] = copied
+		copied.Elem().Set(deepCopy(value.Elem(), seen))
+		return copied
+	case reflect.Interface:
+		if value.IsNil() {
+			return reflect.Zero(value.Type())
+		}
+		copied := reflect.New(value.Type()).Elem()
+		copied.Set(deepCopy(value.Elem(), seen))
+		return copied
+	case reflect.Map:
+		if value.IsNil() {
+			return reflect.Zero(value.Type())
+		}
+		key := copyKey{typ: value.Type(), ptr: value.Pointer()}
+		if copied, ok := seen[key]; ok {
+			return copied
+		}
+		copied := reflect.MakeMapWithSize(value.Type(), value.Len())
+		seen[key] = copied
+		iter := value.MapRange()
+		for iter.Next() {
+			copied.SetMapIndex(iter.Key(), deepCopy(iter.Value(), seen))
+		}
+		return copied
+	case reflect.Slice:
+		if value.IsNil() {
+			return reflect.Zero(value.Type())
+		}
+		key := copyKey{typ: value.Type(), ptr: value.Pointer(), len: value.Len(), cap: value.Cap()}
+		if copied, ok := seen[key]; ok {
+			return copied
+		}
+		copied := reflect.MakeSlice(value.Type(), value.Len(), value.Cap())
+		seen[key] = copied
+		for i := 0; i < value.Len(); i++ {
+			copied.Index(i).Set(deepCopy(value.Index(i), seen))
+		}
+		return copied
+	case reflect.Array:
+		copied := reflect.New(value.Type()).Elem()
+		for i := 0; i < value.Len(); i++ {
+			copied.Index(i).Set(deepCopy(value.Index(i), seen))
+		}
+		return copied
+	case reflect.Struct:
+		copied := reflect.New(value.Type()).Elem()
+		copied.Set(value)
+		for i := 0; i < value.NumField(); i++ {
+			if field := copied.Field(i); field.CanSet() {
+				field.Set(deepCopy(value.Field(i), seen))
+			}
+		}
+		return copied
+	default:
+		copied := reflect.New(value.Type()).Elem()
+		copied.Set(value)
+		return copied
+	}
+}
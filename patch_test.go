@@ -0,0 +1,161 @@
+package empaths
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestApply(t *testing.T) {
+	config := createTestConfig()
+	patch := Patch{
+		{Kind: OpSet, Path: ".Name", Value: "web"},
+		{Kind: OpDelete, Path: ".Labels.team"},
+		{Kind: OpAppend, Path: ".Ports", Value: 9090},
+		{Kind: OpSetCreate, Path: ".Groups.api.Replicas", Value: 4},
+	}
+
+	if err := Apply(patch, config); err != nil {
+		t.Fatalf("Apply returned error: %v", err)
+	}
+
+	checks := []struct {
+		path string
+		want any
+	}{
+		{".Name", "web"},
+		{".Labels", map[string]string{"env": "prod"}},
+		{".Ports", []int{80, 443, 8080, 9090}},
+		{".Groups.api.Replicas", 4},
+		{".Groups.web.Replicas", 2},
+	}
+	for _, check := range checks {
+		if result := Resolve(check.path, config, nil); !reflect.DeepEqual(result, check.want) {
+			t.Errorf("after Apply, Resolve(%q) = %v, want %v", check.path, result, check.want)
+		}
+	}
+}
+
+func TestApply_AllOrNothing(t *testing.T) {
+	tests := []struct {
+		name  string
+		patch Patch
+	}{
+		{"invalid path syntax", Patch{
+			{Kind: OpSet, Path: ".Name", Value: "web"},
+			{Kind: OpDelete, Path: "Labels"},
+		}},
+		{"failing operation after successful ones", Patch{
+			{Kind: OpSet, Path: ".Name", Value: "web"},
+			{Kind: OpDelete, Path: ".Labels.env"},
+			{Kind: OpAppend, Path: ".Spec.Tags", Value: "d"},
+			{Kind: OpSet, Path: ".Extra.list[0]", Value: 42},
+			{Kind: OpSet, Path: ".Ports[7]", Value: 1},
+		}},
+		{"operation depending on an earlier one", Patch{
+			{Kind: OpDelete, Path: ".Labels.env"},
+			{Kind: OpDelete, Path: ".Labels.env"},
+		}},
+		{"unknown kind", Patch{
+			{Kind: OpSet, Path: ".Name", Value: "web"},
+			{Kind: OpKind(99), Path: ".Name"},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := createTestConfig()
+			if err := Apply(tt.patch, config); err == nil {
+				t.Fatalf("Apply should return an error")
+			}
+			if !reflect.DeepEqual(config, createTestConfig()) {
+				t.Errorf("failed Apply modified the data: %+v", config)
+			}
+		})
+	}
+}
+
+func TestApply_AliasedData(t *testing.T) {
+	tests := []struct {
+		name  string
+		patch Patch
+		data  func() *Config
+	}{
+		{"shared map", Patch{
+			{Kind: OpDelete, Path: ".Labels.env"},
+			{Kind: OpDelete, Path: ".Extra.labels.env"},
+		}, func() *Config {
+			config := createTestConfig()
+			config.Extra.(map[string]any)["labels"] = config.Labels
+			return config
+		}},
+		{"shared slice", Patch{
+			{Kind: OpSet, Path: ".Extra.list[0]", Value: nil},
+			{Kind: OpSet, Path: ".Extra.alias[0].x", Value: 1},
+		}, func() *Config {
+			config := createTestConfig()
+			list := []any{map[string]any{"x": 0}}
+			config.Extra.(map[string]any)["list"] = list
+			config.Extra.(map[string]any)["alias"] = list
+			return config
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := tt.data()
+			if err := Apply(tt.patch, config); err == nil {
+				t.Fatalf("Apply should return an error")
+			}
+			if !reflect.DeepEqual(config, tt.data()) {
+				t.Errorf("failed Apply modified the data: %+v", config)
+			}
+		})
+	}
+}
+
+func TestApply_PreservesPointerIdentity(t *testing.T) {
+	config := createTestConfig()
+	spec := config.Spec
+	if err := Apply(Patch{{Kind: OpSet, Path: ".Spec.Replicas", Value: 5}}, config); err != nil {
+		t.Fatalf("Apply returned error: %v", err)
+	}
+	if config.Spec != spec || spec.Replicas != 5 {
+		t.Errorf("Apply should modify the existing Spec in place")
+	}
+}
+
+func TestDeepCopy(t *testing.T) {
+	type Node struct {
+		Name string
+		Next *Node
+		tags []string
+	}
+	first := &Node{Name: "first", tags: []string{"x"}}
+	first.Next = &Node{Name: "second", Next: first}
+
+	copied := deepCopy(reflect.ValueOf(first), map[copyKey]reflect.Value{}).Interface().(*Node)
+	if copied == first || copied.Next == first.Next {
+		t.Fatalf("deepCopy should copy pointers")
+	}
+	if copied.Next.Next != copied {
+		t.Errorf("deepCopy should preserve cycles")
+	}
+	if copied.Name != "first" || copied.Next.Name != "second" || copied.tags[0] != "x" {
+		t.Errorf("deepCopy lost data: %+v", copied)
+	}
+
+	shared := map[string]int{"a": 1}
+	tags := make([]string, 1, 4)
+	pair := deepCopy(reflect.ValueOf([]any{shared, shared, tags, tags, tags[:0]}), map[copyKey]reflect.Value{}).Interface().([]any)
+	pair[0].(map[string]int)["b"] = 2
+	if pair[1].(map[string]int)["b"] != 2 || shared["b"] != 0 {
+		t.Errorf("deepCopy should copy a shared map once")
+	}
+	pair[2].([]string)[0] = "y"
+	if pair[3].([]string)[0] != "y" || tags[0] != "" || cap(pair[2].([]string)) != 4 {
+		t.Errorf("deepCopy should copy a shared slice once with its capacity")
+	}
+	if len(pair[4].([]string)) != 0 {
+		t.Errorf("deepCopy should keep the length of slices of the same array")
+	}
+}
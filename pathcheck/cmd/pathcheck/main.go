@@ -0,0 +1,14 @@
+// The pathcheck command checks empaths path literals against the static type of
+// the data they are resolved against.
+//
+//	go run github.com/authentic-devel/empaths/pathcheck/cmd/pathcheck ./...
+package main
+
+import (
+	"github.com/authentic-devel/empaths/pathcheck"
+	"golang.org/x/tools/go/analysis/singlechecker"
+)
+
+func main() {
+	singlechecker.Main(pathcheck.Analyzer)
+}
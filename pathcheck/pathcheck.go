@@ -0,0 +1,640 @@
+// Package pathcheck defines an Analyzer that checks path literals passed to
+// empaths against the static type of the data they are resolved against.
+//
+// For every call such as
+//
+//	empaths.Resolve(".Address.Cty", user, nil)
+//
+// where the path is a constant string, the model paths in the expression are
+// followed through the type of the data argument, and unknown fields, methods that
+// cannot be called, and invalid indices are reported, as are syntax errors:
+//
+//	unknown field or method "Cty" on models.Address
+//
+// Paths that pass through interface types (including any) cannot be checked beyond
+// that point and are accepted.
+package pathcheck
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/types"
+	"strconv"
+	"strings"
+
+	"github.com/authentic-devel/empaths"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+// empathsPath is the import path of the empaths package.
+const empathsPath = "github.com/authentic-devel/empaths"
+
+// checkedFuncs are the empaths functions whose first two arguments are a path and
+// the data it is resolved against.
+var checkedFuncs = map[string]bool{
+	"Resolve":         true,
+	"ResolveModel":    true,
+	"ResolveWithVars": true,
+	"ResolveString":   true,
+	"ResolveBool":     true,
+	"ResolveInt":      true,
+	"ResolveFloat":    true,
+	"ResolveDefault":  true,
+	"Set":             true,
+	"SetCreate":       true,
+	"Delete":          true,
+	"Append":          true,
+	"Insert":          true,
+}
+
+// Analyzer reports empaths path literals that do not match the type of the data.
+var Analyzer = &analysis.Analyzer{
+	Name:     "pathcheck",
+	Doc:      "check empaths path literals against the static type of the data",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+func run(pass *analysis.Pass) (any, error) {
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+
+	inspect.Preorder([]ast.Node{(*ast.CallExpr)(nil)}, func(node ast.Node) {
+		call := node.(*ast.CallExpr)
+		fn := checkedFunc(pass, call.Fun)
+		if fn == nil || len(call.Args) < 2 {
+			return
+		}
+		pathValue := pass.TypesInfo.Types[call.Args[0]].Value
+		if pathValue == nil || pathValue.Kind() != constant.String {
+			return
+		}
+		dataType := pass.TypesInfo.TypeOf(call.Args[1])
+		if dataType == nil {
+			return
+		}
+		path := constant.StringVal(pathValue)
+		tokens, err := empaths.Tokens(path)
+		if err != nil {
+			// The methods of a Resolver accept the custom prefixes registered with
+			// it, which Tokens does not know.
+			if fn.Type().(*types.Signature).Recv() == nil {
+				pass.Reportf(call.Args[0].Pos(), "%v", err)
+			}
+			return
+		}
+		c := &checker{expr: path}
+		if err := c.checkTokens(tokens, dataType, scope{root: dataType}); err != nil {
+			pass.Reportf(call.Args[0].Pos(), "%v", err)
+		}
+	})
+	return nil, nil
+}
+
+// checkedFunc returns the empaths function that fun refers to if it is one of the
+// checked functions, and nil otherwise.
+func checkedFunc(pass *analysis.Pass, fun ast.Expr) *types.Func {
+	var ident *ast.Ident
+	switch f := fun.(type) {
+	case *ast.Ident:
+		ident = f
+	case *ast.SelectorExpr:
+		ident = f.Sel
+	default:
+		return nil
+	}
+	fn, ok := pass.TypesInfo.Uses[ident].(*types.Func)
+	if !ok || fn.Pkg() == nil || fn.Pkg().Path() != empathsPath || !checkedFuncs[fn.Name()] {
+		return nil
+	}
+	return fn
+}
+
+// scope holds the types that '$' and '^' refer to in an expression.
+type scope struct {
+	// root is the type of the data passed to empaths.
+	root types.Type
+	// parent is the type of the value holding the filtered collection; it is nil
+	// outside of filters.
+	parent types.Type
+}
+
+// checker checks the model paths of a path expression, given as the tokens returned
+// by empaths.Tokens.
+type checker struct {
+	// expr is the path expression, which the positions of the tokens refer to.
+	expr string
+}
+
+// checkTokens checks every model path in tokens against typ. Model paths after '$'
+// and '^' are checked against the types in sc. Model paths after variables
+// ('$name') and after function calls are resolved against values whose type is not
+// known statically, so they are not checked. The expression passed to each is
+// checked against the element type of the collection (see checkEach).
+func (c *checker) checkTokens(tokens []empaths.Token, typ types.Type, sc scope) error {
+	for i := 0; i < len(tokens); {
+		switch tok := tokens[i]; tok.Kind {
+		case empaths.TokenField:
+			end := followingPath(tokens, i+1)
+			if _, err := c.pathType(tokens[i:end], typ, sc); err != nil {
+				return fmt.Errorf("path %q: %w", c.text(tokens[i:end]), err)
+			}
+			i = end
+		case empaths.TokenRoot, empaths.TokenParent:
+			target := sc.root
+			if tok.Kind == empaths.TokenParent {
+				if sc.parent == nil {
+					return fmt.Errorf("'^' used outside of a filter")
+				}
+				target = sc.parent
+			}
+			end := followingPath(tokens, i+1)
+			if _, err := c.pathType(tokens[i+1:end], target, sc); err != nil {
+				return fmt.Errorf("path %q: %w", c.text(tokens[i:end]), err)
+			}
+			i = end
+		case empaths.TokenFunction:
+			called := i+1 < len(tokens) && tokens[i+1].Kind == empaths.TokenLeftParen
+			piped := i > 0 && tokens[i-1].Kind == empaths.TokenPipe
+			switch {
+			case called && tok.Text == "each" && piped:
+				// The elements of the piped value are of unknown type, so the
+				// arguments of each are skipped.
+				i = followingPath(tokens, closing(tokens, i+1)+1)
+			case called && tok.Text == "each":
+				end, err := c.checkEach(tokens, i+1, typ, sc)
+				if err != nil {
+					return err
+				}
+				i = end
+			case called:
+				i++
+			default:
+				// A function after a pipe without arguments; a model path after it is
+				// resolved against its result.
+				i = followingPath(tokens, i+1)
+			}
+		case empaths.TokenVariable, empaths.TokenRightParen:
+			// The type of a variable or of the result of a call is unknown, so a
+			// model path after it is skipped.
+			i = followingPath(tokens, i+1)
+		default:
+			i++
+		}
+	}
+	return nil
+}
+
+// checkEach checks the arguments of an each call, whose '(' is tokens[open], and
+// returns the index of the token after the call. The collection and the separator
+// are checked against typ like other function arguments, and the expression against
+// the element type of the collection, with '^' referring to typ. The expression is
+// skipped if the collection is not a single model path of a known type.
+func (c *checker) checkEach(tokens []empaths.Token, open int, typ types.Type, sc scope) (int, error) {
+	closeIndex := closing(tokens, open)
+	end := followingPath(tokens, closeIndex+1)
+	args := splitArguments(tokens[open+1 : closeIndex])
+	for i, arg := range args {
+		if i == 1 {
+			continue
+		}
+		if err := c.checkTokens(arg, typ, sc); err != nil {
+			return 0, err
+		}
+	}
+	if len(args) < 2 {
+		return end, nil
+	}
+	collection := args[0]
+	if len(collection) == 0 || collection[0].Kind != empaths.TokenField || followingPath(collection, 1) != len(collection) {
+		return end, nil
+	}
+	collectionType, err := c.pathType(collection, typ, sc)
+	if err != nil || collectionType == nil {
+		return end, err
+	}
+	elem, err := checkBracket("*", deref(collectionType))
+	if err != nil {
+		return 0, fmt.Errorf("path %q: %w", c.text(collection), err)
+	}
+	if err := c.checkTokens(args[1], thunkResult(elem), scope{root: sc.root, parent: typ}); err != nil {
+		return 0, err
+	}
+	return end, nil
+}
+
+// splitArguments splits the tokens of the arguments of a function call at the
+// commas outside of lists, filters, and nested calls.
+func splitArguments(tokens []empaths.Token) [][]empaths.Token {
+	var args [][]empaths.Token
+	depth, start := 0, 0
+	for i, tok := range tokens {
+		switch tok.Kind {
+		case empaths.TokenLeftParen, empaths.TokenListStart, empaths.TokenFilterStart:
+			depth++
+		case empaths.TokenRightParen, empaths.TokenListEnd, empaths.TokenFilterEnd:
+			depth--
+		case empaths.TokenComma:
+			if depth == 0 {
+				args = append(args, tokens[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(args, tokens[start:])
+}
+
+// pathType follows the segments of a model path through typ and returns the type
+// they refer to, or nil if the type is not known statically.
+func (c *checker) pathType(segments []empaths.Token, typ types.Type, sc scope) (types.Type, error) {
+	// owner is the type holding the collection of the current bracket segments.
+	owner := typ
+	for i := 0; i < len(segments); i++ {
+		segment := segments[i]
+		if segment.Kind == empaths.TokenField && segment.Text == "." {
+			// The root path refers to typ itself.
+			continue
+		}
+		typ = deref(typ)
+		if _, ok := typ.Underlying().(*types.Interface); ok {
+			// The dynamic type is unknown, so the rest of the path cannot be checked.
+			return nil, nil
+		}
+		if hasResolveMethod(typ, "ResolvePathSegment", types.String) {
+			// The type resolves its segments itself.
+			return nil, nil
+		}
+		if segment.Kind == empaths.TokenField && hasPathAliases(typ) {
+			// The aliases of the type are only known at run time.
+			return nil, nil
+		}
+		if isSyncMap(typ) {
+			// The keys and values of a sync.Map are not typed.
+			return nil, nil
+		}
+		if segment.Kind != empaths.TokenField && (hasResolveMethod(typ, "ResolveIndex", types.Int) || hasResolveMethod(typ, "ResolveKey", types.String)) {
+			// The type resolves its indices or keys itself.
+			return nil, nil
+		}
+
+		var next types.Type
+		var err error
+		switch segment.Kind {
+		case empaths.TokenField:
+			owner = typ
+			next, err = checkName(segment.Text[1:], typ)
+		case empaths.TokenIndex:
+			next, err = checkBracket(segment.Text[1:len(segment.Text)-1], typ)
+		case empaths.TokenWildcard:
+			next, err = checkBracket("*", typ)
+		case empaths.TokenFilterStart:
+			// The filter expression is checked against the element type.
+			end := closing(segments, i)
+			if next, err = checkBracket("*", typ); err == nil {
+				err = c.checkTokens(segments[i+1:end], next, scope{root: sc.root, parent: owner})
+			}
+			i = end
+		}
+		if err != nil {
+			return nil, err
+		}
+		typ = thunkResult(next)
+	}
+	return typ, nil
+}
+
+// text returns the source text of tokens, which are adjacent in the expression.
+func (c *checker) text(tokens []empaths.Token) string {
+	last := tokens[len(tokens)-1]
+	return c.expr[tokens[0].Pos : last.Pos+len(last.Text)]
+}
+
+// followingPath returns the index after the model path segments starting at
+// tokens[i] that directly follow tokens[i-1], such as the segments of a model path
+// after its first one, or of a model path after '$' or a function call. A filter is
+// skipped as a whole.
+func followingPath(tokens []empaths.Token, i int) int {
+	for i > 0 && i < len(tokens) && isSegment(tokens[i].Kind) && tokens[i].Pos == tokens[i-1].Pos+len(tokens[i-1].Text) {
+		if tokens[i].Kind == empaths.TokenFilterStart {
+			i = closing(tokens, i)
+		}
+		i++
+	}
+	return i
+}
+
+// isSegment reports whether a token of the given kind is a model path segment, or
+// starts one.
+func isSegment(kind empaths.TokenKind) bool {
+	switch kind {
+	case empaths.TokenField, empaths.TokenIndex, empaths.TokenWildcard, empaths.TokenFilterStart:
+		return true
+	default:
+		return false
+	}
+}
+
+// closing returns the index of the token that closes the '(', list, or filter
+// opened by tokens[open]. Tokens only returns balanced tokens, so it exists.
+func closing(tokens []empaths.Token, open int) int {
+	depth := 0
+	for i := open; i < len(tokens); i++ {
+		switch tokens[i].Kind {
+		case empaths.TokenLeftParen, empaths.TokenListStart, empaths.TokenFilterStart:
+			depth++
+		case empaths.TokenRightParen, empaths.TokenListEnd, empaths.TokenFilterEnd:
+			if depth--; depth == 0 {
+				return i
+			}
+		}
+	}
+	return len(tokens) - 1
+}
+
+// hasResolveMethod reports whether typ or a pointer to it has a method of the given
+// name that takes one parameter of the given kind and returns (any, bool), like
+// the methods of empaths.PathResolvable, IndexResolvable, and KeyResolvable.
+func hasResolveMethod(typ types.Type, name string, param types.BasicKind) bool {
+	obj, _, _ := types.LookupFieldOrMethod(typ, true, nil, name)
+	fn, ok := obj.(*types.Func)
+	if !ok {
+		return false
+	}
+	sig := fn.Type().(*types.Signature)
+	if sig.Params().Len() != 1 || sig.Results().Len() != 2 {
+		return false
+	}
+	arg, ok := sig.Params().At(0).Type().Underlying().(*types.Basic)
+	found, ok2 := sig.Results().At(1).Type().Underlying().(*types.Basic)
+	return ok && ok2 && arg.Kind() == param && found.Kind() == types.Bool
+}
+
+// hasPathAliases reports whether typ or a pointer to it has the method of
+// empaths.PathAliaser, PathAliases() map[string]string.
+func hasPathAliases(typ types.Type) bool {
+	obj, _, _ := types.LookupFieldOrMethod(typ, true, nil, "PathAliases")
+	fn, ok := obj.(*types.Func)
+	if !ok {
+		return false
+	}
+	sig := fn.Type().(*types.Signature)
+	if sig.Params().Len() != 0 || sig.Results().Len() != 1 {
+		return false
+	}
+	m, ok := sig.Results().At(0).Type().Underlying().(*types.Map)
+	if !ok {
+		return false
+	}
+	key, ok := m.Key().Underlying().(*types.Basic)
+	elem, ok2 := m.Elem().Underlying().(*types.Basic)
+	return ok && ok2 && key.Kind() == types.String && elem.Kind() == types.String
+}
+
+// thunkResult returns the type of the first result of typ if it is a function that
+// takes no arguments and returns a value, which empaths calls when it resolves a
+// field, key, or index holding it, and typ otherwise.
+func thunkResult(typ types.Type) types.Type {
+	if sig, ok := typ.Underlying().(*types.Signature); ok && sig.Params().Len() == 0 && sig.Results().Len() > 0 {
+		return sig.Results().At(0).Type()
+	}
+	return typ
+}
+
+// iteratorElem returns the element type of typ and 1 if it has the form of iter.Seq,
+// the value type and 2 if it has the form of iter.Seq2, and 0 otherwise.
+func iteratorElem(typ types.Type) (types.Type, int) {
+	sig, ok := typ.Underlying().(*types.Signature)
+	if !ok || sig.Params().Len() != 1 || sig.Results().Len() != 0 {
+		return nil, 0
+	}
+	yield, ok := sig.Params().At(0).Type().Underlying().(*types.Signature)
+	if !ok || yield.Results().Len() != 1 {
+		return nil, 0
+	}
+	if result, ok := yield.Results().At(0).Type().Underlying().(*types.Basic); !ok || result.Kind() != types.Bool {
+		return nil, 0
+	}
+	switch n := yield.Params().Len(); n {
+	case 1, 2:
+		return yield.Params().At(n - 1).Type(), n
+	default:
+		return nil, 0
+	}
+}
+
+// isSyncMap reports whether typ is sync.Map, whose keys empaths resolves with Load.
+func isSyncMap(typ types.Type) bool {
+	named, ok := typ.(*types.Named)
+	return ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == "sync" && named.Obj().Name() == "Map"
+}
+
+// checkName resolves a field, method, or map key written in dot notation.
+func checkName(name string, typ types.Type) (types.Type, error) {
+	if name == "" {
+		return nil, fmt.Errorf("empty segment")
+	}
+
+	if base, result, ok := splitResultSelector(name); ok {
+		if next, err := checkResult(base, result, typ); err == nil {
+			return next, nil
+		} else if _, isMap := typ.Underlying().(*types.Map); !isMap {
+			return nil, err
+		}
+	}
+
+	var pkg *types.Package
+	if named, ok := typ.(*types.Named); ok {
+		pkg = named.Obj().Pkg()
+	}
+	obj, _, _ := types.LookupFieldOrMethod(typ, true, pkg, name)
+	if obj != nil && !obj.Exported() {
+		return nil, fmt.Errorf("%q on %s is unexported", name, typ)
+	}
+	switch obj := obj.(type) {
+	case *types.Var:
+		return obj.Type(), nil
+	case *types.Func:
+		sig := obj.Type().(*types.Signature)
+		if sig.Params().Len() > 0 {
+			return nil, fmt.Errorf("method %q on %s requires arguments", name, typ)
+		}
+		if sig.Results().Len() == 0 {
+			return nil, fmt.Errorf("method %q on %s returns no value", name, typ)
+		}
+		return sig.Results().At(0).Type(), nil
+	}
+
+	if m, ok := typ.Underlying().(*types.Map); ok {
+		if err := checkMapKey(name, m.Key()); err != nil {
+			return nil, err
+		}
+		return m.Elem(), nil
+	}
+	if elem, arity := iteratorElem(typ); arity == 2 {
+		return elem, nil
+	}
+	return nil, fmt.Errorf("unknown field or method %q on %s", name, typ)
+}
+
+// splitResultSelector splits a name with a result selector, such as "Lookup#1", into
+// the name and the index of the selected result.
+func splitResultSelector(name string) (string, int, bool) {
+	hash := strings.LastIndexByte(name, '#')
+	if hash <= 0 || hash == len(name)-1 {
+		return name, 0, false
+	}
+	for i := hash + 1; i < len(name); i++ {
+		if name[i] < '0' || name[i] > '9' {
+			return name, 0, false
+		}
+	}
+	result, err := strconv.Atoi(name[hash+1:])
+	if err != nil {
+		return name, 0, false
+	}
+	return name[:hash], result, true
+}
+
+// checkResult resolves a result selected from a method, or from a field or map
+// value holding a function, as in "Lookup#1".
+func checkResult(name string, result int, typ types.Type) (types.Type, error) {
+	var fnType types.Type
+	if obj, _, _ := types.LookupFieldOrMethod(typ, true, nil, name); obj != nil {
+		if !obj.Exported() {
+			return nil, fmt.Errorf("%q on %s is unexported", name, typ)
+		}
+		fnType = obj.Type()
+	} else if m, ok := typ.Underlying().(*types.Map); ok {
+		fnType = m.Elem()
+	}
+	var sig *types.Signature
+	if fnType != nil {
+		if _, ok := fnType.Underlying().(*types.Interface); ok {
+			// The dynamic type is unknown, so the result cannot be checked.
+			return fnType, nil
+		}
+		sig, _ = fnType.Underlying().(*types.Signature)
+	}
+	if sig == nil || sig.Params().Len() > 0 {
+		return nil, fmt.Errorf("%q on %s is not a method or function without arguments", name, typ)
+	}
+	if result >= sig.Results().Len() {
+		return nil, fmt.Errorf("%q on %s has no result %d", name, typ, result)
+	}
+	return sig.Results().At(result).Type(), nil
+}
+
+// checkBracket resolves an index, key, or wildcard written in brackets and returns
+// the element type of typ.
+func checkBracket(selector string, typ types.Type) (types.Type, error) {
+	var elem types.Type
+	var array *types.Array
+	var mapType *types.Map
+	keyed := false
+	switch t := typ.Underlying().(type) {
+	case *types.Slice:
+		elem = t.Elem()
+	case *types.Array:
+		elem, array = t.Elem(), t
+	case *types.Map:
+		elem, mapType = t.Elem(), t
+	default:
+		var arity int
+		if elem, arity = iteratorElem(typ); arity == 0 {
+			return nil, fmt.Errorf("cannot index %s", typ)
+		}
+		// The keys of an iter.Seq2 are compared as strings, so any key is valid.
+		keyed = arity == 2
+	}
+
+	switch {
+	case selector == "*":
+		return elem, nil
+	case mapType != nil:
+		return elem, checkMapKey(unquote(selector), mapType.Key())
+	case keyed:
+		return elem, nil
+	}
+
+	index, err := strconv.Atoi(selector)
+	if err != nil || index < 0 {
+		return nil, fmt.Errorf("invalid index [%s] on %s", selector, typ)
+	}
+	if array != nil && int64(index) >= array.Len() {
+		return nil, fmt.Errorf("index [%d] out of range for %s", index, typ)
+	}
+	return elem, nil
+}
+
+// checkMapKey reports whether key can be converted to the key type of a map, the
+// way empaths converts map keys written in a path.
+func checkMapKey(key string, keyType types.Type) error {
+	if isTextUnmarshaler(keyType) {
+		return nil
+	}
+	valid := true
+	switch t := keyType.Underlying().(type) {
+	case *types.Struct, *types.Array:
+		// Struct and array keys are written as JSON.
+		valid = json.Valid([]byte(key))
+	case *types.Basic:
+		valid = isBasicKey(key, t)
+	}
+	if !valid {
+		return fmt.Errorf("invalid key %q for map key type %s", key, keyType)
+	}
+	return nil
+}
+
+// isBasicKey reports whether key can be parsed as a value of a basic type.
+func isBasicKey(key string, basic *types.Basic) bool {
+	var err error
+	switch {
+	case basic.Info()&types.IsInteger != 0:
+		_, err = strconv.ParseInt(key, 10, 64)
+		if basic.Info()&types.IsUnsigned != 0 {
+			_, err = strconv.ParseUint(key, 10, 64)
+		}
+	case basic.Info()&types.IsFloat != 0:
+		_, err = strconv.ParseFloat(key, 64)
+	case basic.Info()&types.IsBoolean != 0:
+		_, err = strconv.ParseBool(key)
+	}
+	return err == nil
+}
+
+// isTextUnmarshaler reports whether a pointer to typ has an UnmarshalText method,
+// in which case empaths parses map keys of typ with it.
+func isTextUnmarshaler(typ types.Type) bool {
+	obj, _, _ := types.LookupFieldOrMethod(types.NewPointer(typ), true, nil, "UnmarshalText")
+	_, ok := obj.(*types.Func)
+	return ok
+}
+
+// deref removes any number of pointer indirections from typ.
+func deref(typ types.Type) types.Type {
+	for {
+		ptr, ok := typ.Underlying().(*types.Pointer)
+		if !ok {
+			return typ
+		}
+		typ = ptr.Elem()
+	}
+}
+
+// unquote returns the key of a bracket segment: the content of a quoted key with its
+// escape sequences translated the way empaths translates them, and any other key as
+// it is.
+func unquote(key string) string {
+	if key == "" || key[0] != '\'' && key[0] != '"' {
+		return key
+	}
+	tokens, err := empaths.Tokens(key)
+	if err != nil || len(tokens) != 1 || tokens[0].Kind != empaths.TokenString {
+		return key
+	}
+	return tokens[0].Value.(string)
+}
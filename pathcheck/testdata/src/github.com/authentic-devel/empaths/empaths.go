@@ -0,0 +1,20 @@
+// Package empaths is a stub of the empaths API used by the analyzer tests.
+package empaths
+
+type ReferenceResolver func(name string, data any) any
+
+func Resolve(path string, data any, refResolver ReferenceResolver) any { return nil }
+
+func Set(path string, data any, value any) error { return nil }
+
+func Delete(path string, data any) error { return nil }
+
+func ResolveWithVars(path string, data any, vars map[string]any, refResolver ReferenceResolver) any {
+	return nil
+}
+
+func ResolveString(path string, data any, refResolver ReferenceResolver) string { return "" }
+
+func ResolveInt(path string, data any, refResolver ReferenceResolver) (int, bool) { return 0, false }
+
+func ResolveDefault(path string, data any, def any, refResolver ReferenceResolver) any { return def }
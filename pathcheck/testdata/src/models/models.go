@@ -0,0 +1,171 @@
+package models
+
+import (
+	"sync"
+
+	"github.com/authentic-devel/empaths"
+)
+
+type Address struct {
+	City string
+	Zip  int
+}
+
+type User struct {
+	Name     string
+	Address  *Address
+	Tags     []string
+	Scores   map[string]int
+	ByID     map[int]string
+	ByCode   map[Code]string
+	ByZone   map[Zone]int
+	Corners  [4]int
+	Friends  []User
+	Extra    any
+	Config   *sync.Map
+	Pending  func(yield func(Address) bool)
+	Ranked   func(yield func(int, User) bool)
+	Lazy     func() *Address
+	Thunks   map[string]func() User
+	Profile  Profile
+	internal string
+}
+
+type Zone struct {
+	Region string
+	AZ     string
+}
+
+// Code is a numeric key written as a name, such as "gold".
+type Code int
+
+func (c *Code) UnmarshalText(text []byte) error { return nil }
+
+type Record struct {
+	ID int
+}
+
+func (r *Record) ResolvePathSegment(name string) (any, bool) { return nil, false }
+
+type Ring struct {
+	Size int
+}
+
+func (r Ring) ResolveIndex(i int) (any, bool) { return nil, false }
+
+type Profile struct {
+	DisplayName string
+}
+
+func (p Profile) PathAliases() map[string]string { return nil }
+
+func (u User) FullName() string        { return u.Name }
+func (u User) Greet(who string) string { return who }
+func (u User) Touch()                  {}
+func (u User) Friend() (User, bool)    { return u, true }
+
+func check(user User, users []User, record *Record, ring Ring, ref empaths.ReferenceResolver) {
+	// Valid paths.
+	empaths.Resolve(".Name", user, nil)
+	empaths.Resolve(".Address.City", &user, nil)
+	empaths.Resolve(".Tags[0]", user, nil)
+	empaths.Resolve(".Scores.alice", user, nil)
+	empaths.Resolve(".Scores['a.b']", user, nil)
+	empaths.Resolve(".ByID[42]", user, nil)
+	empaths.Resolve(`.ByID['\u0034\u0032']`, user, nil)
+	empaths.Resolve(".ByCode.gold", user, nil)
+	empaths.Resolve(`.ByZone[{"Region": "us", "AZ": "a"}]`, user, nil)
+	empaths.Resolve(".Corners[3]", user, nil)
+	empaths.Resolve(".FullName", user, nil)
+	empaths.Resolve(".Friends[*].Address.City", user, nil)
+	empaths.Resolve(".Friends[?.Name=='bob'].Tags", user, nil)
+	empaths.Resolve(".Extra.Anything[3].Goes", user, nil)
+	empaths.Resolve(".Config.timeout[0].Any", user, nil)
+	empaths.Resolve(".Pending[1].City", user, nil)
+	empaths.Resolve(".Pending[?.Zip > 0]", user, nil)
+	empaths.Resolve(".Ranked.first.Name", user, nil)
+	empaths.Resolve(".Ranked[3].Address.City", user, nil)
+	empaths.Resolve(".Lazy.City", user, nil)
+	empaths.Resolve(".Thunks.owner.Address.Zip", user, nil)
+	empaths.Resolve(".Thunks['a b'].Name", user, nil)
+	empaths.Resolve(".Friend#0.Address.City", user, nil)
+	empaths.Resolve("?.Friend#1", user, nil)
+	empaths.Resolve(".Thunks.owner#0.Name", user, nil)
+	empaths.Resolve(".Extra#3", user, nil)
+	empaths.Resolve(".Scores.a#1", user, nil)
+	empaths.Resolve("'Hello, ' .Name '! 1.5 ' :ref.Thing", user, ref)
+	empaths.Resolve(":ref(.Name).Anything ' ' .Name | :ref.Thing", user, ref)
+	empaths.Resolve("let $a = .Address; let $r = :ref; $a.Anything ' ' .Address.City", user, ref)
+	empaths.Resolve("count(.Tags) ' of ' join(.Friends[*].Name, ', ')", user, nil)
+	empaths.Resolve("?.Address.Zip == 1.5", user, nil)
+	empaths.Resolve(".[0].Name", users, nil)
+	empaths.Set(".Address.City", &user, "NYC")
+	empaths.Resolve("?.Address.Zip>=10000", user, nil)
+	empaths.Resolve(".Friends[?.Name == $.Name]", user, nil)
+	empaths.ResolveWithVars("?.Name == $wanted.Whatever[0]", user, nil, nil)
+	empaths.Resolve(".Friends[?.Friends[?.Address.Zip < ^.Address.Zip]]", user, nil)
+	empaths.Resolve(".Lazy.Anything", record, nil)
+	empaths.Resolve(".[-1].Anything", ring, nil)
+	empaths.Resolve(".Profile.display_name", user, nil)
+	empaths.Resolve("groupby(.Friends, '.Name')['bob'][0].Anything | len", user, nil)
+	empaths.Resolve(".Friends | first.Anything", user, nil)
+	empaths.Resolve("?.Name^='Al'", user, nil)
+	empaths.ResolveInt(".Address.Zip", user, nil)
+	empaths.Resolve(".Friends[?.Name $= ^.Name].Tags", user, nil)
+	empaths.Resolve("?.Address.City~g'N*'", user, nil)
+	empaths.Resolve("!?.Name&&!(.Address.Zip>1||.Tags contains 'a')", user, nil)
+	empaths.Resolve("?.Name >=v v1.2.3 && .Tags contains go.dev || .Name==a.b", user, nil)
+	empaths.Resolve("?.Tags | len > 0 && len(.Friends[?.Tags]) > '0'", user, nil)
+	empaths.Resolve("each(.Friends, '<li>' .Name ' ' ^.Name '</li>', ', ') .Name", user, nil)
+	empaths.Resolve("each(.Tags, . ' ', ',') each(first(.Friends), .Anything)", user, nil)
+	empaths.Resolve(".Friends | each(.Anything)", user, nil)
+	empaths.Resolve("switch(.Name, 'a':'x', 1: .Address.City, default:'y')", user, nil)
+
+	// Invalid paths.
+	empaths.Resolve(".Nmae", user, nil)                        // want `path ".Nmae": unknown field or method "Nmae" on models.User`
+	empaths.Resolve(".Address.Cty", user, nil)                 // want `unknown field or method "Cty" on models.Address`
+	empaths.Resolve(".Tags[x]", user, nil)                     // want `invalid index \[x\] on \[\]string`
+	empaths.Resolve(".Corners[4]", user, nil)                  // want `index \[4\] out of range for \[4\]int`
+	empaths.Resolve(".ByID.abc", user, nil)                    // want `invalid key "abc" for map key type int`
+	empaths.Resolve(".ByZone[us]", user, nil)                  // want `invalid key "us" for map key type models.Zone`
+	empaths.Resolve(`.ByID['4\'']`, user, nil)                 // want `invalid key "4'" for map key type int`
+	empaths.Resolve(".Tags[0", user, nil)                      // want `syntax error at offset 5: missing '\]'`
+	empaths.Resolve("'Hi .Name", user, nil)                    // want `syntax error at offset 0: unterminated string literal`
+	empaths.Resolve(".Name.First", user, nil)                  // want `unknown field or method "First" on string`
+	empaths.Resolve(".Name[0]", user, nil)                     // want `cannot index string`
+	empaths.Resolve(".Greet", user, nil)                       // want `method "Greet" on models.User requires arguments`
+	empaths.Resolve(".Touch", user, nil)                       // want `method "Touch" on models.User returns no value`
+	empaths.Resolve(".internal", user, nil)                    // want `"internal" on models.User is unexported`
+	empaths.Resolve(".Friends[?.Nam=='bob']", user, nil)       // want `unknown field or method "Nam" on models.User`
+	empaths.Resolve("'Hi ' .Address.Town", user, nil)          // want `unknown field or method "Town" on models.Address`
+	empaths.Delete(".Tag", &user)                              // want `unknown field or method "Tag" on models.User`
+	empaths.Resolve(".Friends[?.Name == $.Nmae]", user, nil)   // want `path "\$.Nmae": unknown field or method "Nmae" on models.User`
+	empaths.Resolve(".Tags[?. == ^.Nmae]", user, nil)          // want `path "\^.Nmae": unknown field or method "Nmae" on models.User`
+	empaths.Resolve(".Tags[?^.Tags contains .Zip]", user, nil) // want `unknown field or method "Zip" on string`
+	empaths.ResolveWithVars("?$name == .Nmae", user, nil, nil) // want `unknown field or method "Nmae" on models.User`
+	empaths.Resolve("^.Name", user, nil)                       // want `'\^' used outside of a filter`
+	empaths.Resolve(".Sise", ring, nil)                        // want `unknown field or method "Sise" on models.Ring`
+	empaths.Resolve("first(.Friends).Name .Nmae", user, nil)   // want `unknown field or method "Nmae" on models.User`
+	empaths.Resolve(".Tags|len .Nmae", user, nil)              // want `unknown field or method "Nmae" on models.User`
+	empaths.Resolve(":ref(.Nmae)", user, ref)                  // want `path ".Nmae": unknown field or method "Nmae" on models.User`
+	empaths.Resolve("let $a = .Nmae; $a", user, nil)           // want `path ".Nmae": unknown field or method "Nmae" on models.User`
+	empaths.Resolve("?.Nmae^='Al'", user, nil)                 // want `path ".Nmae": unknown field or method "Nmae" on models.User`
+	empaths.Resolve("?.Name=='x'&&.Nmae=='y'", user, nil)      // want `path ".Nmae": unknown field or method "Nmae" on models.User`
+	empaths.Resolve("?(.Tags||.Nmae)", user, nil)              // want `path ".Nmae": unknown field or method "Nmae" on models.User`
+	empaths.Resolve("?.Name == x.y && len(.Nmae)", user, nil)  // want `path ".Nmae": unknown field or method "Nmae" on models.User`
+	empaths.Resolve("?.Tags | len > len(.Nmae)", user, nil)    // want `path ".Nmae": unknown field or method "Nmae" on models.User`
+	empaths.Resolve("each(.Friends, .Address.Cty)", user, nil) // want `unknown field or method "Cty" on models.Address`
+	empaths.Resolve("each(.Friends, ^.Nmae)", user, nil)       // want `path "\^.Nmae": unknown field or method "Nmae" on models.User`
+	empaths.Resolve("each(.Name, .Anything)", user, nil)       // want `path ".Name": cannot index string`
+	empaths.ResolveString(".Nmae", user, nil)                  // want `path ".Nmae": unknown field or method "Nmae" on models.User`
+	empaths.ResolveDefault(".Nmae", user, "x", nil)            // want `path ".Nmae": unknown field or method "Nmae" on models.User`
+	empaths.Resolve(".Pending[0].Cty", user, nil)              // want `unknown field or method "Cty" on models.Address`
+	empaths.Resolve(".Pending.City", user, nil)                // want `unknown field or method "City" on func\(yield func\(models.Address\) bool\)`
+	empaths.Resolve(".Ranked.x.Nmae", user, nil)               // want `unknown field or method "Nmae" on models.User`
+	empaths.Resolve(".Lazy.Cty", user, nil)                    // want `unknown field or method "Cty" on models.Address`
+	empaths.Resolve(".Profile[0]", user, nil)                  // want `cannot index models.Profile`
+	empaths.Resolve(".Thunks.owner.Nmae", user, nil)           // want `unknown field or method "Nmae" on models.User`
+	empaths.Resolve(".Friend#2", user, nil)                    // want `"Friend" on models.User has no result 2`
+	empaths.Resolve(".Friend#0.Nmae", user, nil)               // want `unknown field or method "Nmae" on models.User`
+	empaths.Resolve(".Name#1", user, nil)                      // want `"Name" on models.User is not a method or function without arguments`
+}
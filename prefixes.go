@@ -0,0 +1,100 @@
+package empaths
+
+import (
+	"strings"
+)
+
+// PrefixHandler resolves an expression that starts with a custom prefix character
+// registered with WithPrefix, such as "@metric.name". It follows the same contract
+// as the built-in expressions: it is called with the whole path expression and the
+// index of the prefix character, reads as much of the path as belongs to the
+// expression, and returns its value together with the index after it.
+//
+// Parameters:
+//   - path: The path expression
+//   - data: The data the expression is evaluated against (the filtered element
+//     inside a filter)
+//   - index: The index of the prefix character in path
+//   - refResolver: The ReferenceResolver passed to Resolve; it may be nil
+//
+// Returns:
+//   - The value of the expression
+//   - The index after the expression
+type PrefixHandler func(path string, data any, index int, refResolver ReferenceResolver) (any, int)
+
+// prefixSigils holds the characters that may be registered as custom prefixes. All
+// other characters already have a meaning in paths.
+const prefixSigils = "@#%*/;"
+
+// WithPrefix makes a Resolver evaluate expressions starting with the character
+// sigil with handler, so applications can add their own kinds of operands without
+// changing the parser:
+//
+//	resolver := empaths.NewResolver(empaths.WithPrefix('@', func(path string, data any, index int, _ empaths.ReferenceResolver) (any, int) {
+//		name, end := empaths.ReadPrefixName(path, index)
+//		return metrics.Get(name), end
+//	}))
+//	resolver.Resolve("'CPU: ' @cpu.load", nil, nil) // "CPU: 0.42"
+//
+// The sigil must be one of '@', '#', '%', '*', '/', and ';'; other characters are
+// ignored. Registering a sigil again replaces its handler. Custom expressions can
+// be used wherever an operand can, including comparisons, function arguments, and
+// filters. Strict mode (see WithStrict) and the access rules assume that a custom
+// expression ends like a reference (see ReadPrefixName) and do not restrict it.
+func WithPrefix(sigil byte, handler PrefixHandler) Option {
+	return func(r *Resolver) {
+		if !strings.ContainsRune(prefixSigils, rune(sigil)) || handler == nil {
+			return
+		}
+		if r.prefixes == nil {
+			r.prefixes = make(map[byte]PrefixHandler)
+		}
+		r.prefixes[sigil] = handler
+	}
+}
+
+// ReadPrefixName reads the name following the prefix character at index the way
+// references (":name") are read: up to the next space, comparison operator, or
+// "&&". It is intended for PrefixHandlers of simple named lookups.
+//
+// Parameters:
+//   - path: The path expression
+//   - index: The index of the prefix character in path
+//
+// Returns:
+//   - The name after the prefix character
+//   - The index after the name
+func ReadPrefixName(path string, index int) (string, int) {
+	return readUntilTerminatorASCII(path, index+1)
+}
+
+// prefixHandler returns the handler registered for the character c, if any.
+func (s *evalState) prefixHandler(c byte) (PrefixHandler, bool) {
+	if s.resolver == nil || s.resolver.prefixes == nil {
+		return nil, false
+	}
+	handler, ok := s.resolver.prefixes[c]
+	return handler, ok
+}
+
+// resolveCustomPrefix evaluates an expression with a custom prefix. The index
+// returned by the handler is clamped to the rest of the path, so a handler that
+// does not advance cannot stall the evaluation.
+func resolveCustomPrefix(path string, data any, handler PrefixHandler, index int, state *evalState) (any, int) {
+	value, end := handler(path, data, index, state.refResolver)
+	if end <= index {
+		end = index + 1
+	}
+	if end > len(path) {
+		end = len(path)
+	}
+	return value, end
+}
+
+// tokens splits a path into tokens like Tokens, treating expressions with the
+// custom prefixes of the Resolver as references.
+func (r *Resolver) tokens(path string) ([]Token, error) {
+	t := &tokenizer{path: path, prefixes: r.prefixes}
+	err := t.tokenize(0, len(path))
+	return t.tokens, err
+}
@@ -0,0 +1,116 @@
+package empaths
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestResolver_WithPrefix(t *testing.T) {
+	team := createTestTeam()
+	metrics := map[string]any{"cpu.load": 0.5, "users.max": 30, "name": "Bob"}
+	resolver := NewResolver(
+		WithPrefix('@', func(path string, data any, index int, refResolver ReferenceResolver) (any, int) {
+			name, end := ReadPrefixName(path, index)
+			return metrics[name], end
+		}),
+		WithPrefix('#', func(path string, data any, index int, refResolver ReferenceResolver) (any, int) {
+			// Resolves against the data like a model path, e.g. inside filters.
+			name, end := ReadPrefixName(path, index)
+			return Resolve("."+name, data, refResolver), end
+		}),
+		WithPrefix('%', func(path string, data any, index int, refResolver ReferenceResolver) (any, int) {
+			return refResolver("percent", data), index
+		}),
+	)
+	refResolver := func(name string, data any) any { return "pct" }
+
+	tests := []struct {
+		name     string
+		path     string
+		expected any
+	}{
+		{"single", "@cpu.load", 0.5},
+		{"unknown name", "@disk", nil},
+		{"concatenation", "'load: ' @cpu.load ' max: ' @users.max", "load: 0.5 max: 30"},
+		{"comparison", "?@users.max >= 30", true},
+		{"right operand", "?.Users[0].Age == @users.max", true},
+		{"logical", "?@cpu.load > 0.1 && @users.max < 10", false},
+		{"filter", "count(.Users[?#Age > @users.max])", 1},
+		{"list literal", "?.Users[1].Name in [@name, 'x']", true},
+		{"function argument", "len(@name)", 3},
+		{"pipe", "@name | len", 3},
+		{"handler that does not advance", "%' '", "pct "},
+		{"unregistered sigil", "';' ;x", ";"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := resolver.Resolve(tt.path, team, refResolver); result != tt.expected {
+				t.Errorf("Resolve(%q) = %v, want %v", tt.path, result, tt.expected)
+			}
+		})
+	}
+
+	t.Run("package-level Resolve ignores prefixes", func(t *testing.T) {
+		if result := Resolve("'a' @cpu.load", team, nil); result != "a" {
+			t.Errorf("Resolve() = %v, want %v", result, "a")
+		}
+	})
+}
+
+func TestWithPrefix_ReservedSigil(t *testing.T) {
+	handler := func(path string, data any, index int, refResolver ReferenceResolver) (any, int) {
+		return "custom", index + 1
+	}
+	for _, sigil := range []byte{'.', ':', '$', 'a', '1', '-'} {
+		resolver := NewResolver(WithPrefix(sigil, handler))
+		if len(resolver.prefixes) != 0 {
+			t.Errorf("WithPrefix(%q) registered a reserved sigil", sigil)
+		}
+	}
+}
+
+func TestResolver_WithPrefixStrictAndAccess(t *testing.T) {
+	team := createTestTeam()
+	handler := func(path string, data any, index int, refResolver ReferenceResolver) (any, int) {
+		name, end := ReadPrefixName(path, index)
+		return name, end
+	}
+
+	tests := []struct {
+		name     string
+		opts     []Option
+		path     string
+		expected any
+		err      error
+	}{
+		{"strict", []Option{WithStrict(), WithPrefix('@', handler)}, "'x ' @metric", "x metric", nil},
+		{"strict without prefix", []Option{WithStrict()}, "'x ' @metric", nil, &SyntaxError{}},
+		{"allowed prefixes", []Option{WithAllowedPrefixes([]string{".Scores"}), WithPrefix('@', handler)}, ".Scores.math @metric", "95metric", nil},
+		{"denied fields", []Option{WithDeniedFields([]string{"Users"}), WithPrefix('@', handler)}, "?@a == .Users", nil, ErrAccessDenied},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := NewResolver(tt.opts...).ResolveErr(tt.path, team, nil)
+			switch want := tt.err.(type) {
+			case nil:
+				if err != nil {
+					t.Fatalf("ResolveErr(%q) error = %v", tt.path, err)
+				}
+			case *SyntaxError:
+				var syntaxErr *SyntaxError
+				if !errors.As(err, &syntaxErr) {
+					t.Fatalf("ResolveErr(%q) error = %v, want a syntax error", tt.path, err)
+				}
+			default:
+				if !errors.Is(err, want) {
+					t.Fatalf("ResolveErr(%q) error = %v, want %v", tt.path, err, want)
+				}
+			}
+			if result != tt.expected {
+				t.Errorf("ResolveErr(%q) = %v, want %v", tt.path, result, tt.expected)
+			}
+		})
+	}
+}
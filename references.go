@@ -0,0 +1,168 @@
+package empaths
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// References returns the names of the external references (without their colon)
+// in a path expression, in the order of their first use. Together with ReferenceMap
+// it allows resolving all references of an expression at once, e.g. with a single
+// database query, instead of calling a ReferenceResolver for every name:
+//
+//	names, err := empaths.References(path)
+//	values := loadSettings(ctx, names) // map[string]any
+//	result := empaths.Resolve(path, data, empaths.ReferenceMap(values))
+//
+// Parameters:
+//   - path: The path expression
+//
+// Returns:
+//   - The distinct reference names, or nil if there are none
+//   - A *SyntaxError if the path is malformed (see Tokens)
+func References(path string) ([]string, error) {
+	tokens, err := Tokens(path)
+	if err != nil {
+		return nil, err
+	}
+	return referenceNames(tokens), nil
+}
+
+// referenceNames returns the distinct names of the external references among tokens.
+func referenceNames(tokens []Token) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, token := range tokens {
+		if token.Kind != TokenReference || !strings.HasPrefix(token.Text, ":") {
+			continue
+		}
+		if name := token.Text[1:]; !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// ReferenceMap returns a ReferenceResolver that resolves references to the values
+// in values, regardless of the data. References that are not in values resolve to
+// nil.
+func ReferenceMap(values map[string]any) ReferenceResolver {
+	return func(name string, _ any) any {
+		return values[name]
+	}
+}
+
+// MemoOption configures a ReferenceResolver returned by MemoResolver.
+type MemoOption func(*memoConfig)
+
+// memoConfig holds the options of MemoResolver.
+type memoConfig struct {
+	// ignoreData caches the value of a reference once for all data.
+	ignoreData bool
+}
+
+// MemoIgnoreData makes the resolver returned by MemoResolver cache the value of a
+// reference by its name alone, regardless of the data it is resolved for. Use it for
+// references whose values do not depend on the data, such as configuration or
+// translated labels.
+func MemoIgnoreData() MemoOption {
+	return func(c *memoConfig) {
+		c.ignoreData = true
+	}
+}
+
+// MemoResolver wraps refResolver so that the value of each reference is only
+// resolved once and then reused until ttl has passed, for references that are
+// expensive to resolve, e.g. because they are fetched from a remote service:
+//
+//	refs := empaths.MemoResolver(configLookup, time.Minute, empaths.MemoIgnoreData())
+//	resolver.Resolve(":config.currency ' ' .Total", order, refs)
+//
+// By default values are cached per reference name and data, so a reference resolved
+// for another order is resolved again. Data that cannot be compared, such as maps
+// and slices, is never cached; pass pointers instead, or use MemoIgnoreData if the
+// values do not depend on the data. Nil values are cached like any other value.
+//
+// The returned ReferenceResolver is safe for concurrent use if refResolver is. Two
+// concurrent evaluations may both resolve a reference that is not cached yet.
+//
+// Parameters:
+//   - refResolver: The ReferenceResolver whose values are cached
+//   - ttl: How long a value is reused; values never expire if ttl is zero or negative
+//   - opts: Options of the cache
+//
+// Returns:
+//   - A ReferenceResolver that resolves references through the cache
+func MemoResolver(refResolver ReferenceResolver, ttl time.Duration, opts ...MemoOption) ReferenceResolver {
+	var config memoConfig
+	for _, opt := range opts {
+		opt(&config)
+	}
+	cache := &referenceCache{entries: make(map[referenceKey]referenceEntry)}
+	return func(name string, data any) any {
+		key := referenceKey{name: name}
+		if !config.ignoreData {
+			if data != nil && !reflect.ValueOf(data).Comparable() {
+				return refResolver(name, data)
+			}
+			key.data = data
+		}
+		if value, ok := cache.get(key); ok {
+			return value
+		}
+		value := refResolver(name, data)
+		cache.put(key, value, ttl)
+		return value
+	}
+}
+
+// referenceKey identifies a cached reference value.
+type referenceKey struct {
+	name string
+	// data is the data the reference was resolved for, or nil if the value does not
+	// depend on it.
+	data any
+}
+
+// referenceEntry is a cached reference value.
+type referenceEntry struct {
+	value any
+	// expires is the time after which the value is resolved again; it is zero if
+	// the value never expires.
+	expires time.Time
+}
+
+// referenceCache holds the values of a MemoResolver.
+type referenceCache struct {
+	mu      sync.Mutex
+	entries map[referenceKey]referenceEntry
+}
+
+// get returns the cached value of key, if it has not expired.
+func (c *referenceCache) get(key referenceKey) (any, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if !entry.expires.IsZero() && time.Now().After(entry.expires) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return entry.value, true
+}
+
+// put caches value for key for ttl.
+func (c *referenceCache) put(key referenceKey, value any, ttl time.Duration) {
+	entry := referenceEntry{value: value}
+	if ttl > 0 {
+		entry.expires = time.Now().Add(ttl)
+	}
+	c.mu.Lock()
+	c.entries[key] = entry
+	c.mu.Unlock()
+}
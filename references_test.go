@@ -0,0 +1,123 @@
+package empaths
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestReferences(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		expected []string
+	}{
+		{"none", ".Users[0].Name", nil},
+		{"single", ":greeting", []string{"greeting"}},
+		{"in order of first use", ":greeting ' ' .Name ' ' :sign ' ' :greeting", []string{"greeting", "sign"}},
+		{"dotted name", ":config.currency", []string{"config.currency"}},
+		{"nested", "count(.Users[?.Age > :minAge]) ?:limit > 2", []string{"minAge", "limit"}},
+		{"inside string literal", "':greeting'", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			names, err := References(tt.path)
+			if err != nil {
+				t.Fatalf("References(%q) error = %v", tt.path, err)
+			}
+			if !reflect.DeepEqual(names, tt.expected) {
+				t.Errorf("References(%q) = %v, want %v", tt.path, names, tt.expected)
+			}
+			if compiled := MustCompile(tt.path); !reflect.DeepEqual(compiled.References(), tt.expected) {
+				t.Errorf("CompiledPath(%q).References() = %v, want %v", tt.path, compiled.References(), tt.expected)
+			}
+		})
+	}
+
+	t.Run("syntax error", func(t *testing.T) {
+		if _, err := References(":a .Users[0"); !errors.Is(err, ErrSyntax) {
+			t.Errorf("References() error = %v, want ErrSyntax", err)
+		}
+	})
+
+	t.Run("prefetched values", func(t *testing.T) {
+		path := ":greeting ', ' .Users[0].Name :sign"
+		names, _ := References(path)
+		values := make(map[string]any, len(names))
+		for _, name := range names {
+			values[name] = map[string]any{"greeting": "Hello", "sign": "!"}[name]
+		}
+		if result := Resolve(path, createTestTeam(), ReferenceMap(values)); result != "Hello, Alice!" {
+			t.Errorf("Resolve() = %v, want %v", result, "Hello, Alice!")
+		}
+	})
+}
+
+// memoCall is a reference resolved in the MemoResolver tests.
+type memoCall struct {
+	ref  string
+	data any
+}
+
+func TestMemoResolver(t *testing.T) {
+	alice, bob := &Member{Name: "Alice"}, &Member{Name: "Bob"}
+
+	tests := []struct {
+		name     string
+		opts     []MemoOption
+		ttl      time.Duration
+		calls    []memoCall
+		resolved int
+	}{
+		{"same name and data", nil, 0, []memoCall{{"currency", alice}, {"currency", alice}, {"currency", alice}}, 1},
+		{"other name", nil, 0, []memoCall{{"currency", alice}, {"locale", alice}, {"currency", alice}}, 2},
+		{"other data", nil, 0, []memoCall{{"currency", alice}, {"currency", bob}, {"currency", alice}}, 2},
+		{"data ignored", []MemoOption{MemoIgnoreData()}, 0, []memoCall{{"currency", alice}, {"currency", bob}, {"currency", nil}}, 1},
+		{"data not comparable", nil, 0, []memoCall{{"currency", map[string]any{}}, {"currency", map[string]any{}}}, 2},
+		{"expired", nil, time.Nanosecond, []memoCall{{"currency", alice}, {"currency", alice}}, 2},
+		{"not expired", nil, time.Hour, []memoCall{{"currency", alice}, {"currency", alice}}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolved := 0
+			refs := MemoResolver(func(name string, data any) any {
+				resolved++
+				if name == "currency" {
+					return "EUR"
+				}
+				return nil
+			}, tt.ttl, tt.opts...)
+			for _, call := range tt.calls {
+				want := any(nil)
+				if call.ref == "currency" {
+					want = "EUR"
+				}
+				if value := refs(call.ref, call.data); value != want {
+					t.Errorf("refs(%q) = %v, want %v", call.ref, value, want)
+				}
+				if tt.ttl == time.Nanosecond {
+					time.Sleep(time.Millisecond)
+				}
+			}
+			if resolved != tt.resolved {
+				t.Errorf("resolved %d references, want %d", resolved, tt.resolved)
+			}
+		})
+	}
+
+	t.Run("in a template", func(t *testing.T) {
+		resolved := 0
+		refs := MemoResolver(func(name string, data any) any {
+			resolved++
+			return "EUR"
+		}, time.Minute, MemoIgnoreData())
+		team := createTestTeam()
+		result := Resolve("count(.Users[?:currency == 'EUR']) ' ' :currency", team, refs)
+		if result != "3 EUR" || resolved != 1 {
+			t.Errorf("Resolve() = %v with %d lookups, want %v with 1", result, resolved, "3 EUR")
+		}
+	})
+}
@@ -0,0 +1,52 @@
+package empaths
+
+import (
+	"fmt"
+	"sync"
+)
+
+// registry holds the paths added with Register.
+var registry = struct {
+	sync.RWMutex
+	paths map[string]*CompiledPath
+}{paths: make(map[string]*CompiledPath)}
+
+// Register compiles a path expression and stores it under a symbolic name, so that
+// other packages can look it up with MustPath instead of repeating the expression.
+// It is meant to be called from init functions or package-level variable
+// declarations, so that malformed paths stop the program at startup:
+//
+//	func init() {
+//		empaths.Register("user.city", ".User.Address.City")
+//	}
+//
+//	city := empaths.MustPath("user.city").Resolve(data, nil)
+//
+// Register panics if the path is malformed (see MustCompile) or if a path is
+// already registered under name. It is safe for concurrent use.
+//
+// Parameters:
+//   - name: The symbolic name of the path
+//   - path: The path expression
+func Register(name string, path string) {
+	compiled := MustCompile(path)
+	registry.Lock()
+	defer registry.Unlock()
+	if existing, ok := registry.paths[name]; ok {
+		panic(fmt.Sprintf("empaths: Register(%q): already registered as %q", name, existing.path))
+	}
+	registry.paths[name] = compiled
+}
+
+// MustPath returns the path registered under name with Register. It panics if no
+// path is registered under name, so a misspelled name is detected the first time
+// it is used. It is safe for concurrent use.
+func MustPath(name string) *CompiledPath {
+	registry.RLock()
+	compiled, ok := registry.paths[name]
+	registry.RUnlock()
+	if !ok {
+		panic(fmt.Sprintf("empaths: MustPath(%q): no path registered", name))
+	}
+	return compiled
+}
@@ -0,0 +1,72 @@
+package empaths
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestRegister(t *testing.T) {
+	team := createTestTeam()
+	Register("test.firstName", ".Users[0].Name")
+	Register("test.greeting", "'Hello, ' .Users[1].Name")
+
+	tests := []struct {
+		name     string
+		expected any
+	}{
+		{"test.firstName", "Alice"},
+		{"test.greeting", "Hello, Bob"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := MustPath(tt.name).Resolve(team, nil); result != tt.expected {
+				t.Errorf("MustPath(%q).Resolve() = %v, want %v", tt.name, result, tt.expected)
+			}
+		})
+	}
+
+	panics := []struct {
+		name string
+		fn   func()
+	}{
+		{"malformed path", func() { Register("test.malformed", ".Users[0") }},
+		{"duplicate name", func() { Register("test.firstName", ".Users[1].Name") }},
+		{"unknown name", func() { MustPath("test.unknown") }},
+	}
+
+	for _, tt := range panics {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s did not panic", tt.name)
+				}
+			}()
+			tt.fn()
+		})
+	}
+
+	t.Run("duplicate keeps the first path", func(t *testing.T) {
+		if result := MustPath("test.firstName").Resolve(team, nil); result != "Alice" {
+			t.Errorf("MustPath().Resolve() = %v, want %v", result, "Alice")
+		}
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				name := fmt.Sprintf("test.concurrent%d", i)
+				Register(name, ".Users[0].Age")
+				if result := MustPath(name).Resolve(team, nil); result != 30 {
+					t.Errorf("MustPath(%q).Resolve() = %v, want 30", name, result)
+				}
+				MustPath("test.firstName")
+			}(i)
+		}
+		wg.Wait()
+	})
+}
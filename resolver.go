@@ -2,8 +2,10 @@ package empaths
 
 import (
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 )
 
 // resolvePathAgainstValue resolves a path against a reflect.Value.
@@ -12,10 +14,11 @@ import (
 // Parameters:
 //   - path: The path string to resolve (e.g., "User.Address.City")
 //   - value: The reflect.Value to resolve the path against
+//   - state: The state of the current evaluation
 //
 // Returns:
 //   - The resolved reflect.Value
-func resolvePathAgainstValue(path string, value reflect.Value) reflect.Value {
+func resolvePathAgainstValue(path string, value reflect.Value, state *evalState) reflect.Value {
 	// Handle nil or invalid values
 	if !value.IsValid() {
 		return reflect.Value{}
@@ -34,13 +37,16 @@ func resolvePathAgainstValue(path string, value reflect.Value) reflect.Value {
 	// Handle pointers and interfaces
 	if value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
 		if value.IsNil() {
+			if state.diagnose() {
+				state.failNil(path)
+			}
 			return reflect.Value{}
 		}
-		return resolvePathAgainstValue(path, value.Elem())
+		return resolvePathAgainstValue(path, value.Elem(), state)
 	}
 
 	// Split the path into segments
-	return resolvePathSegments(path, value)
+	return resolvePathSegments(path, value, state)
 }
 
 // resolvePathSegments handles the resolution of path segments against a reflect.Value.
@@ -50,13 +56,21 @@ func resolvePathAgainstValue(path string, value reflect.Value) reflect.Value {
 // Parameters:
 //   - path: The path string to resolve (e.g., "User.Address" or "Users[0]")
 //   - value: The reflect.Value to resolve the path against
+//   - state: The state of the current evaluation
 //
 // Returns:
 //   - The resolved reflect.Value
-func resolvePathSegments(path string, value reflect.Value) reflect.Value {
+func resolvePathSegments(path string, value reflect.Value, state *evalState) reflect.Value {
+	if expanded := expandPathAlias(path, value); expanded != path {
+		if !state.allowsAlias(path, expanded) {
+			return reflect.Value{}
+		}
+		path = expanded
+	}
+
 	// Check if the path starts with an array/map index
 	if len(path) > 0 && path[0] == '[' {
-		return resolveArrayOrMapAccess(path, value)
+		return resolveArrayOrMapAccess(path, value, state)
 	}
 
 	// Single-pass scan to find first '.' or '['
@@ -83,13 +97,39 @@ func resolvePathSegments(path string, value reflect.Value) reflect.Value {
 		currentSegment = path[:splitIdx]
 		remainingPath = path[splitIdx+1:]
 	} else {
-		// Bracket comes first
+		// Bracket comes first; value holds the collection a filter is applied to
 		currentSegment = path[:splitIdx]
 		remainingPath = path[splitIdx:]
+		state.owner = value
 	}
 
 	// Resolve the current segment
-	resolvedValue := resolveFieldOrMethod(currentSegment, value)
+	if !state.countSegment() || !state.authorize(currentSegment, path) {
+		return reflect.Value{}
+	}
+	var resolvedValue reflect.Value
+	if state.readOnly > 0 || state.readsUnexported(currentSegment, value) {
+		// The rest of the path only reads the value of an unexported field.
+		state.readOnly++
+		defer func() { state.readOnly-- }()
+		resolvedValue = resolveReadOnly(currentSegment, value)
+	} else {
+		var ok bool
+		if resolvedValue, ok = state.resolveKey(currentSegment, value); !ok {
+			if !state.allowsMethod(currentSegment, value) {
+				return reflect.Value{}
+			}
+			resolvedValue = resolveFieldOrMethod(currentSegment, value)
+		}
+		resolvedValue = callThunk(resolvedValue)
+	}
+	if resolvedValue.IsValid() && !state.secret {
+		state.checkSecretField(currentSegment, value)
+	}
+	resolvedValue = state.interceptSegment(currentSegment, resolvedValue)
+	if !resolvedValue.IsValid() && state.diagnose() {
+		state.fail(fieldNotFound(currentSegment, value))
+	}
 
 	// If we couldn't resolve the current segment or there's no remaining path, return the result
 	if !resolvedValue.IsValid() || remainingPath == "" {
@@ -97,28 +137,57 @@ func resolvePathSegments(path string, value reflect.Value) reflect.Value {
 	}
 
 	// Continue resolving with the remaining path
-	return resolvePathAgainstValue(remainingPath, resolvedValue)
+	return resolvePathAgainstValue(remainingPath, resolvedValue, state)
 }
 
 // resolveArrayOrMapAccess handles array, slice, and map access with brackets.
 // It processes path segments that start with '[' for accessing elements by index or key.
+// Wildcards ("[*]") and filters ("[?expr]") select several elements; the remaining path
+// is then applied to each selected element (see resolveProjection).
 //
 // Parameters:
 //   - path: The path string to resolve (e.g., "[0]" or "[\"key\"]")
 //   - value: The reflect.Value to resolve the path against
+//   - state: The state of the current evaluation
 //
 // Returns:
 //   - The resolved reflect.Value
-func resolveArrayOrMapAccess(path string, value reflect.Value) reflect.Value {
+func resolveArrayOrMapAccess(path string, value reflect.Value, state *evalState) reflect.Value {
+	if !state.countSegment() {
+		return reflect.Value{}
+	}
+
 	// Find the closing bracket
-	closeBracketIndex := strings.Index(path, "]")
+	closeBracketIndex := findClosingASCII(path, 0)
 	if closeBracketIndex == -1 {
 		// Invalid path, missing closing bracket
 		return reflect.Value{}
 	}
 
 	indexOrKey := path[1:closeBracketIndex]
-	resolvedValue := resolveIndexOrKey(indexOrKey, value)
+	if indexOrKey == "*" || (len(indexOrKey) > 0 && indexOrKey[0] == '?') {
+		if state.audit != nil && strings.HasSuffix(state.modelPath, path) {
+			defer state.audit.project(state.modelPath[:len(state.modelPath)-len(path)])()
+		}
+		return resolveProjection(indexOrKey, path[closeBracketIndex+1:], value, state)
+	}
+	if !state.authorize(path[:closeBracketIndex+1], path) {
+		return reflect.Value{}
+	}
+	var resolvedValue reflect.Value
+	if state.readOnly > 0 {
+		resolvedValue = resolveReadOnly(unquoteKey(indexOrKey), value)
+	} else {
+		var ok bool
+		if resolvedValue, ok = state.resolveKey(unquoteKey(indexOrKey), value); !ok {
+			resolvedValue = resolveIndexOrKey(indexOrKey, value)
+		}
+		resolvedValue = callThunk(resolvedValue)
+	}
+	resolvedValue = state.interceptSegment(unquoteKey(indexOrKey), resolvedValue)
+	if !resolvedValue.IsValid() && state.diagnose() {
+		state.fail(indexOrKeyError(indexOrKey, value))
+	}
 
 	// If we couldn't resolve or there's no remaining path, return the result
 	if !resolvedValue.IsValid() || closeBracketIndex == len(path)-1 {
@@ -127,11 +196,154 @@ func resolveArrayOrMapAccess(path string, value reflect.Value) reflect.Value {
 
 	// Continue resolving with the remaining path
 	remainingPath := path[closeBracketIndex+1:]
-	return resolvePathAgainstValue(remainingPath, resolvedValue)
+	return resolvePathAgainstValue(remainingPath, resolvedValue, state)
+}
+
+// resolveProjection handles wildcard ("*") and filter ("?expr") selectors.
+// It selects the elements of an array, slice, or map (map values are visited in
+// sorted key order), keeps those for which the filter expression is true, and
+// resolves the remaining path against each of them. Elements for which the
+// remaining path cannot be resolved are skipped.
+//
+// Parameters:
+//   - selector: The bracket content, either "*" or a filter starting with '?'
+//   - remainingPath: The path following the closing bracket
+//   - value: The reflect.Value to select elements from
+//   - state: The state of the current evaluation
+//
+// Returns:
+//   - A reflect.Value holding a slice of the projected values (see typedSlice), or
+//     an invalid reflect.Value if value is not a collection
+func resolveProjection(selector string, remainingPath string, value reflect.Value, state *evalState) reflect.Value {
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return reflect.Value{}
+		}
+		value = value.Elem()
+	}
+
+	if state.readOnly > 0 && value.Kind() != reflect.Array && value.Kind() != reflect.Slice && value.Kind() != reflect.Map {
+		// Iterators and sync.Maps would be called.
+		return reflect.Value{}
+	}
+	elements := collectionElements(value)
+	if elements == nil {
+		return reflect.Value{}
+	}
+
+	// Inside the filter, '^' refers to the value holding the collection.
+	outerParent := state.parent
+	state.parent = extractValue(state.owner)
+	defer func() { state.parent = outerParent }()
+
+	results := make([]any, 0, len(elements))
+	for _, element := range elements {
+		if selector != "*" {
+			state.scopeDepth++
+			matched, _ := resolveExpressions(selector, extractValue(element), state, 0)
+			state.scopeDepth--
+			if !isTrue(matched) {
+				continue
+			}
+		}
+		resolved := resolvePathAgainstValue(remainingPath, element, state)
+		if !resolved.IsValid() {
+			continue
+		}
+		results = append(results, extractValue(resolved))
+	}
+	return typedSlice(results)
 }
 
-// resolveIndexOrKey resolves an index or key against an array, slice, or map.
+// typedSlice returns values as a slice of their common type if they all have the
+// same dynamic type (e.g. a []string), and as a []any otherwise.
+func typedSlice(values []any) reflect.Value {
+	if len(values) == 0 || values[0] == nil {
+		return reflect.ValueOf(values)
+	}
+	typ := reflect.TypeOf(values[0])
+	for _, v := range values[1:] {
+		if reflect.TypeOf(v) != typ {
+			return reflect.ValueOf(values)
+		}
+	}
+	slice := reflect.MakeSlice(reflect.SliceOf(typ), len(values), len(values))
+	for i, v := range values {
+		slice.Index(i).Set(reflect.ValueOf(v))
+	}
+	return slice
+}
+
+// collectionElements returns the elements of an array, slice, or iterator (see
+// iterators.go), or the values of a map or sync.Map in sorted key order. It returns
+// nil for any other kind of value.
+func collectionElements(value reflect.Value) []reflect.Value {
+	switch value.Kind() {
+	case reflect.Array, reflect.Slice:
+		elements := make([]reflect.Value, value.Len())
+		for i := range elements {
+			elements[i] = value.Index(i)
+		}
+		return elements
+	case reflect.Map:
+		keys := sortedMapKeys(value)
+		elements := make([]reflect.Value, len(keys))
+		for i, key := range keys {
+			elements[i] = value.MapIndex(key)
+		}
+		return elements
+	case reflect.Struct:
+		if m, ok := asSyncMap(value); ok {
+			return syncMapValues(m)
+		}
+		return nil
+	case reflect.Func:
+		return iteratorElements(value)
+	default:
+		return nil
+	}
+}
+
+// collectionElemType returns the type of the elements that collectionElements
+// returns for value: the element type of an array, slice, or map, the type of the
+// values of an iterator, and any for a sync.Map.
+func collectionElemType(value reflect.Value) reflect.Type {
+	switch value.Kind() {
+	case reflect.Func:
+		return iteratorElemType(value.Type())
+	case reflect.Struct:
+		return reflect.TypeOf((*any)(nil)).Elem()
+	default:
+		return value.Type().Elem()
+	}
+}
+
+// sortedMapKeys returns the keys of a map in sorted order: numerically for integer
+// and floating-point keys, by their string representation for all other keys.
+func sortedMapKeys(value reflect.Value) []reflect.Value {
+	keys := value.MapKeys()
+	var less func(a, b reflect.Value) bool
+	switch value.Type().Key().Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		less = func(a, b reflect.Value) bool { return a.Int() < b.Int() }
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		less = func(a, b reflect.Value) bool { return a.Uint() < b.Uint() }
+	case reflect.Float32, reflect.Float64:
+		less = func(a, b reflect.Value) bool { return a.Float() < b.Float() }
+	default:
+		less = func(a, b reflect.Value) bool {
+			return toString(extractValue(a)) < toString(extractValue(b))
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return less(keys[i], keys[j]) })
+	return keys
+}
+
+// resolveIndexOrKey resolves an index or key against an array, slice, map, sync.Map, or
+// iterator.
 // It handles numeric indices for array/slice access and various key types for map access.
+// A key enclosed in single or double quotes is parsed as a string literal, so it may
+// contain spaces, dots, brackets, and escape sequences (e.g. ["my key"] or ['a.b[0]']).
 //
 // Parameters:
 //   - indexOrKey: The index or key string to resolve
@@ -144,6 +356,15 @@ func resolveIndexOrKey(indexOrKey string, value reflect.Value) reflect.Value {
 		return reflect.Value{}
 	}
 
+	quoted := len(indexOrKey) > 0 && (indexOrKey[0] == '\'' || indexOrKey[0] == '"')
+	indexOrKey = unquoteKey(indexOrKey)
+	if result, ok := resolveCustomIndexOrKey(indexOrKey, quoted, value); ok {
+		return result
+	}
+	if resolvable, ok := asPathResolvable(value); ok {
+		return resolvePathResolvable(indexOrKey, resolvable)
+	}
+
 	switch value.Kind() {
 	case reflect.Array, reflect.Slice:
 		index, err := strconv.Atoi(indexOrKey)
@@ -153,13 +374,22 @@ func resolveIndexOrKey(indexOrKey string, value reflect.Value) reflect.Value {
 		return value.Index(index)
 	case reflect.Map:
 		return getMapValue(indexOrKey, value)
+	case reflect.Struct:
+		if m, ok := asSyncMap(value); ok {
+			return getSyncMapValue(indexOrKey, m)
+		}
+		return reflect.Value{}
+	case reflect.Func:
+		return iteratorElement(indexOrKey, value)
 	default:
 		return reflect.Value{}
 	}
 }
 
 // resolveFieldOrMethod resolves a field or method name against a value.
-// It first tries to resolve the name as a method, then as a field.
+// It first tries to resolve the name as a method, then as a field. A name with a
+// result selector, such as "Lookup#1", selects a result of a method or function
+// value other than the first (see resolveResult).
 //
 // Parameters:
 //   - name: The field or method name to resolve
@@ -173,6 +403,17 @@ func resolveFieldOrMethod(name string, value reflect.Value) reflect.Value {
 		return reflect.Value{}
 	}
 
+	if resolvable, ok := asPathResolvable(value); ok {
+		return resolvePathResolvable(name, resolvable)
+	}
+
+	if base, result, ok := splitResultSelector(name); ok {
+		if resultValue := resolveResult(base, result, value); resultValue.IsValid() {
+			return resultValue
+		}
+		// Names like "a#1" may also be plain map keys.
+	}
+
 	// Try to resolve as a method first
 	methodValue := resolveMethod(name, value)
 	if methodValue.IsValid() {
@@ -183,6 +424,76 @@ func resolveFieldOrMethod(name string, value reflect.Value) reflect.Value {
 	return resolveField(name, value)
 }
 
+// Interface types that values are checked for before reflection is used.
+var (
+	pathResolvableType  = reflect.TypeOf((*PathResolvable)(nil)).Elem()
+	indexResolvableType = reflect.TypeOf((*IndexResolvable)(nil)).Elem()
+	keyResolvableType   = reflect.TypeOf((*KeyResolvable)(nil)).Elem()
+)
+
+// implementation returns value as an interface value of type iface if it or, for a
+// value reached through a pointer, its address implements iface.
+func implementation(value reflect.Value, iface reflect.Type) (any, bool) {
+	if value.Type().Implements(iface) && value.CanInterface() {
+		return value.Interface(), true
+	}
+	if value.CanAddr() && reflect.PointerTo(value.Type()).Implements(iface) && value.Addr().CanInterface() {
+		return value.Addr().Interface(), true
+	}
+	return nil, false
+}
+
+// asPathResolvable returns value as a PathResolvable if it implements the interface.
+func asPathResolvable(value reflect.Value) (PathResolvable, bool) {
+	resolvable, ok := implementation(value, pathResolvableType)
+	if !ok {
+		return nil, false
+	}
+	return resolvable.(PathResolvable), true
+}
+
+// resolvePathResolvable resolves a segment with a PathResolvable.
+func resolvePathResolvable(name string, resolvable PathResolvable) reflect.Value {
+	return segmentValue(resolvable.ResolvePathSegment(name))
+}
+
+// resolveCustomIndexOrKey resolves a bracket key with an IndexResolvable or
+// KeyResolvable. It returns false if value implements neither interface.
+//
+// Parameters:
+//   - key: The unquoted key
+//   - quoted: Whether the key was quoted in the path, so it is never an index
+//   - value: The reflect.Value to resolve the key against
+//
+// Returns:
+//   - The resolved reflect.Value, invalid if the element does not exist
+//   - Whether value implements IndexResolvable or KeyResolvable
+func resolveCustomIndexOrKey(key string, quoted bool, value reflect.Value) (reflect.Value, bool) {
+	indexer, isIndexer := implementation(value, indexResolvableType)
+	if isIndexer && !quoted {
+		if index, err := strconv.Atoi(key); err == nil {
+			return segmentValue(indexer.(IndexResolvable).ResolveIndex(index)), true
+		}
+	}
+	if keyer, ok := implementation(value, keyResolvableType); ok {
+		return segmentValue(keyer.(KeyResolvable).ResolveKey(key)), true
+	}
+	return reflect.Value{}, isIndexer
+}
+
+// segmentValue returns the result of a custom resolution interface as a
+// reflect.Value. A segment that exists but is nil is returned as a valid
+// reflect.Value holding a nil interface, so it is not mistaken for a missing segment.
+func segmentValue(result any, ok bool) reflect.Value {
+	if !ok {
+		return reflect.Value{}
+	}
+	if result == nil {
+		return reflect.ValueOf(&result).Elem()
+	}
+	return reflect.ValueOf(result)
+}
+
 // resolveMethod tries to resolve a method name against a value.
 // It only resolves methods that take no arguments and returns at least one value.
 //
@@ -194,29 +505,121 @@ func resolveFieldOrMethod(name string, value reflect.Value) reflect.Value {
 //   - The result of calling the method, or an invalid reflect.Value if the method doesn't exist
 //     or requires arguments
 func resolveMethod(name string, value reflect.Value) reflect.Value {
-	// Check if the value has a method with the given name
-	method := value.MethodByName(name)
-	if !method.IsValid() {
+	// Look up a method with the given name that takes no arguments and returns a value
+	index := methodIndex(value, name)
+	if index < 0 {
 		return reflect.Value{}
 	}
 
-	// Check if the method requires arguments
-	if method.Type().NumIn() > 0 {
-		return reflect.Value{}
+	// Call the method and return the first result
+	return value.Method(index).Call(nil)[0]
+}
+
+// splitResultSelector splits a segment name with a result selector, such as
+// "Lookup#1", into the name and the index of the selected result.
+//
+// Parameters:
+//   - name: The segment name
+//
+// Returns:
+//   - The name without the selector
+//   - The index of the selected result
+//   - Whether name has a result selector
+func splitResultSelector(name string) (string, int, bool) {
+	hash := strings.LastIndexByte(name, '#')
+	if hash <= 0 || hash == len(name)-1 {
+		return name, 0, false
+	}
+	for i := hash + 1; i < len(name); i++ {
+		if !isDigit(name[i]) {
+			return name, 0, false
+		}
+	}
+	result, err := strconv.Atoi(name[hash+1:])
+	if err != nil {
+		return name, 0, false
 	}
+	return name[:hash], result, true
+}
 
-	// Call the method
-	results := method.Call(nil)
-	if len(results) == 0 {
+// resolveResult calls the method or function value with the given name and returns
+// the result at the given index, so that the second result of a comma-ok method
+// such as "Lookup() (User, bool)" can be selected with "Lookup#1".
+//
+// Parameters:
+//   - name: The name of a method, or of a field or map key holding a function
+//   - result: The index of the result to return
+//   - value: The reflect.Value to resolve the name against
+//
+// Returns:
+//   - The selected result, or an invalid reflect.Value if there is no method or
+//     function without arguments that has that many results
+func resolveResult(name string, result int, value reflect.Value) reflect.Value {
+	fn := value.MethodByName(name)
+	if !fn.IsValid() {
+		fn = resolveField(name, value)
+		if fn.Kind() == reflect.Interface && !fn.IsNil() {
+			fn = fn.Elem()
+		}
+	}
+	if fn.Kind() != reflect.Func || fn.IsNil() || !fn.CanInterface() {
 		return reflect.Value{}
 	}
+	if typ := fn.Type(); typ.NumIn() != 0 || result >= typ.NumOut() {
+		return reflect.Value{}
+	}
+	return fn.Call(nil)[result]
+}
+
+// methodKey identifies a method name looked up on a type.
+type methodKey struct {
+	typ  reflect.Type
+	name string
+}
+
+// methodIndexes caches the results of methodIndex per type and name, since most
+// segments are fields and looking up a method that does not exist is as expensive
+// as looking up one that does.
+var methodIndexes sync.Map // methodKey -> int
+
+// methodIndex returns the index of the method of value with the given name (for
+// reflect.Value.Method) if it takes no arguments and returns at least one value, or
+// -1 if there is no such method. The result is cached per type.
+func methodIndex(value reflect.Value, name string) int {
+	key := methodKey{typ: value.Type(), name: name}
+	if index, ok := methodIndexes.Load(key); ok {
+		return index.(int)
+	}
+	index := -1
+	if method, ok := key.typ.MethodByName(name); ok {
+		if methodType := value.Method(method.Index).Type(); methodType.NumIn() == 0 && methodType.NumOut() > 0 {
+			index = method.Index
+		}
+	}
+	methodIndexes.Store(key, index)
+	return index
+}
 
-	// Return the first result
-	return results[0]
+// callThunk calls value if it is a function that takes no arguments and returns at
+// least one value, such as a lazily computed field or map value, and returns its
+// first result like that of a method (see resolveMethod). Any other value is
+// returned unchanged.
+func callThunk(value reflect.Value) reflect.Value {
+	fn := value
+	if fn.Kind() == reflect.Interface && !fn.IsNil() {
+		fn = fn.Elem()
+	}
+	if fn.Kind() != reflect.Func || fn.IsNil() || !fn.CanInterface() {
+		return value
+	}
+	if typ := fn.Type(); typ.NumIn() != 0 || typ.NumOut() == 0 {
+		return value
+	}
+	return fn.Call(nil)[0]
 }
 
 // resolveField tries to resolve a field name against a value.
-// It handles struct fields and map keys.
+// It handles struct fields and the keys of maps, sync.Maps, and iter.Seq2 iterators.
 //
 // Parameters:
 //   - name: The field name to resolve
@@ -227,6 +630,9 @@ func resolveMethod(name string, value reflect.Value) reflect.Value {
 func resolveField(name string, value reflect.Value) reflect.Value {
 	switch value.Kind() {
 	case reflect.Struct:
+		if m, ok := asSyncMap(value); ok {
+			return getSyncMapValue(name, m)
+		}
 		field := value.FieldByName(name)
 		if !field.IsValid() {
 			return reflect.Value{}
@@ -234,7 +640,27 @@ func resolveField(name string, value reflect.Value) reflect.Value {
 		return field
 	case reflect.Map:
 		return getMapValue(name, value)
+	case reflect.Func:
+		if iteratorArity(value.Type()) == 2 {
+			return iteratorElement(name, value)
+		}
+		return reflect.Value{}
 	default:
 		return reflect.Value{}
 	}
 }
+
+// unquoteKey returns the content of a quoted bracket key, processed with the same
+// rules as string literals. Keys that are not enclosed in matching quotes are
+// returned unchanged.
+func unquoteKey(key string) string {
+	if len(key) < 2 {
+		return key
+	}
+	quoteChar := key[0]
+	if (quoteChar != '\'' && quoteChar != '"') || skipQuotedASCII(key, 0) != len(key) {
+		return key
+	}
+	unquoted, _ := resolveStringLiteralASCII(key, 0, quoteChar)
+	return unquoted
+}
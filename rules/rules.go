@@ -0,0 +1,250 @@
+// Package rules evaluates named boolean rules, written as empaths expressions,
+// against data and reports which of them matched.
+//
+// A Rule is either a condition such as "?.Age >= 18 && .Country == 'DE'" or a
+// composition of other rules with All, Any, and Not:
+//
+//	adult := rules.MustNew("adult", "?.Age >= 18")
+//	german := rules.MustNew("german", "?.Country == 'DE'")
+//	set := rules.NewRuleSet([]*rules.Rule{
+//		adult,
+//		rules.All("german-adult", adult, german),
+//		rules.Not("foreign", german),
+//	})
+//
+//	result, err := set.Evaluate(customer, nil)
+//	result.Matched        // ["adult", "german-adult"]
+//	result.Has("foreign") // false
+package rules
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/authentic-devel/empaths"
+)
+
+// ruleKind is the kind of a Rule.
+type ruleKind int
+
+const (
+	// kindCondition is a rule with an expression.
+	kindCondition ruleKind = iota
+	// kindAll matches if all of its rules match.
+	kindAll
+	// kindAny matches if any of its rules matches.
+	kindAny
+	// kindNot matches if its single rule does not match.
+	kindNot
+)
+
+// Rule is a named condition. A Rule is immutable and safe for concurrent use; the
+// same Rule may be part of several compositions and RuleSets.
+type Rule struct {
+	name string
+	kind ruleKind
+	// expr is the expression of a condition.
+	expr string
+	// rules are the rules a composition is made of.
+	rules []*Rule
+}
+
+// New returns a rule that matches if expr, evaluated against the data, is true or
+// "true" (case-insensitive), like ResolveBool. Rules are usually comparisons, which
+// start with '?'.
+//
+// Parameters:
+//   - name: The name the rule is reported with
+//   - expr: The condition of the rule
+//
+// Returns:
+//   - The rule
+//   - A *empaths.SyntaxError if expr is malformed (see empaths.Tokens), or an error
+//     if it is empty
+func New(name string, expr string) (*Rule, error) {
+	if strings.TrimSpace(expr) == "" {
+		return nil, fmt.Errorf("rule %q: empty expression", name)
+	}
+	if _, err := empaths.Tokens(expr); err != nil {
+		return nil, fmt.Errorf("rule %q: %w", name, err)
+	}
+	return &Rule{name: name, kind: kindCondition, expr: expr}, nil
+}
+
+// MustNew is like New but panics if the expression is malformed. It simplifies the
+// initialization of global variables holding rules.
+func MustNew(name string, expr string) *Rule {
+	rule, err := New(name, expr)
+	if err != nil {
+		panic(fmt.Sprintf("rules: %v", err))
+	}
+	return rule
+}
+
+// All returns a rule that matches if all of rules match. The rules are evaluated in
+// order until one does not match. Without rules, it always matches.
+func All(name string, rules ...*Rule) *Rule {
+	return &Rule{name: name, kind: kindAll, rules: rules}
+}
+
+// Any returns a rule that matches if any of rules matches. The rules are evaluated
+// in order until one matches. Without rules, it never matches.
+func Any(name string, rules ...*Rule) *Rule {
+	return &Rule{name: name, kind: kindAny, rules: rules}
+}
+
+// Not returns a rule that matches if rule does not match.
+func Not(name string, rule *Rule) *Rule {
+	return &Rule{name: name, kind: kindNot, rules: []*Rule{rule}}
+}
+
+// Name returns the name of the rule.
+func (r *Rule) Name() string {
+	return r.name
+}
+
+// String returns the rule in a readable form: the expression of a condition, and
+// "all(...)", "any(...)", or "not(...)" with the names of the composed rules.
+func (r *Rule) String() string {
+	if r.kind == kindCondition {
+		return r.expr
+	}
+	names := make([]string, len(r.rules))
+	for i, rule := range r.rules {
+		names[i] = rule.name
+	}
+	return [...]string{kindAll: "all", kindAny: "any", kindNot: "not"}[r.kind] + "(" + strings.Join(names, ", ") + ")"
+}
+
+// Option configures a RuleSet.
+type Option func(*RuleSet)
+
+// WithResolver makes a RuleSet evaluate the expressions of its rules with resolver,
+// e.g. one created with empaths.WithStrict, so that rules reading fields that do not
+// exist fail instead of not matching. By default, the expressions are evaluated like
+// empaths.Resolve.
+func WithResolver(resolver *empaths.Resolver) Option {
+	return func(s *RuleSet) {
+		s.resolver = resolver
+	}
+}
+
+// RuleSet is a list of rules that are evaluated together. It is safe for concurrent
+// use.
+type RuleSet struct {
+	rules    []*Rule
+	resolver *empaths.Resolver
+}
+
+// NewRuleSet returns a RuleSet of rules.
+//
+// Parameters:
+//   - rules: The rules that are evaluated, in the order they are reported in
+//   - opts: Options of the RuleSet
+//
+// Returns:
+//   - The RuleSet
+func NewRuleSet(rules []*Rule, opts ...Option) *RuleSet {
+	s := &RuleSet{rules: rules, resolver: empaths.NewResolver()}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Result is the outcome of evaluating a RuleSet.
+type Result struct {
+	// Matched are the names of the rules of the RuleSet that matched, in the order of
+	// the RuleSet.
+	Matched []string
+	matched map[string]bool
+}
+
+// Has reports whether the rule of the RuleSet named name matched.
+func (r *Result) Has(name string) bool {
+	return r.matched[name]
+}
+
+// Evaluate evaluates every rule of the RuleSet against data. A rule that is part of
+// several compositions is evaluated once.
+//
+// Parameters:
+//   - data: The data model the rules are evaluated against
+//   - refResolver: Optional function to resolve external references (prefixed with ':')
+//
+// Returns:
+//   - The rules that matched
+//   - The error of the first rule whose evaluation was aborted (see
+//     empaths.Resolver.ResolveErr), naming the rule
+func (s *RuleSet) Evaluate(data any, refResolver empaths.ReferenceResolver) (*Result, error) {
+	e := evaluation{set: s, data: data, refResolver: refResolver, results: make(map[*Rule]bool)}
+	result := &Result{matched: make(map[string]bool)}
+	for _, rule := range s.rules {
+		matched, err := e.match(rule)
+		if err != nil {
+			return nil, err
+		}
+		if matched {
+			result.Matched = append(result.Matched, rule.name)
+			result.matched[rule.name] = true
+		}
+	}
+	return result, nil
+}
+
+// evaluation holds the state of a single call of Evaluate.
+type evaluation struct {
+	set         *RuleSet
+	data        any
+	refResolver empaths.ReferenceResolver
+	// results holds the rules evaluated so far.
+	results map[*Rule]bool
+}
+
+// match evaluates rule, or returns its earlier result.
+func (e *evaluation) match(rule *Rule) (bool, error) {
+	if matched, ok := e.results[rule]; ok {
+		return matched, nil
+	}
+	var matched bool
+	switch rule.kind {
+	case kindCondition:
+		value, err := e.set.resolver.ResolveErr(rule.expr, e.data, e.refResolver)
+		if err != nil {
+			return false, fmt.Errorf("rule %q: %w", rule.name, err)
+		}
+		matched = isTrue(value)
+	case kindAll, kindAny:
+		matched = rule.kind == kindAll
+		for _, child := range rule.rules {
+			childMatched, err := e.match(child)
+			if err != nil {
+				return false, err
+			}
+			if childMatched != matched {
+				matched = childMatched
+				break
+			}
+		}
+	case kindNot:
+		childMatched, err := e.match(rule.rules[0])
+		if err != nil {
+			return false, err
+		}
+		matched = !childMatched
+	}
+	e.results[rule] = matched
+	return matched, nil
+}
+
+// isTrue reports whether the value of a condition is true or "true".
+func isTrue(v any) bool {
+	switch v := v.(type) {
+	case bool:
+		return v
+	case string:
+		return strings.EqualFold(v, "true")
+	default:
+		return false
+	}
+}
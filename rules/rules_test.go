@@ -0,0 +1,125 @@
+package rules
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/authentic-devel/empaths"
+)
+
+type customer struct {
+	Name    string
+	Age     int
+	Country string
+	Tags    []string
+}
+
+func TestRuleSet_Evaluate(t *testing.T) {
+	adult := MustNew("adult", "?.Age >= 18")
+	german := MustNew("german", "?.Country == 'DE'")
+	vip := MustNew("vip", "?.Tags contains 'vip'")
+	set := NewRuleSet([]*Rule{
+		adult,
+		german,
+		All("german-adult", adult, german),
+		Any("adult-or-vip", adult, vip),
+		Not("foreign", german),
+		Not("not-vip-minor", All("vip-minor", vip, Not("minor", adult))),
+		All("always"),
+		Any("never"),
+	})
+
+	tests := []struct {
+		name     string
+		data     customer
+		expected []string
+	}{
+		{"german adult", customer{Age: 30, Country: "DE"}, []string{"adult", "german", "german-adult", "adult-or-vip", "not-vip-minor", "always"}},
+		{"foreign minor", customer{Age: 12, Country: "FR"}, []string{"foreign", "not-vip-minor", "always"}},
+		{"vip minor", customer{Age: 12, Country: "DE", Tags: []string{"vip"}}, []string{"german", "adult-or-vip", "always"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := set.Evaluate(tt.data, nil)
+			if err != nil {
+				t.Fatalf("Evaluate() error = %v", err)
+			}
+			if !reflect.DeepEqual(result.Matched, tt.expected) {
+				t.Errorf("Evaluate() matched %v, want %v", result.Matched, tt.expected)
+			}
+			for _, name := range tt.expected {
+				if !result.Has(name) {
+					t.Errorf("Has(%q) = false, want true", name)
+				}
+			}
+			if result.Has("never") {
+				t.Errorf("Has(%q) = true, want false", "never")
+			}
+		})
+	}
+
+	t.Run("references", func(t *testing.T) {
+		set := NewRuleSet([]*Rule{MustNew("of age", "?.Age >= :minAge")})
+		refs := func(name string, data any) any { return 21 }
+		result, err := set.Evaluate(customer{Age: 20}, refs)
+		if err != nil || len(result.Matched) != 0 {
+			t.Errorf("Evaluate() = %v, %v, want no matches", result, err)
+		}
+	})
+
+	t.Run("strict resolver", func(t *testing.T) {
+		set := NewRuleSet([]*Rule{adult, MustNew("typo", "?.Contry == 'DE'")}, WithResolver(empaths.NewResolver(empaths.WithStrict())))
+		_, err := set.Evaluate(customer{Age: 30}, nil)
+		var notFound *empaths.FieldNotFoundError
+		if !errors.As(err, &notFound) {
+			t.Errorf("Evaluate() error = %v, want *FieldNotFoundError", err)
+		}
+	})
+
+	t.Run("shared rule evaluated once", func(t *testing.T) {
+		calls := 0
+		counted := MustNew("counted", "?:count")
+		set := NewRuleSet([]*Rule{counted, All("a", counted), Not("b", counted)})
+		refs := func(name string, data any) any {
+			calls++
+			return true
+		}
+		if _, err := set.Evaluate(customer{}, refs); err != nil || calls != 1 {
+			t.Errorf("Evaluate() resolved the reference %d times (error %v), want once", calls, err)
+		}
+	})
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		wantErr bool
+	}{
+		{"comparison", "?.Age >= 18", false},
+		{"field", ".Active", false},
+		{"empty", " ", true},
+		{"malformed", "?.Tags[0 == 'x'", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := New(tt.name, tt.expr)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("New(%q) error = %v, wantErr %v", tt.expr, err, tt.wantErr)
+			}
+			if err == nil && rule.String() != tt.expr {
+				t.Errorf("String() = %q, want %q", rule.String(), tt.expr)
+			}
+		})
+	}
+
+	t.Run("composition", func(t *testing.T) {
+		a, b := MustNew("a", ".A"), MustNew("b", ".B")
+		if s := All("x", a, Not("nb", b)).String(); s != "all(a, nb)" {
+			t.Errorf("String() = %q, want %q", s, "all(a, nb)")
+		}
+	})
+}
@@ -0,0 +1,135 @@
+package empaths
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+)
+
+// Redacted replaces secret values in the diagnostics of a Resolver (see
+// WithSecrets).
+const Redacted = "[REDACTED]"
+
+// WithSecrets marks the values at the given model paths, such as ".Credentials" or
+// ".Users.Password", and everything inside them as secret. Like for
+// WithAllowedPrefixes, indices and wildcards are not part of the paths, so
+// ".Users.Password" covers ".Users[0].Password" and ".Users[?.Admin].Password".
+// Struct fields tagged `empaths:"secret"` are secret for every Resolver:
+//
+//	type Account struct {
+//		Name   string
+//		APIKey string `empaths:"secret"`
+//	}
+//
+// Evaluations that read a secret value still return it, but the diagnostics of the
+// Resolver leave it out, so they can be logged: the Value of a PartialResult is
+// Redacted, errors and traces (see WithStrict and ResolveTrace) do not suggest the
+// names of map keys, and Metrics and AuditRecord report Secret, so observers and
+// auditors can tell which evaluations must not be logged with their results.
+//
+// Paths are matched against the model paths that are read, as recorded by
+// WithAudit; model paths resolved against variables or function results are only
+// secret through tags. Malformed paths mark nothing.
+func WithSecrets(paths ...string) Option {
+	return func(r *Resolver) {
+		r.secrets = make([][]string, 0, len(paths))
+		for _, path := range paths {
+			if names, ok := pathNames(path); ok {
+				r.secrets = append(r.secrets, names)
+			}
+		}
+	}
+}
+
+// secretTag is the value of the struct tag "empaths" that marks a field as secret.
+const secretTag = "secret"
+
+// secretFields caches the names of the fields of a struct type that are tagged as
+// secret.
+var secretFields sync.Map // reflect.Type -> map[string]bool
+
+// checkSecretField records that the evaluation read a secret value if name is a
+// field of value, a struct or a pointer to one, that is tagged as secret.
+func (s *evalState) checkSecretField(name string, value reflect.Value) {
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return
+	}
+	if isSecretField(value.Type(), name) {
+		s.secret = true
+	}
+}
+
+// isSecretField reports whether the field name of the struct type typ is tagged as
+// secret.
+func isSecretField(typ reflect.Type, name string) bool {
+	cached, ok := secretFields.Load(typ)
+	if !ok {
+		var names map[string]bool
+		for _, field := range reflect.VisibleFields(typ) {
+			if field.Tag.Get("empaths") == secretTag {
+				if names == nil {
+					names = make(map[string]bool)
+				}
+				names[field.Name] = true
+			}
+		}
+		cached, _ = secretFields.LoadOrStore(typ, names)
+	}
+	return cached.(map[string]bool)[name]
+}
+
+// readSecret reports whether the evaluation read a secret value (see WithSecrets).
+// state is nil if the path was not evaluated.
+func (s *evalState) readSecret() bool {
+	if s == nil {
+		return false
+	}
+	if s.secret {
+		return true
+	}
+	if s.resolver == nil || len(s.resolver.secrets) == 0 || s.audit == nil {
+		return false
+	}
+	for _, field := range s.audit.fields {
+		names, ok := pathNames(field)
+		if !ok {
+			continue
+		}
+		for _, secret := range s.resolver.secrets {
+			if hasNamePrefix(names, secret) {
+				s.secret = true
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// redactErrors removes the names of map keys, which may be data, from the errors of
+// an evaluation that read a secret value.
+func (s *evalState) redactErrors() {
+	if !s.readSecret() {
+		return
+	}
+	for _, err := range append(s.trace, s.err) {
+		var notFound *FieldNotFoundError
+		if errors.As(err, &notFound) && notFound.Type != nil && indirectType(notFound.Type).Kind() == reflect.Map {
+			notFound.Suggestions = nil
+		}
+	}
+}
+
+// redact returns Redacted instead of v if the evaluation that resolved v read a
+// secret value.
+func redact(v any, state *evalState) any {
+	if state.readSecret() {
+		return Redacted
+	}
+	return v
+}
@@ -0,0 +1,104 @@
+package empaths
+
+import (
+	"errors"
+	"testing"
+)
+
+type secretAccount struct {
+	Name   string
+	APIKey string `empaths:"secret"`
+	Tokens map[string]string
+	Vault  *secretVault `empaths:"secret"`
+}
+
+type secretVault struct {
+	Keys map[string]string
+}
+
+func TestResolver_WithSecrets(t *testing.T) {
+	data := map[string]any{
+		"Account": &secretAccount{
+			Name:   "ci",
+			APIKey: "key-123",
+			Tokens: map[string]string{"github": "ghp_123"},
+			Vault:  &secretVault{Keys: map[string]string{"prod": "p-456"}},
+		},
+		"Users": []map[string]any{{"Name": "Alice", "Password": "hunter2"}},
+	}
+	var metrics []Metrics
+	var records []AuditRecord
+	resolver := NewResolver(
+		WithSecrets(".Users.Password", ".Account.Tokens"),
+		WithMetrics(func(m Metrics) { metrics = append(metrics, m) }),
+		WithAudit(func(record AuditRecord) { records = append(records, record) }),
+	)
+
+	tests := []struct {
+		name     string
+		path     string
+		expected any
+		secret   bool
+	}{
+		{"public field", ".Account.Name", "ci", false},
+		{"tagged field", ".Account.APIKey", "key-123", true},
+		{"inside tagged field", ".Account.Vault.Keys['prod']", "p-456", true},
+		{"secret path", ".Account.Tokens.github", "ghp_123", true},
+		{"secret path with index", ".Users[0].Password", "hunter2", true},
+		{"secret path in filter", "count(.Users[?.Password == 'hunter2'])", 1, true},
+		{"sibling of secret path", ".Users[0].Name", "Alice", false},
+		{"concatenation", ".Account.Name ':' .Account.APIKey", "ci:key-123", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metrics, records = nil, nil
+			if result := resolver.Resolve(tt.path, data, nil); result != tt.expected {
+				t.Errorf("Resolve(%q) = %v, want %v", tt.path, result, tt.expected)
+			}
+			if len(metrics) != 1 || metrics[0].Secret != tt.secret {
+				t.Errorf("Resolve(%q) reported metrics %+v, want Secret %v", tt.path, metrics, tt.secret)
+			}
+			if len(records) != 1 || records[0].Secret != tt.secret {
+				t.Errorf("Resolve(%q) audited %+v, want Secret %v", tt.path, records, tt.secret)
+			}
+
+			want := tt.expected
+			if tt.secret {
+				want = Redacted
+			}
+			if p := resolver.ResolvePartial(tt.path, data, nil); p.Value != want {
+				t.Errorf("ResolvePartial(%q).Value = %v, want %v", tt.path, p.Value, want)
+			}
+		})
+	}
+
+	t.Run("tags without option", func(t *testing.T) {
+		if p := ResolvePartial(".Account.Vault.Keys.prdo", data, nil); p.Value != Redacted || p.Resolved != ".Account.Vault.Keys" {
+			t.Errorf("ResolvePartial() = %+v, want Value %v", p, Redacted)
+		}
+		if p := ResolvePartial(".Account.Tokens", data, nil); p.Value == Redacted {
+			t.Errorf("ResolvePartial() = %+v, want the tokens", p)
+		}
+	})
+
+	t.Run("no suggestions from secret map keys", func(t *testing.T) {
+		strict := NewResolver(WithStrict(), WithSecrets(".Account.Tokens"))
+		for _, path := range []string{".Account.Tokens.githb", ".Account.Vault.Keys.prdo"} {
+			_, err := strict.ResolveErr(path, data, nil)
+			var notFound *FieldNotFoundError
+			if !errors.As(err, &notFound) || len(notFound.Suggestions) != 0 {
+				t.Errorf("ResolveErr(%q) error = %v, want *FieldNotFoundError without suggestions", path, err)
+			}
+			_, trace := strict.ResolveTrace(path, data, nil)
+			if len(trace) != 1 || !errors.As(trace[0], &notFound) || len(notFound.Suggestions) != 0 {
+				t.Errorf("ResolveTrace(%q) = %v, want *FieldNotFoundError without suggestions", path, trace)
+			}
+		}
+		_, err := strict.ResolveErr(".Account.Nmae", data, nil)
+		var notFound *FieldNotFoundError
+		if !errors.As(err, &notFound) || len(notFound.Suggestions) == 0 {
+			t.Errorf("ResolveErr() error = %v, want suggestions for a public field", err)
+		}
+	})
+}
@@ -0,0 +1,79 @@
+package empaths
+
+import (
+	"fmt"
+)
+
+// pathSegment is one step of a plain model path such as ".User.Tags[0]".
+type pathSegment struct {
+	// name is the field name, map key, or index. Quoted bracket keys are unquoted.
+	name string
+	// bracket is true if the segment was written in bracket notation.
+	bracket bool
+}
+
+// String returns the segment in path notation.
+func (s pathSegment) String() string {
+	if s.bracket {
+		return "[" + s.name + "]"
+	}
+	return "." + s.name
+}
+
+// parseSegments splits a plain model path into its segments. A plain model path
+// starts with '.' and consists only of field names, map keys, and indices; wildcards,
+// filters, and any other expression syntax are rejected. It is used by the APIs that
+// modify data, where every segment must address exactly one location.
+//
+// Parameters:
+//   - path: The model path (e.g. ".User.Tags[0]" or ".Data['a.b']")
+//
+// Returns:
+//   - The segments of the path (empty for the root path ".")
+//   - A *SyntaxError if the path is not a plain model path
+func parseSegments(path string) ([]pathSegment, error) {
+	if len(path) == 0 || path[0] != '.' {
+		return nil, &SyntaxError{Pos: 0, Msg: "path must start with '.'"}
+	}
+
+	var segments []pathSegment
+	index := 1
+	for index < len(path) {
+		c := path[index]
+		switch c {
+		case '[':
+			closeIndex := findClosingASCII(path, index)
+			if closeIndex == -1 {
+				return nil, &SyntaxError{Pos: index, Msg: "missing ']'"}
+			}
+			raw := path[index+1 : closeIndex]
+			if raw == "*" || (len(raw) > 0 && raw[0] == '?') {
+				return nil, &SyntaxError{Pos: index, Msg: "wildcards and filters are not supported here"}
+			}
+			if raw == "" {
+				return nil, &SyntaxError{Pos: index, Msg: "empty brackets"}
+			}
+			segments = append(segments, pathSegment{name: unquoteKey(raw), bracket: true})
+			index = closeIndex + 1
+		case '.':
+			if index == 1 || path[index-1] == '.' {
+				return nil, &SyntaxError{Pos: index - 1, Msg: "empty path segment"}
+			}
+			index++
+			if index == len(path) {
+				return nil, &SyntaxError{Pos: index - 1, Msg: "empty path segment"}
+			}
+		default:
+			start := index
+			for index < len(path) && path[index] != '.' && path[index] != '[' {
+				switch path[index] {
+				case ' ', '!', '=', '<', '>', ',', ')', ']', '\'', '"':
+					return nil, &SyntaxError{Pos: index, Msg: fmt.Sprintf("unexpected %q", path[index])}
+				}
+				index++
+			}
+			segments = append(segments, pathSegment{name: path[start:index]})
+		}
+	}
+	return segments, nil
+}
@@ -0,0 +1,213 @@
+package empaths
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+)
+
+// Row is a row of a database query that paths can be resolved against, with its
+// columns as fields: ".email" resolves to the email column. Columns holding JSON are
+// decoded the first time they are accessed, so ".metadata.plan" resolves to a key of
+// the JSON document in the metadata column:
+//
+//	rows, err := db.QueryContext(ctx, "SELECT name, email, metadata FROM users")
+//	...
+//	defer rows.Close()
+//	for rows.Next() {
+//		row, err := empaths.ScanRow(rows)
+//		...
+//		body := empaths.Interpolate("Hi {{ .name }}, your plan is {{ .metadata.plan }}.", row, nil)
+//	}
+//
+// Columns are matched by name exactly or, failing that, case-insensitively. The
+// values are those the driver returns when scanning into an any: typically int64,
+// float64, bool, []byte, string, time.Time, or nil. A Row is safe for concurrent use.
+type Row struct {
+	columns []string
+	values  []any
+	// json reports which columns hold JSON that is decoded on first access.
+	json []bool
+	// decodeOnce guards the decoding of each JSON column.
+	decodeOnce []sync.Once
+}
+
+// NewRow returns a Row with the given columns and values, for rows scanned by other
+// means, such as a *sql.Row. The values of the columns named in jsonColumns are
+// decoded as JSON on first access.
+//
+// Parameters:
+//   - columns: The column names
+//   - values: The values, in the order of the columns
+//   - jsonColumns: The names of the columns that hold JSON
+//
+// Returns:
+//   - The row
+func NewRow(columns []string, values []any, jsonColumns ...string) *Row {
+	row := &Row{
+		columns:    columns,
+		values:     values,
+		json:       make([]bool, len(columns)),
+		decodeOnce: make([]sync.Once, len(columns)),
+	}
+	for _, name := range jsonColumns {
+		if i := row.index(name); i != -1 {
+			row.json[i] = true
+		}
+	}
+	return row
+}
+
+// ScanRow scans the current row of rows, after a call to rows.Next, into a Row.
+// Columns whose database type is JSON or JSONB (as reported by the driver) and the
+// columns named in jsonColumns are decoded as JSON on first access.
+//
+// Parameters:
+//   - rows: The query result, positioned at a row
+//   - jsonColumns: The names of further columns that hold JSON
+//
+// Returns:
+//   - The row
+//   - Error if the columns cannot be read or the row cannot be scanned
+func ScanRow(rows *sql.Rows, jsonColumns ...string) (*Row, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	values := make([]any, len(columns))
+	targets := make([]any, len(columns))
+	for i := range values {
+		targets[i] = &values[i]
+	}
+	if err := rows.Scan(targets...); err != nil {
+		return nil, err
+	}
+	row := NewRow(columns, values, jsonColumns...)
+	if types, err := rows.ColumnTypes(); err == nil {
+		for i, columnType := range types {
+			switch strings.ToUpper(columnType.DatabaseTypeName()) {
+			case "JSON", "JSONB":
+				row.json[i] = true
+			}
+		}
+	}
+	return row, nil
+}
+
+// ScanRows scans all remaining rows of rows into Rows (see ScanRow). It does not
+// close rows.
+//
+// Parameters:
+//   - rows: The query result
+//   - jsonColumns: The names of further columns that hold JSON
+//
+// Returns:
+//   - The rows
+//   - Error if a row cannot be scanned or the iteration fails
+func ScanRows(rows *sql.Rows, jsonColumns ...string) ([]*Row, error) {
+	var result []*Row
+	for rows.Next() {
+		row, err := ScanRow(rows, jsonColumns...)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ScanQueryRow scans the row of a *sql.Row, which reports neither its columns nor
+// their types, into a Row with the given columns. Mark JSON columns with WithJSON.
+//
+// Parameters:
+//   - row: The result of QueryRow
+//   - columns: The names of the selected columns, in order
+//
+// Returns:
+//   - The row
+//   - Error if the row cannot be scanned, such as sql.ErrNoRows
+func ScanQueryRow(row *sql.Row, columns ...string) (*Row, error) {
+	if len(columns) == 0 {
+		return nil, errors.New("no columns to scan")
+	}
+	values := make([]any, len(columns))
+	targets := make([]any, len(columns))
+	for i := range values {
+		targets[i] = &values[i]
+	}
+	if err := row.Scan(targets...); err != nil {
+		return nil, err
+	}
+	return NewRow(columns, values), nil
+}
+
+// WithJSON marks the named columns as holding JSON, which is decoded on first
+// access, and returns the row. It must be called before paths are resolved against
+// the row.
+func (r *Row) WithJSON(columns ...string) *Row {
+	for _, name := range columns {
+		if i := r.index(name); i != -1 {
+			r.json[i] = true
+		}
+	}
+	return r
+}
+
+// Columns returns the column names of the row.
+func (r Row) Columns() []string {
+	return r.columns
+}
+
+// ResolvePathSegment resolves a column name (see Row). It has a value receiver
+// because filters resolve their conditions against copies of the elements; copies
+// share the columns, values, and decoded JSON of the row.
+func (r Row) ResolvePathSegment(name string) (any, bool) {
+	i := r.index(name)
+	if i == -1 {
+		return nil, false
+	}
+	if r.json[i] {
+		r.decodeOnce[i].Do(func() { r.values[i] = decodeJSONColumn(r.values[i]) })
+	}
+	return r.values[i], true
+}
+
+// index returns the position of the column named name, matched exactly or
+// case-insensitively, or -1.
+func (r Row) index(name string) int {
+	for i, column := range r.columns {
+		if column == name {
+			return i
+		}
+	}
+	for i, column := range r.columns {
+		if strings.EqualFold(column, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// decodeJSONColumn decodes the value of a JSON column. Values that are not valid
+// JSON are returned unchanged.
+func decodeJSONColumn(value any) any {
+	var raw []byte
+	switch v := value.(type) {
+	case []byte:
+		raw = v
+	case string:
+		raw = []byte(v)
+	default:
+		return value
+	}
+	var decoded any
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		return value
+	}
+	return decoded
+}
@@ -0,0 +1,140 @@
+package empaths
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// fakeDriver is a database/sql driver that returns a fixed table for every query.
+type fakeDriver struct{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) { return fakeConn{}, nil }
+
+type fakeConn struct{}
+
+func (fakeConn) Prepare(string) (driver.Stmt, error) { return fakeStmt{}, nil }
+func (fakeConn) Close() error                        { return nil }
+func (fakeConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+
+type fakeStmt struct{}
+
+func (fakeStmt) Close() error                               { return nil }
+func (fakeStmt) NumInput() int                              { return -1 }
+func (fakeStmt) Exec([]driver.Value) (driver.Result, error) { return nil, errors.New("not supported") }
+func (fakeStmt) Query([]driver.Value) (driver.Rows, error) {
+	return &fakeRows{
+		columns: []string{"id", "Email", "metadata", "settings"},
+		types:   []string{"INTEGER", "TEXT", "JSONB", "TEXT"},
+		rows: [][]driver.Value{
+			{int64(1), "alice@example.com", []byte(`{"plan":"pro","seats":5}`), `{"theme":"dark"}`},
+			{int64(2), "bob@example.com", []byte(`not json`), nil},
+		},
+	}, nil
+}
+
+type fakeRows struct {
+	columns []string
+	types   []string
+	rows    [][]driver.Value
+	next    int
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.next == len(r.rows) {
+		return io.EOF
+	}
+	copy(dest, r.rows[r.next])
+	r.next++
+	return nil
+}
+func (r *fakeRows) ColumnTypeDatabaseTypeName(index int) string { return r.types[index] }
+
+var registerFakeDriver sync.Once
+
+// openFakeDB opens a database backed by fakeDriver.
+func openFakeDB(t *testing.T) *sql.DB {
+	registerFakeDriver.Do(func() { sql.Register("empaths-fake", fakeDriver{}) })
+	db, err := sql.Open("empaths-fake", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestScanRows(t *testing.T) {
+	db := openFakeDB(t)
+	rows, err := db.Query("SELECT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	scanned, err := ScanRows(rows, "settings")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scanned) != 2 {
+		t.Fatalf("ScanRows() returned %d rows, want 2", len(scanned))
+	}
+
+	tests := []struct {
+		name     string
+		row      int
+		path     string
+		expected any
+	}{
+		{"column", 0, ".id", int64(1)},
+		{"case-insensitive column", 0, ".email", "alice@example.com"},
+		{"exact column", 0, ".Email", "alice@example.com"},
+		{"json type", 0, ".metadata.plan", "pro"},
+		{"json number", 0, ".metadata.seats", float64(5)},
+		{"named json column", 0, ".settings.theme", "dark"},
+		{"missing column", 0, ".name", nil},
+		{"missing json key", 0, ".metadata.missing", nil},
+		{"invalid json is kept", 1, ".metadata", []byte("not json")},
+		{"null json column", 1, ".settings", nil},
+		{"comparison", 0, "?.metadata.seats > 3", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Resolve(tt.path, scanned[tt.row], nil)
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("Resolve(%q) = %#v, want %#v", tt.path, result, tt.expected)
+			}
+		})
+	}
+
+	t.Run("filter over rows", func(t *testing.T) {
+		result := Resolve("join(.[?.metadata.plan == 'pro'].email, ',')", scanned, nil)
+		if result != "alice@example.com" {
+			t.Errorf("Resolve() = %v, want alice@example.com", result)
+		}
+	})
+}
+
+func TestScanQueryRow(t *testing.T) {
+	db := openFakeDB(t)
+	row, err := ScanQueryRow(db.QueryRow("SELECT"), "id", "email", "metadata", "settings")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := Resolve(".metadata.plan", row, nil); got != nil {
+		t.Errorf("Resolve() before WithJSON = %v, want nil", got)
+	}
+	row = NewRow(row.Columns(), []any{int64(1), "a", `{"plan":"pro"}`, nil}).WithJSON("METADATA")
+	if got := Resolve(".metadata.plan", row, nil); got != "pro" {
+		t.Errorf("Resolve() after WithJSON = %v, want pro", got)
+	}
+
+	if _, err := ScanQueryRow(db.QueryRow("SELECT")); err == nil {
+		t.Errorf("ScanQueryRow() without columns should fail")
+	}
+}
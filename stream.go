@@ -0,0 +1,92 @@
+package empaths
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// ResolveTo evaluates a path expression and writes its result to w. See
+// Resolver.ResolveTo.
+//
+// Parameters:
+//   - w: The writer the result is written to
+//   - path: The path expression to evaluate
+//   - data: The data model to evaluate the path against
+//   - refResolver: Optional function to resolve external references (prefixed with ':')
+//
+// Returns:
+//   - The first error returned by w
+func ResolveTo(w io.Writer, path string, data any, refResolver ReferenceResolver) error {
+	return defaultResolver.ResolveTo(w, path, data, refResolver)
+}
+
+// ResolveTo evaluates a path expression and writes its result, converted to a string
+// the way operands of a concatenation are, to w. The operands of a concatenation are
+// written one by one as they are evaluated, so a large rendered body, e.g. written to
+// an http.ResponseWriter or a bufio.Writer, is never built in memory as a whole:
+//
+//	err := resolver.ResolveTo(w, "'<h1>' htmlescape(.Title) '</h1>' .Body", page, nil)
+//
+// If the evaluation is aborted (see ResolveErr), the operands written before remain
+// written and the error is returned. The output limit (see WithMaxOutputBytes)
+// applies to the bytes written for a concatenation. The interceptors of the Resolver
+// (see WithInterceptor) receive the result as a whole; with interceptors, the result
+// is therefore built before it is written.
+//
+// Parameters:
+//   - w: The writer the result is written to
+//   - path: The path expression to evaluate
+//   - data: The data model to evaluate the path against
+//   - refResolver: Optional function to resolve external references (prefixed with ':')
+//
+// Returns:
+//   - The first error returned by w, or an error as returned by ResolveErr
+func (r *Resolver) ResolveTo(w io.Writer, path string, data any, refResolver ReferenceResolver) error {
+	if path == "" || len(r.interceptors) > 0 {
+		result, err := r.ResolveErr(path, data, refResolver)
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(w, (&evalState{resolver: r}).format(result, ""))
+		return err
+	}
+	_, _, err := r.run(context.Background(), path, data, refResolver, w)
+	return err
+}
+
+// output is the writer that ResolveTo writes the result of an evaluation to.
+type output struct {
+	w io.Writer
+	// n is the number of bytes written for a concatenation so far.
+	n int
+	// full is set when the output was truncated at the output limit; nothing is
+	// written after it.
+	full bool
+}
+
+// write writes the string form of v (see format) to out. concatenated is true for
+// the operands of a concatenation, which are subject to the output limit of the
+// Resolver (see WithMaxOutputBytes). An error returned by the writer aborts the
+// evaluation.
+func (s *evalState) write(out *output, v any, concatenated bool) {
+	if out.full || s.err != nil {
+		return
+	}
+	str := s.format(v, "")
+	if concatenated && s.resolver != nil && s.resolver.maxOutputBytes > 0 {
+		limit := s.resolver.maxOutputBytes
+		if out.n+len(str) > limit {
+			if !s.resolver.truncateOutput {
+				s.err = fmt.Errorf("%w: concatenation longer than %d bytes", ErrLimitExceeded, limit)
+				return
+			}
+			str = truncateUTF8(str, limit-out.n)
+			out.full = true
+		}
+		out.n += len(str)
+	}
+	if _, err := io.WriteString(out.w, str); err != nil {
+		s.err = err
+	}
+}
@@ -0,0 +1,98 @@
+package empaths
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestResolveTo(t *testing.T) {
+	team := createTestTeam()
+	refs := func(name string, data any) any { return "Hello" }
+
+	tests := []struct {
+		name     string
+		path     string
+		expected string
+	}{
+		{"concatenation", ":greeting ', ' .Users[0].Name '!'", "Hello, Alice!"},
+		{"single value", ".Users[0].Age", "30"},
+		{"pipe on last operand", "'Name: ' .Users[1].Name | substr(0, 2)", "Name: Bo"},
+		{"function", "'Users: ' join(.Users[*].Name, ', ')", "Users: Alice, Bob, Carol"},
+		{"let binding", "let $n = .Users[2].Name; 'Hi ' $n", "Hi Carol"},
+		{"json value", ".Scores", `{"math":95,"science":88}`},
+		{"missing field", ".Missing", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sb strings.Builder
+			if err := ResolveTo(&sb, tt.path, team, refs); err != nil {
+				t.Fatalf("ResolveTo(%q) error = %v", tt.path, err)
+			}
+			if sb.String() != tt.expected {
+				t.Errorf("ResolveTo(%q) wrote %q, want %q", tt.path, sb.String(), tt.expected)
+			}
+			if resolved := (&evalState{resolver: defaultResolver}).format(Resolve(tt.path, team, refs), ""); resolved != sb.String() {
+				t.Errorf("ResolveTo(%q) wrote %q, Resolve returned %q", tt.path, sb.String(), resolved)
+			}
+		})
+	}
+
+	t.Run("output limit", func(t *testing.T) {
+		var sb strings.Builder
+		err := NewResolver(WithMaxOutputBytes(8)).ResolveTo(&sb, "'Hello, ' .Users[0].Name", team, nil)
+		if !errors.Is(err, ErrLimitExceeded) {
+			t.Errorf("ResolveTo() error = %v, want ErrLimitExceeded", err)
+		}
+		if sb.String() != "Hello, " {
+			t.Errorf("ResolveTo() wrote %q, want %q", sb.String(), "Hello, ")
+		}
+	})
+
+	t.Run("truncated output", func(t *testing.T) {
+		var sb strings.Builder
+		resolver := NewResolver(WithMaxOutputBytes(8), WithTruncatedOutput())
+		if err := resolver.ResolveTo(&sb, "'Hello, ' .Users[0].Name '!'", team, nil); err != nil {
+			t.Fatalf("ResolveTo() error = %v", err)
+		}
+		if sb.String() != "Hello, A" {
+			t.Errorf("ResolveTo() wrote %q, want %q", sb.String(), "Hello, A")
+		}
+	})
+
+	t.Run("write error", func(t *testing.T) {
+		writeErr := errors.New("connection closed")
+		w := &failingWriter{err: writeErr}
+		if err := ResolveTo(w, "'a' 'b' 'c'", team, nil); !errors.Is(err, writeErr) {
+			t.Errorf("ResolveTo() error = %v, want %v", err, writeErr)
+		}
+		if w.writes != 1 {
+			t.Errorf("ResolveTo() wrote %d times, want 1", w.writes)
+		}
+	})
+
+	t.Run("interceptor", func(t *testing.T) {
+		var sb strings.Builder
+		resolver := NewResolver(WithInterceptor(func(path string, result any) any {
+			return strings.ToUpper(result.(string))
+		}))
+		if err := resolver.ResolveTo(&sb, "'Hello, ' .Users[0].Name", team, nil); err != nil {
+			t.Fatalf("ResolveTo() error = %v", err)
+		}
+		if sb.String() != "HELLO, ALICE" {
+			t.Errorf("ResolveTo() wrote %q, want %q", sb.String(), "HELLO, ALICE")
+		}
+	})
+}
+
+// failingWriter is an io.Writer whose writes fail.
+type failingWriter struct {
+	err    error
+	writes int
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	w.writes++
+	return 0, w.err
+}
@@ -0,0 +1,76 @@
+package empaths
+
+import (
+	"sort"
+	"strings"
+)
+
+// maxSuggestions is the maximum number of names suggested for a misspelled name.
+const maxSuggestions = 3
+
+// suggestNames returns the candidates that are close to name, closest first: names
+// that differ only in case or by at most two edits (insertions, deletions,
+// substitutions, or swaps of adjacent characters), and by at most one edit per three
+// characters of name (rounded), so short names do not match everything.
+//
+// Parameters:
+//   - name: The name that was not found
+//   - candidates: The names that exist
+//
+// Returns:
+//   - Up to maxSuggestions candidates, or nil if none is close
+func suggestNames(name string, candidates []string) []string {
+	type match struct {
+		name     string
+		distance int
+	}
+	lower := strings.ToLower(name)
+	limit := min(2, (len([]rune(name))+1)/3)
+	var matches []match
+	for _, candidate := range candidates {
+		if candidate == name {
+			continue
+		}
+		if d := editDistance(lower, strings.ToLower(candidate)); d <= limit {
+			matches = append(matches, match{candidate, d})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].distance < matches[j].distance
+	})
+
+	var names []string
+	for i := 0; i < len(matches) && i < maxSuggestions; i++ {
+		names = append(names, matches[i].name)
+	}
+	return names
+}
+
+// editDistance returns the optimal string alignment distance between a and b: the
+// number of insertions, deletions, substitutions, and swaps of adjacent characters
+// needed to turn a into b, where no substring is edited more than once.
+func editDistance(a, b string) int {
+	s, t := []rune(a), []rune(b)
+	// Three rows of the distance matrix: two rows back, the previous, and the current.
+	prev2 := make([]int, len(t)+1)
+	prev := make([]int, len(t)+1)
+	curr := make([]int, len(t)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(s); i++ {
+		curr[0] = i
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && s[i-1] == t[j-2] && s[i-2] == t[j-1] {
+				curr[j] = min(curr[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, curr = prev, curr, prev2
+	}
+	return prev[len(t)]
+}
@@ -0,0 +1,56 @@
+package empaths
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSuggestNames(t *testing.T) {
+	candidates := []string{"Name", "Names", "Age", "Address", "Active", "ID", "Tags"}
+
+	tests := []struct {
+		name     string
+		expected []string
+	}{
+		{"Nmae", []string{"Name"}},
+		{"Nam", []string{"Name"}},
+		{"name", []string{"Name", "Names"}},
+		{"Adress", []string{"Address"}},
+		{"Addresss", []string{"Address"}},
+		{"Tag", []string{"Tags"}},
+		{"Id", []string{"ID"}},
+		{"Xy", nil},
+		{"Salary", nil},
+		{"Name", []string{"Names"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := suggestNames(tt.name, candidates); !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("suggestNames(%q) = %v, want %v", tt.name, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"Name", "Name", 0},
+		{"Nmae", "Name", 1},
+		{"Cityy", "City", 1},
+		{"kitten", "sitting", 3},
+		{"ca", "abc", 3},
+		{"Größe", "Grösse", 2},
+	}
+
+	for _, tt := range tests {
+		if result := editDistance(tt.a, tt.b); result != tt.expected {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tt.a, tt.b, result, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package empaths
+
+// funcSwitch implements switch(value, case:result, ..., default:result). It
+// compares value with the literal of every case in order, like '==', and returns
+// the result of the first case that matches, or of the default case if none does,
+// so "switch(.Status, 'open':'green', 'closed':'red', default:'grey')" maps an
+// enumeration to labels. Only the result that is returned is evaluated. Without a
+// default case, switch returns nil if no case matches. Arguments that are not
+// cases are ignored.
+func funcSwitch(rawArgs []string, args []any, data any, state *evalState) any {
+	if len(args) == 0 {
+		if len(rawArgs) == 0 {
+			return nil
+		}
+		value, _ := resolveExpressions(rawArgs[0], data, state, 0)
+		args = append(args, value)
+		rawArgs = rawArgs[1:]
+	}
+	fallback, hasDefault := "", false
+	for _, rawCase := range rawArgs {
+		c, ok := readSwitchCase(rawCase, 0)
+		if !ok {
+			continue
+		}
+		result := rawCase[c.colon+1:]
+		if c.isDefault {
+			if !hasDefault {
+				fallback, hasDefault = result, true
+			}
+			continue
+		}
+		if valuesEqual(args[0], c.key) {
+			value, _ := resolveExpressions(result, data, state, 0)
+			return value
+		}
+	}
+	if !hasDefault {
+		return nil
+	}
+	value, _ := resolveExpressions(fallback, data, state, 0)
+	return value
+}
+
+// switchCase is the head of a case of switch, such as "'open':" or "default:".
+type switchCase struct {
+	// key is the value of the literal of the case.
+	key any
+	// isDefault is true for the default case.
+	isDefault bool
+	// keyStart and keyEnd delimit the literal or the keyword default.
+	keyStart, keyEnd int
+	// colon is the index of the ':' that separates the case from its result.
+	colon int
+}
+
+// readSwitchCase reads the head of a case of switch, starting at index: a string,
+// number, or keyword literal or the keyword default, followed by a ':', with
+// optional spaces around the literal.
+//
+// Parameters:
+//   - path: The path expression as a string
+//   - index: The index at which the argument of switch starts
+//
+// Returns:
+//   - The head of the case
+//   - false if no case starts at index
+func readSwitchCase(path string, index int) (switchCase, bool) {
+	for index < len(path) && path[index] == ' ' {
+		index++
+	}
+	if index == len(path) {
+		return switchCase{}, false
+	}
+	c := switchCase{keyStart: index}
+	switch {
+	case path[index] == '\'' || path[index] == '"':
+		end := quotedStringEnd(path, index)
+		if end == -1 {
+			return switchCase{}, false
+		}
+		c.key, _ = resolveStringLiteralASCII(path, index, path[index])
+		c.keyEnd = end
+	case isNumberStart(path, index):
+		c.key, c.keyEnd = resolveNumberLiteralASCII(path, index)
+	case isIdentStart(path, index):
+		name, end := readIdentifier(path, index)
+		literal, ok := keywordLiterals[name]
+		if !ok && name != "default" {
+			return switchCase{}, false
+		}
+		c.key, c.isDefault, c.keyEnd = literal, !ok, end
+	default:
+		return switchCase{}, false
+	}
+	index = c.keyEnd
+	for index < len(path) && path[index] == ' ' {
+		index++
+	}
+	if index == len(path) || path[index] != ':' {
+		return switchCase{}, false
+	}
+	c.colon = index
+	return c, true
+}
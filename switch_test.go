@@ -0,0 +1,67 @@
+package empaths
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFunc_Switch(t *testing.T) {
+	data := map[string]any{
+		"Status":   "closed",
+		"Priority": 2,
+		"Active":   true,
+		"Since":    "May",
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		expected any
+	}{
+		{"first case", "switch(.Status, 'closed':'🔴', 'open':'🟢', default:'❓')", "🔴"},
+		{"later case", "switch(.Status, 'open':'🟢', 'closed':'🔴', default:'❓')", "🔴"},
+		{"default", "switch(.Status, 'open':'🟢', default:'❓')", "❓"},
+		{"default before cases", "switch(.Status, default:'❓', 'closed':'🔴')", "🔴"},
+		{"no match without default", "switch(.Status, 'open':'🟢')", nil},
+		{"number", "switch(.Priority, 1:'low', 2.0:'medium', 3:'high')", "medium"},
+		{"keyword", "switch(.Active, true:'yes', false:'no')", "yes"},
+		{"nil", "switch(.Missing, nil:'unset', default:'set')", "unset"},
+		{"double quotes", `switch(.Status, "closed":"done")`, "done"},
+		{"spaces around colon", "switch(.Status, 'closed' : 'done')", "done"},
+		{"result expression", "switch(.Status, 'closed':'closed in ' .Since)", "closed in May"},
+		{"result value", "switch(.Status, 'closed':.Priority)", 2},
+		{"piped", ".Status | switch('open':'🟢', 'closed':'🔴')", "🔴"},
+		{"in concatenation", "'Status: ' switch(.Status, 'closed':'done') '.'", "Status: done."},
+		{"nested", "switch(.Status, 'closed':switch(.Priority, 2:'urgent'))", "urgent"},
+		{"comma in literal", "switch(.Status, 'a,b':'x', 'closed':'y,z')", "y,z"},
+		{"malformed case ignored", "switch(.Status, .Status:'x', 'closed':'y')", "y"},
+		{"no cases", "switch(.Status)", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := Resolve(tt.path, data, nil); !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("Resolve(%q) = %#v, want %#v", tt.path, result, tt.expected)
+			}
+		})
+	}
+
+	t.Run("only the selected result is evaluated", func(t *testing.T) {
+		var resolved []string
+		refs := func(name string, data any) any {
+			resolved = append(resolved, name)
+			return name
+		}
+		result := Resolve("switch(.Status, 'open': :green, 'closed': :red, default: :grey)", data, refs)
+		if result != "red" || !reflect.DeepEqual(resolved, []string{"red"}) {
+			t.Errorf("Resolve() = %v resolving %v, want red resolving [red]", result, resolved)
+		}
+	})
+
+	t.Run("references", func(t *testing.T) {
+		names, err := References("switch(.Status, 'open':'🟢', default: :label )")
+		if err != nil || !reflect.DeepEqual(names, []string{"label"}) {
+			t.Errorf("References() = %v, %v, want [label]", names, err)
+		}
+	})
+}
@@ -0,0 +1,64 @@
+package empaths
+
+import (
+	"time"
+)
+
+// WithTimeLayouts makes a Resolver recognize strings in the given layouts (see
+// time.Parse) as times in comparisons, in addition to RFC 3339. When both operands
+// of a comparison are times, they are compared chronologically:
+//
+//	resolver := empaths.NewResolver(empaths.WithTimeLayouts("2006-01-02", time.RFC1123))
+//	resolver.Resolve("?.ExpiresAt < '2025-01-01'", data, nil)
+func WithTimeLayouts(layouts ...string) Option {
+	return func(r *Resolver) {
+		r.timeLayouts = append([]string(nil), layouts...)
+	}
+}
+
+// timeLayouts returns the layouts configured with WithTimeLayouts.
+func (s *evalState) timeLayouts() []string {
+	if s.resolver == nil {
+		return nil
+	}
+	return s.resolver.timeLayouts
+}
+
+// asTimes converts both operands of a comparison to time.Time if both are times:
+// time.Time values (or pointers to them) or strings in RFC 3339 format or one of
+// the layouts. Otherwise the operands are returned unchanged.
+func asTimes(left any, right any, layouts []string) (any, any) {
+	leftTime, ok := toTime(left, layouts)
+	if !ok {
+		return left, right
+	}
+	rightTime, ok := toTime(right, layouts)
+	if !ok {
+		return left, right
+	}
+	return leftTime, rightTime
+}
+
+// toTime converts a time.Time, a pointer to one, or a string in RFC 3339 format or
+// one of the layouts to a time.Time.
+func toTime(v any, layouts []string) (time.Time, bool) {
+	switch v := v.(type) {
+	case time.Time:
+		return v, true
+	case *time.Time:
+		if v == nil {
+			return time.Time{}, false
+		}
+		return *v, true
+	case string:
+		if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+			return t, true
+		}
+		for _, layout := range layouts {
+			if t, err := time.Parse(layout, v); err == nil {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,85 @@
+package empaths
+
+import (
+	"testing"
+	"time"
+)
+
+func TestResolve_ComparisonTimes(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	expires := now.Add(time.Hour)
+	data := map[string]any{
+		"ExpiresAt": expires,
+		"Pointer":   &expires,
+		"Created":   "2024-06-01T14:00:00+02:00",
+		"Updated":   "2024-06-01T12:30:00.5Z",
+		"Day":       "2024-05-31",
+		"Name":      "Alice",
+		"Orders": []map[string]any{
+			{"ID": 1, "Due": "2024-06-01T11:00:00Z"},
+			{"ID": 2, "Due": "2024-06-02T11:00:00Z"},
+			{"ID": 3, "Due": "2024-06-01T13:00:00+02:00"},
+		},
+	}
+	refs := func(name string, data any) any {
+		if name == "now" {
+			return now
+		}
+		return nil
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		expected any
+	}{
+		{"time against reference", "?.ExpiresAt > :now", true},
+		{"time against reference false", "?.ExpiresAt < :now", false},
+		{"pointer to time", "?.Pointer > :now", true},
+		{"string against time", "?.Updated > :now", true},
+		{"time against string", "?:now < .Updated", true},
+		{"equal across time zones", "?.Created == :now", true},
+		{"equal strings across time zones", "?.Created == '2024-06-01T12:00:00Z'", true},
+		{"not equal across time zones", "?.Created != '2024-06-01T12:00:00Z'", false},
+		{"ordering across time zones", "?.Created < '2024-06-01T13:00:00Z'", true},
+		{"lexical order would differ", "?'2024-06-01T13:00:00+02:00' < '2024-06-01T12:00:00Z'", true},
+		{"filter", "count(.Orders[?.Due <= :now])", 2},
+		{"layout not configured", "?.Day == '2024-05-31T00:00:00Z'", false},
+		{"not a time", "?.Name < :now", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Resolve(tt.path, data, refs)
+			if result != tt.expected {
+				t.Errorf("Resolve(%q) = %v, want %v", tt.path, result, tt.expected)
+			}
+		})
+	}
+
+	t.Run("configured layouts", func(t *testing.T) {
+		r := NewResolver(WithTimeLayouts("2006-01-02", time.RFC1123))
+		layoutTests := []struct {
+			path     string
+			expected any
+		}{
+			{"?.Day < :now", true},
+			{"?.Day == '2024-05-31T00:00:00Z'", true},
+			{"?'Sat, 01 Jun 2024 12:00:00 UTC' == :now", true},
+			{"?.Day > '2024-06-01'", false},
+		}
+		for _, tt := range layoutTests {
+			if result := r.Resolve(tt.path, data, refs); result != tt.expected {
+				t.Errorf("Resolve(%q) = %v, want %v", tt.path, result, tt.expected)
+			}
+		}
+	})
+
+	t.Run("sort by time", func(t *testing.T) {
+		times := map[string]any{"Times": []time.Time{expires, now, now.Add(-time.Hour)}}
+		sorted, ok := Resolve("sort(.Times)", times, nil).([]time.Time)
+		if !ok || len(sorted) != 3 || !sorted[0].Before(sorted[1]) || !sorted[1].Before(sorted[2]) {
+			t.Errorf("sort(.Times) = %v, want chronological order", sorted)
+		}
+	})
+}
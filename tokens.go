@@ -0,0 +1,621 @@
+package empaths
+
+import (
+	"fmt"
+)
+
+// TokenKind identifies the kind of a Token.
+type TokenKind int
+
+const (
+	// TokenField is a model path segment in dot notation, including its dot (".User").
+	// The root path "." is a TokenField with the text ".".
+	TokenField TokenKind = iota
+	// TokenIndex is a model path segment in bracket notation ("[0]" or "['a.b']").
+	TokenIndex
+	// TokenWildcard is the wildcard segment "[*]".
+	TokenWildcard
+	// TokenFilterStart is the "[?" that opens a filter. It is followed by the tokens
+	// of the filter expression and a TokenFilterEnd.
+	TokenFilterStart
+	// TokenFilterEnd is the "]" that closes a filter.
+	TokenFilterEnd
+	// TokenString is a quoted string literal.
+	TokenString
+	// TokenNumber is a number literal.
+	TokenNumber
+	// TokenKeyword is one of the literals true, false, and nil.
+	TokenKeyword
+	// TokenWord is a bare word that is neither a keyword, an operator, nor a function
+	// name. Bare words do not contribute to the result, except after a comparison
+	// operator, where an unquoted token that is not a keyword, number, or function
+	// call is a string literal ("active" in "?.Status==active", "1.20.0" in
+	// "?.Version >=v 1.20.0").
+	TokenWord
+	// TokenReference is an external reference including its colon (":config"). For a
+	// Resolver, an expression with a custom prefix (see WithPrefix) is a reference too.
+	TokenReference
+	// TokenComparison is the '?' that starts a comparison.
+	TokenComparison
+	// TokenNegation is the '!' that negates an operand, a comparison, or a group of
+	// conditions.
+	TokenNegation
+	// TokenOperator is a comparison operator ("==", "!=", "==i", "!=i", "<", "<=", ">",
+	// ">=", "<v", "<=v", ">v", ">=v", "^=", "$=", "~g", "contains", or "in").
+	TokenOperator
+	// TokenFunction is the name of a called function. The ')' of its call (or the
+	// name itself after a pipe) may be followed by the tokens of a model path that is
+	// resolved against the result (as in "first(.Users).Name").
+	TokenFunction
+	// TokenLeftParen is the '(' of a function call or of a group of conditions.
+	TokenLeftParen
+	// TokenRightParen is the ')' of a function call or of a group of conditions.
+	TokenRightParen
+	// TokenComma separates function arguments and list elements.
+	TokenComma
+	// TokenListStart is the '[' that opens a list literal.
+	TokenListStart
+	// TokenListEnd is the ']' that closes a list literal.
+	TokenListEnd
+	// TokenRoot is the '$' that refers to the root data. It may be followed by the
+	// tokens of a model path (as in "$.Budget").
+	TokenRoot
+	// TokenParent is the '^' that refers to the data of the enclosing filter. It may
+	// be followed by the tokens of a model path (as in "^.MinAge").
+	TokenParent
+	// TokenVariable is a variable including its dollar sign ("$wanted"). It may be
+	// followed by the tokens of a model path (as in "$user.Name" or "$users[0]").
+	TokenVariable
+	// TokenPipe is the '|' that passes the preceding operand to a function or
+	// reference. It is followed by a TokenFunction, which may be followed by its
+	// parenthesized arguments, or a TokenReference.
+	TokenPipe
+	// TokenLogical is one of the logical operators "&&" and "||" that combine the
+	// conditions of a comparison.
+	TokenLogical
+	// TokenLet is the keyword "let" that starts a binding ("let $u = .Order.Customer;").
+	// It is followed by a TokenVariable, a TokenAssign, the tokens of the bound
+	// expression, and a TokenSemicolon.
+	TokenLet
+	// TokenAssign is the '=' of a binding.
+	TokenAssign
+	// TokenSemicolon is the ';' that ends a binding.
+	TokenSemicolon
+	// TokenCase is the ':' that separates a case of switch from its result, as in
+	// "'open':'green'". It follows the literal of the case, or the TokenWord "default".
+	TokenCase
+)
+
+// tokenKindNames holds the names returned by TokenKind.String.
+var tokenKindNames = [...]string{
+	TokenField:       "field",
+	TokenIndex:       "index",
+	TokenWildcard:    "wildcard",
+	TokenFilterStart: "filter start",
+	TokenFilterEnd:   "filter end",
+	TokenString:      "string",
+	TokenNumber:      "number",
+	TokenKeyword:     "keyword",
+	TokenWord:        "word",
+	TokenReference:   "reference",
+	TokenComparison:  "comparison",
+	TokenNegation:    "negation",
+	TokenOperator:    "operator",
+	TokenFunction:    "function",
+	TokenLeftParen:   "left paren",
+	TokenRightParen:  "right paren",
+	TokenComma:       "comma",
+	TokenListStart:   "list start",
+	TokenListEnd:     "list end",
+	TokenRoot:        "root",
+	TokenParent:      "parent",
+	TokenVariable:    "variable",
+	TokenPipe:        "pipe",
+	TokenLogical:     "logical operator",
+	TokenLet:         "let",
+	TokenAssign:      "assign",
+	TokenSemicolon:   "semicolon",
+	TokenCase:        "case",
+}
+
+// String returns the name of the token kind.
+func (k TokenKind) String() string {
+	if k >= 0 && int(k) < len(tokenKindNames) {
+		return tokenKindNames[k]
+	}
+	return fmt.Sprintf("TokenKind(%d)", int(k))
+}
+
+// Token is a lexical element of a path expression.
+type Token struct {
+	// Kind is the kind of the token.
+	Kind TokenKind
+	// Text is the source text of the token.
+	Text string
+	// Pos is the byte offset of the token in the path.
+	Pos int
+	// Value is the value denoted by a TokenString, TokenNumber, or TokenKeyword
+	// (e.g. the unescaped string); it is nil for all other tokens.
+	Value any
+}
+
+// SyntaxError describes a malformed path expression.
+type SyntaxError struct {
+	// Pos is the byte offset in the path at which the error was detected.
+	Pos int
+	// Msg describes the error.
+	Msg string
+}
+
+// Error implements the error interface.
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("syntax error at offset %d: %s", e.Pos, e.Msg)
+}
+
+// Tokens splits a path expression into tokens, following the same grammar as
+// Resolve. Whitespace is not returned. It is intended for tools such as syntax
+// highlighters that need to understand paths without evaluating them.
+//
+// Resolve is lenient and ignores malformed parts of a path; Tokens reports them
+// instead, e.g. an unterminated string or an unclosed bracket.
+//
+// Parameters:
+//   - path: The path expression
+//
+// Returns:
+//   - The tokens of the path, up to the first error
+//   - A *SyntaxError if the path is malformed
+func Tokens(path string) ([]Token, error) {
+	t := &tokenizer{path: path}
+	err := t.tokenize(0, len(path))
+	return t.tokens, err
+}
+
+// tokenizer holds the state of Tokens.
+type tokenizer struct {
+	path   string
+	tokens []Token
+	// prefixes holds the custom prefixes of a Resolver (see WithPrefix); it may be nil.
+	prefixes map[byte]PrefixHandler
+	// lenient records syntax errors in warnings and continues after them (see
+	// ResolveDiag).
+	lenient  bool
+	warnings []Warning
+}
+
+// emit appends a token for path[start:end].
+func (t *tokenizer) emit(kind TokenKind, start int, end int, value any) {
+	t.tokens = append(t.tokens, Token{Kind: kind, Text: t.path[start:end], Pos: start, Value: value})
+}
+
+// tokenize tokenizes the expression path[start:end].
+func (t *tokenizer) tokenize(start int, end int) error {
+	// closers holds the expected closing characters of open parentheses and lists.
+	var closers []byte
+	// bindings holds the number of open closers at each binding whose ';' is missing.
+	var bindings []int
+	// switches holds the number of open closers at each open switch call.
+	var switches []int
+	path := t.path[:end]
+	index := start
+	for index < end {
+		c := path[index]
+		switch {
+		case c == ' ':
+			index++
+		case c == '.':
+			newIndex, err := t.modelPath(index, end)
+			if err != nil {
+				return err
+			}
+			index = newIndex
+		case c == '\'' || c == '"':
+			newIndex := quotedStringEnd(path, index)
+			if newIndex == -1 {
+				// The interpreter closes the literal at the end of the path.
+				if err := t.fail(index, "unterminated string literal"); err != nil {
+					return err
+				}
+				newIndex = end
+			}
+			value, _ := resolveStringLiteralASCII(path, index, c)
+			t.emit(TokenString, index, newIndex, value)
+			index = newIndex
+		case c == '!' && index+1 < end && path[index+1] == '=', c == '=' && index+1 < end && path[index+1] == '=':
+			operatorEnd := index + 2
+			if isOperatorSuffix(path, operatorEnd, 'i') {
+				operatorEnd++
+			}
+			t.emit(TokenOperator, index, operatorEnd, nil)
+			index = operatorEnd
+		case c == '<' || c == '>':
+			operatorEnd := index + 1
+			if operatorEnd < end && path[operatorEnd] == '=' {
+				operatorEnd++
+			}
+			if isOperatorSuffix(path, operatorEnd, 'v') {
+				operatorEnd++
+			}
+			t.emit(TokenOperator, index, operatorEnd, nil)
+			index = operatorEnd
+		case isAffixOperator(path, index), c == '~' && index+1 < end && path[index+1] == 'g':
+			t.emit(TokenOperator, index, index+2, nil)
+			index += 2
+		case c == '!':
+			t.emit(TokenNegation, index, index+1, nil)
+			index++
+		case c == '$' && index+1 < end && isIdentStart(path, index+1):
+			_, newIndex := readIdentifier(path, index+1)
+			t.emit(TokenVariable, index, newIndex, nil)
+			newIndex, err := t.trailingPath(newIndex, end)
+			if err != nil {
+				return err
+			}
+			index = newIndex
+		case c == '$':
+			t.emit(TokenRoot, index, index+1, nil)
+			index++
+		case c == '^':
+			t.emit(TokenParent, index, index+1, nil)
+			index++
+		case c == '?':
+			t.emit(TokenComparison, index, index+1, nil)
+			index++
+		case c == ':':
+			_, newIndex := readReferenceName(path, index+1)
+			if newIndex == index+1 {
+				if err := t.fail(index, "missing reference name"); err != nil {
+					return err
+				}
+				index++
+				continue
+			}
+			t.emit(TokenReference, index, newIndex, nil)
+			if newIndex < end && path[newIndex] == '(' {
+				t.emit(TokenLeftParen, newIndex, newIndex+1, nil)
+				closers = append(closers, ')')
+				newIndex++
+			}
+			index = newIndex
+		case c == '[':
+			t.emit(TokenListStart, index, index+1, nil)
+			closers = append(closers, ']')
+			index++
+		case c == ']' || c == ')':
+			if len(closers) == 0 || closers[len(closers)-1] != c {
+				if err := t.fail(index, fmt.Sprintf("unexpected %q", c)); err != nil {
+					return err
+				}
+				index++
+				continue
+			}
+			if len(bindings) > 0 && bindings[len(bindings)-1] == len(closers) {
+				if err := t.fail(index, "missing ';' after binding"); err != nil {
+					return err
+				}
+				bindings = bindings[:len(bindings)-1]
+			}
+			if len(switches) > 0 && switches[len(switches)-1] == len(closers) {
+				switches = switches[:len(switches)-1]
+			}
+			closers = closers[:len(closers)-1]
+			if c == ']' {
+				t.emit(TokenListEnd, index, index+1, nil)
+				index++
+				continue
+			}
+			t.emit(TokenRightParen, index, index+1, nil)
+			newIndex, err := t.trailingPath(index+1, end)
+			if err != nil {
+				return err
+			}
+			index = newIndex
+		case c == '&' && index+1 < end && path[index+1] == '&', c == '|' && index+1 < end && path[index+1] == '|':
+			t.emit(TokenLogical, index, index+2, nil)
+			index += 2
+		case c == '(' && t.startsGroup():
+			t.emit(TokenLeftParen, index, index+1, nil)
+			closers = append(closers, ')')
+			index++
+		case c == '|':
+			t.emit(TokenPipe, index, index+1, nil)
+			index++
+			for index < end && path[index] == ' ' {
+				index++
+			}
+			if index < end && path[index] == ':' {
+				// The reference is tokenized like any other reference.
+				continue
+			}
+			if index == end || !isIdentStart(path, index) {
+				if err := t.fail(index, "missing function name after '|'"); err != nil {
+					return err
+				}
+				continue
+			}
+			_, newIndex := readIdentifier(path, index)
+			if newIndex < end && path[newIndex] == '(' {
+				// The call is tokenized like any other function call.
+				continue
+			}
+			t.emit(TokenFunction, index, newIndex, nil)
+			newIndex, err := t.trailingPath(newIndex, end)
+			if err != nil {
+				return err
+			}
+			index = newIndex
+		case c == ';':
+			if len(bindings) == 0 || bindings[len(bindings)-1] != len(closers) {
+				if err := t.fail(index, "unexpected ';'"); err != nil {
+					return err
+				}
+				index++
+				continue
+			}
+			bindings = bindings[:len(bindings)-1]
+			t.emit(TokenSemicolon, index, index+1, nil)
+			index++
+		case c == ',':
+			if len(closers) == 0 {
+				if err := t.fail(index, "unexpected ','"); err != nil {
+					return err
+				}
+				index++
+				continue
+			}
+			t.emit(TokenComma, index, index+1, nil)
+			index++
+			if len(switches) > 0 && switches[len(switches)-1] == len(closers) {
+				newIndex, err := t.switchCase(index, end)
+				if err != nil {
+					return err
+				}
+				index = newIndex
+			}
+		case t.afterOperator() && bareLiteralEnd(path, index) > index:
+			newIndex := bareLiteralEnd(path, index)
+			t.emit(TokenWord, index, newIndex, nil)
+			index = newIndex
+		case c == 'l' && t.startsExpression() && isLetBinding(path, index):
+			name, nameStart, valueStart, _ := readLetBinding(path, index)
+			t.emit(TokenLet, index, index+3, nil)
+			t.emit(TokenVariable, nameStart, nameStart+1+len(name), nil)
+			t.emit(TokenAssign, valueStart-1, valueStart, nil)
+			bindings = append(bindings, len(closers))
+			index = valueStart
+		case isIdentStart(path, index):
+			name, newIndex := readIdentifier(path, index)
+			switch {
+			case newIndex < end && path[newIndex] == '(':
+				piped := len(t.tokens) > 0 && t.tokens[len(t.tokens)-1].Kind == TokenPipe
+				t.emit(TokenFunction, index, newIndex, nil)
+				t.emit(TokenLeftParen, newIndex, newIndex+1, nil)
+				closers = append(closers, ')')
+				newIndex++
+				if name == "switch" {
+					switches = append(switches, len(closers))
+					if piped {
+						// The piped operand is the value, so the first argument is a case.
+						var err error
+						if newIndex, err = t.switchCase(newIndex, end); err != nil {
+							return err
+						}
+					}
+				}
+			case isKeyword(name):
+				t.emit(TokenKeyword, index, newIndex, keywordLiterals[name])
+			case isWordOperator(name):
+				t.emit(TokenOperator, index, newIndex, nil)
+			default:
+				t.emit(TokenWord, index, newIndex, nil)
+				if t.lenient {
+					t.warnings = append(t.warnings, Warning{Pos: index, Msg: fmt.Sprintf("word %q is ignored", name)})
+				}
+			}
+			index = newIndex
+		case isNumberStart(path, index):
+			value, newIndex := resolveNumberLiteralASCII(path, index)
+			t.emit(TokenNumber, index, newIndex, value)
+			index = newIndex
+		case t.prefixes[c] != nil:
+			_, newIndex := ReadPrefixName(path, index)
+			t.emit(TokenReference, index, newIndex, nil)
+			index = newIndex
+		default:
+			if err := t.fail(index, fmt.Sprintf("unexpected %q", c)); err != nil {
+				return err
+			}
+			index++
+		}
+	}
+	if len(closers) > 0 {
+		return t.fail(end, fmt.Sprintf("missing %q", closers[len(closers)-1]))
+	}
+	if len(bindings) > 0 {
+		return t.fail(end, "missing ';' after binding")
+	}
+	return nil
+}
+
+// switchCase tokenizes the head of the case of switch ("'open':" or "default:") in
+// the argument starting at index and returns the index after its ':'.
+func (t *tokenizer) switchCase(index int, end int) (int, error) {
+	path := t.path[:end]
+	c, ok := readSwitchCase(path, index)
+	if !ok {
+		for index < end && path[index] == ' ' {
+			index++
+		}
+		if index < end && path[index] == ')' {
+			// No case, as in "switch()" after a pipe.
+			return index, nil
+		}
+		return index, t.fail(index, "missing switch case")
+	}
+	switch {
+	case c.isDefault:
+		t.emit(TokenWord, c.keyStart, c.keyEnd, nil)
+	case path[c.keyStart] == '\'' || path[c.keyStart] == '"':
+		t.emit(TokenString, c.keyStart, c.keyEnd, c.key)
+	case isNumberStart(path, c.keyStart):
+		t.emit(TokenNumber, c.keyStart, c.keyEnd, c.key)
+	default:
+		t.emit(TokenKeyword, c.keyStart, c.keyEnd, c.key)
+	}
+	t.emit(TokenCase, c.colon, c.colon+1, nil)
+	return c.colon + 1, nil
+}
+
+// fail reports a syntax error at pos. When the tokenizer is lenient, the error is
+// recorded as a warning instead and fail returns nil, so that the caller can skip
+// the malformed part the way the interpreter does.
+func (t *tokenizer) fail(pos int, msg string) error {
+	if !t.lenient {
+		return &SyntaxError{Pos: pos, Msg: msg}
+	}
+	t.warnings = append(t.warnings, Warning{Pos: pos, Msg: msg})
+	return nil
+}
+
+// startsExpression reports whether the next token starts an expression that may
+// begin with a binding: the whole path, an argument, a list element, or the rest of
+// the expression after a binding.
+func (t *tokenizer) startsExpression() bool {
+	n := len(t.tokens)
+	if n == 0 {
+		return true
+	}
+	switch t.tokens[n-1].Kind {
+	case TokenListStart, TokenComma, TokenSemicolon:
+		return true
+	case TokenLeftParen:
+		// The '(' of a call, not of a group of conditions.
+		return n > 1 && (t.tokens[n-2].Kind == TokenFunction || t.tokens[n-2].Kind == TokenReference)
+	default:
+		return false
+	}
+}
+
+// afterOperator reports whether the last token is a comparison operator.
+func (t *tokenizer) afterOperator() bool {
+	return len(t.tokens) > 0 && t.tokens[len(t.tokens)-1].Kind == TokenOperator
+}
+
+// startsGroup reports whether a '(' that does not follow a function name opens a
+// group of conditions, that is, follows '?', '!', a logical operator, or another '('.
+func (t *tokenizer) startsGroup() bool {
+	if len(t.tokens) == 0 {
+		return false
+	}
+	switch t.tokens[len(t.tokens)-1].Kind {
+	case TokenComparison, TokenNegation, TokenLogical, TokenLeftParen:
+		return true
+	default:
+		return false
+	}
+}
+
+// modelPath tokenizes the model path starting at the '.' at index and returns the
+// index after it.
+func (t *tokenizer) modelPath(index int, end int) (int, error) {
+	path := t.path[:end]
+	_, pathEnd := readModelPathASCII(path, index+1)
+	if index+1 == pathEnd || path[index+1] == '[' {
+		// The root path, possibly followed by bracket segments (".[0]").
+		t.emit(TokenField, index, index+1, nil)
+		index++
+	}
+	return t.segments(index, pathEnd)
+}
+
+// trailingPath tokenizes a model path in bracket notation directly following a
+// function call or variable ("['open']") and returns the index after it. A path in dot notation
+// is tokenized as any other model path.
+func (t *tokenizer) trailingPath(index int, end int) (int, error) {
+	if index >= end || t.path[index] != '[' {
+		return index, nil
+	}
+	_, pathEnd := readModelPathASCII(t.path[:end], index)
+	return t.segments(index, pathEnd)
+}
+
+// segments tokenizes the segments of a model path in path[index:pathEnd] and returns
+// pathEnd.
+func (t *tokenizer) segments(index int, pathEnd int) (int, error) {
+	path := t.path[:pathEnd]
+	for index < pathEnd {
+		switch path[index] {
+		case '[':
+			closeIndex := findClosingASCII(path, index)
+			if closeIndex == -1 || closeIndex >= pathEnd {
+				// The interpreter ignores the rest of the model path.
+				return pathEnd, t.fail(index, "missing ']'")
+			}
+			selector := path[index+1 : closeIndex]
+			switch {
+			case selector == "":
+				if err := t.fail(index, "empty brackets"); err != nil {
+					return 0, err
+				}
+			case selector == "*":
+				t.emit(TokenWildcard, index, closeIndex+1, nil)
+			case selector[0] == '?':
+				t.emit(TokenFilterStart, index, index+2, nil)
+				if err := t.tokenize(index+2, closeIndex); err != nil {
+					return 0, err
+				}
+				t.emit(TokenFilterEnd, closeIndex, closeIndex+1, nil)
+			default:
+				t.emit(TokenIndex, index, closeIndex+1, nil)
+			}
+			index = closeIndex + 1
+		case '.':
+			segmentEnd := index + 1
+			for segmentEnd < pathEnd && path[segmentEnd] != '.' && path[segmentEnd] != '[' {
+				segmentEnd++
+			}
+			if segmentEnd == index+1 {
+				if err := t.fail(index, "empty path segment"); err != nil {
+					return 0, err
+				}
+				index++
+				continue
+			}
+			t.emit(TokenField, index, segmentEnd, nil)
+			index = segmentEnd
+		default:
+			if err := t.fail(index, fmt.Sprintf("unexpected %q", path[index])); err != nil {
+				return 0, err
+			}
+			index++
+		}
+	}
+	return pathEnd, nil
+}
+
+// quotedStringEnd returns the index just after the string literal starting at index,
+// or -1 if the literal is not terminated.
+func quotedStringEnd(path string, index int) int {
+	quoteChar := path[index]
+	for i := index + 1; i < len(path); i++ {
+		switch path[i] {
+		case '\\':
+			i++
+		case quoteChar:
+			return i + 1
+		}
+	}
+	return -1
+}
+
+// isKeyword reports whether name is one of the keyword literals.
+func isKeyword(name string) bool {
+	_, ok := keywordLiterals[name]
+	return ok
+}
+
+// isWordOperator reports whether name is one of the word operators.
+func isWordOperator(name string) bool {
+	_, ok := wordOperators[name]
+	return ok
+}
@@ -0,0 +1,319 @@
+package empaths
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestTokens(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		expected []Token
+	}{
+		{"field path", ".User.Name", []Token{
+			{Kind: TokenField, Text: ".User", Pos: 0},
+			{Kind: TokenField, Text: ".Name", Pos: 5},
+		}},
+		{"root", ".", []Token{
+			{Kind: TokenField, Text: ".", Pos: 0},
+		}},
+		{"root index", ".[0]", []Token{
+			{Kind: TokenField, Text: ".", Pos: 0},
+			{Kind: TokenIndex, Text: "[0]", Pos: 1},
+		}},
+		{"index and quoted key", ".Users[0].Data['a.b']", []Token{
+			{Kind: TokenField, Text: ".Users", Pos: 0},
+			{Kind: TokenIndex, Text: "[0]", Pos: 6},
+			{Kind: TokenField, Text: ".Data", Pos: 9},
+			{Kind: TokenIndex, Text: "['a.b']", Pos: 14},
+		}},
+		{"concatenation", "'Hi ' .Name :suffix", []Token{
+			{Kind: TokenString, Text: "'Hi '", Pos: 0, Value: "Hi "},
+			{Kind: TokenField, Text: ".Name", Pos: 6},
+			{Kind: TokenReference, Text: ":suffix", Pos: 12},
+		}},
+		{"comparison", "?.Age == 30", []Token{
+			{Kind: TokenComparison, Text: "?", Pos: 0},
+			{Kind: TokenField, Text: ".Age", Pos: 1},
+			{Kind: TokenOperator, Text: "==", Pos: 6},
+			{Kind: TokenNumber, Text: "30", Pos: 9, Value: 30},
+		}},
+		{"negation and not equals", "?!.Active!=false", []Token{
+			{Kind: TokenComparison, Text: "?", Pos: 0},
+			{Kind: TokenNegation, Text: "!", Pos: 1},
+			{Kind: TokenField, Text: ".Active", Pos: 2},
+			{Kind: TokenOperator, Text: "!=", Pos: 9},
+			{Kind: TokenKeyword, Text: "false", Pos: 11, Value: false},
+		}},
+		{"ordering operators", "?.Age>=1 .Age<2", []Token{
+			{Kind: TokenComparison, Text: "?", Pos: 0},
+			{Kind: TokenField, Text: ".Age", Pos: 1},
+			{Kind: TokenOperator, Text: ">=", Pos: 5},
+			{Kind: TokenNumber, Text: "1", Pos: 7, Value: 1},
+			{Kind: TokenField, Text: ".Age", Pos: 9},
+			{Kind: TokenOperator, Text: "<", Pos: 13},
+			{Kind: TokenNumber, Text: "2", Pos: 14, Value: 2},
+		}},
+		{"root and parent", ".Items[?.Price > $.Budget] ^", []Token{
+			{Kind: TokenField, Text: ".Items", Pos: 0},
+			{Kind: TokenFilterStart, Text: "[?", Pos: 6},
+			{Kind: TokenField, Text: ".Price", Pos: 8},
+			{Kind: TokenOperator, Text: ">", Pos: 15},
+			{Kind: TokenRoot, Text: "$", Pos: 17},
+			{Kind: TokenField, Text: ".Budget", Pos: 18},
+			{Kind: TokenFilterEnd, Text: "]", Pos: 25},
+			{Kind: TokenParent, Text: "^", Pos: 27},
+		}},
+		{"variables", "?$wanted == $user.Name", []Token{
+			{Kind: TokenComparison, Text: "?", Pos: 0},
+			{Kind: TokenVariable, Text: "$wanted", Pos: 1},
+			{Kind: TokenOperator, Text: "==", Pos: 9},
+			{Kind: TokenVariable, Text: "$user", Pos: 12},
+			{Kind: TokenField, Text: ".Name", Pos: 17},
+		}},
+		{"index on variable", "$users[0].Name [$a]", []Token{
+			{Kind: TokenVariable, Text: "$users", Pos: 0},
+			{Kind: TokenIndex, Text: "[0]", Pos: 6},
+			{Kind: TokenField, Text: ".Name", Pos: 9},
+			{Kind: TokenListStart, Text: "[", Pos: 15},
+			{Kind: TokenVariable, Text: "$a", Pos: 16},
+			{Kind: TokenListEnd, Text: "]", Pos: 18},
+		}},
+		{"word operator and list", "?.Role in ['a', nil]", []Token{
+			{Kind: TokenComparison, Text: "?", Pos: 0},
+			{Kind: TokenField, Text: ".Role", Pos: 1},
+			{Kind: TokenOperator, Text: "in", Pos: 7},
+			{Kind: TokenListStart, Text: "[", Pos: 10},
+			{Kind: TokenString, Text: "'a'", Pos: 11, Value: "a"},
+			{Kind: TokenComma, Text: ",", Pos: 14},
+			{Kind: TokenKeyword, Text: "nil", Pos: 16},
+			{Kind: TokenListEnd, Text: "]", Pos: 19},
+		}},
+		{"function with filter and wildcard", "join(.Users[?.Age == 1.5][*].Name, ',')", []Token{
+			{Kind: TokenFunction, Text: "join", Pos: 0},
+			{Kind: TokenLeftParen, Text: "(", Pos: 4},
+			{Kind: TokenField, Text: ".Users", Pos: 5},
+			{Kind: TokenFilterStart, Text: "[?", Pos: 11},
+			{Kind: TokenField, Text: ".Age", Pos: 13},
+			{Kind: TokenOperator, Text: "==", Pos: 18},
+			{Kind: TokenNumber, Text: "1.5", Pos: 21, Value: 1.5},
+			{Kind: TokenFilterEnd, Text: "]", Pos: 24},
+			{Kind: TokenWildcard, Text: "[*]", Pos: 25},
+			{Kind: TokenField, Text: ".Name", Pos: 28},
+			{Kind: TokenComma, Text: ",", Pos: 33},
+			{Kind: TokenString, Text: "','", Pos: 35, Value: ","},
+			{Kind: TokenRightParen, Text: ")", Pos: 38},
+		}},
+		{"pipe and trailing path", "groupby(.Users, '.Active')['true'] | len", []Token{
+			{Kind: TokenFunction, Text: "groupby", Pos: 0},
+			{Kind: TokenLeftParen, Text: "(", Pos: 7},
+			{Kind: TokenField, Text: ".Users", Pos: 8},
+			{Kind: TokenComma, Text: ",", Pos: 14},
+			{Kind: TokenString, Text: "'.Active'", Pos: 16, Value: ".Active"},
+			{Kind: TokenRightParen, Text: ")", Pos: 25},
+			{Kind: TokenIndex, Text: "['true']", Pos: 26},
+			{Kind: TokenPipe, Text: "|", Pos: 35},
+			{Kind: TokenFunction, Text: "len", Pos: 37},
+		}},
+		{"pipe with arguments", ".Tags|join(', ')[0]", []Token{
+			{Kind: TokenField, Text: ".Tags", Pos: 0},
+			{Kind: TokenPipe, Text: "|", Pos: 5},
+			{Kind: TokenFunction, Text: "join", Pos: 6},
+			{Kind: TokenLeftParen, Text: "(", Pos: 10},
+			{Kind: TokenString, Text: "', '", Pos: 11, Value: ", "},
+			{Kind: TokenRightParen, Text: ")", Pos: 15},
+			{Kind: TokenIndex, Text: "[0]", Pos: 16},
+		}},
+		{"reference argument and pipe", ":fmt(.At).Year | :shout", []Token{
+			{Kind: TokenReference, Text: ":fmt", Pos: 0},
+			{Kind: TokenLeftParen, Text: "(", Pos: 4},
+			{Kind: TokenField, Text: ".At", Pos: 5},
+			{Kind: TokenRightParen, Text: ")", Pos: 8},
+			{Kind: TokenField, Text: ".Year", Pos: 9},
+			{Kind: TokenPipe, Text: "|", Pos: 15},
+			{Kind: TokenReference, Text: ":shout", Pos: 17},
+		}},
+		{"binding", "let $u = .Customer; $u.Name", []Token{
+			{Kind: TokenLet, Text: "let", Pos: 0},
+			{Kind: TokenVariable, Text: "$u", Pos: 4},
+			{Kind: TokenAssign, Text: "=", Pos: 7},
+			{Kind: TokenField, Text: ".Customer", Pos: 9},
+			{Kind: TokenSemicolon, Text: ";", Pos: 18},
+			{Kind: TokenVariable, Text: "$u", Pos: 20},
+			{Kind: TokenField, Text: ".Name", Pos: 22},
+		}},
+		{"affix and glob operators", "?.Name^='A' .File $= 'x' ?.Host~g'*'", []Token{
+			{Kind: TokenComparison, Text: "?", Pos: 0},
+			{Kind: TokenField, Text: ".Name", Pos: 1},
+			{Kind: TokenOperator, Text: "^=", Pos: 6},
+			{Kind: TokenString, Text: "'A'", Pos: 8, Value: "A"},
+			{Kind: TokenField, Text: ".File", Pos: 12},
+			{Kind: TokenOperator, Text: "$=", Pos: 18},
+			{Kind: TokenString, Text: "'x'", Pos: 21, Value: "x"},
+			{Kind: TokenComparison, Text: "?", Pos: 25},
+			{Kind: TokenField, Text: ".Host", Pos: 26},
+			{Kind: TokenOperator, Text: "~g", Pos: 31},
+			{Kind: TokenString, Text: "'*'", Pos: 33, Value: "*"},
+		}},
+		{"case-insensitive operators", "?.A==i'x' ?.B !=i .C ?.D==isnil(.E)", []Token{
+			{Kind: TokenComparison, Text: "?", Pos: 0},
+			{Kind: TokenField, Text: ".A", Pos: 1},
+			{Kind: TokenOperator, Text: "==i", Pos: 3},
+			{Kind: TokenString, Text: "'x'", Pos: 6, Value: "x"},
+			{Kind: TokenComparison, Text: "?", Pos: 10},
+			{Kind: TokenField, Text: ".B", Pos: 11},
+			{Kind: TokenOperator, Text: "!=i", Pos: 14},
+			{Kind: TokenField, Text: ".C", Pos: 18},
+			{Kind: TokenComparison, Text: "?", Pos: 21},
+			{Kind: TokenField, Text: ".D", Pos: 22},
+			{Kind: TokenOperator, Text: "==", Pos: 24},
+			{Kind: TokenFunction, Text: "isnil", Pos: 26},
+			{Kind: TokenLeftParen, Text: "(", Pos: 31},
+			{Kind: TokenField, Text: ".E", Pos: 32},
+			{Kind: TokenRightParen, Text: ")", Pos: 34},
+		}},
+		{"version operators", "?.V>=v'1.2' ?.W <v .X", []Token{
+			{Kind: TokenComparison, Text: "?", Pos: 0},
+			{Kind: TokenField, Text: ".V", Pos: 1},
+			{Kind: TokenOperator, Text: ">=v", Pos: 3},
+			{Kind: TokenString, Text: "'1.2'", Pos: 6, Value: "1.2"},
+			{Kind: TokenComparison, Text: "?", Pos: 12},
+			{Kind: TokenField, Text: ".W", Pos: 13},
+			{Kind: TokenOperator, Text: "<v", Pos: 16},
+			{Kind: TokenField, Text: ".X", Pos: 19},
+		}},
+		{"logical operators and groups", "!?.A&&!(.B||.C)", []Token{
+			{Kind: TokenNegation, Text: "!", Pos: 0},
+			{Kind: TokenComparison, Text: "?", Pos: 1},
+			{Kind: TokenField, Text: ".A", Pos: 2},
+			{Kind: TokenLogical, Text: "&&", Pos: 4},
+			{Kind: TokenNegation, Text: "!", Pos: 6},
+			{Kind: TokenLeftParen, Text: "(", Pos: 7},
+			{Kind: TokenField, Text: ".B", Pos: 8},
+			{Kind: TokenLogical, Text: "||", Pos: 10},
+			{Kind: TokenField, Text: ".C", Pos: 12},
+			{Kind: TokenRightParen, Text: ")", Pos: 14},
+		}},
+		{"bare word after operator", "?.V==v1.2.3&&.S in x.y", []Token{
+			{Kind: TokenComparison, Text: "?", Pos: 0},
+			{Kind: TokenField, Text: ".V", Pos: 1},
+			{Kind: TokenOperator, Text: "==", Pos: 3},
+			{Kind: TokenWord, Text: "v1.2.3", Pos: 5},
+			{Kind: TokenLogical, Text: "&&", Pos: 11},
+			{Kind: TokenField, Text: ".S", Pos: 13},
+			{Kind: TokenOperator, Text: "in", Pos: 16},
+			{Kind: TokenWord, Text: "x.y", Pos: 19},
+		}},
+		{"number after operator", "?.A==1.5", []Token{
+			{Kind: TokenComparison, Text: "?", Pos: 0},
+			{Kind: TokenField, Text: ".A", Pos: 1},
+			{Kind: TokenOperator, Text: "==", Pos: 3},
+			{Kind: TokenNumber, Text: "1.5", Pos: 5, Value: 1.5},
+		}},
+		{"switch", "switch(.S, 'a':1, default: nil)", []Token{
+			{Kind: TokenFunction, Text: "switch", Pos: 0},
+			{Kind: TokenLeftParen, Text: "(", Pos: 6},
+			{Kind: TokenField, Text: ".S", Pos: 7},
+			{Kind: TokenComma, Text: ",", Pos: 9},
+			{Kind: TokenString, Text: "'a'", Pos: 11, Value: "a"},
+			{Kind: TokenCase, Text: ":", Pos: 14},
+			{Kind: TokenNumber, Text: "1", Pos: 15, Value: 1},
+			{Kind: TokenComma, Text: ",", Pos: 16},
+			{Kind: TokenWord, Text: "default", Pos: 18},
+			{Kind: TokenCase, Text: ":", Pos: 25},
+			{Kind: TokenKeyword, Text: "nil", Pos: 27},
+			{Kind: TokenRightParen, Text: ")", Pos: 30},
+		}},
+		{"piped switch", ".S | switch(true:'y')", []Token{
+			{Kind: TokenField, Text: ".S", Pos: 0},
+			{Kind: TokenPipe, Text: "|", Pos: 3},
+			{Kind: TokenFunction, Text: "switch", Pos: 5},
+			{Kind: TokenLeftParen, Text: "(", Pos: 11},
+			{Kind: TokenKeyword, Text: "true", Pos: 12, Value: true},
+			{Kind: TokenCase, Text: ":", Pos: 16},
+			{Kind: TokenString, Text: "'y'", Pos: 17, Value: "y"},
+			{Kind: TokenRightParen, Text: ")", Pos: 20},
+		}},
+		{"bare word", "hello .Name", []Token{
+			{Kind: TokenWord, Text: "hello", Pos: 0},
+			{Kind: TokenField, Text: ".Name", Pos: 6},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens, err := Tokens(tt.path)
+			if err != nil {
+				t.Fatalf("Tokens(%q) returned error: %v", tt.path, err)
+			}
+			if !reflect.DeepEqual(tokens, tt.expected) {
+				t.Errorf("Tokens(%q) =\n%v\nwant\n%v", tt.path, tokens, tt.expected)
+			}
+		})
+	}
+}
+
+func TestTokens_Filter(t *testing.T) {
+	tokens, err := Tokens(".Users[?.Tags contains 'go'][*].Name")
+	if err != nil {
+		t.Fatalf("Tokens returned error: %v", err)
+	}
+	var kinds []TokenKind
+	for _, token := range tokens {
+		kinds = append(kinds, token.Kind)
+	}
+	expected := []TokenKind{TokenField, TokenFilterStart, TokenField, TokenOperator, TokenString, TokenFilterEnd, TokenWildcard, TokenField}
+	if !reflect.DeepEqual(kinds, expected) {
+		t.Errorf("token kinds = %v, want %v", kinds, expected)
+	}
+}
+
+func TestTokens_Errors(t *testing.T) {
+	tests := []struct {
+		path string
+		pos  int
+	}{
+		{"'unterminated", 0},
+		{".Name 'a\\'", 6},
+		{".Users[0", 6},
+		{".Users[]", 6},
+		{".Users..Name", 6},
+		{"count(.Users", 12},
+		{"count(.Users))", 13},
+		{"['a', 'b'", 9},
+		{"a, b", 1},
+		{"?.A = 1", 4},
+		{": x", 0},
+		{".Users[?.Name == 'x]", 6},
+		{".Users[?.Age % 1]", 13},
+		{".Tags | ", 8},
+		{".Tags | 'x'", 8},
+		{":fmt(.At", 8},
+		{".Tags | :", 8},
+		{"let $u = .Customer", 18},
+		{"len(let $u = .A) $u", 15},
+		{".A; .B", 2},
+		{"?.A == 1 && let $u = 1; $u", 19},
+		{"first(.Users)[0", 13},
+		{".A (.B)", 3},
+		{"?(.A && .B", 10},
+		{"?.A & .B", 4},
+		{"switch(.S, 'a' 'b')", 11},
+		{"switch(.S, .T:'b')", 11},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			_, err := Tokens(tt.path)
+			var syntaxErr *SyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("Tokens(%q) error = %v, want *SyntaxError", tt.path, err)
+			}
+			if syntaxErr.Pos != tt.pos {
+				t.Errorf("Tokens(%q) error at %d, want %d (%v)", tt.path, syntaxErr.Pos, tt.pos, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package empaths
+
+// ResolveTrace evaluates a path expression like Resolve and additionally records
+// why parts of it resolved to nil. See Resolver.ResolveTrace.
+//
+// Parameters:
+//   - path: The path expression to evaluate
+//   - data: The data model to evaluate the path against
+//   - refResolver: Optional function to resolve external references (prefixed with ':')
+//
+// Returns:
+//   - The resolved value, the same as Resolve returns
+//   - The reasons why parts of the path resolved to nil, in the order they occurred
+func ResolveTrace(path string, data any, refResolver ReferenceResolver) (any, []error) {
+	return defaultResolver.ResolveTrace(path, data, refResolver)
+}
+
+// ResolveTrace evaluates a path expression like Resolve and records every point
+// where a part of it resolved to nil, so an expression that "rendered empty" can
+// be explained:
+//
+//	result, trace := resolver.ResolveTrace("'Ship to ' .Order.Address.City", data, nil)
+//	// result == "Ship to ", trace == [.Order.Address is nil]
+//
+// The trace holds the errors that strict mode (see WithStrict) would report:
+// *NilIntermediateError for a nil pointer, interface, or map value that the path
+// continues after, *FieldNotFoundError for a missing field or key,
+// *IndexOutOfRangeError for an index out of range, and *UnresolvedReferenceError for
+// a reference that resolved to nil. Unlike strict mode, the evaluation continues
+// after a failure, also for a Resolver created with WithStrict, so the result is
+// the same as Resolve returns. Failures that occur repeatedly, e.g. for every
+// element of a wildcard, are recorded once.
+//
+// If the evaluation is aborted (see ResolveErr), the result is nil and the error is
+// the last entry of the trace.
+//
+// Parameters:
+//   - path: The path expression to evaluate
+//   - data: The data model to evaluate the path against
+//   - refResolver: Optional function to resolve external references (prefixed with ':')
+//
+// Returns:
+//   - The resolved value
+//   - The reasons why parts of the path resolved to nil, in the order they occurred
+func (r *Resolver) ResolveTrace(path string, data any, refResolver ReferenceResolver) (any, []error) {
+	if r.hasAccessRules() {
+		if err := r.checkAccess(path); err != nil {
+			return nil, []error{err}
+		}
+	}
+	if path == "" {
+		return r.intercept(path, data), nil
+	}
+	state := &evalState{refResolver: refResolver, resolver: r, root: data, tracing: true}
+	if r.tracksFields() {
+		state.audit = newAuditLog()
+	}
+	result, _ := resolveExpressions(path, data, state, 0)
+	state.redactErrors()
+	if state.err != nil {
+		return nil, append(state.trace, state.err)
+	}
+	return r.intercept(path, result), state.trace
+}
@@ -0,0 +1,77 @@
+package empaths
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestResolveTrace(t *testing.T) {
+	data := map[string]any{
+		"User":  StrictUser{Name: "Alice", Tags: []string{"a", "b"}},
+		"Users": []*StrictUser{{Name: "Bob", Address: &Address{City: "LA"}}, nil, {Name: "Carol"}},
+	}
+	refResolver := func(name string, data any) any { return nil }
+
+	tests := []struct {
+		name     string
+		path     string
+		expected any
+		trace    []error
+	}{
+		{"resolves", ".User.Name", "Alice", nil},
+		{"nil leaf", ".User.Address", nil, nil},
+		{"nil pointer", "'Ship to ' .User.Address.City", "Ship to ", []error{
+			&NilIntermediateError{Segment: ".User.Address"},
+		}},
+		{"missing field", ".User.Nmae", nil, []error{
+			&FieldNotFoundError{Field: "Nmae", Type: reflect.TypeOf(StrictUser{}), Suggestions: []string{"Name"}},
+		}},
+		{"out of range", ".User.Tags[5]", nil, []error{&IndexOutOfRangeError{Index: 5, Len: 2}}},
+		{"continues after failure", ".User.Tags[5] .User.Name :missing", "Alice", []error{
+			&IndexOutOfRangeError{Index: 5, Len: 2},
+			&UnresolvedReferenceError{Name: "missing"},
+		}},
+		{"wildcard records once", "join(.Users[*].Address.City, ',')", "LA", []error{
+			&NilIntermediateError{Segment: ".Users[*]"},
+			&NilIntermediateError{Segment: ".Users[*].Address"},
+		}},
+		{"repeated path", ".User.Address.City .User.Address.City", "", []error{
+			&NilIntermediateError{Segment: ".User.Address"},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, trace := ResolveTrace(tt.path, data, refResolver)
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("ResolveTrace(%q) = %v, want %v", tt.path, result, tt.expected)
+			}
+			if !reflect.DeepEqual(trace, tt.trace) {
+				t.Errorf("ResolveTrace(%q) trace = %v, want %v", tt.path, trace, tt.trace)
+			}
+			if lenient := Resolve(tt.path, data, refResolver); !reflect.DeepEqual(lenient, result) {
+				t.Errorf("Resolve(%q) = %v, but ResolveTrace returned %v", tt.path, lenient, result)
+			}
+		})
+	}
+}
+
+func TestResolver_ResolveTrace(t *testing.T) {
+	data := map[string]any{"User": StrictUser{Name: "Alice"}}
+
+	result, trace := NewResolver(WithStrict()).ResolveTrace(".User.Nmae ' ' .User.Name", data, nil)
+	if result != " Alice" || len(trace) != 1 {
+		t.Errorf("strict ResolveTrace = %q, %v, want \" Alice\" and one failure", result, trace)
+	}
+
+	result, trace = NewResolver(WithMaxSegments(1)).ResolveTrace(".User.Address.City", data, nil)
+	if result != nil || len(trace) != 1 || !errors.Is(trace[0], ErrLimitExceeded) {
+		t.Errorf("ResolveTrace over limit = %v, %v, want nil and ErrLimitExceeded", result, trace)
+	}
+
+	result, trace = NewResolver(WithDeniedFields([]string{"User"})).ResolveTrace(".User", data, nil)
+	if result != nil || len(trace) != 1 || !errors.Is(trace[0], ErrAccessDenied) {
+		t.Errorf("ResolveTrace of denied path = %v, %v, want nil and ErrAccessDenied", result, trace)
+	}
+}
@@ -0,0 +1,62 @@
+package empaths
+
+import (
+	"reflect"
+)
+
+// WithTemplateTruthiness makes a Resolver decide whether a value is true the way Go
+// templates do, for the operand of '!' and for a single operand of a comparison
+// (as in ".Users[?.Tags]"): false, 0, nil, and empty strings, arrays, slices, and
+// maps are false, and all other values are true. So "!.Tags" means "has no tags".
+//
+// Without this option only the boolean true and the string "true" are true, '!'
+// negates only booleans and the strings "true" and "false", and every other value
+// negates to false.
+func WithTemplateTruthiness() Option {
+	return func(r *Resolver) {
+		r.templateTruthiness = true
+	}
+}
+
+// truthy reports whether a single operand of a comparison counts as true.
+func (s *evalState) truthy(v any) bool {
+	if s.resolver != nil {
+		return s.resolver.truthy(v)
+	}
+	return isTrue(v)
+}
+
+// truthy reports whether v counts as true with the truthiness rules of the Resolver.
+func (r *Resolver) truthy(v any) bool {
+	if r.templateTruthiness {
+		return isTemplateTrue(v)
+	}
+	return isTrue(v)
+}
+
+// isTemplateTrue reports whether v is true for text/template: a non-zero number, the
+// boolean true, a non-empty string, array, slice, or map, a non-nil pointer,
+// interface, channel, or function, or a struct.
+func isTemplateTrue(v any) bool {
+	value := reflect.ValueOf(v)
+	switch value.Kind() {
+	case reflect.Invalid:
+		return false
+	case reflect.Bool:
+		return value.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return value.Int() != 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return value.Uint() != 0
+	case reflect.Float32, reflect.Float64:
+		return value.Float() != 0
+	case reflect.Complex64, reflect.Complex128:
+		return value.Complex() != 0
+	case reflect.String, reflect.Array, reflect.Slice, reflect.Map:
+		return value.Len() > 0
+	case reflect.Ptr, reflect.Interface, reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		return !value.IsNil()
+	default:
+		return true
+	}
+}
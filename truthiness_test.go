@@ -0,0 +1,65 @@
+package empaths
+
+import (
+	"testing"
+)
+
+func TestResolver_WithTemplateTruthiness(t *testing.T) {
+	var nilPointer *Person
+	data := map[string]any{
+		"Tags":    []string{"go"},
+		"NoTags":  []string{},
+		"Name":    "Alice",
+		"Empty":   "",
+		"False":   "false",
+		"Zero":    0,
+		"Count":   3,
+		"Price":   0.0,
+		"Labels":  map[string]string{},
+		"Person":  Person{},
+		"Nil":     nilPointer,
+		"Enabled": true,
+		"Users": []map[string]any{
+			{"Name": "a", "Tags": []string{"x"}},
+			{"Name": "b"},
+			{"Name": "c", "Tags": []string{}},
+		},
+	}
+	resolver := NewResolver(WithTemplateTruthiness())
+
+	tests := []struct {
+		name     string
+		path     string
+		expected any
+		lenient  any
+	}{
+		{"non-empty slice", "!.Tags", false, false},
+		{"empty slice", "!.NoTags", true, false},
+		{"non-empty string", "!.Name", false, false},
+		{"empty string", "!.Empty", true, false},
+		{"string false", "!.False", false, true},
+		{"zero", "!.Zero", true, false},
+		{"non-zero", "!.Count", false, false},
+		{"zero float", "!.Price", true, false},
+		{"empty map", "!.Labels", true, false},
+		{"struct", "!.Person", false, false},
+		{"nil pointer", "!.Nil", true, false},
+		{"missing", "!.Missing", true, false},
+		{"bool", "!.Enabled", false, false},
+		{"single operand", "?.Count", true, false},
+		{"single operand in condition", "?.Name && !.NoTags", true, false},
+		{"filter", "count(.Users[?.Tags])", 1, 0},
+		{"negated filter", "count(.Users[?!.Tags])", 2, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := resolver.Resolve(tt.path, data, nil); result != tt.expected {
+				t.Errorf("Resolve(%q) = %v, want %v", tt.path, result, tt.expected)
+			}
+			if result := Resolve(tt.path, data, nil); result != tt.lenient {
+				t.Errorf("Resolve(%q) without option = %v, want %v", tt.path, result, tt.lenient)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package empaths
+
+import (
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ResolveString evaluates a path expression like Resolve and converts the result to a
+// string, the way operands of a concatenation are converted. nil becomes "".
+func ResolveString(path string, data any, refResolver ReferenceResolver) string {
+	return defaultResolver.ResolveString(path, data, refResolver)
+}
+
+// ResolveBool evaluates a path expression like Resolve and reports whether the result
+// is true: the boolean true or the string "true" (case-insensitive). Everything else,
+// including nil, is false.
+func ResolveBool(path string, data any, refResolver ReferenceResolver) bool {
+	return defaultResolver.ResolveBool(path, data, refResolver)
+}
+
+// ResolveInt evaluates a path expression like Resolve and converts the result to an
+// int (see Resolver.ResolveInt).
+//
+// Returns:
+//   - The result as an int
+//   - false if the result is not an integer or does not fit into an int
+func ResolveInt(path string, data any, refResolver ReferenceResolver) (int, bool) {
+	return defaultResolver.ResolveInt(path, data, refResolver)
+}
+
+// ResolveFloat evaluates a path expression like Resolve and converts the result to a
+// float64 (see Resolver.ResolveFloat).
+//
+// Returns:
+//   - The result as a float64
+//   - false if the result is not a number
+func ResolveFloat(path string, data any, refResolver ReferenceResolver) (float64, bool) {
+	return defaultResolver.ResolveFloat(path, data, refResolver)
+}
+
+// ResolveString evaluates a path expression like Resolve and converts the result to a
+// string with the Resolver's Formatter (see WithFormatter), if any, or the way
+// operands of a concatenation are converted. nil becomes "".
+func (r *Resolver) ResolveString(path string, data any, refResolver ReferenceResolver) string {
+	state := evalState{resolver: r}
+	return state.format(r.Resolve(path, data, refResolver), "")
+}
+
+// ResolveBool evaluates a path expression like Resolve and reports whether the result
+// is true: the boolean true or the string "true" (case-insensitive), or with
+// WithTemplateTruthiness every value that Go templates treat as true.
+func (r *Resolver) ResolveBool(path string, data any, refResolver ReferenceResolver) bool {
+	return r.truthy(r.Resolve(path, data, refResolver))
+}
+
+// ResolveInt evaluates a path expression like Resolve and converts the result to an
+// int. Integers of any type, floats without a fractional part, and strings holding
+// such numbers (as in "42" or "42.0") are converted.
+//
+// Returns:
+//   - The result as an int
+//   - false if the result is not an integer or does not fit into an int
+func (r *Resolver) ResolveInt(path string, data any, refResolver ReferenceResolver) (int, bool) {
+	return toInt(r.Resolve(path, data, refResolver))
+}
+
+// ResolveFloat evaluates a path expression like Resolve and converts the result to a
+// float64. Numbers of any type and strings holding a number are converted.
+//
+// Returns:
+//   - The result as a float64
+//   - false if the result is not a number
+func (r *Resolver) ResolveFloat(path string, data any, refResolver ReferenceResolver) (float64, bool) {
+	return toNumber(r.Resolve(path, data, refResolver))
+}
+
+// toInt converts an integer, a float without a fractional part, or a string holding
+// one of them to an int. Integers are converted exactly, without going through
+// float64.
+func toInt(v any) (int, bool) {
+	if v == nil {
+		return 0, false
+	}
+	value := reflect.ValueOf(v)
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n := value.Int()
+		return int(n), n >= math.MinInt && n <= math.MaxInt
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n := value.Uint()
+		return int(n), n <= math.MaxInt
+	case reflect.String:
+		if n, err := strconv.Atoi(strings.TrimSpace(value.String())); err == nil {
+			return n, true
+		}
+	}
+	f, ok := toNumber(v)
+	if !ok || f != math.Trunc(f) || f < math.MinInt || f >= math.MaxInt {
+		return 0, false
+	}
+	return int(f), true
+}
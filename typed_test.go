@@ -0,0 +1,125 @@
+package empaths
+
+import (
+	"math"
+	"testing"
+)
+
+func TestResolveTyped(t *testing.T) {
+	data := map[string]any{
+		"Name":    "Alice",
+		"Age":     30,
+		"Big":     uint64(math.MaxUint64),
+		"Int64":   int64(1) << 62,
+		"Price":   12.5,
+		"Whole":   3.0,
+		"Count":   "42",
+		"Ratio":   " 0.25 ",
+		"Active":  true,
+		"Flag":    "TRUE",
+		"Tags":    []string{"a"},
+		"Nothing": nil,
+	}
+
+	t.Run("ResolveString", func(t *testing.T) {
+		tests := []struct {
+			path     string
+			expected string
+		}{
+			{".Name", "Alice"},
+			{".Age", "30"},
+			{".Price", "12.5"},
+			{".Active", "true"},
+			{".Missing", ""},
+			{"'Hi ' .Name", "Hi Alice"},
+		}
+		for _, tt := range tests {
+			if result := ResolveString(tt.path, data, nil); result != tt.expected {
+				t.Errorf("ResolveString(%q) = %q, want %q", tt.path, result, tt.expected)
+			}
+		}
+	})
+
+	t.Run("ResolveBool", func(t *testing.T) {
+		tests := []struct {
+			path     string
+			expected bool
+		}{
+			{".Active", true},
+			{".Flag", true},
+			{"?.Age > 18", true},
+			{".Name", false},
+			{".Tags", false},
+			{".Missing", false},
+		}
+		for _, tt := range tests {
+			if result := ResolveBool(tt.path, data, nil); result != tt.expected {
+				t.Errorf("ResolveBool(%q) = %v, want %v", tt.path, result, tt.expected)
+			}
+		}
+	})
+
+	t.Run("ResolveInt", func(t *testing.T) {
+		tests := []struct {
+			path     string
+			expected int
+			ok       bool
+		}{
+			{".Age", 30, true},
+			{".Int64", 1 << 62, true},
+			{".Whole", 3, true},
+			{".Count", 42, true},
+			{"'42.0'", 42, true},
+			{".Price", 0, false},
+			{".Big", 0, false},
+			{".Name", 0, false},
+			{".Active", 0, false},
+			{".Missing", 0, false},
+		}
+		for _, tt := range tests {
+			result, ok := ResolveInt(tt.path, data, nil)
+			if ok != tt.ok || ok && result != tt.expected {
+				t.Errorf("ResolveInt(%q) = %v, %v, want %v, %v", tt.path, result, ok, tt.expected, tt.ok)
+			}
+		}
+	})
+
+	t.Run("ResolveFloat", func(t *testing.T) {
+		tests := []struct {
+			path     string
+			expected float64
+			ok       bool
+		}{
+			{".Price", 12.5, true},
+			{".Age", 30, true},
+			{".Ratio", 0.25, true},
+			{".Count", 42, true},
+			{".Name", 0, false},
+			{".Nothing", 0, false},
+		}
+		for _, tt := range tests {
+			result, ok := ResolveFloat(tt.path, data, nil)
+			if ok != tt.ok || ok && result != tt.expected {
+				t.Errorf("ResolveFloat(%q) = %v, %v, want %v, %v", tt.path, result, ok, tt.expected, tt.ok)
+			}
+		}
+	})
+
+	t.Run("Resolver options", func(t *testing.T) {
+		r := NewResolver(WithTemplateTruthiness(), WithFormatter(func(v any, hint string) (string, bool) {
+			if f, ok := v.(float64); ok {
+				return "€" + ResolveString(".", f, nil), true
+			}
+			return "", false
+		}))
+		if result := r.ResolveBool(".Tags", data, nil); !result {
+			t.Errorf("ResolveBool(\".Tags\") = false, want true with template truthiness")
+		}
+		if result := r.ResolveString(".Price", data, nil); result != "€12.5" {
+			t.Errorf("ResolveString(\".Price\") = %q, want %q", result, "€12.5")
+		}
+		if result, ok := r.ResolveInt(".Age", data, nil); !ok || result != 30 {
+			t.Errorf("ResolveInt(\".Age\") = %v, %v, want 30, true", result, ok)
+		}
+	})
+}
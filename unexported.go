@@ -0,0 +1,99 @@
+package empaths
+
+import (
+	"reflect"
+	"strconv"
+	"unsafe"
+)
+
+// WithUnexportedFields makes a Resolver read unexported struct fields, which are
+// otherwise not accessible. This allows paths over third-party structs whose
+// useful state is unexported:
+//
+//	resolver := empaths.NewResolver(empaths.WithUnexportedFields(true))
+//	resolver.Resolve(".conn.remoteAddr", client, nil)
+//
+// The values of unexported fields are read with package unsafe, bypassing Go's
+// visibility rules, and are only read: the rest of a path below an unexported
+// field resolves fields, map keys, and indices, but does not call methods or
+// function values, and wildcards and filters there only select the elements of
+// slices, arrays, and maps. Only use this option with trusted data and paths, as
+// unexported state is often not meant to be relied upon or exposed.
+func WithUnexportedFields(enabled bool) Option {
+	return func(r *Resolver) {
+		r.unexportedFields = enabled
+	}
+}
+
+// readsUnexported reports whether name is an unexported field of value that the
+// Resolver reads.
+func (s *evalState) readsUnexported(name string, value reflect.Value) bool {
+	if s.resolver == nil || !s.resolver.unexportedFields || value.Kind() != reflect.Struct {
+		return false
+	}
+	field, ok := value.Type().FieldByName(name)
+	return ok && !field.IsExported()
+}
+
+// resolveReadOnly resolves a field name, map key, or index against a value without
+// calling any methods or functions, reading unexported struct fields.
+//
+// Parameters:
+//   - key: The field name, map key, or index, without quotes
+//   - value: The reflect.Value to resolve the key against
+//
+// Returns:
+//   - The resolved reflect.Value, or an invalid reflect.Value if there is no such
+//     field, key, or element
+func resolveReadOnly(key string, value reflect.Value) reflect.Value {
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return reflect.Value{}
+		}
+		value = value.Elem()
+	}
+
+	switch value.Kind() {
+	case reflect.Struct:
+		return readField(key, value)
+	case reflect.Map:
+		return getMapValue(key, value)
+	case reflect.Array, reflect.Slice:
+		index, err := strconv.Atoi(key)
+		if err != nil || index < 0 || index >= value.Len() {
+			return reflect.Value{}
+		}
+		return value.Index(index)
+	default:
+		return reflect.Value{}
+	}
+}
+
+// readField returns the value of a struct field, including unexported fields.
+func readField(name string, value reflect.Value) reflect.Value {
+	field, ok := value.Type().FieldByName(name)
+	if !ok {
+		return reflect.Value{}
+	}
+	if !value.CanAddr() {
+		// Fields can only be read with unsafe from an addressable copy.
+		addressable := reflect.New(value.Type()).Elem()
+		addressable.Set(value)
+		value = addressable
+	}
+	fieldValue, err := value.FieldByIndexErr(field.Index)
+	if err != nil {
+		// An embedded pointer on the way to the field is nil.
+		return reflect.Value{}
+	}
+	return readable(fieldValue)
+}
+
+// readable returns an addressable value obtained through unexported fields as a
+// value whose contents can be read.
+func readable(value reflect.Value) reflect.Value {
+	if value.CanInterface() || !value.CanAddr() {
+		return value
+	}
+	return reflect.NewAt(value.Type(), unsafe.Pointer(value.UnsafeAddr())).Elem()
+}
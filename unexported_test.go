@@ -0,0 +1,81 @@
+package empaths
+
+import (
+	"testing"
+	"time"
+)
+
+// client stands in for a third-party type whose state is unexported.
+type client struct {
+	Name    string
+	retries int
+	remote  *Address
+	members []Member
+	limits  map[string]int
+	started time.Time
+	dial    func() string
+	state
+}
+
+type state struct {
+	open bool
+}
+
+func (c client) Retries() int { return c.retries * 10 }
+
+func TestResolver_WithUnexportedFields(t *testing.T) {
+	c := client{
+		Name:    "api",
+		retries: 3,
+		remote:  &Address{City: "Berlin"},
+		members: []Member{{Name: "Alice", Active: true}, {Name: "Bob"}},
+		limits:  map[string]int{"rps": 100},
+		started: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+		dial:    func() string { return "dialed" },
+		state:   state{open: true},
+	}
+	resolver := NewResolver(WithUnexportedFields(true))
+
+	tests := []struct {
+		name     string
+		path     string
+		data     any
+		expected any
+	}{
+		{"unexported field", ".retries", c, 3},
+		{"through pointer", ".remote.City", &c, "Berlin"},
+		{"slice element", ".members[1].Name", c, "Bob"},
+		{"map value", ".limits.rps", c, 100},
+		{"map value in brackets", ".limits['rps']", c, 100},
+		{"embedded", ".state.open", c, true},
+		{"promoted", ".open", c, true},
+		{"wildcard", "join(.members[*].Name, ',')", c, "Alice,Bob"},
+		{"filter", "count(.members[?.Active])", c, 1},
+		{"comparison", "?.retries > 2", c, true},
+		{"concatenation", ".Name ':' .retries", c, "api:3"},
+		{"exported method", ".Retries", c, 30},
+		{"no methods below unexported fields", ".started.Year", c, nil},
+		{"missing field", ".missing", c, nil},
+		{"nested map", ".Extra.limits.rps", map[string]any{"Extra": c}, 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := resolver.Resolve(tt.path, tt.data, nil); result != tt.expected {
+				t.Errorf("Resolve(%q) = %v, want %v", tt.path, result, tt.expected)
+			}
+		})
+	}
+
+	t.Run("function values are not called", func(t *testing.T) {
+		if result := resolver.Resolve("typeof(.dial)", c, nil); result != "func() string" {
+			t.Errorf("Resolve() = %v, want %v", result, "func() string")
+		}
+	})
+
+	t.Run("not read by default", func(t *testing.T) {
+		if result := Resolve(".retries", c, nil); result != nil {
+			t.Errorf("Resolve() = %v, want nil", result)
+		}
+	})
+}
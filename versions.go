@@ -0,0 +1,127 @@
+package empaths
+
+import (
+	"strconv"
+	"strings"
+)
+
+// compareVersions compares two semantic-version-style operands for the operators
+// '<v', '<=v', '>v', and '>=v'. Versions such as "1.20.0", "v1.9", or "2.0.0-rc.1"
+// are compared numerically component by component, so "1.9" is less than "1.10".
+// Missing components count as 0 ("1.2" equals "1.2.0"), a leading 'v' is ignored, and
+// so is build metadata after a '+'. A version with a pre-release suffix after a '-'
+// is less than the version without it; pre-release identifiers are compared as
+// described by Semantic Versioning 2.0.0.
+//
+// Returns:
+//   - -1, 0, or +1 if left is less than, equal to, or greater than right
+//   - false if an operand is nil or not a version
+func compareVersions(left any, right any) (int, bool) {
+	if isNil(left) || isNil(right) {
+		return 0, false
+	}
+	leftCore, leftPre, ok := parseVersion(toString(left))
+	if !ok {
+		return 0, false
+	}
+	rightCore, rightPre, ok := parseVersion(toString(right))
+	if !ok {
+		return 0, false
+	}
+	for i := 0; i < len(leftCore) || i < len(rightCore); i++ {
+		var l, r uint64
+		if i < len(leftCore) {
+			l = leftCore[i]
+		}
+		if i < len(rightCore) {
+			r = rightCore[i]
+		}
+		if l != r {
+			if l < r {
+				return -1, true
+			}
+			return 1, true
+		}
+	}
+	return comparePreReleases(leftPre, rightPre), true
+}
+
+// parseVersion splits a version into its numeric components and its pre-release
+// identifiers.
+//
+// Returns:
+//   - The numeric components, such as [1 20 0] for "v1.20.0-rc.1"
+//   - The pre-release identifiers, such as ["rc" "1"], or nil if there are none
+//   - false if the string is not a version
+func parseVersion(s string) ([]uint64, []string, bool) {
+	s = strings.TrimSpace(s)
+	if s != "" && (s[0] == 'v' || s[0] == 'V') {
+		s = s[1:]
+	}
+	if plus := strings.IndexByte(s, '+'); plus != -1 {
+		s = s[:plus]
+	}
+	var pre []string
+	if dash := strings.IndexByte(s, '-'); dash != -1 {
+		pre = strings.Split(s[dash+1:], ".")
+		s = s[:dash]
+	}
+	if s == "" {
+		return nil, nil, false
+	}
+	parts := strings.Split(s, ".")
+	core := make([]uint64, len(parts))
+	for i, part := range parts {
+		n, err := strconv.ParseUint(part, 10, 64)
+		if err != nil {
+			return nil, nil, false
+		}
+		core[i] = n
+	}
+	return core, pre, true
+}
+
+// comparePreReleases compares the pre-release identifiers of two versions with equal
+// numeric components. No identifiers rank above any identifiers, numeric identifiers
+// are compared by value and rank below alphanumeric ones, and alphanumeric
+// identifiers are compared lexically; a shorter list of otherwise equal identifiers
+// ranks below a longer one.
+func comparePreReleases(left []string, right []string) int {
+	switch {
+	case len(left) == 0 && len(right) == 0:
+		return 0
+	case len(left) == 0:
+		return 1
+	case len(right) == 0:
+		return -1
+	}
+	for i := 0; i < len(left) && i < len(right); i++ {
+		leftNum, leftErr := strconv.ParseUint(left[i], 10, 64)
+		rightNum, rightErr := strconv.ParseUint(right[i], 10, 64)
+		switch {
+		case leftErr == nil && rightErr == nil:
+			if leftNum != rightNum {
+				if leftNum < rightNum {
+					return -1
+				}
+				return 1
+			}
+		case leftErr == nil:
+			return -1
+		case rightErr == nil:
+			return 1
+		default:
+			if c := strings.Compare(left[i], right[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	switch {
+	case len(left) < len(right):
+		return -1
+	case len(left) > len(right):
+		return 1
+	default:
+		return 0
+	}
+}
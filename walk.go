@@ -0,0 +1,129 @@
+package empaths
+
+import (
+	"reflect"
+	"strconv"
+)
+
+// Walk calls fn for data and every value inside it, depth-first, with the model path
+// of the value, so tools can scan whole models with the paths Resolve accepts:
+//
+//	empaths.Walk(config, func(path string, value any) bool {
+//		fmt.Println(path, value) // ".", ".Spec", ".Spec.Replicas", ...
+//		return true
+//	})
+//
+// The traversal is the one Flatten uses: pointers and interfaces are followed (fn
+// sees the value they refer to, or nil), structs contribute their exported fields
+// (promoted fields under the path of the embedding struct), maps their entries in
+// sorted key order, and slices and arrays their elements. As with Resolve, map
+// entries and struct fields are passed as copies. A pointer that refers back to a
+// value containing it is not visited again. data itself has the path ".".
+//
+// Parameters:
+//   - data: The data to walk
+//   - fn: Called for every value; returning false skips the values inside it
+func Walk(data any, fn func(path string, value any) bool) {
+	walkValue("", reflect.ValueOf(data), make(map[uintptr]bool), func(path string, value reflect.Value, leaf bool) bool {
+		return fn(path, extractValue(value))
+	})
+}
+
+// walkFunc is called by walkValue for every value. leaf is true if the value has
+// no values inside it. Returning false skips the values inside it.
+type walkFunc func(path string, value reflect.Value, leaf bool) bool
+
+// walkValue calls fn for value at path and the values inside it. path is built
+// without the leading "." of the root, e.g. "" or "[0].Name"; fn receives it with
+// the dot. visiting holds the pointers being walked, to detect cycles.
+func walkValue(path string, value reflect.Value, visiting map[uintptr]bool, fn walkFunc) {
+	value, marked, ok := walkIndirect(value, visiting)
+	if !ok {
+		return
+	}
+	defer unmark(visiting, marked)
+
+	leaf := true
+	switch value.Kind() {
+	case reflect.Struct:
+		leaf = !hasExportedFields(value.Type())
+	case reflect.Map, reflect.Array, reflect.Slice:
+		leaf = value.Len() == 0
+	}
+	if path == "" || path[0] == '[' {
+		// The root itself, or a key or index of the root (".[0]").
+		if !fn("."+path, value, leaf) || leaf {
+			return
+		}
+	} else if !fn(path, value, leaf) || leaf {
+		return
+	}
+
+	switch value.Kind() {
+	case reflect.Struct:
+		walkFields(path, value, visiting, fn)
+	case reflect.Map:
+		for _, key := range sortedMapKeys(value) {
+			walkValue(path+keySegment(toString(extractValue(key))), value.MapIndex(key), visiting, fn)
+		}
+	case reflect.Array, reflect.Slice:
+		for i := 0; i < value.Len(); i++ {
+			walkValue(path+"["+strconv.Itoa(i)+"]", value.Index(i), visiting, fn)
+		}
+	}
+}
+
+// walkFields walks the exported fields of a struct. The fields of embedded structs
+// are walked as if they were fields of the struct itself.
+func walkFields(path string, value reflect.Value, visiting map[uintptr]bool, fn walkFunc) {
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		if !field.Anonymous || indirectType(field.Type).Kind() != reflect.Struct {
+			walkValue(path+"."+field.Name, value.Field(i), visiting, fn)
+			continue
+		}
+		embedded, marked, ok := walkIndirect(value.Field(i), visiting)
+		if ok && embedded.Kind() == reflect.Struct {
+			walkFields(path, embedded, visiting, fn)
+		}
+		unmark(visiting, marked)
+	}
+}
+
+// walkIndirect follows pointers and interfaces and marks the pointers as being
+// visited. It returns the value they refer to (or the nil pointer or interface) and
+// the pointers it marked, or false if a pointer is already being visited.
+func walkIndirect(value reflect.Value, visiting map[uintptr]bool) (reflect.Value, []uintptr, bool) {
+	var marked []uintptr
+	for (value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface) && !value.IsNil() {
+		if value.Kind() == reflect.Ptr {
+			ptr := value.Pointer()
+			if visiting[ptr] {
+				unmark(visiting, marked)
+				return value, nil, false
+			}
+			visiting[ptr] = true
+			marked = append(marked, ptr)
+		}
+		value = value.Elem()
+	}
+	return value, marked, true
+}
+
+// unmark removes the pointers marked by walkIndirect from visiting.
+func unmark(visiting map[uintptr]bool, marked []uintptr) {
+	for _, ptr := range marked {
+		delete(visiting, ptr)
+	}
+}
+
+// indirectType returns the element type of a pointer type, and any other type as is.
+func indirectType(typ reflect.Type) reflect.Type {
+	if typ.Kind() == reflect.Ptr {
+		return typ.Elem()
+	}
+	return typ
+}
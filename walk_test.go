@@ -0,0 +1,81 @@
+package empaths
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWalk(t *testing.T) {
+	var paths []string
+	Walk(createTestPerson(), func(path string, value any) bool {
+		paths = append(paths, path)
+		return true
+	})
+	expected := []string{
+		".", ".Name", ".Age", ".Active",
+		".Address", ".Address.Street", ".Address.City", ".Address.Zip",
+		".Tags", ".Tags[0]", ".Tags[1]", ".Tags[2]",
+		".Scores", ".Scores.math", ".Scores.science",
+	}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Errorf("Walk visited\n%v\nwant\n%v", paths, expected)
+	}
+}
+
+func TestWalk_Values(t *testing.T) {
+	node := &Timestamped{Address: Address{City: "NYC"}}
+	node.Next = node
+	data := map[string]any{
+		"Node":  node,
+		"Nil":   (*Address)(nil),
+		"List":  []any{[]int{7}},
+		"Empty": map[string]int{},
+	}
+
+	visited := map[string]any{}
+	Walk(data, func(path string, value any) bool {
+		visited[path] = value
+		return path != ".Node"
+	})
+
+	tests := []struct {
+		path     string
+		expected any
+	}{
+		{".Nil", nil},
+		{".List[0]", []int{7}},
+		{".List[0][0]", 7},
+		{".Empty", map[string]int{}},
+	}
+	for _, tt := range tests {
+		if value, ok := visited[tt.path]; !ok || !reflect.DeepEqual(value, tt.expected) {
+			t.Errorf("value at %q = %v (visited %v), want %v", tt.path, value, ok, tt.expected)
+		}
+	}
+	if _, ok := visited[".Node.City"]; ok {
+		t.Errorf("children of a skipped value were visited")
+	}
+	if _, ok := visited[".Node"].(Timestamped); !ok {
+		t.Errorf("value at .Node = %T, want the struct the pointer refers to", visited[".Node"])
+	}
+
+	var cyclic []string
+	Walk(node, func(path string, value any) bool {
+		cyclic = append(cyclic, path)
+		return true
+	})
+	expected := []string{".", ".Street", ".City", ".Zip", ".Created"}
+	if !reflect.DeepEqual(cyclic, expected) {
+		t.Errorf("Walk of a cycle visited %v, want %v", cyclic, expected)
+	}
+}
+
+func TestWalk_PathsResolve(t *testing.T) {
+	data := map[string]any{"Team": createTestTeam(), "a b": []string{"x"}}
+	Walk(data, func(path string, value any) bool {
+		if result := Resolve(path, data, nil); !reflect.DeepEqual(result, value) {
+			t.Errorf("Resolve(%q) = %v, want %v", path, result, value)
+		}
+		return true
+	})
+}